@@ -0,0 +1,343 @@
+// Package client is a typed Go client of the blog API. It talks to the v2 posts
+// endpoints and turns failed responses into errors that match ErrNotFound and the
+// other sentinel errors.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const postsPath = "/v2/api/blog/posts"
+
+type Post struct {
+	ID       int64  `json:"id"`
+	AuthorID int64  `json:"author_id"`
+	Author   string `json:"author"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	Draft    bool   `json:"draft"`
+}
+
+// PostInput holds the fields of a post that are set on create and update. The
+// author is given by AuthorID or by name, a name the server does not know creates the author.
+// An update replaces the post, so Draft must be set again to keep a draft.
+type PostInput struct {
+	AuthorID int64  `json:"author_id,omitempty"`
+	Author   string `json:"author,omitempty"`
+	Title    string `json:"title"`
+	Content  string `json:"content"`
+	Draft    bool   `json:"draft"`
+}
+
+// RetryPolicy retries requests that failed with a network error, 429 or 502-504.
+// The backoff doubles after every attempt, a Retry-After header takes precedence.
+// POST requests are only retried after 429, when the server has not processed them.
+type RetryPolicy struct {
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 4, InitialBackoff: 200 * time.Millisecond, MaxBackoff: 5 * time.Second}
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	token      string
+	retry      RetryPolicy
+}
+
+type Option func(*Client)
+
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithAPIKey identifies the client to the rate limiter of the server.
+func WithAPIKey(apiKey string) Option {
+	return func(c *Client) {
+		c.apiKey = apiKey
+	}
+}
+
+// WithBearerToken authenticates requests with an access token of the auth endpoints.
+func WithBearerToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+func WithRetryPolicy(retry RetryPolicy) Option {
+	return func(c *Client) {
+		c.retry = retry
+	}
+}
+
+// New returns a client of the server at baseURL, e.g. "http://localhost:8080".
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retry:      DefaultRetryPolicy,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+type postEnvelope struct {
+	Data Post `json:"data"`
+}
+
+type postsEnvelope struct {
+	Data []Post `json:"data"`
+	Meta struct {
+		Total     int    `json:"total"`
+		NextAfter *int64 `json:"next_after"`
+	} `json:"meta"`
+}
+
+func (c *Client) GetPost(ctx context.Context, id int64) (*Post, error) {
+	var envelope postEnvelope
+	if err := c.do(ctx, http.MethodGet, postPath(id), nil, &envelope); err != nil {
+		return nil, err
+	}
+
+	return &envelope.Data, nil
+}
+
+func (c *Client) CreatePost(ctx context.Context, input PostInput) (*Post, error) {
+	var envelope postEnvelope
+	if err := c.do(ctx, http.MethodPost, postsPath, input, &envelope); err != nil {
+		return nil, err
+	}
+
+	return &envelope.Data, nil
+}
+
+func (c *Client) UpdatePost(ctx context.Context, id int64, input PostInput) (*Post, error) {
+	var envelope postEnvelope
+	if err := c.do(ctx, http.MethodPut, postPath(id), input, &envelope); err != nil {
+		return nil, err
+	}
+
+	return &envelope.Data, nil
+}
+
+func (c *Client) DeletePost(ctx context.Context, id int64) error {
+	return c.do(ctx, http.MethodDelete, postPath(id), nil, nil)
+}
+
+func postPath(id int64) string {
+	return postsPath + "/" + strconv.FormatInt(id, 10)
+}
+
+// do sends the request with retries and decodes a successful response into dst.
+func (c *Client) do(ctx context.Context, method string, path string, body any, dst any) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	backoff := c.retry.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		wait, err := c.attempt(ctx, method, path, payload, dst)
+		if err == nil || wait < 0 || attempt >= c.retry.MaxAttempts {
+			return err
+		}
+
+		if wait == 0 {
+			wait = backoff
+			backoff = min(2*backoff, c.retry.MaxBackoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+	}
+}
+
+// attempt sends the request once. A non-negative wait means the request can be
+// retried, after the Retry-After duration when it is positive.
+func (c *Client) attempt(ctx context.Context, method string, path string, payload []byte, dst any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(payload))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if len(c.apiKey) > 0 {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if len(c.token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil || method == http.MethodPost {
+			return -1, err
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		apiErr := readError(resp)
+		return retryWait(method, resp), apiErr
+	}
+
+	if dst == nil || resp.StatusCode == http.StatusNoContent {
+		return -1, nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+		return -1, fmt.Errorf("blog api: decoding response: %w", err)
+	}
+
+	return -1, nil
+}
+
+func readError(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	var envelope errorEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Errors) == 0 {
+		return &APIError{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	}
+
+	return envelope.toAPIError(resp.StatusCode)
+}
+
+func retryWait(method string, resp *http.Response) time.Duration {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		if method == http.MethodPost {
+			return -1
+		}
+	default:
+		return -1
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	return 0
+}
+
+// ListOptions configure ListPosts. PageSize defaults to 50.
+type ListOptions struct {
+	PageSize int
+}
+
+// PostIterator walks through all posts ordered by ID, fetching a page at a time:
+//
+//	it := c.ListPosts(ctx, client.ListOptions{})
+//	for it.Next() {
+//		post := it.Post()
+//	}
+//	if err := it.Err(); err != nil {
+//	}
+type PostIterator struct {
+	client   *Client
+	ctx      context.Context
+	pageSize int
+
+	page  []Post
+	index int
+	after *int64
+	err   error
+	total int
+}
+
+func (c *Client) ListPosts(ctx context.Context, opts ListOptions) *PostIterator {
+	pageSize := opts.PageSize
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	return &PostIterator{client: c, ctx: ctx, pageSize: pageSize, index: -1, after: new(int64)}
+}
+
+// Next advances to the next post and fetches the next page when needed.
+// It returns false when there are no more posts or a request failed.
+func (it *PostIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.index++
+	for it.index >= len(it.page) {
+		if it.after == nil {
+			return false
+		}
+		if err := it.fetch(); err != nil {
+			it.err = err
+			return false
+		}
+	}
+
+	return true
+}
+
+func (it *PostIterator) fetch() error {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(it.pageSize))
+	query.Set("after", strconv.FormatInt(*it.after, 10))
+
+	var envelope postsEnvelope
+	if err := it.client.do(it.ctx, http.MethodGet, postsPath+"?"+query.Encode(), nil, &envelope); err != nil {
+		return err
+	}
+
+	it.page = envelope.Data
+	it.index = 0
+	it.after = envelope.Meta.NextAfter
+	it.total = envelope.Meta.Total
+	return nil
+}
+
+// Post returns the current post.
+func (it *PostIterator) Post() Post {
+	return it.page[it.index]
+}
+
+// Total returns the number of all posts as of the last fetched page.
+func (it *PostIterator) Total() int {
+	return it.total
+}
+
+func (it *PostIterator) Err() error {
+	return it.err
+}
+
+// All collects the remaining posts.
+func (it *PostIterator) All() ([]Post, error) {
+	var posts []Post
+	for it.Next() {
+		posts = append(posts, it.Post())
+	}
+
+	return posts, it.Err()
+}
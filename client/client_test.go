@@ -0,0 +1,183 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/client"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy/policytest"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+var fastRetries = client.WithRetryPolicy(client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+
+// openPolicy lets anonymous requests write posts, so that the tests need no login.
+func SetupRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	server.SetupMiddleware(router)
+	server.SetupValidation(router)
+	server.RegisterHandlers(router, usecase.NewBlogUseCase(repository.NewRepository(), repository.NewAuthorRepository(), events.NewBus(0), policytest.Open()))
+
+	return router
+}
+
+func SetupClient(t *testing.T, handler http.Handler) *client.Client {
+	testServer := httptest.NewServer(handler)
+	t.Cleanup(testServer.Close)
+
+	return client.New(testServer.URL, fastRetries)
+}
+
+func Test_Client_ShouldManagePostsLifecycle(t *testing.T) {
+	c := SetupClient(t, SetupRouter())
+	ctx := context.Background()
+
+	created, err := c.CreatePost(ctx, client.PostInput{Author: "Anton", Title: "On golang", Content: "some content"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), created.ID)
+
+	updated, err := c.UpdatePost(ctx, created.ID, client.PostInput{Author: "Anton", Title: "On golang NEW", Content: "some content"})
+	assert.NoError(t, err)
+	assert.Equal(t, "On golang NEW", updated.Title)
+
+	fetched, err := c.GetPost(ctx, created.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, updated, fetched)
+
+	assert.NoError(t, c.DeletePost(ctx, created.ID))
+
+	_, err = c.GetPost(ctx, created.ID)
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func Test_Client_InvalidInput_ShouldReturnFieldErrors(t *testing.T) {
+	c := SetupClient(t, SetupRouter())
+
+	_, err := c.CreatePost(context.Background(), client.PostInput{Author: "Anton", Title: " ", Content: "some content"})
+
+	assert.ErrorIs(t, err, client.ErrBadRequest)
+	var apiErr *client.APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, []client.FieldError{{Field: "body.title", Message: "must contain a visible character and no control characters"}}, apiErr.Fields)
+}
+
+func Test_Client_ListPosts_ShouldIterateOverAllPages(t *testing.T) {
+	router := SetupRouter()
+	var requests atomic.Int32
+	c := SetupClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			requests.Add(1)
+		}
+		router.ServeHTTP(w, r)
+	}))
+	ctx := context.Background()
+
+	for i := 1; i <= 5; i++ {
+		_, err := c.CreatePost(ctx, client.PostInput{Author: "Anton", Title: fmt.Sprintf("Post %d", i), Content: "content"})
+		assert.NoError(t, err)
+	}
+
+	it := c.ListPosts(ctx, client.ListOptions{PageSize: 2})
+	posts, err := it.All()
+
+	assert.NoError(t, err)
+	assert.Len(t, posts, 5)
+	for i, post := range posts {
+		assert.Equal(t, int64(i+1), post.ID)
+	}
+	assert.Equal(t, 5, it.Total())
+	assert.Equal(t, int32(3), requests.Load())
+}
+
+func Test_Client_ListPosts_Empty_ShouldStopWithoutError(t *testing.T) {
+	c := SetupClient(t, SetupRouter())
+
+	it := c.ListPosts(context.Background(), client.ListOptions{})
+
+	assert.False(t, it.Next())
+	assert.NoError(t, it.Err())
+}
+
+func Test_Client_UnavailableServer_ShouldRetryReads(t *testing.T) {
+	router := SetupRouter()
+	var attempts atomic.Int32
+	c := SetupClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/posts/1") && attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	ctx := context.Background()
+
+	_, err := c.CreatePost(ctx, client.PostInput{Author: "Anton", Title: "On golang", Content: "some content"})
+	assert.NoError(t, err)
+
+	post, err := c.GetPost(ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "On golang", post.Title)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func Test_Client_UnavailableServer_ShouldNotRetryCreate(t *testing.T) {
+	var attempts atomic.Int32
+	c := SetupClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+
+	_, err := c.CreatePost(context.Background(), client.PostInput{Author: "Anton", Title: "On golang", Content: "some content"})
+
+	assert.ErrorIs(t, err, client.ErrServer)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func Test_Client_RateLimited_ShouldRetryCreateAndGiveUp(t *testing.T) {
+	var attempts atomic.Int32
+	c := SetupClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusTooManyRequests)
+		fmt.Fprint(w, `{"errors":[{"status":429,"message":"Too many requests"}]}`)
+	}))
+
+	_, err := c.CreatePost(context.Background(), client.PostInput{Author: "Anton", Title: "On golang", Content: "some content"})
+
+	assert.ErrorIs(t, err, client.ErrRateLimited)
+	assert.EqualError(t, err, "blog api: 429: Too many requests")
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func Test_Client_WithBearerToken_ShouldSendAuthorization(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer access" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"errors":[{"status":401,"message":"Unauthorized: invalid access token"}]}`)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(testServer.Close)
+
+	err := client.New(testServer.URL, fastRetries).DeletePost(context.Background(), 1)
+	assert.ErrorIs(t, err, client.ErrUnauthorized)
+
+	err = client.New(testServer.URL, fastRetries, client.WithBearerToken("access")).DeletePost(context.Background(), 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,88 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	ErrBadRequest   = errors.New("bad request")
+	ErrNotFound     = errors.New("not found")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrForbidden    = errors.New("forbidden")
+	ErrTooLarge     = errors.New("request too large")
+	ErrRateLimited  = errors.New("rate limited")
+	ErrServer       = errors.New("server error")
+)
+
+// FieldError is a value of the request that violates the API contract.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// APIError is a failed response of the API. It matches one of the sentinel
+// errors with errors.Is, e.g. errors.Is(err, client.ErrNotFound).
+type APIError struct {
+	StatusCode int
+	Message    string
+	Fields     []FieldError
+}
+
+func (e *APIError) Error() string {
+	if len(e.Fields) == 0 {
+		return fmt.Sprintf("blog api: %d: %s", e.StatusCode, e.Message)
+	}
+
+	fields := make([]string, 0, len(e.Fields))
+	for _, field := range e.Fields {
+		fields = append(fields, field.Field+" "+field.Message)
+	}
+	return fmt.Sprintf("blog api: %d: %s", e.StatusCode, strings.Join(fields, ", "))
+}
+
+func (e *APIError) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusRequestEntityTooLarge:
+		return ErrTooLarge
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	case e.StatusCode >= http.StatusBadRequest:
+		return ErrBadRequest
+	default:
+		return nil
+	}
+}
+
+// errorEnvelope is the body of failed v2 responses.
+type errorEnvelope struct {
+	Errors []struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+		Field   string `json:"field"`
+	} `json:"errors"`
+}
+
+func (e *errorEnvelope) toAPIError(statusCode int) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Message: http.StatusText(statusCode)}
+	for _, item := range e.Errors {
+		if len(item.Field) > 0 {
+			apiErr.Fields = append(apiErr.Fields, FieldError{Field: item.Field, Message: item.Message})
+			apiErr.Message = "Request validation failed"
+		} else {
+			apiErr.Message = item.Message
+		}
+	}
+
+	return apiErr
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/client"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy/policytest"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// openPolicy lets anonymous requests write posts, so that the tests need no login.
+func SetupServerURL(t *testing.T) string {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	server.SetupMiddleware(router)
+	server.SetupValidation(router)
+	server.RegisterHandlers(router, usecase.NewBlogUseCase(repository.NewRepository(), repository.NewAuthorRepository(), events.NewBus(0), policytest.Open()))
+
+	testServer := httptest.NewServer(router)
+	t.Cleanup(testServer.Close)
+
+	return testServer.URL
+}
+
+func runCommand(t *testing.T, serverURL string, stdin string, args ...string) (string, error) {
+	var stdout bytes.Buffer
+	err := run(context.Background(), append([]string{"-server", serverURL}, args...), strings.NewReader(stdin), &stdout)
+
+	return stdout.String(), err
+}
+
+func Test_ParseMarkdown_ShouldReadFrontMatterAndHeading(t *testing.T) {
+	withFrontMatter, err := parseMarkdown("---\ntitle: On golang\nauthor: Anton\n---\n\nSome *content*\n")
+	assert.NoError(t, err)
+	assert.Equal(t, client.PostInput{Author: "Anton", Title: "On golang", Content: "Some *content*"}, withFrontMatter)
+
+	withHeading, err := parseMarkdown("# On golang\n\nFirst paragraph\n\n## Details\n")
+	assert.NoError(t, err)
+	assert.Equal(t, client.PostInput{Title: "On golang", Content: "First paragraph\n\n## Details"}, withHeading)
+
+	_, err = parseMarkdown("---\ntitle: On golang\n")
+	assert.Error(t, err)
+}
+
+func Test_FormatMarkdown_ShouldRoundTrip(t *testing.T) {
+	post := client.PostInput{Author: "Anton", Title: "On: golang", Content: "line 1\n\nline 2"}
+
+	parsed, err := parseMarkdown(formatMarkdown(post))
+
+	assert.NoError(t, err)
+	assert.Equal(t, post, parsed)
+}
+
+func Test_Run_ShouldCreateListAndGetPosts(t *testing.T) {
+	serverURL := SetupServerURL(t)
+
+	_, err := runCommand(t, serverURL, "# On golang\n\nsome content\n", "create", "-author", "Anton")
+	assert.NoError(t, err)
+
+	table, err := runCommand(t, serverURL, "", "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "ID  AUTHOR  TITLE\n1   Anton   On golang\n", table)
+
+	json, err := runCommand(t, serverURL, "", "-o", "json", "get", "1")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"author_id":1,"author":"Anton","title":"On golang","content":"some content"}`, json)
+
+	yaml, err := runCommand(t, serverURL, "", "-o", "yaml", "list")
+	assert.NoError(t, err)
+	assert.Equal(t, "- id: 1\n  author_id: 1\n  author: Anton\n  title: On golang\n  content: some content\n", yaml)
+}
+
+func Test_Run_Edit_ShouldUpdatePostFromEditor(t *testing.T) {
+	serverURL := SetupServerURL(t)
+	t.Setenv("EDITOR", "sed -i s/golang/rust/")
+
+	_, err := runCommand(t, serverURL, "---\ntitle: On golang\nauthor: Anton\n---\nsome content", "create")
+	assert.NoError(t, err)
+
+	_, err = runCommand(t, serverURL, "", "edit", "1")
+	assert.NoError(t, err)
+
+	json, err := runCommand(t, serverURL, "", "-o", "json", "get", "1")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"author_id":1,"author":"Anton","title":"On rust","content":"some content"}`, json)
+}
+
+func Test_Run_EditDraft_ShouldKeepDraft(t *testing.T) {
+	serverURL := SetupServerURL(t)
+	t.Setenv("EDITOR", "sed -i s/golang/rust/")
+
+	_, err := runCommand(t, serverURL, "---\ntitle: On golang\nauthor: Anton\ndraft: true\n---\nsome content", "create")
+	assert.NoError(t, err)
+
+	_, err = runCommand(t, serverURL, "", "edit", "1")
+	assert.NoError(t, err)
+
+	json, err := runCommand(t, serverURL, "", "-o", "json", "get", "1")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id":1,"author_id":1,"author":"Anton","title":"On rust","content":"some content","draft":true}`, json)
+}
+
+func Test_Run_Delete_ShouldRemovePost(t *testing.T) {
+	serverURL := SetupServerURL(t)
+
+	_, err := runCommand(t, serverURL, "# On golang\n\nsome content", "create", "-author", "Anton")
+	assert.NoError(t, err)
+
+	out, err := runCommand(t, serverURL, "", "delete", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "Post 1 was deleted.\n", out)
+
+	_, err = runCommand(t, serverURL, "", "get", "1")
+	assert.ErrorIs(t, err, client.ErrNotFound)
+}
+
+func Test_Run_InvalidArguments_ShouldFail(t *testing.T) {
+	serverURL := SetupServerURL(t)
+
+	_, err := runCommand(t, serverURL, "", "get", "abc")
+	assert.EqualError(t, err, `invalid post ID "abc"`)
+
+	_, err = runCommand(t, serverURL, "", "-o", "xml", "list")
+	assert.EqualError(t, err, `unknown output format "xml"`)
+
+	_, err = runCommand(t, serverURL, "", "publish")
+	assert.EqualError(t, err, `unknown command "publish"`)
+}
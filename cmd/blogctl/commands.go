@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/kondrushin/blog/client"
+)
+
+const usage = `Usage: blogctl [flags] <command> [arguments]
+
+Commands:
+  list                       List all posts
+  get <id>                   Show a post
+  create [-author name] [file.md]
+                             Create a post from a Markdown file, or from stdin without a file
+  edit <id>                  Edit a post in $EDITOR
+  delete <id>                Delete a post
+
+Flags:
+`
+
+type command struct {
+	client *client.Client
+	output string
+	stdin  io.Reader
+	stdout io.Writer
+}
+
+func run(ctx context.Context, args []string, stdin io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("blogctl", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	serverURL := flags.String("server", envOr("BLOG_SERVER", "http://localhost:8080"), "Address of the blog server, or $BLOG_SERVER")
+	apiKey := flags.String("api-key", os.Getenv("BLOG_API_KEY"), "API key sent to the server, or $BLOG_API_KEY")
+	token := flags.String("token", os.Getenv("BLOG_TOKEN"), "Access token of a login, or $BLOG_TOKEN")
+	output := flags.String("o", formatTable, "Output format: table, json or yaml")
+	flags.Usage = func() {
+		fmt.Fprint(stdout, usage)
+		flags.PrintDefaults()
+	}
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if !isFormat(*output) {
+		return fmt.Errorf("unknown output format %q", *output)
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no command given")
+	}
+
+	var opts []client.Option
+	if len(*apiKey) > 0 {
+		opts = append(opts, client.WithAPIKey(*apiKey))
+	}
+	if len(*token) > 0 {
+		opts = append(opts, client.WithBearerToken(*token))
+	}
+	cmd := command{
+		client: client.New(*serverURL, opts...),
+		output: *output,
+		stdin:  stdin,
+		stdout: stdout,
+	}
+
+	name, rest := flags.Arg(0), flags.Args()[1:]
+	switch name {
+	case "list":
+		return cmd.list(ctx)
+	case "get":
+		return withID(rest, func(id int64) error { return cmd.get(ctx, id) })
+	case "create":
+		return cmd.create(ctx, rest)
+	case "edit":
+		return withID(rest, func(id int64) error { return cmd.edit(ctx, id) })
+	case "delete":
+		return withID(rest, func(id int64) error { return cmd.delete(ctx, id) })
+	default:
+		return fmt.Errorf("unknown command %q", name)
+	}
+}
+
+func (cmd *command) list(ctx context.Context) error {
+	posts, err := cmd.client.ListPosts(ctx, client.ListOptions{PageSize: 100}).All()
+	if err != nil {
+		return err
+	}
+
+	return writePosts(cmd.stdout, cmd.output, posts)
+}
+
+func (cmd *command) get(ctx context.Context, id int64) error {
+	post, err := cmd.client.GetPost(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	return writePost(cmd.stdout, cmd.output, *post)
+}
+
+func (cmd *command) create(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("create", flag.ContinueOnError)
+	flags.SetOutput(cmd.stdout)
+	author := flags.String("author", "", "Author of the post, overrides the author in the front matter")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	source := cmd.stdin
+	if flags.NArg() > 0 {
+		file, err := os.Open(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		source = file
+	}
+
+	text, err := io.ReadAll(source)
+	if err != nil {
+		return err
+	}
+
+	input, err := parseMarkdown(string(text))
+	if err != nil {
+		return err
+	}
+	if len(*author) > 0 {
+		input.Author = *author
+	}
+
+	post, err := cmd.client.CreatePost(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return writePost(cmd.stdout, cmd.output, *post)
+}
+
+// edit opens the post as Markdown in $EDITOR and saves it when it was changed.
+func (cmd *command) edit(ctx context.Context, id int64) error {
+	post, err := cmd.client.GetPost(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.CreateTemp("", fmt.Sprintf("blogctl-post-%d-*.md", id))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	original := formatMarkdown(client.PostInput{Author: post.Author, Title: post.Title, Content: post.Content, Draft: post.Draft})
+	_, err = file.WriteString(original)
+	file.Close()
+	if err != nil {
+		return err
+	}
+
+	if err := openEditor(file.Name()); err != nil {
+		return err
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return err
+	}
+	if string(edited) == original {
+		fmt.Fprintln(cmd.stdout, "Post was not changed.")
+		return nil
+	}
+
+	input, err := parseMarkdown(string(edited))
+	if err != nil {
+		return err
+	}
+
+	updated, err := cmd.client.UpdatePost(ctx, id, input)
+	if err != nil {
+		return err
+	}
+
+	return writePost(cmd.stdout, cmd.output, *updated)
+}
+
+func (cmd *command) delete(ctx context.Context, id int64) error {
+	if err := cmd.client.DeletePost(ctx, id); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.stdout, "Post %d was deleted.\n", id)
+	return nil
+}
+
+// openEditor runs $EDITOR through the shell, so that it may contain arguments, e.g. "code --wait".
+func openEditor(path string) error {
+	editor := envOr("EDITOR", "vi")
+
+	cmd := exec.Command("sh", "-c", editor+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", editor, err)
+	}
+	return nil
+}
+
+func withID(args []string, f func(id int64) error) error {
+	if len(args) != 1 {
+		return errors.New("expected a post ID")
+	}
+
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid post ID %q", args[0])
+	}
+
+	return f(id)
+}
+
+func envOr(name string, fallback string) string {
+	if value, isIn := os.LookupEnv(name); isIn && len(value) > 0 {
+		return value
+	}
+	return fallback
+}
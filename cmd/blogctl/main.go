@@ -0,0 +1,15 @@
+// Command blogctl manages posts of a running blog server through its HTTP API.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "blogctl:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kondrushin/blog/client"
+)
+
+type frontMatter struct {
+	Title  string `yaml:"title"`
+	Author string `yaml:"author"`
+	Draft  bool   `yaml:"draft,omitempty"`
+}
+
+// parseMarkdown reads a post from Markdown with optional YAML front matter.
+// Without a title in the front matter, a leading "# Heading" becomes the title.
+func parseMarkdown(text string) (client.PostInput, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	var meta frontMatter
+	if rest, found := strings.CutPrefix(text, "---\n"); found {
+		header, body, found := strings.Cut(rest, "\n---\n")
+		if !found {
+			return client.PostInput{}, errors.New("front matter is not closed with ---")
+		}
+		if err := yaml.Unmarshal([]byte(header), &meta); err != nil {
+			return client.PostInput{}, err
+		}
+		text = body
+	}
+
+	text = strings.TrimLeft(text, "\n")
+	if heading, found := strings.CutPrefix(text, "# "); found && len(meta.Title) == 0 {
+		title, body, _ := strings.Cut(heading, "\n")
+		meta.Title = strings.TrimSpace(title)
+		text = body
+	}
+
+	return client.PostInput{
+		Author:  meta.Author,
+		Title:   meta.Title,
+		Content: strings.TrimSpace(text),
+		Draft:   meta.Draft,
+	}, nil
+}
+
+// formatMarkdown is the inverse of parseMarkdown.
+func formatMarkdown(post client.PostInput) string {
+	header, _ := yaml.Marshal(frontMatter{Title: post.Title, Author: post.Author, Draft: post.Draft})
+
+	return "---\n" + string(header) + "---\n\n" + post.Content + "\n"
+}
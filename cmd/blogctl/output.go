@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kondrushin/blog/client"
+)
+
+const (
+	formatTable = "table"
+	formatJSON  = "json"
+	formatYAML  = "yaml"
+)
+
+// maxCellWidth truncates long titles in tables.
+const maxCellWidth = 50
+
+func isFormat(format string) bool {
+	return format == formatTable || format == formatJSON || format == formatYAML
+}
+
+// outputPost names the fields in YAML like in JSON.
+type outputPost struct {
+	ID       int64  `json:"id" yaml:"id"`
+	AuthorID int64  `json:"author_id" yaml:"author_id"`
+	Author   string `json:"author" yaml:"author"`
+	Title    string `json:"title" yaml:"title"`
+	Content  string `json:"content" yaml:"content"`
+	Draft    bool   `json:"draft,omitempty" yaml:"draft,omitempty"`
+}
+
+func writePosts(w io.Writer, format string, posts []client.Post) error {
+	output := make([]outputPost, 0, len(posts))
+	for _, post := range posts {
+		output = append(output, outputPost(post))
+	}
+
+	switch format {
+	case formatJSON:
+		return writeJSON(w, output)
+	case formatYAML:
+		return yaml.NewEncoder(w).Encode(output)
+	default:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tAUTHOR\tTITLE")
+		for _, post := range output {
+			fmt.Fprintf(table, "%d\t%s\t%s\n", post.ID, post.Author, truncate(post.Title))
+		}
+		return table.Flush()
+	}
+}
+
+func writePost(w io.Writer, format string, post client.Post) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, outputPost(post))
+	case formatYAML:
+		return yaml.NewEncoder(w).Encode(outputPost(post))
+	default:
+		table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(table, "ID:\t%d\nAuthor:\t%s\nTitle:\t%s\n", post.ID, post.Author, post.Title)
+		if err := table.Flush(); err != nil {
+			return err
+		}
+		_, err := fmt.Fprintf(w, "\n%s\n", post.Content)
+		return err
+	}
+}
+
+func writeJSON(w io.Writer, value any) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func truncate(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxCellWidth {
+		return text
+	}
+
+	return string([]rune(text)[:maxCellWidth-1]) + "…"
+}
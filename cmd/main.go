@@ -2,51 +2,71 @@ package main
 
 import (
 	"context"
-	"flag"
-
-	"github.com/gin-gonic/gin"
-	"github.com/kondrushin/blog/internal/repository"
-	"github.com/kondrushin/blog/internal/seeding"
-	"github.com/kondrushin/blog/internal/server"
-	"github.com/kondrushin/blog/internal/usecase"
-
 	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
 )
 
-func main() {
-	dataFilePath := flag.String("seed", "", "Location of a data file to seed the database")
-	flag.Parse()
-
-	engine := gin.Default()
-	server.SetupMiddleware(engine)
+const usage = `Usage: cmd [command] [flags] [arguments]
 
-	repository := repository.NewRepository()
-	usecase := usecase.NewBlogUseCase(repository)
-	server.RegisterHandlers(engine, usecase)
+Commands:
+  serve                      Run the HTTP and gRPC servers (default)
+  seed <file.json>           Add the posts of a data file to the store
+  export [file.json]         Write the posts of the store as a data file, to stdout without a file
+  verify                     Check the integrity of the store and its ID sequence
+  compact                    Rewrite the journal of the store without overwritten and deleted posts
+  reindex                    Rebuild the index of the store from its journal
+  grant-admin <email>        Give the registered user with the email the admin role
 
-	slog.Info("Service started")
+Every command except serve works with a stopped server. Run "cmd <command> -h" for its flags.
+`
 
-	if len(*dataFilePath) > 0 {
-		seed(dataFilePath, repository)
-	}
+type subcommand func(ctx context.Context, args []string, stdout io.Writer) error
 
-	engine.Run(":8080")
+var subcommands = map[string]subcommand{
+	"serve":       serve,
+	"seed":        seedStore,
+	"export":      exportStore,
+	"verify":      verifyStore,
+	"compact":     compactStore,
+	"reindex":     reindexStore,
+	"grant-admin": grantAdmin,
 }
 
-func seed(dataFilePath *string, repository *repository.Repository) {
-	if _, err := os.Stat(*dataFilePath); err == nil {
-		slog.Info("DB seeding started.", "source", *dataFilePath)
-		err := seeding.Seed(context.Background(), *dataFilePath, repository)
-		if err != nil {
-			slog.Error("Error while seeding.", "error", err)
-			return
+func main() {
+	if err := run(context.Background(), os.Args[1:], os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			slog.Error("Command failed.", "error", err)
 		}
+		os.Exit(1)
+	}
+}
 
-		slog.Info("DB seeding is completed.")
+// run dispatches to a subcommand. Without one, or when the arguments start with
+// a flag, the servers are run.
+func run(ctx context.Context, args []string, stdout io.Writer) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(ctx, args, stdout)
+	}
 
-	} else if errors.Is(err, os.ErrNotExist) {
-		slog.Error("Data file is not found.", "file", *dataFilePath)
+	cmd, isIn := subcommands[args[0]]
+	if !isIn {
+		fmt.Fprint(stdout, usage)
+		return fmt.Errorf("unknown command %q", args[0])
 	}
+
+	return cmd(ctx, args[1:], stdout)
+}
+
+// newFlagSet creates the flags of a subcommand with the data directory flag shared by all of them.
+func newFlagSet(name string, stdout io.Writer) (*flag.FlagSet, *string) {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	dataDir := flags.String("data", "", "Directory of the post store, empty keeps posts in memory")
+
+	return flags, dataDir
 }
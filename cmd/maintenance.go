@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/seeding"
+	"github.com/kondrushin/blog/internal/storage"
+)
+
+var errorNoDataDir = errors.New("the -data directory of the store is required")
+
+// repositories share the store of a data directory.
+type repositories struct {
+	posts       *repository.Repository
+	authors     *repository.AuthorRepository
+	audit       *repository.AuditRepository
+	attachments *repository.AttachmentRepository
+	users       *repository.UserRepository
+	sessions    *repository.SessionRepository
+	webhooks    *repository.WebhookRepository
+	close       func() error
+}
+
+// openRepositories opens the store in dataDir with config, or keeps posts,
+// authors, attachments, the audit log, users, sessions and webhooks in memory
+// when it is empty.
+func openRepositories(dataDir string, config storage.Config) (*repositories, error) {
+	if len(dataDir) == 0 {
+		return &repositories{
+			posts:       repository.NewRepository(),
+			authors:     repository.NewAuthorRepository(),
+			audit:       repository.NewAuditRepository(),
+			attachments: repository.NewAttachmentRepository(),
+			users:       repository.NewUserRepository(),
+			sessions:    repository.NewSessionRepository(),
+			webhooks:    repository.NewWebhookRepository(),
+			close:       func() error { return nil },
+		}, nil
+	}
+
+	store, err := storage.OpenWithConfig(dataDir, config)
+	if err != nil {
+		return nil, err
+	}
+
+	posts, err := repository.NewPersistentRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	authors, err := repository.NewPersistentAuthorRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	audit, err := repository.NewPersistentAuditRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	attachments, err := repository.NewPersistentAttachmentRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	users, err := repository.NewPersistentUserRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	sessions, err := repository.NewPersistentSessionRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	webhooks, err := repository.NewPersistentWebhookRepository(store)
+	if err != nil {
+		store.Close()
+		return nil, err
+	}
+
+	return &repositories{
+		posts:       posts,
+		authors:     authors,
+		audit:       audit,
+		attachments: attachments,
+		users:       users,
+		sessions:    sessions,
+		webhooks:    webhooks,
+		close:       store.Close,
+	}, nil
+}
+
+func seedStore(ctx context.Context, args []string, stdout io.Writer) (err error) {
+	flags, dataDir := newFlagSet("seed", stdout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*dataDir) == 0 {
+		return errorNoDataDir
+	}
+	if flags.NArg() != 1 {
+		return errors.New("seed expects a data file")
+	}
+
+	repos, err := openRepositories(*dataDir, storage.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, repos.close()) }()
+
+	before := len(repos.posts.GetPosts(ctx))
+	if err := seeding.Seed(ctx, flags.Arg(0), repos.posts, repos.authors); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "Seeded %d posts.\n", len(repos.posts.GetPosts(ctx))-before)
+	return nil
+}
+
+func exportStore(ctx context.Context, args []string, stdout io.Writer) (err error) {
+	flags, dataDir := newFlagSet("export", stdout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*dataDir) == 0 {
+		return errorNoDataDir
+	}
+
+	repos, err := openRepositories(*dataDir, storage.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, repos.close()) }()
+
+	if flags.NArg() == 0 {
+		return seeding.Export(stdout, repos.posts.GetPosts(ctx))
+	}
+
+	file, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, file.Close()) }()
+
+	return seeding.Export(file, repos.posts.GetPosts(ctx))
+}
+
+// grantAdmin gives a registered user the admin role. It is the only way to get
+// the first admin, who then assigns the roles of other users over the API.
+func grantAdmin(ctx context.Context, args []string, stdout io.Writer) (err error) {
+	flags, dataDir := newFlagSet("grant-admin", stdout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*dataDir) == 0 {
+		return errorNoDataDir
+	}
+	if flags.NArg() != 1 {
+		return errors.New("grant-admin expects the email of a registered user")
+	}
+
+	repos, err := openRepositories(*dataDir, storage.DefaultConfig())
+	if err != nil {
+		return err
+	}
+	defer func() { err = errors.Join(err, repos.close()) }()
+
+	user, err := repos.users.GetUserByEmail(ctx, flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("%s: %w", flags.Arg(0), err)
+	}
+	if _, err := repos.users.SetUserRole(ctx, user.ID, domain.RoleAdmin, user.AuthorID); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "User %d (%s) is an admin.\n", user.ID, user.Email)
+	return nil
+}
+
+func verifyStore(ctx context.Context, args []string, stdout io.Writer) error {
+	flags, dataDir := newFlagSet("verify", stdout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*dataDir) == 0 {
+		return errorNoDataDir
+	}
+
+	report, err := storage.Verify(*dataDir)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "%d records, %d posts, %d authors, %d attachments, %d users, %d sessions, %d webhooks, %d deliveries, sequence %d\n", report.Records, report.Posts, report.Authors, report.Attachments, report.Users, report.Sessions, report.Webhooks, report.Deliveries, report.Sequence)
+	for _, problem := range report.Problems {
+		fmt.Fprintf(stdout, "problem: %s\n", problem)
+	}
+	if !report.OK() {
+		return fmt.Errorf("found %d problems", len(report.Problems))
+	}
+
+	fmt.Fprintln(stdout, "OK")
+	return nil
+}
+
+func compactStore(ctx context.Context, args []string, stdout io.Writer) error {
+	flags, dataDir := newFlagSet("compact", stdout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*dataDir) == 0 {
+		return errorNoDataDir
+	}
+
+	return storage.Compact(*dataDir)
+}
+
+func reindexStore(ctx context.Context, args []string, stdout io.Writer) error {
+	flags, dataDir := newFlagSet("reindex", stdout)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if len(*dataDir) == 0 {
+		return errorNoDataDir
+	}
+
+	return storage.Reindex(*dataDir)
+}
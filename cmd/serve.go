@@ -0,0 +1,317 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/audit"
+	"github.com/kondrushin/blog/internal/blob"
+	"github.com/kondrushin/blog/internal/cache"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/gql"
+	"github.com/kondrushin/blog/internal/idempotency"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/rpc"
+	"github.com/kondrushin/blog/internal/seeding"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/kondrushin/blog/internal/tracing"
+	"github.com/kondrushin/blog/internal/trash"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/webhook"
+	"google.golang.org/grpc"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func serve(ctx context.Context, args []string, stdout io.Writer) error {
+	flags, dataDir := newFlagSet("serve", stdout)
+	storeConfig := storage.DefaultConfig()
+	flags.StringVar(&storeConfig.Sync, "fsync", storeConfig.Sync, "When the store flushes writes to the disk: always, periodic or close")
+	flags.DurationVar(&storeConfig.SyncInterval, "fsync-interval", storeConfig.SyncInterval, "How often the store flushes writes to the disk with -fsync periodic")
+	addr := flags.String("addr", ":8080", "Address of the HTTP server")
+	dataFilePath := flags.String("seed", "", "Location of a data file to seed the database")
+	traceExporter := flags.String("trace-exporter", tracing.ExporterNone, "Trace exporter: none, stdout or otlp")
+	otlpEndpoint := flags.String("otlp-endpoint", "", "OTLP/HTTP collector address, e.g. localhost:4318")
+	readRate := flags.Float64("read-rate", 20, "Sustained read requests per second per client")
+	readBurst := flags.Int("read-burst", 40, "Read requests a client can make at once")
+	writeRate := flags.Float64("write-rate", 2, "Sustained write requests per second per client")
+	writeBurst := flags.Int("write-burst", 5, "Write requests a client can make at once")
+	trustedProxies := flags.String("trusted-proxies", "", "Comma-separated IPs or CIDRs of the proxies whose X-Forwarded-For header is trusted, the client IP is the peer address without them")
+	apiKeysPath := flags.String("api-keys", "", "Location of a file with an API key per line, clients sending one of them in X-API-Key get rate limit budgets of their own")
+	maxBodyBytes := flags.Int64("max-body-bytes", 1<<20, "Largest accepted request body in bytes")
+	attachmentConfig := usecase.DefaultAttachmentConfig()
+	flags.Int64Var(&attachmentConfig.MaxBytes, "max-attachment-bytes", attachmentConfig.MaxBytes, "Largest accepted attachment in bytes")
+	var s3Config blob.S3Config
+	flags.StringVar(&s3Config.Endpoint, "s3-endpoint", "", "URL of an S3-compatible storage for attachments, which are kept in the data directory without it")
+	flags.StringVar(&s3Config.Bucket, "s3-bucket", "", "Bucket of the attachments in the S3-compatible storage")
+	flags.StringVar(&s3Config.Region, "s3-region", "us-east-1", "Region of the S3-compatible storage")
+	flags.DurationVar(&s3Config.Timeout, "s3-timeout", blob.DefaultS3Timeout, "How long a request to the S3-compatible storage may take, reading attachments only waits this long for a response")
+	idempotencyConfig := idempotency.DefaultConfig()
+	flags.DurationVar(&idempotencyConfig.TTL, "idempotency-ttl", idempotencyConfig.TTL, "How long responses to POST requests with an Idempotency-Key are replayed")
+	flags.IntVar(&idempotencyConfig.MaxKeysPerScope, "idempotency-max-keys", idempotencyConfig.MaxKeysPerScope, "Idempotency keys kept per user or anonymous client")
+	flags.Int64Var(&idempotencyConfig.MaxBytesPerScope, "idempotency-max-bytes", idempotencyConfig.MaxBytesPerScope, "Bytes of replayed responses kept per user or anonymous client")
+	compressMinBytes := flags.Int("compress-min-bytes", 1024, "Smallest response body in bytes that is compressed, 0 disables compression")
+	cacheSize := flags.Int("cache-size", 1000, "Number of cached posts and post lists, 0 disables caching")
+	eventReplaySize := flags.Int("event-replay-size", 1000, "Number of recent post events kept for reconnecting subscribers")
+	graphQLMaxDepth := flags.Int("graphql-max-depth", gql.DefaultLimits.MaxDepth, "Deepest field nesting of a GraphQL query")
+	graphQLMaxComplexity := flags.Int("graphql-max-complexity", gql.DefaultLimits.MaxComplexity, "Highest complexity of a GraphQL query")
+	graphiQLAssets := flags.String("graphiql-assets", "", "Directory with the GraphiQL assets, the GraphiQL IDE is served at GET /graphql only with it")
+	grpcAddr := flags.String("grpc-addr", ":9090", "Address of the gRPC server, empty disables it")
+	trashConfig := trash.DefaultConfig()
+	flags.DurationVar(&trashConfig.Retention, "trash-retention", trashConfig.Retention, "How long deleted posts can be restored before they are purged")
+	flags.DurationVar(&trashConfig.Interval, "purge-interval", trashConfig.Interval, "How often the trash is purged")
+	webhookConfig := webhook.DefaultConfig()
+	flags.DurationVar(&webhookConfig.Retention, "webhook-retention", webhookConfig.Retention, "How long completed webhook deliveries are kept, 0 keeps them")
+	flags.IntVar(&webhookConfig.MaxDeliveriesPerWebhook, "webhook-max-deliveries", webhookConfig.MaxDeliveriesPerWebhook, "Completed deliveries kept per webhook, 0 keeps all of them")
+	flags.IntVar(&webhookConfig.Concurrency, "webhook-concurrency", webhookConfig.Concurrency, "Webhook deliveries attempted at the same time")
+	flags.BoolVar(&webhookConfig.AllowPrivateTargets, "webhook-allow-private", false, "Let webhooks reach loopback, private and link-local addresses")
+	policyPath := flags.String("policy", "", "Location of a YAML policy of the roles, the default policy is used without it")
+	authConfig := usecase.DefaultAuthConfig()
+	flags.DurationVar(&authConfig.AccessTokenTTL, "access-token-ttl", authConfig.AccessTokenTTL, "Lifetime of access tokens")
+	flags.DurationVar(&authConfig.RefreshTokenTTL, "refresh-token-ttl", authConfig.RefreshTokenTTL, "Lifetime of refresh tokens")
+	flags.IntVar(&authConfig.MaxFailedLogins, "max-failed-logins", authConfig.MaxFailedLogins, "Failed logins in a row that lock an account")
+	flags.DurationVar(&authConfig.LockoutDuration, "lockout-duration", authConfig.LockoutDuration, "How long an account stays locked")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Config{
+		Exporter: *traceExporter,
+		Endpoint: *otlpEndpoint,
+	})
+	if err != nil {
+		return err
+	}
+	defer shutdownTracing(context.Background())
+
+	blogPolicy, err := loadPolicy(*policyPath)
+	if err != nil {
+		return err
+	}
+
+	apiKeys, err := loadAPIKeys(*apiKeysPath)
+	if err != nil {
+		return err
+	}
+
+	repos, err := openRepositories(*dataDir, storeConfig)
+	if err != nil {
+		return err
+	}
+	defer repos.close()
+
+	engine := gin.Default()
+	if err := server.SetupTrustedProxies(engine, splitList(*trustedProxies)); err != nil {
+		return err
+	}
+	server.SetupMiddleware(engine)
+	if *compressMinBytes > 0 {
+		server.SetupCompression(engine, *compressMinBytes)
+	}
+	server.SetupLimits(engine, server.LimitsConfig{
+		ReadRate:       *readRate,
+		ReadBurst:      *readBurst,
+		WriteRate:      *writeRate,
+		WriteBurst:     *writeBurst,
+		MaxBodyBytes:   *maxBodyBytes,
+		MaxUploadBytes: attachmentConfig.MaxBytes + multipartOverhead,
+		APIKeys:        apiKeys,
+	})
+	server.SetupValidation(engine)
+
+	authUseCase := usecase.NewAuthUseCase(repos.users, repos.sessions, repos.authors, blogPolicy, authConfig)
+	server.SetupAuthentication(engine, authUseCase)
+	server.SetupIdempotency(engine, idempotencyConfig)
+	server.RegisterAuthHandlers(engine, authUseCase)
+
+	dispatcher := webhook.NewDispatcher(repos.webhooks, webhookConfig)
+	go dispatcher.Run(ctx)
+
+	eventBus := events.NewBus(*eventReplaySize)
+	eventBus.AddHandler(dispatcher.HandleEvent)
+	eventUseCase := usecase.NewEventUseCase(eventBus, blogPolicy)
+	server.RegisterEventHandlers(engine, eventUseCase)
+	server.RegisterWebhookHandlers(engine, usecase.NewWebhookUseCase(repos.webhooks, dispatcher, blogPolicy))
+
+	// The cache is behind the use case, so that cached posts are authorized too.
+	var postRepository usecase.IBlogRepository = tracing.NewRepository(repos.posts)
+	if *cacheSize > 0 {
+		cachedRepository := cache.NewUseCase(postRepository, *cacheSize)
+		server.RegisterCacheStats(engine, usecase.NewCacheStatsUseCase(cachedRepository, blogPolicy))
+		postRepository = cachedRepository
+	}
+	blogUseCase := usecase.NewBlogUseCase(postRepository, repos.authors, eventBus, blogPolicy)
+	tracedUseCase := tracing.NewUseCase(audit.NewUseCase(blogUseCase, repos.audit))
+	server.RegisterHandlers(engine, tracedUseCase)
+	server.RegisterTrashHandlers(engine, tracedUseCase)
+
+	blobs, closeBlobs, err := openBlobStore(*dataDir, s3Config)
+	if err != nil {
+		return err
+	}
+	defer closeBlobs()
+	attachmentUseCase := usecase.NewAttachmentUseCase(repos.attachments, blobs, postRepository, blogPolicy, attachmentConfig)
+	server.RegisterAttachmentHandlers(engine, attachmentUseCase)
+	go trash.NewPurger(repos.posts, repos.audit, trashConfig).WithAttachments(attachmentUseCase).Run(ctx)
+	server.RegisterAuditHandlers(engine, usecase.NewAuditUseCase(repos.audit, blogPolicy))
+	server.RegisterAuthorHandlers(engine, usecase.NewAuthorUseCase(repos.authors, tracedUseCase, blogPolicy))
+	server.RegisterOpenAPI(engine)
+	err = server.RegisterGraphQL(engine, tracedUseCase, server.GraphQLConfig{
+		Limits:         gql.Limits{MaxDepth: *graphQLMaxDepth, MaxComplexity: *graphQLMaxComplexity},
+		GraphiQLAssets: *graphiQLAssets,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Seeding writes to the repositories behind the cache, so it must be done
+	// before any request can fill the cache.
+	if len(*dataFilePath) > 0 {
+		seed(dataFilePath, repos)
+	}
+
+	if len(*grpcAddr) > 0 {
+		grpcServer := rpc.NewServer(tracedUseCase, eventUseCase, authUseCase)
+		go serveGRPC(*grpcAddr, grpcServer)
+		defer grpcServer.GracefulStop()
+	}
+
+	slog.Info("Service started")
+
+	httpServer := &http.Server{Addr: *addr, Handler: engine}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	if err := httpServer.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	slog.Info("Service stopped")
+	return nil
+}
+
+// multipartOverhead is the room for the headers and boundaries of a multipart
+// body around an attachment.
+const multipartOverhead = 64 << 10
+
+// openBlobStore keeps attachments in the S3-compatible storage when it is
+// configured, with the credentials of the AWS_ACCESS_KEY_ID and
+// AWS_SECRET_ACCESS_KEY environment variables. Otherwise they are kept in the
+// blobs directory of the store, or in a temporary directory without one.
+func openBlobStore(dataDir string, s3Config blob.S3Config) (usecase.IBlobStore, func(), error) {
+	if len(s3Config.Endpoint) > 0 {
+		s3Config.AccessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+		s3Config.SecretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		store, err := blob.NewS3Store(s3Config)
+		return store, func() {}, err
+	}
+
+	if len(dataDir) > 0 {
+		store, err := blob.NewFileStore(filepath.Join(dataDir, "blobs"))
+		return store, func() {}, err
+	}
+
+	dir, err := os.MkdirTemp("", "blog-blobs-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	store, err := blob.NewFileStore(dir)
+	return store, func() { os.RemoveAll(dir) }, err
+}
+
+func loadPolicy(path string) (*policy.Policy, error) {
+	if len(path) == 0 {
+		return policy.Default(), nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return policy.Load(file)
+}
+
+// loadAPIKeys reads the non-empty lines of the file at path, which are trimmed.
+// Lines starting with # are comments.
+func loadAPIKeys(path string) ([]string, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	for _, line := range strings.Split(string(data), "\n") {
+		key := strings.TrimSpace(line)
+		if len(key) > 0 && !strings.HasPrefix(key, "#") {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys, nil
+}
+
+// splitList returns the trimmed non-empty items of a comma-separated list.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+func serveGRPC(addr string, grpcServer *grpc.Server) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		slog.Error("Could not listen for gRPC.", "addr", addr, "error", err)
+		os.Exit(1)
+	}
+
+	slog.Info("gRPC server started", "addr", addr)
+	if err := grpcServer.Serve(listener); err != nil {
+		slog.Error("gRPC server stopped.", "error", err)
+	}
+}
+
+func seed(dataFilePath *string, repos *repositories) {
+	if _, err := os.Stat(*dataFilePath); err == nil {
+		slog.Info("DB seeding started.", "source", *dataFilePath)
+		err := seeding.Seed(context.Background(), *dataFilePath, repos.posts, repos.authors)
+		if err != nil {
+			slog.Error("Error while seeding.", "error", err)
+			return
+		}
+
+		slog.Info("DB seeding is completed.")
+
+	} else if errors.Is(err, os.ErrNotExist) {
+		slog.Error("Data file is not found.", "file", *dataFilePath)
+	}
+}
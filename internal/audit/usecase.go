@@ -0,0 +1,141 @@
+// Package audit records who changed which post, when and how.
+package audit
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/requestid"
+)
+
+type IBlogUseCase interface {
+	GetPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetPosts(ctx context.Context) []*domain.Post
+	EachPost(ctx context.Context, yield func(*domain.Post) bool) error
+	CreatePost(ctx context.Context, p *domain.Post) (int64, error)
+	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
+	DeletePost(ctx context.Context, id int64) error
+	GetDeletedPosts(ctx context.Context) ([]*domain.Post, error)
+	RestorePost(ctx context.Context, id int64) (*domain.Post, error)
+}
+
+// ILog is the append-only audit log.
+type ILog interface {
+	AppendEntry(ctx context.Context, entry *domain.AuditEntry) error
+}
+
+// UseCase wraps an IBlogUseCase and appends an entry to the audit log for every
+// successful change. Updated and deleted posts are read before the change, so
+// that the entry has the post before and after it.
+type UseCase struct {
+	next IBlogUseCase
+	log  ILog
+	now  func() time.Time
+}
+
+func NewUseCase(next IBlogUseCase, log ILog) *UseCase {
+	return &UseCase{next: next, log: log, now: time.Now}
+}
+
+// WithClock sets the clock that stamps the recorded changes.
+func (u *UseCase) WithClock(now func() time.Time) *UseCase {
+	u.now = now
+	return u
+}
+
+func (u *UseCase) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
+	return u.next.GetPost(ctx, id)
+}
+
+func (u *UseCase) GetPosts(ctx context.Context) []*domain.Post {
+	return u.next.GetPosts(ctx)
+}
+
+func (u *UseCase) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	return u.next.EachPost(ctx, yield)
+}
+
+func (u *UseCase) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
+	id, err := u.next.CreatePost(ctx, post)
+	if err != nil {
+		return id, err
+	}
+
+	u.record(ctx, domain.AuditPostCreated, id, nil, withID(post, id))
+	return id, nil
+}
+
+func (u *UseCase) UpdatePost(ctx context.Context, post *domain.Post, id int64) error {
+	before := u.read(ctx, id)
+	if err := u.next.UpdatePost(ctx, post, id); err != nil {
+		return err
+	}
+
+	u.record(ctx, domain.AuditPostUpdated, id, before, withID(post, id))
+	return nil
+}
+
+func (u *UseCase) DeletePost(ctx context.Context, id int64) error {
+	before := u.read(ctx, id)
+	if err := u.next.DeletePost(ctx, id); err != nil {
+		return err
+	}
+
+	u.record(ctx, domain.AuditPostDeleted, id, before, nil)
+	return nil
+}
+
+func (u *UseCase) GetDeletedPosts(ctx context.Context) ([]*domain.Post, error) {
+	return u.next.GetDeletedPosts(ctx)
+}
+
+func (u *UseCase) RestorePost(ctx context.Context, id int64) (*domain.Post, error) {
+	post, err := u.next.RestorePost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.record(ctx, domain.AuditPostRestored, id, nil, withID(post, id))
+	return post, nil
+}
+
+// read returns a copy of the post before a change. A post that cannot be read
+// is recorded without its previous state, the change itself decides on errors.
+func (u *UseCase) read(ctx context.Context, id int64) *domain.Post {
+	post, err := u.next.GetPost(ctx, id)
+	if err != nil {
+		return nil
+	}
+
+	return withID(post, id)
+}
+
+// record appends the entry of a change. The change is done at this point, so a
+// failed append is logged instead of failing the request.
+func (u *UseCase) record(ctx context.Context, action domain.AuditAction, id int64, before, after *domain.Post) {
+	entry := &domain.AuditEntry{
+		OccurredAt: u.now().UTC(),
+		Action:     action,
+		PostID:     id,
+		RequestID:  requestid.FromContext(ctx),
+		Before:     before,
+		After:      after,
+	}
+	if user, isIn := auth.UserFromContext(ctx); isIn {
+		entry.ActorID = user.ID
+		entry.Actor = user.Email
+	}
+
+	if err := u.log.AppendEntry(ctx, entry); err != nil {
+		slog.Error("Could not append audit entry.", "action", action, "post", id, "error", err)
+	}
+}
+
+func withID(post *domain.Post, id int64) *domain.Post {
+	copied := *post
+	copied.ID = id
+	return &copied
+}
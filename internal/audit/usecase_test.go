@@ -0,0 +1,128 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/audit"
+	"github.com/kondrushin/blog/internal/audit/mocks"
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+type AuditTestSuite struct {
+	mockUseCase *mocks.IBlogUseCase
+	log         *repository.AuditRepository
+	useCase     *audit.UseCase
+	ctx         context.Context
+}
+
+func SetSuite() *AuditTestSuite {
+	var suite = AuditTestSuite{}
+	suite.mockUseCase = new(mocks.IBlogUseCase)
+	suite.log = repository.NewAuditRepository()
+	suite.useCase = audit.NewUseCase(suite.mockUseCase, suite.log).WithClock(func() time.Time { return now })
+	suite.ctx = requestid.With(auth.WithUser(context.Background(), &domain.User{ID: 7, Email: "anton@example.com"}), "req-1")
+
+	return &suite
+}
+
+func Test_CreatePost_ShouldRecordActorRequestAndPost(t *testing.T) {
+	suite := SetSuite()
+	post := &domain.Post{Author: "Anton", Title: "On audits", Content: "qwerty"}
+
+	suite.mockUseCase.
+		On("CreatePost", suite.ctx, post).
+		Return(int64(3), nil)
+
+	id, err := suite.useCase.CreatePost(suite.ctx, post)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, id)
+
+	entries := suite.log.GetEntries(suite.ctx, domain.AuditFilter{})
+	assert.Equal(t, []*domain.AuditEntry{{
+		ID:         1,
+		OccurredAt: now,
+		ActorID:    7,
+		Actor:      "anton@example.com",
+		Action:     domain.AuditPostCreated,
+		PostID:     3,
+		RequestID:  "req-1",
+		After:      &domain.Post{ID: 3, Author: "Anton", Title: "On audits", Content: "qwerty"},
+	}}, entries)
+}
+
+func Test_UpdatePost_ShouldRecordPostBeforeAndAfter(t *testing.T) {
+	suite := SetSuite()
+	before := &domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "Old", Content: "qwerty"}
+	post := &domain.Post{AuthorID: 1, Author: "Anton", Title: "New", Content: "qwerty"}
+
+	suite.mockUseCase.
+		On("GetPost", suite.ctx, int64(1)).
+		Return(before, nil)
+	suite.mockUseCase.
+		On("UpdatePost", suite.ctx, post, int64(1)).
+		Return(nil)
+
+	assert.NoError(t, suite.useCase.UpdatePost(suite.ctx, post, 1))
+
+	entries := suite.log.GetEntries(suite.ctx, domain.AuditFilter{Action: domain.AuditPostUpdated})
+	assert.Len(t, entries, 1)
+	assert.Equal(t, before, entries[0].Before)
+	assert.Equal(t, []domain.FieldChange{{Field: "title", Before: "Old", After: "New"}}, entries[0].Changes())
+}
+
+func Test_DeletePost_Anonymous_ShouldRecordWithoutActor(t *testing.T) {
+	suite := SetSuite()
+	ctx := context.Background()
+	before := &domain.Post{ID: 1, Author: "Anton", Title: "Gone", Content: "qwerty"}
+
+	suite.mockUseCase.
+		On("GetPost", ctx, int64(1)).
+		Return(before, nil)
+	suite.mockUseCase.
+		On("DeletePost", ctx, int64(1)).
+		Return(nil)
+
+	assert.NoError(t, suite.useCase.DeletePost(ctx, 1))
+
+	entries := suite.log.GetEntries(ctx, domain.AuditFilter{})
+	assert.Len(t, entries, 1)
+	assert.Zero(t, entries[0].ActorID)
+	assert.Equal(t, domain.AuditPostDeleted, entries[0].Action)
+	assert.Equal(t, before, entries[0].Before)
+	assert.Nil(t, entries[0].After)
+}
+
+func Test_DeletePost_Failed_ShouldNotRecord(t *testing.T) {
+	suite := SetSuite()
+
+	suite.mockUseCase.
+		On("GetPost", suite.ctx, int64(1)).
+		Return(nil, domain.ErrorPostNotFound)
+	suite.mockUseCase.
+		On("DeletePost", suite.ctx, int64(1)).
+		Return(domain.ErrorForbidden)
+
+	err := suite.useCase.DeletePost(suite.ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	assert.Empty(t, suite.log.GetEntries(suite.ctx, domain.AuditFilter{}))
+}
+
+func Test_GetPosts_ShouldNotRecord(t *testing.T) {
+	suite := SetSuite()
+
+	suite.mockUseCase.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{})
+
+	suite.useCase.GetPosts(suite.ctx)
+	assert.Empty(t, suite.log.GetEntries(suite.ctx, domain.AuditFilter{}))
+}
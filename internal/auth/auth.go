@@ -0,0 +1,78 @@
+// Package auth holds the authenticated user of a request and the primitives of
+// password and token handling.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// MaxPasswordBytes is the longest password bcrypt hashes completely.
+const MaxPasswordBytes = 72
+
+var ErrorInvalidAuthorization = errors.New("Authorization must be a bearer token")
+
+type userKey struct{}
+
+// WithUser returns a context of a request authenticated as the user.
+func WithUser(ctx context.Context, user *domain.User) context.Context {
+	return context.WithValue(ctx, userKey{}, user)
+}
+
+// UserFromContext returns the authenticated user, or false for anonymous requests.
+func UserFromContext(ctx context.Context) (*domain.User, bool) {
+	user, isIn := ctx.Value(userKey{}).(*domain.User)
+	return user, isIn
+}
+
+func HashPassword(password string) ([]byte, error) {
+	return bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+}
+
+func CheckPassword(hash []byte, password string) bool {
+	return bcrypt.CompareHashAndPassword(hash, []byte(password)) == nil
+}
+
+// dummyHash is compared with passwords of unknown users, so that their logins
+// take as long as logins of existing users.
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+
+// WastePasswordCheck spends the time of a password check.
+func WastePasswordCheck(password string) {
+	_ = bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+}
+
+// NewToken generates an opaque token. Only its hash is stored.
+func NewToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+func HashToken(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
+
+// BearerToken returns the token of an authorization value of the Bearer scheme.
+func BearerToken(authorization string) (string, bool) {
+	scheme, token, _ := strings.Cut(authorization, " ")
+	token = strings.TrimSpace(token)
+	if !strings.EqualFold(scheme, "Bearer") || len(token) == 0 {
+		return "", false
+	}
+
+	return token, true
+}
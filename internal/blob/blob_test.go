@@ -0,0 +1,147 @@
+package blob_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/blob"
+	"github.com/stretchr/testify/assert"
+)
+
+type store interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// s3StandIn serves the part of the S3 API the store uses from memory.
+type s3StandIn struct {
+	t       *testing.T
+	mutex   sync.Mutex
+	objects map[string][]byte
+}
+
+func (s *s3StandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=access/") ||
+		r.Header.Get("X-Amz-Content-Sha256") != "UNSIGNED-PAYLOAD" {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	key, isIn := strings.CutPrefix(r.URL.Path, "/bucket/")
+	if !isIn {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	object, exists := s.objects[key]
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		assert.EqualValues(s.t, r.ContentLength, len(body))
+		s.objects[key] = body
+	case http.MethodDelete:
+		delete(s.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	case http.MethodHead, http.MethodGet:
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if start, isRange := strings.CutPrefix(r.Header.Get("Range"), "bytes="); isRange && r.Method == http.MethodGet {
+			offset, _ := strconv.Atoi(strings.TrimSuffix(start, "-"))
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(object)-1, len(object)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(object[offset:])
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+		if r.Method == http.MethodGet {
+			w.Write(object)
+		}
+	}
+}
+
+func stores(t *testing.T) map[string]store {
+	fileStore, err := blob.NewFileStore(t.TempDir())
+	assert.NoError(t, err)
+
+	standIn := httptest.NewServer(&s3StandIn{t: t, objects: map[string][]byte{}})
+	t.Cleanup(standIn.Close)
+	s3Store, err := blob.NewS3Store(blob.S3Config{Endpoint: standIn.URL, Bucket: "bucket", AccessKey: "access", SecretKey: "secret"})
+	assert.NoError(t, err)
+
+	return map[string]store{"file": fileStore, "s3": s3Store}
+}
+
+func Test_Store_ShouldPutOpenAndDeleteBlobs(t *testing.T) {
+	ctx := context.Background()
+	content := []byte("0123456789abcdef")
+
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			exists, err := s.Exists(ctx, "e3b0c4")
+			assert.NoError(t, err)
+			assert.False(t, exists)
+
+			assert.NoError(t, s.Put(ctx, "e3b0c4", bytes.NewReader(content), int64(len(content))))
+			exists, err = s.Exists(ctx, "e3b0c4")
+			assert.NoError(t, err)
+			assert.True(t, exists)
+
+			reader, err := s.Open(ctx, "e3b0c4")
+			assert.NoError(t, err)
+			size, err := reader.Seek(0, io.SeekEnd)
+			assert.NoError(t, err)
+			assert.EqualValues(t, len(content), size)
+			_, err = reader.Seek(10, io.SeekStart)
+			assert.NoError(t, err)
+			rest, err := io.ReadAll(reader)
+			assert.NoError(t, err)
+			assert.Equal(t, "abcdef", string(rest))
+			assert.NoError(t, reader.Close())
+
+			assert.NoError(t, s.Delete(ctx, "e3b0c4"))
+			assert.NoError(t, s.Delete(ctx, "e3b0c4"))
+			_, err = s.Open(ctx, "e3b0c4")
+			assert.ErrorIs(t, err, blob.ErrorNotFound)
+		})
+	}
+}
+
+func Test_Store_InvalidKey_ShouldReturnError(t *testing.T) {
+	for name, s := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			assert.Error(t, s.Put(context.Background(), "../etc/passwd", strings.NewReader("x"), 1))
+		})
+	}
+}
+
+func Test_S3Store_UnresponsiveStorage_ShouldTimeOut(t *testing.T) {
+	release := make(chan struct{})
+	standIn := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(standIn.Close)
+	t.Cleanup(func() { close(release) })
+	s3Store, err := blob.NewS3Store(blob.S3Config{Endpoint: standIn.URL, Bucket: "bucket", Timeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	_, err = s3Store.Exists(context.Background(), "aaa")
+	assert.Error(t, err)
+
+	_, err = s3Store.Open(context.Background(), "aaa")
+	assert.Error(t, err)
+}
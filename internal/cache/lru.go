@@ -0,0 +1,65 @@
+package cache
+
+import "container/list"
+
+// lru is a fixed capacity map that evicts the least recently used entry.
+// It is not safe for concurrent use.
+type lru[K comparable, V any] struct {
+	capacity int
+	items    map[K]*list.Element
+	order    *list.List
+}
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRU[K comparable, V any](capacity int) *lru[K, V] {
+	return &lru[K, V]{
+		capacity: capacity,
+		items:    map[K]*list.Element{},
+		order:    list.New(),
+	}
+}
+
+func (l *lru[K, V]) get(key K) (V, bool) {
+	element, isIn := l.items[key]
+	if !isIn {
+		var zero V
+		return zero, false
+	}
+
+	l.order.MoveToFront(element)
+	return element.Value.(*lruEntry[K, V]).value, true
+}
+
+// add stores the value and reports whether another entry was evicted to make room.
+func (l *lru[K, V]) add(key K, value V) bool {
+	if element, isIn := l.items[key]; isIn {
+		element.Value.(*lruEntry[K, V]).value = value
+		l.order.MoveToFront(element)
+		return false
+	}
+
+	l.items[key] = l.order.PushFront(&lruEntry[K, V]{key: key, value: value})
+	if l.order.Len() <= l.capacity {
+		return false
+	}
+
+	oldest := l.order.Back()
+	l.order.Remove(oldest)
+	delete(l.items, oldest.Value.(*lruEntry[K, V]).key)
+	return true
+}
+
+func (l *lru[K, V]) remove(key K) {
+	if element, isIn := l.items[key]; isIn {
+		l.order.Remove(element)
+		delete(l.items, key)
+	}
+}
+
+func (l *lru[K, V]) len() int {
+	return l.order.Len()
+}
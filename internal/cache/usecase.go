@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type IBlogUseCase interface {
+	GetPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetPosts(ctx context.Context) []*domain.Post
+	EachPost(ctx context.Context, yield func(*domain.Post) bool) error
+	CreatePost(ctx context.Context, p *domain.Post) (int64, error)
+	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
+	DeletePost(ctx context.Context, id int64) error
+	GetDeletedPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetDeletedPosts(ctx context.Context) []*domain.Post
+	RestorePost(ctx context.Context, id int64) error
+}
+
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+	Capacity  int    `json:"capacity"`
+}
+
+// listKey is the cache key of the GetPosts result. Post IDs start with 1.
+const listKey int64 = 0
+
+type entry struct {
+	post  *domain.Post
+	posts []*domain.Post
+}
+
+// UseCase caches results of an IBlogUseCase. Single posts and the post list are kept
+// in one LRU bounded by capacity entries and invalidated by the writes that affect them.
+// The blog repository has the same methods, so a repository can be cached as well.
+type UseCase struct {
+	next IBlogUseCase
+
+	mutex sync.Mutex
+	items *lru[int64, entry]
+	stats Stats
+
+	// generation is incremented on every invalidation so that a read which
+	// raced with a write does not put a stale value back into the cache.
+	generation uint64
+}
+
+func NewUseCase(next IBlogUseCase, capacity int) *UseCase {
+	return &UseCase{
+		next:  next,
+		items: newLRU[int64, entry](capacity),
+		stats: Stats{Capacity: capacity},
+	}
+}
+
+func (u *UseCase) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
+	cached, generation, isIn := u.get(id)
+	if isIn {
+		return cached.post, nil
+	}
+
+	post, err := u.next.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	u.add(id, entry{post: post}, generation)
+	return post, nil
+}
+
+func (u *UseCase) GetPosts(ctx context.Context) []*domain.Post {
+	cached, generation, isIn := u.get(listKey)
+	if isIn {
+		return append([]*domain.Post(nil), cached.posts...)
+	}
+
+	posts := u.next.GetPosts(ctx)
+	u.add(listKey, entry{posts: append([]*domain.Post(nil), posts...)}, generation)
+	return posts
+}
+
+// EachPost is not cached, it is meant for lists too large to be kept in memory.
+func (u *UseCase) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	return u.next.EachPost(ctx, yield)
+}
+
+func (u *UseCase) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
+	id, err := u.next.CreatePost(ctx, post)
+	if err == nil {
+		u.invalidate(listKey)
+	}
+
+	return id, err
+}
+
+func (u *UseCase) UpdatePost(ctx context.Context, post *domain.Post, id int64) error {
+	err := u.next.UpdatePost(ctx, post, id)
+	if err == nil {
+		u.invalidate(id, listKey)
+	}
+
+	return err
+}
+
+func (u *UseCase) DeletePost(ctx context.Context, id int64) error {
+	err := u.next.DeletePost(ctx, id)
+	if err == nil {
+		u.invalidate(id, listKey)
+	}
+
+	return err
+}
+
+// GetDeletedPost is not cached, the trash is rarely read.
+func (u *UseCase) GetDeletedPost(ctx context.Context, id int64) (*domain.Post, error) {
+	return u.next.GetDeletedPost(ctx, id)
+}
+
+func (u *UseCase) GetDeletedPosts(ctx context.Context) []*domain.Post {
+	return u.next.GetDeletedPosts(ctx)
+}
+
+func (u *UseCase) RestorePost(ctx context.Context, id int64) error {
+	err := u.next.RestorePost(ctx, id)
+	if err == nil {
+		u.invalidate(id, listKey)
+	}
+
+	return err
+}
+
+func (u *UseCase) Stats() Stats {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	stats := u.stats
+	stats.Size = u.items.len()
+	return stats
+}
+
+func (u *UseCase) get(key int64) (entry, uint64, bool) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	cached, isIn := u.items.get(key)
+	if isIn {
+		u.stats.Hits++
+	} else {
+		u.stats.Misses++
+	}
+
+	return cached, u.generation, isIn
+}
+
+func (u *UseCase) add(key int64, value entry, generation uint64) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	if generation != u.generation {
+		return
+	}
+
+	if u.items.add(key, value) {
+		u.stats.Evictions++
+	}
+}
+
+func (u *UseCase) invalidate(keys ...int64) {
+	u.mutex.Lock()
+	defer u.mutex.Unlock()
+
+	u.generation++
+	for _, key := range keys {
+		u.items.remove(key)
+	}
+}
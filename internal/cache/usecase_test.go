@@ -0,0 +1,205 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/cache"
+	"github.com/kondrushin/blog/internal/cache/mocks"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+type CacheTestSuite struct {
+	mockUseCase *mocks.IBlogUseCase
+	cache       *cache.UseCase
+	ctx         context.Context
+	post        *domain.Post
+}
+
+func SetSuite(capacity int) *CacheTestSuite {
+	var suite = CacheTestSuite{}
+	suite.mockUseCase = new(mocks.IBlogUseCase)
+	suite.cache = cache.NewUseCase(suite.mockUseCase, capacity)
+	suite.ctx = context.Background()
+	suite.post = &domain.Post{
+		ID:      1,
+		Author:  "Anton",
+		Title:   "On caching",
+		Content: "qwerty",
+	}
+
+	return &suite
+}
+
+func Test_GetPost_SecondCall_ShouldBeServedFromCache(t *testing.T) {
+	suite := SetSuite(10)
+
+	suite.mockUseCase.
+		On("GetPost", suite.ctx, int64(1)).
+		Once().
+		Return(suite.post, nil)
+
+	for i := 0; i < 2; i++ {
+		post, err := suite.cache.GetPost(suite.ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, suite.post, post)
+	}
+
+	assert.Equal(t, cache.Stats{Hits: 1, Misses: 1, Size: 1, Capacity: 10}, suite.cache.Stats())
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_GetPost_Error_ShouldNotBeCached(t *testing.T) {
+	suite := SetSuite(10)
+
+	suite.mockUseCase.
+		On("GetPost", suite.ctx, int64(1)).
+		Twice().
+		Return(nil, domain.ErrorPostNotFound)
+
+	for i := 0; i < 2; i++ {
+		_, err := suite.cache.GetPost(suite.ctx, 1)
+		assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+	}
+
+	assert.Equal(t, uint64(2), suite.cache.Stats().Misses)
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_GetPosts_SecondCall_ShouldBeServedFromCache(t *testing.T) {
+	suite := SetSuite(10)
+
+	suite.mockUseCase.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return([]*domain.Post{suite.post})
+
+	for i := 0; i < 2; i++ {
+		posts := suite.cache.GetPosts(suite.ctx)
+		assert.Equal(t, []*domain.Post{suite.post}, posts)
+	}
+
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_CreatePost_ShouldInvalidateListOnly(t *testing.T) {
+	suite := SetSuite(10)
+	newPost := &domain.Post{Author: "Jonny", Title: "New", Content: "new"}
+
+	suite.mockUseCase.On("GetPost", suite.ctx, int64(1)).Once().Return(suite.post, nil)
+	suite.mockUseCase.On("GetPosts", suite.ctx).Twice().Return([]*domain.Post{suite.post})
+	suite.mockUseCase.On("CreatePost", suite.ctx, newPost).Once().Return(int64(2), nil)
+
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPosts(suite.ctx)
+
+	_, err := suite.cache.CreatePost(suite.ctx, newPost)
+	assert.NoError(t, err)
+
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPosts(suite.ctx)
+
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_UpdatePost_ShouldInvalidatePostAndList(t *testing.T) {
+	suite := SetSuite(10)
+	otherPost := &domain.Post{ID: 2, Author: "Jonny", Title: "Other", Content: "other"}
+
+	suite.mockUseCase.On("GetPost", suite.ctx, int64(1)).Twice().Return(suite.post, nil)
+	suite.mockUseCase.On("GetPost", suite.ctx, int64(2)).Once().Return(otherPost, nil)
+	suite.mockUseCase.On("GetPosts", suite.ctx).Twice().Return([]*domain.Post{suite.post, otherPost})
+	suite.mockUseCase.On("UpdatePost", suite.ctx, suite.post, int64(1)).Once().Return(nil)
+
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPost(suite.ctx, 2)
+	suite.cache.GetPosts(suite.ctx)
+
+	err := suite.cache.UpdatePost(suite.ctx, suite.post, 1)
+	assert.NoError(t, err)
+
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPost(suite.ctx, 2)
+	suite.cache.GetPosts(suite.ctx)
+
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_UpdatePost_Error_ShouldKeepCache(t *testing.T) {
+	suite := SetSuite(10)
+
+	suite.mockUseCase.On("GetPost", suite.ctx, int64(1)).Once().Return(suite.post, nil)
+	suite.mockUseCase.On("UpdatePost", suite.ctx, suite.post, int64(1)).Once().Return(errors.New("DB error"))
+
+	suite.cache.GetPost(suite.ctx, 1)
+
+	err := suite.cache.UpdatePost(suite.ctx, suite.post, 1)
+	assert.Error(t, err)
+
+	suite.cache.GetPost(suite.ctx, 1)
+
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_DeletePost_ShouldInvalidatePostAndList(t *testing.T) {
+	suite := SetSuite(10)
+
+	suite.mockUseCase.On("GetPost", suite.ctx, int64(1)).Once().Return(suite.post, nil)
+	suite.mockUseCase.On("GetPosts", suite.ctx).Twice().Return([]*domain.Post{suite.post})
+	suite.mockUseCase.On("DeletePost", suite.ctx, int64(1)).Once().Return(nil)
+
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPosts(suite.ctx)
+
+	err := suite.cache.DeletePost(suite.ctx, 1)
+	assert.NoError(t, err)
+
+	suite.mockUseCase.On("GetPost", suite.ctx, int64(1)).Once().Return(nil, domain.ErrorPostNotFound)
+	_, err = suite.cache.GetPost(suite.ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+	suite.cache.GetPosts(suite.ctx)
+
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_RestorePost_ShouldInvalidateList(t *testing.T) {
+	suite := SetSuite(10)
+
+	suite.mockUseCase.On("GetPosts", suite.ctx).Once().Return([]*domain.Post{})
+	suite.mockUseCase.On("RestorePost", suite.ctx, int64(1)).Once().Return(nil)
+
+	suite.cache.GetPosts(suite.ctx)
+
+	err := suite.cache.RestorePost(suite.ctx, 1)
+	assert.NoError(t, err)
+
+	suite.mockUseCase.On("GetPosts", suite.ctx).Once().Return([]*domain.Post{suite.post})
+	assert.Equal(t, []*domain.Post{suite.post}, suite.cache.GetPosts(suite.ctx))
+
+	suite.mockUseCase.AssertExpectations(t)
+}
+
+func Test_GetPost_OverCapacity_ShouldEvictLeastRecentlyUsed(t *testing.T) {
+	suite := SetSuite(2)
+
+	for id := int64(1); id <= 3; id++ {
+		suite.mockUseCase.On("GetPost", suite.ctx, id).Return(&domain.Post{ID: id}, nil)
+	}
+
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPost(suite.ctx, 2)
+	suite.cache.GetPost(suite.ctx, 1)
+	suite.cache.GetPost(suite.ctx, 3)
+
+	stats := suite.cache.Stats()
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Size)
+
+	suite.cache.GetPost(suite.ctx, 1)
+	assert.Equal(t, uint64(2), suite.cache.Stats().Hits)
+
+	suite.cache.GetPost(suite.ctx, 2)
+	suite.mockUseCase.AssertNumberOfCalls(t, "GetPost", 4)
+}
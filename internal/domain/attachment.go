@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Attachment is a file uploaded to a post. Its content is kept in the blob store
+// under the SHA-256 hash of the content, so that equal files are stored once.
+type Attachment struct {
+	ID          int64
+	PostID      int64
+	Filename    string
+	ContentType string
+	Size        int64
+	// Hash is the hex encoded SHA-256 hash of the content.
+	Hash string
+	// ThumbnailType is the content type of the thumbnail of an image, empty when
+	// there is no thumbnail.
+	ThumbnailType string
+	CreatedAt     time.Time
+}
+
+// BlobKey is the key of the content in the blob store.
+func (a *Attachment) BlobKey() string {
+	return a.Hash
+}
+
+// ThumbnailKey is the key of the thumbnail in the blob store.
+func (a *Attachment) ThumbnailKey() string {
+	return a.Hash + ".thumbnail"
+}
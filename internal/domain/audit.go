@@ -0,0 +1,100 @@
+package domain
+
+import (
+	"strconv"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditPostCreated AuditAction = "post.created"
+	AuditPostUpdated AuditAction = "post.updated"
+	AuditPostDeleted AuditAction = "post.deleted"
+	// AuditPostRestored records a post taken out of the trash and AuditPostPurged
+	// a post removed from the trash for good.
+	AuditPostRestored AuditAction = "post.restored"
+	AuditPostPurged   AuditAction = "post.purged"
+)
+
+// AuditEntry records a change of a post. Before is empty for created and restored
+// posts and After for deleted and purged posts. Anonymous and purge changes have
+// no actor. ID is assigned by the audit log and grows monotonically.
+type AuditEntry struct {
+	ID         int64
+	OccurredAt time.Time
+	ActorID    int64
+	Actor      string
+	Action     AuditAction
+	PostID     int64
+	RequestID  string
+	Before     *Post
+	After      *Post
+}
+
+// FieldChange is a field of a post that a change set from Before to After.
+type FieldChange struct {
+	Field  string
+	Before string
+	After  string
+}
+
+// Changes returns the fields that differ between Before and After.
+func (e *AuditEntry) Changes() []FieldChange {
+	var before, after Post
+	if e.Before != nil {
+		before = *e.Before
+	}
+	if e.After != nil {
+		after = *e.After
+	}
+
+	var changes []FieldChange
+	add := func(field, from, to string) {
+		if from != to {
+			changes = append(changes, FieldChange{Field: field, Before: from, After: to})
+		}
+	}
+	add("author_id", formatID(before.AuthorID), formatID(after.AuthorID))
+	add("author", before.Author, after.Author)
+	add("title", before.Title, after.Title)
+	add("content", before.Content, after.Content)
+
+	return changes
+}
+
+func formatID(id int64) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// AuditFilter selects audit entries. Zero fields match every entry, Until is exclusive.
+type AuditFilter struct {
+	ActorID   int64
+	Action    AuditAction
+	PostID    int64
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+}
+
+func (f *AuditFilter) Matches(entry *AuditEntry) bool {
+	switch {
+	case f.ActorID != 0 && entry.ActorID != f.ActorID:
+		return false
+	case len(f.Action) > 0 && entry.Action != f.Action:
+		return false
+	case f.PostID != 0 && entry.PostID != f.PostID:
+		return false
+	case len(f.RequestID) > 0 && entry.RequestID != f.RequestID:
+		return false
+	case !f.Since.IsZero() && entry.OccurredAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !entry.OccurredAt.Before(f.Until):
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,22 @@
+package domain
+
+import "strings"
+
+type Author struct {
+	ID        int64
+	Name      string
+	Bio       string
+	AvatarURL string
+}
+
+// NormalizeAuthorName trims the name and collapses inner whitespace, so that
+// "Anton" and " Anton " are displayed the same.
+func NormalizeAuthorName(name string) string {
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// AuthorNameKey identifies an author by name regardless of case and whitespace.
+// Two authors cannot have the same key.
+func AuthorNameKey(name string) string {
+	return strings.ToLower(NormalizeAuthorName(name))
+}
@@ -3,3 +3,36 @@ package domain
 import "errors"
 
 var ErrorPostNotFound = errors.New("Resource was not found")
+
+var ErrorAuthorNotFound = errors.New("Author was not found")
+
+var ErrorWebhookNotFound = errors.New("Webhook was not found")
+
+var ErrorDeliveryNotFound = errors.New("Delivery was not found")
+
+var ErrorAttachmentNotFound = errors.New("Attachment was not found")
+
+// ErrorAttachmentTooLarge is wrapped by errors of uploads above the size limit.
+var ErrorAttachmentTooLarge = errors.New("Attachment is too large")
+
+// ErrorUnsupportedAttachment is wrapped by errors of uploads of a type that is not allowed.
+var ErrorUnsupportedAttachment = errors.New("Unsupported attachment type")
+
+// ErrorInvalidInput is wrapped by validation errors of the use cases.
+var ErrorInvalidInput = errors.New("Invalid input")
+
+// ErrorConflict is wrapped by errors of changes that conflict with the current state.
+var ErrorConflict = errors.New("Conflict")
+
+var ErrorUserNotFound = errors.New("User was not found")
+
+var ErrorSessionNotFound = errors.New("Session was not found")
+
+// ErrorUnauthorized is wrapped by errors of wrong credentials and of invalid, expired or revoked tokens.
+var ErrorUnauthorized = errors.New("Unauthorized")
+
+// ErrorAccountLocked is returned for logins to an account locked after repeated failures.
+var ErrorAccountLocked = errors.New("Account is temporarily locked")
+
+// ErrorForbidden is wrapped by errors of operations the policy does not allow to the user.
+var ErrorForbidden = errors.New("Forbidden")
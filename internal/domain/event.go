@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type EventType string
+
+const (
+	EventPostCreated EventType = "post.created"
+	EventPostUpdated EventType = "post.updated"
+	EventPostDeleted EventType = "post.deleted"
+)
+
+// PostEvent describes a change of a post. ID is assigned by the event bus
+// and grows monotonically.
+type PostEvent struct {
+	ID         int64
+	Type       EventType
+	PostID     int64
+	Post       Post
+	OccurredAt time.Time
+}
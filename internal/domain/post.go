@@ -1,8 +1,129 @@
 package domain
 
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Post references its author by AuthorID. Author is the name of the author,
+// which is updated when the author is renamed.
 type Post struct {
-	ID      int64
-	Author  string
-	Title   string
-	Content string
+	ID       int64
+	AuthorID int64
+	Author   string
+	Title    string
+	Content  string
+	// Draft posts are only shown to users who may read drafts, such as their
+	// author. Posts are published when they are not drafts.
+	Draft bool `json:",omitempty" xml:",omitempty"`
+	// Excerpt, WordCount and ReadingMinutes are derived from Content by Summarize,
+	// so that lists can be shown without the content. The v1 representation only
+	// has them in the summary view.
+	Excerpt        string `json:"-" xml:"-"`
+	WordCount      int    `json:"-" xml:"-"`
+	ReadingMinutes int    `json:"-" xml:"-"`
+	// DeletedAt is set while the post is in the trash. It is not part of the
+	// v1 representation of posts, which is this struct.
+	DeletedAt time.Time `json:"-" xml:"-"`
+}
+
+func (p *Post) IsDeleted() bool {
+	return !p.DeletedAt.IsZero()
+}
+
+// Limits of the fields of posts in characters.
+const (
+	MaxAuthorLength  = 100
+	MaxTitleLength   = 200
+	MaxContentLength = 100000
+)
+
+// Validate checks the fields of a post given by a client, whatever the
+// transport. The author is required by name unless the post has an AuthorID.
+// Author and title must not contain control characters, the content may
+// contain tabs and line breaks.
+func (p *Post) Validate() error {
+	if p.AuthorID < 0 {
+		return fmt.Errorf("%w: author_id must be positive", ErrorInvalidInput)
+	}
+	if p.AuthorID == 0 || len(p.Author) > 0 {
+		if err := validateText("author", p.Author, MaxAuthorLength, false); err != nil {
+			return err
+		}
+	}
+	if err := validateText("title", p.Title, MaxTitleLength, false); err != nil {
+		return err
+	}
+	return validateText("content", p.Content, MaxContentLength, true)
+}
+
+// validateText requires a visible character and rejects control characters,
+// except for tabs and line breaks when multiline is set.
+func validateText(field, value string, maxLength int, multiline bool) error {
+	if len(strings.Trim(value, " \t\n\f\r")) == 0 {
+		return fmt.Errorf("%w: %s is required", ErrorInvalidInput, field)
+	}
+	if utf8.RuneCountInString(value) > maxLength {
+		return fmt.Errorf("%w: %s must not be longer than %d characters", ErrorInvalidInput, field, maxLength)
+	}
+	for _, r := range value {
+		if (r < 0x20 || r == 0x7F) && !(multiline && (r == '\t' || r == '\n' || r == '\r')) {
+			return fmt.Errorf("%w: %s must not contain control characters", ErrorInvalidInput, field)
+		}
+	}
+
+	return nil
+}
+
+const (
+	// ExcerptLength is the length of excerpts in characters, without the ellipsis.
+	ExcerptLength = 200
+	// WordsPerMinute is the reading speed the reading time is estimated with.
+	WordsPerMinute = 200
+)
+
+// paragraphBreak is an empty or blank line.
+var paragraphBreak = regexp.MustCompile(`\n[ \t\r]*\n`)
+
+// Summarize derives the excerpt, the word count and the reading time in whole
+// minutes from the content.
+func (p *Post) Summarize() {
+	p.WordCount = len(strings.Fields(p.Content))
+	p.ReadingMinutes = (p.WordCount + WordsPerMinute - 1) / WordsPerMinute
+	p.Excerpt = excerpt(p.Content, ExcerptLength)
+}
+
+// excerpt returns the first paragraph of the text with collapsed whitespace. A
+// paragraph longer than maxLength characters is cut at the last word boundary
+// before and gets an ellipsis, a single word is cut within.
+func excerpt(text string, maxLength int) string {
+	paragraph := paragraphBreak.Split(strings.TrimSpace(text), 2)[0]
+
+	var b strings.Builder
+	length := 0
+	for _, word := range strings.Fields(paragraph) {
+		wordLength := utf8.RuneCountInString(word)
+		if length > 0 {
+			wordLength++
+		}
+
+		if length+wordLength > maxLength {
+			if length == 0 {
+				b.WriteString(string([]rune(word)[:maxLength]))
+			}
+			b.WriteString("…")
+			break
+		}
+
+		if length > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(word)
+		length += wordLength
+	}
+
+	return b.String()
 }
@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"strings"
+	"time"
+)
+
+// Role decides what a user may do, see the policy package.
+type Role string
+
+const (
+	RoleReader Role = "reader"
+	RoleAuthor Role = "author"
+	RoleEditor Role = "editor"
+	RoleAdmin  Role = "admin"
+)
+
+var Roles = []Role{RoleReader, RoleAuthor, RoleEditor, RoleAdmin}
+
+func (r Role) IsValid() bool {
+	for _, role := range Roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+type User struct {
+	ID    int64
+	Email string
+	Role  Role
+	// AuthorID links the user to the author they write as. Posts of that author
+	// are their own. It is zero for users who are not authors.
+	AuthorID int64
+	// PasswordHash is the bcrypt hash of the password.
+	PasswordHash []byte
+	CreatedAt    time.Time
+	// FailedLogins counts failed logins since the last successful one. The
+	// account is locked until LockedUntil after too many of them.
+	FailedLogins int
+	LockedUntil  time.Time
+}
+
+func (u *User) IsLocked(now time.Time) bool {
+	return now.Before(u.LockedUntil)
+}
+
+// NormalizeEmail makes emails that differ in case or surrounding whitespace equal.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Session is a login of a user. It is identified by the hashes of its tokens,
+// the tokens themselves are never stored.
+type Session struct {
+	ID               int64
+	UserID           int64
+	AccessTokenHash  string
+	AccessExpiresAt  time.Time
+	RefreshTokenHash string
+	RefreshExpiresAt time.Time
+	CreatedAt        time.Time
+	// RevokedAt is set on logout. Revoked sessions are kept until their refresh
+	// token expires, so that their tokens are rejected rather than unknown.
+	RevokedAt time.Time
+}
+
+func (s *Session) IsRevoked() bool {
+	return !s.RevokedAt.IsZero()
+}
+
+// Tokens are issued on login and refresh.
+type Tokens struct {
+	AccessToken  string
+	RefreshToken string
+	// ExpiresIn is the lifetime of the access token.
+	ExpiresIn time.Duration
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "time"
+
+type Webhook struct {
+	ID     int64
+	URL    string
+	Secret string
+	// Events the webhook is subscribed to. All events are delivered when it is empty.
+	Events    []EventType
+	CreatedAt time.Time
+}
+
+func (w *Webhook) Accepts(eventType EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, e := range w.Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	// DeliveryDead marks a delivery that ran out of attempts. Dead deliveries
+	// form the dead-letter list and can be redelivered manually.
+	DeliveryDead DeliveryStatus = "dead"
+)
+
+type Delivery struct {
+	ID             int64
+	WebhookID      int64
+	EventID        int64
+	EventType      EventType
+	Payload        []byte
+	Status         DeliveryStatus
+	Attempts       int
+	ResponseStatus int
+	LastError      string
+	CreatedAt      time.Time
+	NextAttemptAt  time.Time
+	CompletedAt    time.Time
+}
+
+type DeliveryFilter struct {
+	WebhookID int64
+	Status    DeliveryStatus
+}
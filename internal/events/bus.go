@@ -0,0 +1,166 @@
+package events
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// subscriptionBufferSize is the number of undelivered events after which
+// a slow subscriber is disconnected instead of blocking publishers.
+const subscriptionBufferSize = 64
+
+// Bus is an in-process publish/subscribe hub for post events. It keeps the last
+// replaySize events so that reconnecting subscribers can resume where they stopped.
+type Bus struct {
+	mutex       sync.Mutex
+	lastId      int64
+	replay      []domain.PostEvent
+	replaySize  int
+	subscribers map[*Subscription]struct{}
+	handlers    []Handler
+}
+
+// Handler is called synchronously for every published event, after the event ID
+// has been assigned. Unlike subscribers, handlers are never dropped, so they must not block.
+type Handler func(ctx context.Context, event domain.PostEvent)
+
+// Filter selects events by post and by author. An event matches the authors when
+// its post is by one of AuthorIDs or by one of Authors, which are compared by
+// domain.AuthorNameKey, so that "Anton" and "anton " are the same author.
+type Filter struct {
+	PostIDs   []int64
+	AuthorIDs []int64
+	Authors   []string
+	// Allows drops the events it returns false for, such as events of posts
+	// the subscriber may not read. Every event is allowed without it.
+	Allows func(event domain.PostEvent) bool
+}
+
+func (f Filter) Match(event domain.PostEvent) bool {
+	if f.Allows != nil && !f.Allows(event) {
+		return false
+	}
+
+	if len(f.PostIDs) > 0 && !slices.Contains(f.PostIDs, event.PostID) {
+		return false
+	}
+
+	if (len(f.AuthorIDs) > 0 || len(f.Authors) > 0) && !f.matchAuthor(event.Post) {
+		return false
+	}
+
+	return true
+}
+
+func (f Filter) matchAuthor(post domain.Post) bool {
+	if post.AuthorID != 0 && slices.Contains(f.AuthorIDs, post.AuthorID) {
+		return true
+	}
+
+	key := domain.AuthorNameKey(post.Author)
+	return len(key) > 0 && slices.ContainsFunc(f.Authors, func(author string) bool { return domain.AuthorNameKey(author) == key })
+}
+
+type Subscription struct {
+	// Replay holds buffered events published after the requested event ID.
+	Replay []domain.PostEvent
+
+	events chan domain.PostEvent
+	filter Filter
+	bus    *Bus
+	once   sync.Once
+}
+
+// Events delivers live events. The channel is closed when the subscription is
+// closed or when the subscriber fell too far behind.
+func (s *Subscription) Events() <-chan domain.PostEvent {
+	return s.events
+}
+
+func (s *Subscription) Close() {
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+
+	s.close()
+}
+
+func (s *Subscription) close() {
+	s.once.Do(func() {
+		delete(s.bus.subscribers, s)
+		close(s.events)
+	})
+}
+
+func NewBus(replaySize int) *Bus {
+	return &Bus{
+		replaySize:  replaySize,
+		replay:      make([]domain.PostEvent, 0, replaySize),
+		subscribers: map[*Subscription]struct{}{},
+	}
+}
+
+// Publish assigns the next event ID and delivers the event to matching subscribers.
+func (b *Bus) Publish(ctx context.Context, event domain.PostEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.lastId++
+	event.ID = b.lastId
+
+	if b.replaySize > 0 {
+		if len(b.replay) == b.replaySize {
+			b.replay = append(b.replay[:0], b.replay[1:]...)
+		}
+		b.replay = append(b.replay, event)
+	}
+
+	for _, handle := range b.handlers {
+		handle(ctx, event)
+	}
+
+	for s := range b.subscribers {
+		if !s.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+		default:
+			s.close()
+		}
+	}
+}
+
+func (b *Bus) AddHandler(handler Handler) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.handlers = append(b.handlers, handler)
+}
+
+// Subscribe registers a subscriber for events matching the filter. Buffered events
+// with an ID greater than lastEventId are returned in Subscription.Replay.
+func (b *Bus) Subscribe(filter Filter, lastEventId int64) *Subscription {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	s := &Subscription{
+		events: make(chan domain.PostEvent, subscriptionBufferSize),
+		filter: filter,
+		bus:    b,
+	}
+
+	if lastEventId > 0 {
+		for _, event := range b.replay {
+			if event.ID > lastEventId && filter.Match(event) {
+				s.Replay = append(s.Replay, event)
+			}
+		}
+	}
+
+	b.subscribers[s] = struct{}{}
+	return s
+}
@@ -0,0 +1,108 @@
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/stretchr/testify/assert"
+)
+
+func postEvent(postId int64, author string) domain.PostEvent {
+	return domain.PostEvent{
+		Type:   domain.EventPostCreated,
+		PostID: postId,
+		Post:   domain.Post{ID: postId, Author: author},
+	}
+}
+
+func Test_Publish_ShouldDeliverEventsWithSequentialIds(t *testing.T) {
+	bus := events.NewBus(10)
+	subscription := bus.Subscribe(events.Filter{}, 0)
+	defer subscription.Close()
+
+	bus.Publish(context.Background(), postEvent(1, "Anton"))
+	bus.Publish(context.Background(), postEvent(2, "Jonny"))
+
+	first := <-subscription.Events()
+	second := <-subscription.Events()
+	assert.EqualValues(t, 1, first.ID)
+	assert.EqualValues(t, 1, first.PostID)
+	assert.EqualValues(t, 2, second.ID)
+	assert.EqualValues(t, 2, second.PostID)
+}
+
+func Test_Publish_ShouldApplyFilters(t *testing.T) {
+	bus := events.NewBus(10)
+	byPost := bus.Subscribe(events.Filter{PostIDs: []int64{2}}, 0)
+	defer byPost.Close()
+	byAuthor := bus.Subscribe(events.Filter{Authors: []string{"Anton"}}, 0)
+	defer byAuthor.Close()
+
+	bus.Publish(context.Background(), postEvent(1, "Anton"))
+	bus.Publish(context.Background(), postEvent(2, "Jonny"))
+
+	assert.Len(t, byPost.Events(), 1)
+	assert.EqualValues(t, 2, (<-byPost.Events()).PostID)
+	assert.Len(t, byAuthor.Events(), 1)
+	assert.Equal(t, "Anton", (<-byAuthor.Events()).Post.Author)
+}
+
+func Test_Publish_AuthorFilters_ShouldMatchByIdOrNormalizedName(t *testing.T) {
+	bus := events.NewBus(10)
+	byName := bus.Subscribe(events.Filter{Authors: []string{" anton "}}, 0)
+	defer byName.Close()
+	byID := bus.Subscribe(events.Filter{AuthorIDs: []int64{8}}, 0)
+	defer byID.Close()
+
+	anton := postEvent(1, "Anton")
+	anton.Post.AuthorID = 7
+	jonny := postEvent(2, "Jonny")
+	jonny.Post.AuthorID = 8
+	bus.Publish(context.Background(), anton)
+	bus.Publish(context.Background(), jonny)
+
+	assert.Len(t, byName.Events(), 1)
+	assert.EqualValues(t, 1, (<-byName.Events()).PostID)
+	assert.Len(t, byID.Events(), 1)
+	assert.EqualValues(t, 2, (<-byID.Events()).PostID)
+}
+
+func Test_Subscribe_ShouldReplayEventsAfterLastEventId(t *testing.T) {
+	bus := events.NewBus(2)
+	for id := int64(1); id <= 4; id++ {
+		bus.Publish(context.Background(), postEvent(id, "Anton"))
+	}
+
+	subscription := bus.Subscribe(events.Filter{}, 2)
+	defer subscription.Close()
+	assert.Len(t, subscription.Replay, 2)
+	assert.EqualValues(t, 3, subscription.Replay[0].ID)
+	assert.EqualValues(t, 4, subscription.Replay[1].ID)
+
+	withoutResume := bus.Subscribe(events.Filter{}, 0)
+	defer withoutResume.Close()
+	assert.Empty(t, withoutResume.Replay)
+
+	upToDate := bus.Subscribe(events.Filter{}, 4)
+	defer upToDate.Close()
+	assert.Empty(t, upToDate.Replay)
+}
+
+func Test_Publish_SlowSubscriber_ShouldBeDisconnected(t *testing.T) {
+	bus := events.NewBus(0)
+	subscription := bus.Subscribe(events.Filter{}, 0)
+
+	for id := int64(1); id <= 100; id++ {
+		bus.Publish(context.Background(), postEvent(id, "Anton"))
+	}
+
+	received := 0
+	for range subscription.Events() {
+		received++
+	}
+	assert.Less(t, received, 100)
+
+	subscription.Close()
+}
@@ -0,0 +1,51 @@
+package gql
+
+import (
+	"errors"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// Error codes are reported in the extensions of GraphQL errors.
+const (
+	CodeBadUserInput  = "BAD_USER_INPUT"
+	CodeNotFound      = "NOT_FOUND"
+	CodeUnauthorized  = "UNAUTHENTICATED"
+	CodeForbidden     = "FORBIDDEN"
+	CodeInternal      = "INTERNAL"
+	CodeQueryTooDeep  = "QUERY_TOO_DEEP"
+	CodeQueryTooLarge = "QUERY_TOO_COMPLEX"
+)
+
+// codedError is a GraphQL error with a code in its extensions.
+type codedError struct {
+	message string
+	code    string
+}
+
+func (e *codedError) Error() string {
+	return e.message
+}
+
+func (e *codedError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func badInput(message string) error {
+	return &codedError{message: message, code: CodeBadUserInput}
+}
+
+func toError(err error) error {
+	switch {
+	case errors.Is(err, domain.ErrorPostNotFound):
+		return &codedError{message: err.Error(), code: CodeNotFound}
+	case errors.Is(err, domain.ErrorInvalidInput):
+		return &codedError{message: err.Error(), code: CodeBadUserInput}
+	case errors.Is(err, domain.ErrorUnauthorized):
+		return &codedError{message: err.Error(), code: CodeUnauthorized}
+	case errors.Is(err, domain.ErrorForbidden):
+		return &codedError{message: err.Error(), code: CodeForbidden}
+	default:
+		return &codedError{message: err.Error(), code: CodeInternal}
+	}
+}
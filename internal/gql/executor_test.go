@@ -0,0 +1,261 @@
+package gql_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/graphql-go/graphql"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/gql"
+	"github.com/kondrushin/blog/internal/gql/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type ExecutorTestSuite struct {
+	useCase  *mocks.IBlogUseCase
+	executor *gql.Executor
+	ctx      context.Context
+}
+
+func SetSuite(t *testing.T, limits gql.Limits) *ExecutorTestSuite {
+	suite := &ExecutorTestSuite{useCase: new(mocks.IBlogUseCase), ctx: context.Background()}
+
+	executor, err := gql.NewExecutor(suite.useCase, limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	suite.executor = executor
+
+	return suite
+}
+
+func toJSON(t *testing.T, result *graphql.Result) string {
+	body, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func somePosts() []*domain.Post {
+	return []*domain.Post{
+		{ID: 3, Author: "Jonny", Title: "Third", Content: "three"},
+		{ID: 1, Author: "Anton", Title: "First", Content: "one"},
+		{ID: 2, Author: "Anton", Title: "Second", Content: "two"},
+	}
+}
+
+func Test_Execute_Post_ShouldReturnRequestedFields(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("GetPost", suite.ctx, int64(1)).
+		Return(&domain.Post{ID: 1, Author: "Anton", Title: "First", Content: "one"}, nil)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{Query: `{ post(id: "1") { id title } }`})
+
+	assert.JSONEq(t, `{"data":{"post":{"id":"1","title":"First"}}}`, toJSON(t, result))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_Post_ShouldReturnSummary(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("GetPost", suite.ctx, int64(1)).
+		Return(&domain.Post{ID: 1, Author: "Anton", Title: "First", Content: "one", Excerpt: "one", WordCount: 1, ReadingMinutes: 1}, nil)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{Query: `{ post(id: "1") { excerpt wordCount readingMinutes } }`})
+
+	assert.JSONEq(t, `{"data":{"post":{"excerpt":"one","wordCount":1,"readingMinutes":1}}}`, toJSON(t, result))
+}
+
+func Test_Execute_MissingPost_ShouldReturnNull(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("GetPost", suite.ctx, int64(9)).
+		Return(nil, domain.ErrorPostNotFound)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{Query: `{ post(id: "9") { id } }`})
+
+	assert.JSONEq(t, `{"data":{"post":null}}`, toJSON(t, result))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_Posts_ShouldFilterAndPaginate(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("GetPosts", suite.ctx).
+		Return(somePosts())
+
+	query := `query($after: String) {
+		posts(filter: {author: "Anton"}, first: 1, after: $after) {
+			totalCount
+			edges { cursor node { id } }
+			pageInfo { hasNextPage endCursor }
+		}
+	}`
+
+	first := suite.executor.Execute(suite.ctx, gql.Request{Query: query})
+	assert.Empty(t, first.Errors)
+	connection := first.Data.(map[string]any)["posts"].(map[string]any)
+	assert.Equal(t, 2, connection["totalCount"])
+	assert.Equal(t, true, connection["pageInfo"].(map[string]any)["hasNextPage"])
+	endCursor := connection["pageInfo"].(map[string]any)["endCursor"]
+
+	second := suite.executor.Execute(suite.ctx, gql.Request{Query: query, Variables: map[string]any{"after": endCursor}})
+	assert.Empty(t, second.Errors)
+	connection = second.Data.(map[string]any)["posts"].(map[string]any)
+	edges := connection["edges"].([]any)
+	assert.Len(t, edges, 1)
+	assert.Equal(t, "2", edges[0].(map[string]any)["node"].(map[string]any)["id"])
+	assert.Equal(t, false, connection["pageInfo"].(map[string]any)["hasNextPage"])
+}
+
+func Test_Execute_Posts_AuthorFilters_ShouldMatchByIdOrNormalizedName(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("GetPosts", suite.ctx).
+		Return([]*domain.Post{
+			{ID: 1, AuthorID: 7, Author: "Anton", Title: "First", Content: "one"},
+			{ID: 2, AuthorID: 8, Author: "Jonny", Title: "Second", Content: "two"},
+		})
+
+	byName := suite.executor.Execute(suite.ctx, gql.Request{Query: `{ posts(filter: {author: "anton "}) { edges { node { id } } } }`})
+	assert.JSONEq(t, `{"data":{"posts":{"edges":[{"node":{"id":"1"}}]}}}`, toJSON(t, byName))
+
+	byID := suite.executor.Execute(suite.ctx, gql.Request{Query: `{ posts(filter: {authorId: "8"}) { edges { node { id } } } }`})
+	assert.JSONEq(t, `{"data":{"posts":{"edges":[{"node":{"id":"2"}}]}}}`, toJSON(t, byID))
+}
+
+func Test_Execute_CreatePost_AuthorId_ShouldNotRequireAuthorName(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("CreatePost", suite.ctx, &domain.Post{AuthorID: 7, Title: "New", Content: "text"}).
+		Return(int64(4), nil)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query: `mutation { createPost(input: {authorId: "7", title: "New", content: "text"}) { id authorId } }`,
+	})
+
+	assert.JSONEq(t, `{"data":{"createPost":{"id":"4","authorId":"7"}}}`, toJSON(t, result))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_CreatePost_ShouldReturnCreatedPost(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("CreatePost", suite.ctx, &domain.Post{Author: "Anton", Title: "New", Content: "text"}).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Post).AuthorID = 2
+		}).
+		Return(int64(4), nil)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query:     `mutation($input: PostInput!) { createPost(input: $input) { id authorId author } }`,
+		Variables: map[string]any{"input": map[string]any{"author": "Anton", "title": "New", "content": "text"}},
+	})
+
+	assert.JSONEq(t, `{"data":{"createPost":{"id":"4","authorId":"2","author":"Anton"}}}`, toJSON(t, result))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_UpdateDraft_ShouldKeepDraft(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("UpdatePost", suite.ctx, &domain.Post{ID: 9, Author: "Anton", Title: "New", Content: "text", Draft: true}, int64(9)).
+		Return(nil)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query: `mutation { updatePost(id: "9", input: {author: "Anton", title: "New", content: "text", draft: true}) { id draft } }`,
+	})
+
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, map[string]any{"updatePost": map[string]any{"id": "9", "draft": true}}, result.Data)
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_UpdateMissingPost_ShouldReturnNotFoundCode(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("UpdatePost", suite.ctx, mock.Anything, int64(9)).
+		Return(domain.ErrorPostNotFound)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query: `mutation { updatePost(id: "9", input: {author: "Anton", title: "New", content: "text"}) { id } }`,
+	})
+
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, map[string]any{"code": gql.CodeNotFound}, result.Errors[0].Extensions)
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_BlankInput_ShouldReturnBadUserInput(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query: `mutation { createPost(input: {author: "Anton", title: " ", content: "text"}) { id } }`,
+	})
+
+	assert.Len(t, result.Errors, 1)
+	assert.Equal(t, "Invalid input: title is required", result.Errors[0].Message)
+	assert.Equal(t, map[string]any{"code": gql.CodeBadUserInput}, result.Errors[0].Extensions)
+	suite.useCase.AssertNotCalled(t, "CreatePost")
+}
+
+func Test_Execute_DeletePost_ShouldReturnId(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	suite.useCase.
+		On("DeletePost", suite.ctx, int64(2)).
+		Return(nil)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{Query: `mutation { deletePost(id: "2") }`})
+
+	assert.JSONEq(t, `{"data":{"deletePost":"2"}}`, toJSON(t, result))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_Execute_TooDeep_ShouldNotResolve(t *testing.T) {
+	suite := SetSuite(t, gql.Limits{MaxDepth: 3, MaxComplexity: 1000})
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query: `{ posts { ...page } } fragment page on PostConnection { edges { node { id } } }`,
+	})
+
+	assert.Nil(t, result.Data)
+	assert.Equal(t, map[string]any{"code": gql.CodeQueryTooDeep}, result.Errors[0].Extensions)
+	suite.useCase.AssertNotCalled(t, "GetPosts")
+}
+
+func Test_Execute_TooComplex_ShouldNotResolve(t *testing.T) {
+	suite := SetSuite(t, gql.Limits{MaxDepth: 10, MaxComplexity: 100})
+
+	// posts(first: 50) costs 1 + 50 * (edges + node + id + title) = 201.
+	result := suite.executor.Execute(suite.ctx, gql.Request{
+		Query:     `query($n: Int) { posts(first: $n) { edges { node { id title } } } }`,
+		Variables: map[string]any{"n": float64(50)},
+	})
+
+	assert.Nil(t, result.Data)
+	assert.Equal(t, "query complexity 201 exceeds the limit of 100", result.Errors[0].Message)
+	suite.useCase.AssertNotCalled(t, "GetPosts")
+}
+
+func Test_Execute_InvalidQuery_ShouldReturnValidationError(t *testing.T) {
+	suite := SetSuite(t, gql.DefaultLimits)
+
+	result := suite.executor.Execute(suite.ctx, gql.Request{Query: `{ post(id: "1") { likes } }`})
+
+	assert.Nil(t, result.Data)
+	assert.Contains(t, result.Errors[0].Message, `Cannot query field "likes"`)
+}
@@ -0,0 +1,117 @@
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// Limits bound the cost of a query before it is executed. Depth is the deepest
+// nesting of fields. Complexity counts every field once, and the fields inside
+// a list as many times as the list can have items.
+type Limits struct {
+	MaxDepth      int
+	MaxComplexity int
+}
+
+var DefaultLimits = Limits{MaxDepth: 8, MaxComplexity: 1000}
+
+// listSizes are the default sizes of list fields that accept a first argument.
+var listSizes = map[string]int{"posts": defaultPageSize}
+
+type measurer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]any
+}
+
+// checkLimits measures the operation that is going to be executed. Introspection
+// fields are not counted, so that tools like GraphiQL keep working.
+func checkLimits(doc *ast.Document, operationName string, variables map[string]any, limits Limits) error {
+	m := measurer{fragments: map[string]*ast.FragmentDefinition{}, variables: variables}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch d := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[d.Name.Value] = d
+		case *ast.OperationDefinition:
+			if operation == nil || (d.Name != nil && d.Name.Value == operationName) {
+				operation = d
+			}
+		}
+	}
+	if operation == nil {
+		return nil
+	}
+
+	depth, complexity := m.measure(operation.SelectionSet, map[string]bool{})
+	if depth > limits.MaxDepth {
+		return &codedError{message: fmt.Sprintf("query depth %d exceeds the limit of %d", depth, limits.MaxDepth), code: CodeQueryTooDeep}
+	}
+	if complexity > limits.MaxComplexity {
+		return &codedError{message: fmt.Sprintf("query complexity %d exceeds the limit of %d", complexity, limits.MaxComplexity), code: CodeQueryTooLarge}
+	}
+
+	return nil
+}
+
+// measure returns the depth and complexity of a selection set. visited guards
+// against fragment cycles on the current path.
+func (m *measurer) measure(set *ast.SelectionSet, visited map[string]bool) (int, int) {
+	if set == nil {
+		return 0, 0
+	}
+
+	depth, complexity := 0, 0
+	for _, selection := range set.Selections {
+		var d, c int
+		switch s := selection.(type) {
+		case *ast.Field:
+			if strings.HasPrefix(s.Name.Value, "__") {
+				continue
+			}
+			childDepth, childComplexity := m.measure(s.SelectionSet, visited)
+			d, c = childDepth+1, 1+m.listSize(s)*childComplexity
+		case *ast.InlineFragment:
+			d, c = m.measure(s.SelectionSet, visited)
+		case *ast.FragmentSpread:
+			fragment, isIn := m.fragments[s.Name.Value]
+			if !isIn || visited[s.Name.Value] {
+				continue
+			}
+			visited[s.Name.Value] = true
+			d, c = m.measure(fragment.SelectionSet, visited)
+			delete(visited, s.Name.Value)
+		}
+
+		depth = max(depth, d)
+		complexity += c
+	}
+
+	return depth, complexity
+}
+
+func (m *measurer) listSize(field *ast.Field) int {
+	size, isList := listSizes[field.Name.Value]
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "first" {
+			continue
+		}
+		switch value := argument.Value.(type) {
+		case *ast.IntValue:
+			size, _ = strconv.Atoi(value.Value)
+		case *ast.Variable:
+			if n, isNumber := m.variables[value.Name.Value].(float64); isNumber {
+				size = int(n)
+			}
+		}
+		isList = true
+	}
+
+	if !isList || size < 1 {
+		return 1
+	}
+	return size
+}
@@ -0,0 +1,358 @@
+package gql
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+type IBlogUseCase interface {
+	GetPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetPosts(ctx context.Context) []*domain.Post
+	CreatePost(ctx context.Context, p *domain.Post) (int64, error)
+	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
+	DeletePost(ctx context.Context, id int64) error
+}
+
+var postType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Post",
+	Fields: graphql.Fields{
+		"id":             &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolvePostField(func(p *domain.Post) any { return strconv.FormatInt(p.ID, 10) })},
+		"authorId":       &graphql.Field{Type: graphql.NewNonNull(graphql.ID), Resolve: resolvePostField(func(p *domain.Post) any { return strconv.FormatInt(p.AuthorID, 10) })},
+		"author":         &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolvePostField(func(p *domain.Post) any { return p.Author })},
+		"title":          &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolvePostField(func(p *domain.Post) any { return p.Title })},
+		"content":        &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolvePostField(func(p *domain.Post) any { return p.Content })},
+		"excerpt":        &graphql.Field{Type: graphql.NewNonNull(graphql.String), Resolve: resolvePostField(func(p *domain.Post) any { return p.Excerpt })},
+		"wordCount":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolvePostField(func(p *domain.Post) any { return p.WordCount })},
+		"readingMinutes": &graphql.Field{Type: graphql.NewNonNull(graphql.Int), Resolve: resolvePostField(func(p *domain.Post) any { return p.ReadingMinutes })},
+		"draft":          &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean), Resolve: resolvePostField(func(p *domain.Post) any { return p.Draft })},
+	},
+})
+
+var postEdgeType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PostEdge",
+	Fields: graphql.Fields{
+		"cursor": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"node":   &graphql.Field{Type: graphql.NewNonNull(postType)},
+	},
+})
+
+var pageInfoType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PageInfo",
+	Fields: graphql.Fields{
+		"hasNextPage": &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"endCursor":   &graphql.Field{Type: graphql.String},
+	},
+})
+
+var postConnectionType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "PostConnection",
+	Fields: graphql.Fields{
+		"edges":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postEdgeType)))},
+		"pageInfo":   &graphql.Field{Type: graphql.NewNonNull(pageInfoType)},
+		"totalCount": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+var postFilterType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PostFilter",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"authorId":      &graphql.InputObjectFieldConfig{Type: graphql.ID, Description: "Only posts by the author with this ID."},
+		"author":        &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Only posts by this author, ignoring case and extra whitespace."},
+		"titleContains": &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Only posts with a title containing this text, ignoring case."},
+	},
+})
+
+var postInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "PostInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"authorId": &graphql.InputObjectFieldConfig{Type: graphql.ID, Description: "ID of an existing author, which wins over author when both are set."},
+		"author":   &graphql.InputObjectFieldConfig{Type: graphql.String, Description: "Name of the author, required without authorId. An unknown name creates the author."},
+		"title":    &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"content":  &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"draft":    &graphql.InputObjectFieldConfig{Type: graphql.Boolean, DefaultValue: false, Description: "Whether the post is a draft, which only its author and editors can read. Updates replace the post, so a draft stays one only with draft: true."},
+	},
+})
+
+// NewSchema returns the GraphQL schema of the blog resolved through the use case.
+func NewSchema(useCase IBlogUseCase) (graphql.Schema, error) {
+	r := resolver{useCase: useCase}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{
+				Type: postType,
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.post,
+			},
+			"posts": &graphql.Field{
+				Type:        graphql.NewNonNull(postConnectionType),
+				Description: fmt.Sprintf("Posts ordered by ID. At most %d posts are returned at once.", maxPageSize),
+				Args: graphql.FieldConfigArgument{
+					"filter": &graphql.ArgumentConfig{Type: postFilterType},
+					"first":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultPageSize},
+					"after":  &graphql.ArgumentConfig{Type: graphql.String},
+				},
+				Resolve: r.posts,
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createPost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)},
+				},
+				Resolve: r.createPost,
+			},
+			"updatePost": &graphql.Field{
+				Type: graphql.NewNonNull(postType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(postInputType)},
+				},
+				Resolve: r.updatePost,
+			},
+			"deletePost": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.ID),
+				Args: graphql.FieldConfigArgument{
+					"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+				},
+				Resolve: r.deletePost,
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+type resolver struct {
+	useCase IBlogUseCase
+}
+
+func (r *resolver) post(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := r.useCase.GetPost(p.Context, id)
+	if errors.Is(err, domain.ErrorPostNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toError(err)
+	}
+
+	return post, nil
+}
+
+type postEdge struct {
+	Cursor string       `json:"cursor"`
+	Node   *domain.Post `json:"node"`
+}
+
+type pageInfo struct {
+	HasNextPage bool    `json:"hasNextPage"`
+	EndCursor   *string `json:"endCursor"`
+}
+
+type postConnection struct {
+	Edges      []postEdge `json:"edges"`
+	PageInfo   pageInfo   `json:"pageInfo"`
+	TotalCount int        `json:"totalCount"`
+}
+
+func (r *resolver) posts(p graphql.ResolveParams) (any, error) {
+	first, _ := p.Args["first"].(int)
+	if first < 0 || first > maxPageSize {
+		return nil, badInput(fmt.Sprintf("first must be between 0 and %d", maxPageSize))
+	}
+
+	var afterId int64
+	if after, isIn := p.Args["after"].(string); isIn {
+		var err error
+		if afterId, err = decodeCursor(after); err != nil {
+			return nil, err
+		}
+	}
+
+	filter, _ := p.Args["filter"].(map[string]any)
+	posts, err := filterPosts(r.useCase.GetPosts(p.Context), filter)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(posts, func(a, b *domain.Post) int { return cmp.Compare(a.ID, b.ID) })
+
+	connection := postConnection{Edges: []postEdge{}, TotalCount: len(posts)}
+	start, _ := slices.BinarySearchFunc(posts, afterId+1, func(post *domain.Post, id int64) int { return cmp.Compare(post.ID, id) })
+	for _, post := range posts[start:] {
+		if len(connection.Edges) == first {
+			connection.PageInfo.HasNextPage = true
+			break
+		}
+		connection.Edges = append(connection.Edges, postEdge{Cursor: encodeCursor(post.ID), Node: post})
+	}
+	if len(connection.Edges) > 0 {
+		connection.PageInfo.EndCursor = &connection.Edges[len(connection.Edges)-1].Cursor
+	}
+
+	return connection, nil
+}
+
+// filterPosts matches the author by ID, or by name with domain.AuthorNameKey.
+func filterPosts(posts []*domain.Post, filter map[string]any) ([]*domain.Post, error) {
+	var authorID int64
+	if value, isIn := filter["authorId"]; isIn && value != nil {
+		id, err := parseID(value)
+		if err != nil {
+			return nil, badInput("authorId must be an integer")
+		}
+		authorID = id
+	}
+	author, _ := filter["author"].(string)
+	authorKey := domain.AuthorNameKey(author)
+	titleContains, _ := filter["titleContains"].(string)
+
+	filtered := make([]*domain.Post, 0, len(posts))
+	for _, post := range posts {
+		if authorID != 0 && post.AuthorID != authorID {
+			continue
+		}
+		if len(authorKey) > 0 && domain.AuthorNameKey(post.Author) != authorKey {
+			continue
+		}
+		if len(titleContains) > 0 && !strings.Contains(strings.ToLower(post.Title), strings.ToLower(titleContains)) {
+			continue
+		}
+		filtered = append(filtered, post)
+	}
+
+	return filtered, nil
+}
+
+func (r *resolver) createPost(p graphql.ResolveParams) (any, error) {
+	post, err := readPostInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := r.useCase.CreatePost(p.Context, post)
+	if err != nil {
+		return nil, toError(err)
+	}
+	post.ID = id
+
+	return post, nil
+}
+
+func (r *resolver) updatePost(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := readPostInput(p.Args["input"])
+	if err != nil {
+		return nil, err
+	}
+	post.ID = id
+
+	if err := r.useCase.UpdatePost(p.Context, post, id); err != nil {
+		return nil, toError(err)
+	}
+
+	return post, nil
+}
+
+func (r *resolver) deletePost(p graphql.ResolveParams) (any, error) {
+	id, err := parseID(p.Args["id"])
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.useCase.DeletePost(p.Context, id); err != nil {
+		return nil, toError(err)
+	}
+
+	return strconv.FormatInt(id, 10), nil
+}
+
+// readPostInput checks the post with the same rules as the other transports.
+func readPostInput(value any) (*domain.Post, error) {
+	input, _ := value.(map[string]any)
+	post := &domain.Post{}
+	if value, isIn := input["authorId"]; isIn && value != nil {
+		id, err := parseID(value)
+		if err != nil {
+			return nil, badInput("authorId must be an integer")
+		}
+		post.AuthorID = id
+	}
+	post.Author, _ = input["author"].(string)
+	post.Title, _ = input["title"].(string)
+	post.Content, _ = input["content"].(string)
+	post.Draft, _ = input["draft"].(bool)
+
+	if err := post.Validate(); err != nil {
+		return nil, toError(err)
+	}
+
+	return post, nil
+}
+
+func resolvePostField(get func(*domain.Post) any) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (any, error) {
+		return get(p.Source.(*domain.Post)), nil
+	}
+}
+
+func parseID(value any) (int64, error) {
+	text, _ := value.(string)
+	id, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return 0, badInput("id must be an integer")
+	}
+
+	return id, nil
+}
+
+const cursorPrefix = "post:"
+
+func encodeCursor(id int64) string {
+	return base64.StdEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	text, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil || !strings.HasPrefix(string(text), cursorPrefix) {
+		return 0, badInput("after is not a valid cursor")
+	}
+
+	id, err := strconv.ParseInt(strings.TrimPrefix(string(text), cursorPrefix), 10, 64)
+	if err != nil {
+		return 0, badInput("after is not a valid cursor")
+	}
+
+	return id, nil
+}
@@ -0,0 +1,238 @@
+// Package idempotency remembers the responses of requests sent with an
+// idempotency key, so that retries of a request get the first response
+// instead of repeating its effects.
+package idempotency
+
+import (
+	"container/list"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Outcome int
+
+const (
+	// Started means the key is new. The caller executes the request and then
+	// calls Complete or Release.
+	Started Outcome = iota
+	// Replayed means the key was used for the same request, whose response is returned.
+	Replayed
+	// InProgress means the first request with the key has not completed yet.
+	InProgress
+	// Mismatched means the key was used for a different request.
+	Mismatched
+	// Full means the scope, or the store, has no room for another key, since
+	// all of its keys are in progress.
+	Full
+)
+
+// Response is a recorded response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type Config struct {
+	// TTL is how long the response of a key is replayed after the key was first used.
+	TTL time.Duration
+	// MaxKeysPerScope and MaxBytesPerScope cap the keys and the size of the
+	// recorded bodies of a scope, a user or an anonymous client. The oldest
+	// completed keys of the scope are forgotten to make room for new ones.
+	// Responses larger than MaxBytesPerScope are not recorded.
+	MaxKeysPerScope  int
+	MaxBytesPerScope int64
+	// MaxKeys caps the keys of all scopes, whose number is not limited.
+	MaxKeys int
+}
+
+func DefaultConfig() Config {
+	return Config{
+		TTL:              24 * time.Hour,
+		MaxKeysPerScope:  1000,
+		MaxBytesPerScope: 10 << 20,
+		MaxKeys:          100000,
+	}
+}
+
+// Store keeps the response of every key for the TTL after the key was first
+// used, unless the key is forgotten earlier to keep its scope within the limits.
+//
+// Entries are kept in the order their keys were first used, which is also the
+// order they expire in, both in the store and in their scope.
+type Store struct {
+	mutex   sync.Mutex
+	entries map[scopedKey]*entry
+	order   *list.List
+	scopes  map[string]*scope
+
+	config Config
+
+	now func() time.Time
+}
+
+type scopedKey struct {
+	scope string
+	key   string
+}
+
+type scope struct {
+	order *list.List
+	bytes int64
+}
+
+type entry struct {
+	key         scopedKey
+	fingerprint string
+	response    *Response
+	expiresAt   time.Time
+
+	inStore *list.Element
+	inScope *list.Element
+}
+
+func NewStore(config Config) *Store {
+	return &Store{
+		entries: map[scopedKey]*entry{},
+		order:   list.New(),
+		scopes:  map[string]*scope{},
+		config:  config,
+		now:     time.Now,
+	}
+}
+
+// WithClock sets the clock that expires the recorded responses after the TTL.
+func (s *Store) WithClock(now func() time.Time) *Store {
+	s.now = now
+	return s
+}
+
+// Begin claims the key of the scope for the request with the fingerprint. The
+// recorded response is returned when the outcome is Replayed.
+func (s *Store) Begin(scopeName, key, fingerprint string) (Outcome, *Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.expire(s.now())
+
+	k := scopedKey{scope: scopeName, key: key}
+	if e, isIn := s.entries[k]; isIn {
+		switch {
+		case e.fingerprint != fingerprint:
+			return Mismatched, nil
+		case e.response == nil:
+			return InProgress, nil
+		default:
+			return Replayed, e.response
+		}
+	}
+
+	sc := s.scopes[scopeName]
+	if sc != nil && sc.order.Len() >= s.config.MaxKeysPerScope && !s.forgetOldest(sc.order) {
+		return Full, nil
+	}
+	if len(s.entries) >= s.config.MaxKeys && !s.forgetOldest(s.order) {
+		return Full, nil
+	}
+
+	if sc = s.scopes[scopeName]; sc == nil {
+		sc = &scope{order: list.New()}
+		s.scopes[scopeName] = sc
+	}
+	e := &entry{key: k, fingerprint: fingerprint, expiresAt: s.now().Add(s.config.TTL)}
+	e.inStore = s.order.PushBack(e)
+	e.inScope = sc.order.PushBack(e)
+	s.entries[k] = e
+
+	return Started, nil
+}
+
+// Complete records the response of the request that claimed the key of the
+// scope. A response larger than MaxBytesPerScope is not recorded and the key
+// is forgotten.
+func (s *Store) Complete(scopeName, key string, response *Response) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, isIn := s.entries[scopedKey{scope: scopeName, key: key}]
+	if !isIn {
+		return
+	}
+
+	size := int64(len(response.Body))
+	if size > s.config.MaxBytesPerScope {
+		s.remove(e)
+		return
+	}
+
+	sc := s.scopes[scopeName]
+	for sc.bytes+size > s.config.MaxBytesPerScope {
+		if !s.forgetOldest(sc.order) {
+			break
+		}
+	}
+	e.response = response
+	sc.bytes += size
+}
+
+// Release forgets a key of the scope whose request had no effect, so that it
+// can be retried.
+func (s *Store) Release(scopeName, key string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e, isIn := s.entries[scopedKey{scope: scopeName, key: key}]; isIn {
+		s.remove(e)
+	}
+}
+
+// Len returns the number of keys of all scopes.
+func (s *Store) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return len(s.entries)
+}
+
+// expire drops the expired entries, which are the oldest ones. The caller must
+// hold the lock.
+func (s *Store) expire(now time.Time) {
+	for element := s.order.Front(); element != nil; element = s.order.Front() {
+		e := element.Value.(*entry)
+		if now.Before(e.expiresAt) {
+			return
+		}
+		s.remove(e)
+	}
+}
+
+// forgetOldest drops the oldest completed entry of the order and reports
+// whether there was one. Entries in progress are kept, as their requests are
+// still running. The caller must hold the lock.
+func (s *Store) forgetOldest(order *list.List) bool {
+	for element := order.Front(); element != nil; element = element.Next() {
+		if e := element.Value.(*entry); e.response != nil {
+			s.remove(e)
+			return true
+		}
+	}
+
+	return false
+}
+
+// remove drops the entry from the store and its scope. The caller must hold
+// the lock.
+func (s *Store) remove(e *entry) {
+	delete(s.entries, e.key)
+	s.order.Remove(e.inStore)
+
+	sc := s.scopes[e.key.scope]
+	sc.order.Remove(e.inScope)
+	if e.response != nil {
+		sc.bytes -= int64(len(e.response.Body))
+	}
+	if sc.order.Len() == 0 {
+		delete(s.scopes, e.key.scope)
+	}
+}
@@ -0,0 +1,102 @@
+package idempotency_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/idempotency"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+var testConfig = idempotency.Config{TTL: time.Hour, MaxKeysPerScope: 2, MaxBytesPerScope: 10, MaxKeys: 3}
+
+func Test_Begin_ShouldReplayCompletedResponseForSameRequest(t *testing.T) {
+	store := idempotency.NewStore(testConfig)
+	response := &idempotency.Response{Status: 201, Body: []byte(`{"Id":1}`)}
+
+	outcome, _ := store.Begin("user:1", "key", "request")
+	assert.Equal(t, idempotency.Started, outcome)
+
+	outcome, _ = store.Begin("user:1", "key", "request")
+	assert.Equal(t, idempotency.InProgress, outcome)
+
+	store.Complete("user:1", "key", response)
+	outcome, replayed := store.Begin("user:1", "key", "request")
+	assert.Equal(t, idempotency.Replayed, outcome)
+	assert.Equal(t, response, replayed)
+
+	outcome, _ = store.Begin("user:1", "key", "other request")
+	assert.Equal(t, idempotency.Mismatched, outcome)
+}
+
+func Test_Begin_AfterTTL_ShouldStartAgain(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	store := idempotency.NewStore(idempotency.Config{TTL: time.Minute, MaxKeysPerScope: 10, MaxBytesPerScope: 100, MaxKeys: 10}).WithClock(clock.Now)
+
+	store.Begin("user:1", "key", "request")
+	store.Complete("user:1", "key", &idempotency.Response{Status: 201})
+
+	clock.now = clock.now.Add(time.Minute)
+	outcome, _ := store.Begin("user:1", "key", "other request")
+	assert.Equal(t, idempotency.Started, outcome)
+}
+
+func Test_Release_ShouldAllowRetryOfKey(t *testing.T) {
+	store := idempotency.NewStore(testConfig)
+
+	store.Begin("user:1", "key", "request")
+	store.Release("user:1", "key")
+
+	outcome, _ := store.Begin("user:1", "key", "other request")
+	assert.Equal(t, idempotency.Started, outcome)
+}
+
+func Test_Begin_ScopeFull_ShouldForgetOldestCompletedKey(t *testing.T) {
+	store := idempotency.NewStore(testConfig)
+
+	store.Begin("user:1", "key-1", "request")
+	store.Begin("user:1", "key-2", "request")
+	outcome, _ := store.Begin("user:1", "key-3", "request")
+	assert.Equal(t, idempotency.Full, outcome)
+
+	store.Complete("user:1", "key-2", &idempotency.Response{Status: 201})
+	outcome, _ = store.Begin("user:1", "key-3", "request")
+	assert.Equal(t, idempotency.Started, outcome)
+
+	outcome, _ = store.Begin("user:1", "key-1", "other request")
+	assert.Equal(t, idempotency.Mismatched, outcome)
+	outcome, _ = store.Begin("user:1", "key-2", "other request")
+	assert.Equal(t, idempotency.Full, outcome, "the completed key was forgotten")
+
+	outcome, _ = store.Begin("user:2", "key-1", "request")
+	assert.Equal(t, idempotency.Started, outcome)
+	outcome, _ = store.Begin("user:2", "key-2", "request")
+	assert.Equal(t, idempotency.Full, outcome, "the store is full of keys in progress")
+	assert.Equal(t, 3, store.Len())
+}
+
+func Test_Complete_ScopeOverBytes_ShouldForgetOldestResponses(t *testing.T) {
+	store := idempotency.NewStore(testConfig)
+
+	store.Begin("user:1", "key-1", "request")
+	store.Complete("user:1", "key-1", &idempotency.Response{Status: 201, Body: []byte("123456")})
+	store.Begin("user:1", "key-2", "request")
+	store.Complete("user:1", "key-2", &idempotency.Response{Status: 201, Body: []byte("123456")})
+
+	outcome, _ := store.Begin("user:1", "key-1", "request")
+	assert.Equal(t, idempotency.Started, outcome)
+	outcome, _ = store.Begin("user:1", "key-2", "request")
+	assert.Equal(t, idempotency.Replayed, outcome)
+
+	store.Complete("user:1", "key-1", &idempotency.Response{Status: 201, Body: []byte("12345678901")})
+	outcome, _ = store.Begin("user:1", "key-1", "other request")
+	assert.Equal(t, idempotency.Started, outcome, "a response over the limit is not recorded")
+}
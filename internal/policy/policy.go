@@ -0,0 +1,234 @@
+// Package policy decides which operations a user may perform. A policy grants
+// every role a scope per action: any resource, only the user's own resources, or
+// nothing. Resources are owned by authors, a user owns the resources of the
+// author they are linked to.
+package policy
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type Action string
+
+const (
+	ReadPost   Action = "post:read"
+	CreatePost Action = "post:create"
+	UpdatePost Action = "post:update"
+	DeletePost Action = "post:delete"
+	// ReadDraft allows reading draft posts, which also takes ReadPost.
+	ReadDraft Action = "post:read_draft"
+
+	CreateAuthor Action = "author:create"
+	UpdateAuthor Action = "author:update"
+	DeleteAuthor Action = "author:delete"
+
+	// ManageUsers allows changing the roles of users.
+	ManageUsers Action = "user:manage"
+	// ReadAudit allows reading the audit log of changes.
+	ReadAudit Action = "audit:read"
+	// ReadStats allows reading operational statistics, such as those of the cache.
+	ReadStats Action = "stats:read"
+	// ManageWebhooks allows creating, reading and deleting webhooks and their deliveries.
+	ManageWebhooks Action = "webhook:manage"
+)
+
+// ownable tells whether an action is on a resource with an owner, so that it
+// can be granted with ScopeOwn.
+var ownable = map[Action]bool{
+	ReadPost:       true,
+	CreatePost:     true,
+	UpdatePost:     true,
+	DeletePost:     true,
+	ReadDraft:      true,
+	CreateAuthor:   false,
+	UpdateAuthor:   true,
+	DeleteAuthor:   true,
+	ManageUsers:    false,
+	ReadAudit:      false,
+	ReadStats:      false,
+	ManageWebhooks: false,
+}
+
+type Scope string
+
+const (
+	ScopeNone Scope = ""
+	ScopeOwn  Scope = "own"
+	ScopeAny  Scope = "any"
+)
+
+// Config is the policy as written in a configuration file.
+type Config struct {
+	// AnonymousRole applies to requests without a user.
+	AnonymousRole domain.Role `yaml:"anonymous_role"`
+	// DefaultRole is given to registered users.
+	DefaultRole domain.Role `yaml:"default_role"`
+	// Roles lists the actions granted to every role. Actions that are not
+	// listed are denied.
+	Roles map[domain.Role]map[Action]Scope `yaml:"roles"`
+}
+
+// DefaultConfig lets everybody read published posts, authors write their own
+// posts and profile and read their own drafts, editors manage all posts and
+// authors and admins also manage users and webhooks and read the audit log and
+// statistics.
+func DefaultConfig() Config {
+	return Config{
+		AnonymousRole: domain.RoleReader,
+		DefaultRole:   domain.RoleReader,
+		Roles: map[domain.Role]map[Action]Scope{
+			domain.RoleReader: {
+				ReadPost: ScopeAny,
+			},
+			domain.RoleAuthor: {
+				ReadPost:     ScopeAny,
+				CreatePost:   ScopeOwn,
+				UpdatePost:   ScopeOwn,
+				DeletePost:   ScopeOwn,
+				ReadDraft:    ScopeOwn,
+				UpdateAuthor: ScopeOwn,
+			},
+			domain.RoleEditor: {
+				ReadPost:     ScopeAny,
+				CreatePost:   ScopeAny,
+				UpdatePost:   ScopeAny,
+				DeletePost:   ScopeAny,
+				ReadDraft:    ScopeAny,
+				CreateAuthor: ScopeAny,
+				UpdateAuthor: ScopeAny,
+				DeleteAuthor: ScopeAny,
+			},
+			domain.RoleAdmin: {
+				ReadPost:       ScopeAny,
+				CreatePost:     ScopeAny,
+				UpdatePost:     ScopeAny,
+				DeletePost:     ScopeAny,
+				ReadDraft:      ScopeAny,
+				CreateAuthor:   ScopeAny,
+				UpdateAuthor:   ScopeAny,
+				DeleteAuthor:   ScopeAny,
+				ManageUsers:    ScopeAny,
+				ReadAudit:      ScopeAny,
+				ReadStats:      ScopeAny,
+				ManageWebhooks: ScopeAny,
+			},
+		},
+	}
+}
+
+// Subject is who performs an operation.
+type Subject struct {
+	Role     domain.Role
+	AuthorID int64
+	// Authenticated is false for anonymous requests.
+	Authenticated bool
+}
+
+type Policy struct {
+	cfg Config
+}
+
+// New validates the configuration. Unknown roles, actions and scopes are errors,
+// so that a typo does not silently deny or grant access.
+func New(cfg Config) (*Policy, error) {
+	if !cfg.AnonymousRole.IsValid() {
+		return nil, fmt.Errorf("unknown anonymous role %q", cfg.AnonymousRole)
+	}
+	if !cfg.DefaultRole.IsValid() {
+		return nil, fmt.Errorf("unknown default role %q", cfg.DefaultRole)
+	}
+
+	for role, grants := range cfg.Roles {
+		if !role.IsValid() {
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+		for action, scope := range grants {
+			isOwnable, isIn := ownable[action]
+			if !isIn {
+				return nil, fmt.Errorf("role %s: unknown action %q", role, action)
+			}
+			if scope != ScopeAny && scope != ScopeOwn {
+				return nil, fmt.Errorf("role %s: unknown scope %q of %s, must be any or own", role, scope, action)
+			}
+			if scope == ScopeOwn && !isOwnable {
+				return nil, fmt.Errorf("role %s: %s can only be granted for any", role, action)
+			}
+		}
+	}
+
+	return &Policy{cfg: cfg}, nil
+}
+
+// Default returns the policy of DefaultConfig.
+func Default() *Policy {
+	return &Policy{cfg: DefaultConfig()}
+}
+
+// Load reads a policy configuration in YAML or JSON.
+func Load(r io.Reader) (*Policy, error) {
+	var cfg Config
+	decoder := yaml.NewDecoder(r)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("could not read policy: %w", err)
+	}
+
+	return New(cfg)
+}
+
+// DefaultRole is the role of newly registered users.
+func (p *Policy) DefaultRole() domain.Role {
+	return p.cfg.DefaultRole
+}
+
+// Subject returns the user of the request, or the anonymous role for requests without one.
+func (p *Policy) Subject(ctx context.Context) Subject {
+	user, isIn := auth.UserFromContext(ctx)
+	if !isIn {
+		return Subject{Role: p.cfg.AnonymousRole}
+	}
+
+	return Subject{Role: user.Role, AuthorID: user.AuthorID, Authenticated: true}
+}
+
+// Scope returns the scope the subject is granted for the action.
+func (p *Policy) Scope(subject Subject, action Action) Scope {
+	return p.cfg.Roles[subject.Role][action]
+}
+
+// Allows tells whether the subject may perform the action on a resource of the
+// author ownerID. Actions on resources without an owner pass zero.
+func (p *Policy) Allows(subject Subject, action Action, ownerID int64) bool {
+	switch p.Scope(subject, action) {
+	case ScopeAny:
+		return true
+	case ScopeOwn:
+		return subject.AuthorID != 0 && subject.AuthorID == ownerID
+	default:
+		return false
+	}
+}
+
+// Authorize is Allows returning an error: domain.ErrorUnauthorized for anonymous
+// subjects, who may be allowed after logging in, and domain.ErrorForbidden otherwise.
+func (p *Policy) Authorize(subject Subject, action Action, ownerID int64) error {
+	if p.Allows(subject, action, ownerID) {
+		return nil
+	}
+
+	if !subject.Authenticated {
+		return fmt.Errorf("%w: log in to perform %s", domain.ErrorUnauthorized, action)
+	}
+	if p.Scope(subject, action) == ScopeOwn {
+		return fmt.Errorf("%w: role %s can perform %s only on own resources", domain.ErrorForbidden, subject.Role, action)
+	}
+
+	return fmt.Errorf("%w: role %s cannot perform %s", domain.ErrorForbidden, subject.Role, action)
+}
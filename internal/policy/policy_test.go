@@ -0,0 +1,146 @@
+package policy_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	ownAuthor   int64 = 1
+	otherAuthor int64 = 2
+)
+
+func Test_DefaultPolicy_ShouldFollowDecisionTable(t *testing.T) {
+	anonymous := policy.Subject{Role: domain.RoleReader}
+	reader := policy.Subject{Role: domain.RoleReader, Authenticated: true}
+	author := policy.Subject{Role: domain.RoleAuthor, AuthorID: ownAuthor, Authenticated: true}
+	unlinkedAuthor := policy.Subject{Role: domain.RoleAuthor, Authenticated: true}
+	editor := policy.Subject{Role: domain.RoleEditor, Authenticated: true}
+	admin := policy.Subject{Role: domain.RoleAdmin, Authenticated: true}
+
+	tests := []struct {
+		subject policy.Subject
+		action  policy.Action
+		owner   int64
+		allowed bool
+	}{
+		{anonymous, policy.ReadPost, otherAuthor, true},
+		{anonymous, policy.CreatePost, otherAuthor, false},
+		{reader, policy.ReadPost, otherAuthor, true},
+		{reader, policy.CreatePost, ownAuthor, false},
+		{reader, policy.UpdatePost, otherAuthor, false},
+		{reader, policy.DeletePost, otherAuthor, false},
+		{reader, policy.UpdateAuthor, otherAuthor, false},
+		{author, policy.ReadPost, otherAuthor, true},
+		{author, policy.CreatePost, ownAuthor, true},
+		{author, policy.CreatePost, otherAuthor, false},
+		{author, policy.UpdatePost, ownAuthor, true},
+		{author, policy.UpdatePost, otherAuthor, false},
+		{author, policy.DeletePost, ownAuthor, true},
+		{author, policy.DeletePost, otherAuthor, false},
+		{author, policy.CreateAuthor, 0, false},
+		{author, policy.UpdateAuthor, ownAuthor, true},
+		{author, policy.UpdateAuthor, otherAuthor, false},
+		{author, policy.DeleteAuthor, ownAuthor, false},
+		{unlinkedAuthor, policy.CreatePost, 0, false},
+		{editor, policy.CreatePost, otherAuthor, true},
+		{editor, policy.UpdatePost, otherAuthor, true},
+		{editor, policy.DeletePost, otherAuthor, true},
+		{editor, policy.CreateAuthor, 0, true},
+		{editor, policy.DeleteAuthor, otherAuthor, true},
+		{editor, policy.ManageUsers, 0, false},
+		{admin, policy.DeletePost, otherAuthor, true},
+		{admin, policy.ManageUsers, 0, true},
+		{editor, policy.ReadAudit, 0, false},
+		{admin, policy.ReadAudit, 0, true},
+		{anonymous, policy.ReadStats, 0, false},
+		{editor, policy.ReadStats, 0, false},
+		{admin, policy.ReadStats, 0, true},
+		{anonymous, policy.ManageWebhooks, 0, false},
+		{editor, policy.ManageWebhooks, 0, false},
+		{admin, policy.ManageWebhooks, 0, true},
+		{anonymous, policy.ReadDraft, otherAuthor, false},
+		{reader, policy.ReadDraft, otherAuthor, false},
+		{author, policy.ReadDraft, ownAuthor, true},
+		{author, policy.ReadDraft, otherAuthor, false},
+		{unlinkedAuthor, policy.ReadDraft, 0, false},
+		{editor, policy.ReadDraft, otherAuthor, true},
+		{admin, policy.ReadDraft, otherAuthor, true},
+	}
+
+	p := policy.Default()
+	for _, test := range tests {
+		name := fmt.Sprintf("%s(author %d) %s on author %d", test.subject.Role, test.subject.AuthorID, test.action, test.owner)
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, test.allowed, p.Allows(test.subject, test.action, test.owner))
+		})
+	}
+}
+
+func Test_Authorize_ShouldTellUnauthorizedFromForbidden(t *testing.T) {
+	p := policy.Default()
+
+	err := p.Authorize(policy.Subject{Role: domain.RoleReader}, policy.CreatePost, 1)
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+
+	err = p.Authorize(policy.Subject{Role: domain.RoleReader, Authenticated: true}, policy.CreatePost, 1)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+
+	err = p.Authorize(policy.Subject{Role: domain.RoleAuthor, AuthorID: 1, Authenticated: true}, policy.UpdatePost, 2)
+	assert.EqualError(t, err, "Forbidden: role author can perform post:update only on own resources")
+}
+
+func Test_Subject_ShouldTakeUserFromContext(t *testing.T) {
+	p := policy.Default()
+
+	assert.Equal(t, policy.Subject{Role: domain.RoleReader}, p.Subject(context.Background()))
+
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 3, Role: domain.RoleAuthor, AuthorID: 1})
+	assert.Equal(t, policy.Subject{Role: domain.RoleAuthor, AuthorID: 1, Authenticated: true}, p.Subject(ctx))
+}
+
+func Test_Load_ShouldReadConfig(t *testing.T) {
+	config := `
+anonymous_role: reader
+default_role: author
+roles:
+  reader: {}
+  author:
+    post:read: any
+    post:create: own
+  admin:
+    user:manage: any
+`
+	p, err := policy.Load(strings.NewReader(config))
+	assert.NoError(t, err)
+
+	assert.Equal(t, domain.RoleAuthor, p.DefaultRole())
+	assert.False(t, p.Allows(policy.Subject{Role: domain.RoleReader}, policy.ReadPost, 1))
+	assert.True(t, p.Allows(policy.Subject{Role: domain.RoleAuthor, AuthorID: 1}, policy.CreatePost, 1))
+	assert.False(t, p.Allows(policy.Subject{Role: domain.RoleEditor}, policy.ReadPost, 1))
+}
+
+func Test_Load_InvalidConfig_ShouldFail(t *testing.T) {
+	tests := map[string]string{
+		"unknown role":      "anonymous_role: reader\ndefault_role: reader\nroles:\n  owner: {}\n",
+		"unknown action":    "anonymous_role: reader\ndefault_role: reader\nroles:\n  reader:\n    post:publish: any\n",
+		"unknown scope":     "anonymous_role: reader\ndefault_role: reader\nroles:\n  reader:\n    post:read: all\n",
+		"unownable action":  "anonymous_role: reader\ndefault_role: reader\nroles:\n  admin:\n    user:manage: own\n",
+		"missing anonymous": "default_role: reader\n",
+		"unknown field":     "anonymous_role: reader\ndefault_role: reader\ngrants: {}\n",
+	}
+
+	for name, config := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := policy.Load(strings.NewReader(config))
+			assert.Error(t, err)
+		})
+	}
+}
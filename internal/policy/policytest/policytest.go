@@ -0,0 +1,20 @@
+// Package policytest provides policies for the tests of packages that authorize
+// through the policy package.
+package policytest
+
+import (
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+// Open returns the default policy with anonymous users in the editor role, so
+// that tests can read and change any post without authenticating.
+func Open() *policy.Policy {
+	cfg := policy.DefaultConfig()
+	cfg.AnonymousRole = domain.RoleEditor
+	open, err := policy.New(cfg)
+	if err != nil {
+		panic(err)
+	}
+	return open
+}
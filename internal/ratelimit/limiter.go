@@ -0,0 +1,115 @@
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+// maxBuckets is the number of tracked clients after which the least recently
+// seen one is dropped even when its bucket has not refilled yet.
+const maxBuckets = 10000
+
+// Limiter is a token bucket rate limiter keyed by client.
+// Every key gets its own bucket of Burst tokens refilled at Rate tokens per second.
+//
+// Buckets are kept in the order clients were last seen. Buckets that have
+// refilled completely are equal to fresh ones, so they are dropped from the
+// least recently seen end whenever a client is seen, which takes amortized
+// constant time.
+type Limiter struct {
+	mutex   sync.Mutex
+	buckets map[string]*list.Element
+	order   *list.List
+
+	rate  float64
+	burst float64
+
+	now func() time.Time
+}
+
+type bucket struct {
+	key      string
+	tokens   float64
+	lastSeen time.Time
+}
+
+func NewLimiter(rate float64, burst int) *Limiter {
+	return &Limiter{
+		buckets: map[string]*list.Element{},
+		order:   list.New(),
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+	}
+}
+
+// WithClock sets the clock the buckets refill by, so that tests need not wait
+// for tokens.
+func (l *Limiter) WithClock(now func() time.Time) *Limiter {
+	l.now = now
+	return l
+}
+
+// Allow takes a token from the bucket of the key. When the bucket is empty
+// it returns false and the time until the next token is available.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := l.now()
+	l.evictFullBuckets(now)
+
+	var b *bucket
+	if element, isIn := l.buckets[key]; isIn {
+		l.order.MoveToBack(element)
+		b = element.Value.(*bucket)
+	} else {
+		if l.order.Len() >= maxBuckets {
+			l.evict(l.order.Front())
+		}
+		b = &bucket{key: key, tokens: l.burst, lastSeen: now}
+		l.buckets[key] = l.order.PushBack(b)
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate)
+	b.lastSeen = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	if l.rate <= 0 {
+		return false, time.Duration(math.MaxInt64)
+	}
+
+	wait := (1 - b.tokens) / l.rate
+	return false, time.Duration(wait * float64(time.Second))
+}
+
+// Len returns the number of tracked clients.
+func (l *Limiter) Len() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return l.order.Len()
+}
+
+// evictFullBuckets drops the least recently seen buckets up to the first one
+// that has not refilled yet.
+func (l *Limiter) evictFullBuckets(now time.Time) {
+	for element := l.order.Front(); element != nil; element = l.order.Front() {
+		b := element.Value.(*bucket)
+		if b.tokens+now.Sub(b.lastSeen).Seconds()*l.rate < l.burst {
+			return
+		}
+		l.evict(element)
+	}
+}
+
+func (l *Limiter) evict(element *list.Element) {
+	l.order.Remove(element)
+	delete(l.buckets, element.Value.(*bucket).key)
+}
@@ -0,0 +1,95 @@
+package ratelimit_test
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func Test_Allow_ShouldAllowBurstAndThenLimit(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := ratelimit.NewLimiter(1, 3).WithClock(clock.Now)
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("client")
+		assert.True(t, allowed)
+	}
+
+	allowed, retryAfter := limiter.Allow("client")
+	assert.False(t, allowed)
+	assert.Equal(t, time.Second, retryAfter)
+}
+
+func Test_Allow_ShouldRefillOverTime(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := ratelimit.NewLimiter(2, 1).WithClock(clock.Now)
+
+	allowed, _ := limiter.Allow("client")
+	assert.True(t, allowed)
+
+	allowed, retryAfter := limiter.Allow("client")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, retryAfter)
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	allowed, _ = limiter.Allow("client")
+	assert.True(t, allowed)
+}
+
+func Test_Allow_ShouldKeepSeparateBucketsPerKey(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := ratelimit.NewLimiter(1, 1).WithClock(clock.Now)
+
+	allowed, _ := limiter.Allow("client1")
+	assert.True(t, allowed)
+
+	allowed, _ = limiter.Allow("client1")
+	assert.False(t, allowed)
+
+	allowed, _ = limiter.Allow("client2")
+	assert.True(t, allowed)
+}
+
+func Test_Allow_ShouldDropRefilledBuckets(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := ratelimit.NewLimiter(1, 2).WithClock(clock.Now)
+
+	limiter.Allow("client1")
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	limiter.Allow("client2")
+	assert.Equal(t, 2, limiter.Len())
+
+	clock.now = clock.now.Add(700 * time.Millisecond)
+	limiter.Allow("client3")
+	assert.Equal(t, 2, limiter.Len())
+
+	clock.now = clock.now.Add(time.Second)
+	limiter.Allow("client3")
+	assert.Equal(t, 1, limiter.Len())
+}
+
+func Test_Allow_ShouldKeepBucketsOfRecentlySeenClients(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := ratelimit.NewLimiter(0, 1).WithClock(clock.Now)
+
+	allowed, _ := limiter.Allow("client")
+	assert.True(t, allowed)
+	for i := 0; i < 10000; i++ {
+		limiter.Allow("other" + strconv.Itoa(i))
+	}
+	assert.Equal(t, 10000, limiter.Len())
+
+	allowed, _ = limiter.Allow("client")
+	assert.True(t, allowed, "the least recently seen client is dropped at the cap")
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// AttachmentRepository keeps the metadata of attachments. Their content is in a blob store.
+type AttachmentRepository struct {
+	mutex       sync.RWMutex
+	attachments map[int64]*domain.Attachment
+	store       IAttachmentStore
+
+	sequenceId int64
+}
+
+// IAttachmentStore persists the changes of the attachment repository.
+type IAttachmentStore interface {
+	LoadAttachments() (map[int64]*domain.Attachment, int64, error)
+	PutAttachment(attachment *domain.Attachment, sequence int64) error
+	DeleteAttachment(id int64) error
+}
+
+func NewAttachmentRepository() *AttachmentRepository {
+	return &AttachmentRepository{attachments: map[int64]*domain.Attachment{}}
+}
+
+// NewPersistentAttachmentRepository loads the attachments of the store and writes every change to it.
+func NewPersistentAttachmentRepository(store IAttachmentStore) (*AttachmentRepository, error) {
+	attachments, sequence, err := store.LoadAttachments()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AttachmentRepository{
+		attachments: attachments,
+		store:       store,
+		sequenceId:  sequence,
+	}, nil
+}
+
+func (r *AttachmentRepository) CreateAttachment(ctx context.Context, attachment *domain.Attachment) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	attachment.ID = r.sequenceId + 1
+	if r.store != nil {
+		if err := r.store.PutAttachment(attachment, attachment.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	r.sequenceId = attachment.ID
+	r.attachments[attachment.ID] = attachment
+	return attachment.ID, nil
+}
+
+// GetAttachment returns the attachment of the post, attachments of other posts are not found.
+func (r *AttachmentRepository) GetAttachment(ctx context.Context, postID int64, id int64) (*domain.Attachment, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, isIn := r.attachments[id]
+	if !isIn || a.PostID != postID {
+		return nil, domain.ErrorAttachmentNotFound
+	}
+
+	return a, nil
+}
+
+// GetAttachments returns the attachments of the post ordered by ID.
+func (r *AttachmentRepository) GetAttachments(ctx context.Context, postID int64) []*domain.Attachment {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	attachments := []*domain.Attachment{}
+	for _, a := range r.attachments {
+		if a.PostID == postID {
+			attachments = append(attachments, a)
+		}
+	}
+	sort.Slice(attachments, func(i, j int) bool { return attachments[i].ID < attachments[j].ID })
+
+	return attachments
+}
+
+func (r *AttachmentRepository) DeleteAttachment(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, isIn := r.attachments[id]; !isIn {
+		return domain.ErrorAttachmentNotFound
+	}
+
+	if r.store != nil {
+		if err := r.store.DeleteAttachment(id); err != nil {
+			return err
+		}
+	}
+
+	delete(r.attachments, id)
+	return nil
+}
+
+// HasHash reports whether an attachment has content with the hash, so that the
+// blob of a deleted attachment is kept while other attachments share it.
+func (r *AttachmentRepository) HasHash(ctx context.Context, hash string) bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, a := range r.attachments {
+		if a.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
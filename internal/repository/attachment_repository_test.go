@@ -0,0 +1,74 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetAttachment_OfOtherPost_ShouldReturnNotFound(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAttachmentRepository()
+
+	id, err := repo.CreateAttachment(suite.ctx, &domain.Attachment{PostID: 1, Filename: "a.txt", Hash: "aaa"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+
+	attachment, err := repo.GetAttachment(suite.ctx, 1, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "a.txt", attachment.Filename)
+
+	_, err = repo.GetAttachment(suite.ctx, 2, id)
+	assert.ErrorIs(t, err, domain.ErrorAttachmentNotFound)
+}
+
+func Test_DeleteAttachment_ShouldKeepHashWhileShared(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAttachmentRepository()
+
+	first, _ := repo.CreateAttachment(suite.ctx, &domain.Attachment{PostID: 1, Hash: "aaa"})
+	second, _ := repo.CreateAttachment(suite.ctx, &domain.Attachment{PostID: 2, Hash: "aaa"})
+
+	assert.NoError(t, repo.DeleteAttachment(suite.ctx, first))
+	assert.True(t, repo.HasHash(suite.ctx, "aaa"))
+
+	assert.NoError(t, repo.DeleteAttachment(suite.ctx, second))
+	assert.False(t, repo.HasHash(suite.ctx, "aaa"))
+	assert.ErrorIs(t, repo.DeleteAttachment(suite.ctx, second), domain.ErrorAttachmentNotFound)
+}
+
+func Test_PersistentAttachmentRepository_ShouldKeepAttachmentsAndSequenceAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+	createdAt := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentAttachmentRepository(store)
+	assert.NoError(t, err)
+
+	_, err = repo.CreateAttachment(suite.ctx, &domain.Attachment{PostID: 1, Filename: "cat.png", ContentType: "image/png",
+		Size: 42, Hash: "aaa", ThumbnailType: "image/png", CreatedAt: createdAt})
+	assert.NoError(t, err)
+	_, err = repo.CreateAttachment(suite.ctx, &domain.Attachment{PostID: 1, Filename: "notes.txt", Hash: "bbb"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeleteAttachment(suite.ctx, 2))
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentAttachmentRepository(store)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*domain.Attachment{{ID: 1, PostID: 1, Filename: "cat.png", ContentType: "image/png",
+		Size: 42, Hash: "aaa", ThumbnailType: "image/png", CreatedAt: createdAt}}, repo.GetAttachments(suite.ctx, 1))
+
+	id, err := repo.CreateAttachment(suite.ctx, &domain.Attachment{PostID: 1, Hash: "ccc"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, id)
+}
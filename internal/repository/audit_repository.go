@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"sync"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// AuditRepository is an append-only log of audit entries. Entries can be neither
+// changed nor deleted.
+type AuditRepository struct {
+	mutex   sync.RWMutex
+	entries []*domain.AuditEntry
+	store   IAuditStore
+}
+
+// IAuditStore persists the audit log.
+type IAuditStore interface {
+	LoadAudit() ([]*domain.AuditEntry, error)
+	AppendAudit(entry *domain.AuditEntry) error
+}
+
+func NewAuditRepository() *AuditRepository {
+	return &AuditRepository{}
+}
+
+// NewPersistentAuditRepository loads the audit log of the store and appends every entry to it.
+func NewPersistentAuditRepository(store IAuditStore) (*AuditRepository, error) {
+	entries, err := store.LoadAudit()
+	if err != nil {
+		return nil, err
+	}
+
+	return &AuditRepository{entries: entries, store: store}, nil
+}
+
+// AppendEntry assigns the next ID to the entry and appends it. An entry that
+// could not be persisted is not appended.
+func (r *AuditRepository) AppendEntry(ctx context.Context, entry *domain.AuditEntry) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	appended := *entry
+	appended.ID = 1
+	if len(r.entries) > 0 {
+		appended.ID = r.entries[len(r.entries)-1].ID + 1
+	}
+
+	if r.store != nil {
+		if err := r.store.AppendAudit(&appended); err != nil {
+			return err
+		}
+	}
+
+	r.entries = append(r.entries, &appended)
+	entry.ID = appended.ID
+	return nil
+}
+
+// GetEntries returns the entries matching the filter ordered by ID.
+func (r *AuditRepository) GetEntries(ctx context.Context, filter domain.AuditFilter) []*domain.AuditEntry {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	entries := []*domain.AuditEntry{}
+	for _, entry := range r.entries {
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
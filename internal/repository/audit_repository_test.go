@@ -0,0 +1,62 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_AppendEntry_ShouldSetIdSequentiallyAndFilter(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAuditRepository()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []*domain.AuditEntry{
+		{OccurredAt: start, ActorID: 1, Action: domain.AuditPostCreated, PostID: 1},
+		{OccurredAt: start.Add(time.Minute), ActorID: 2, Action: domain.AuditPostUpdated, PostID: 1},
+		{OccurredAt: start.Add(2 * time.Minute), ActorID: 1, Action: domain.AuditPostDeleted, PostID: 1},
+	}
+	for i, entry := range entries {
+		assert.NoError(t, repo.AppendEntry(suite.ctx, entry))
+		assert.EqualValues(t, i+1, entry.ID)
+	}
+
+	found := repo.GetEntries(suite.ctx, domain.AuditFilter{ActorID: 1})
+	assert.Len(t, found, 2)
+	assert.EqualValues(t, 1, found[0].ID)
+	assert.EqualValues(t, 3, found[1].ID)
+
+	found = repo.GetEntries(suite.ctx, domain.AuditFilter{Since: start.Add(time.Minute), Until: start.Add(2 * time.Minute)})
+	assert.Len(t, found, 1)
+	assert.Equal(t, domain.AuditPostUpdated, found[0].Action)
+
+	assert.Empty(t, repo.GetEntries(suite.ctx, domain.AuditFilter{PostID: 2}))
+}
+
+func Test_PersistentAuditRepository_ShouldKeepEntriesAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentAuditRepository(store)
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.AppendEntry(suite.ctx, &domain.AuditEntry{Action: domain.AuditPostCreated, PostID: 1}))
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentAuditRepository(store)
+	assert.NoError(t, err)
+
+	entry := &domain.AuditEntry{Action: domain.AuditPostDeleted, PostID: 1}
+	assert.NoError(t, repo.AppendEntry(suite.ctx, entry))
+	assert.EqualValues(t, 2, entry.ID)
+	assert.Len(t, repo.GetEntries(suite.ctx, domain.AuditFilter{PostID: 1}), 2)
+}
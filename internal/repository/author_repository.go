@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// AuthorRepository keeps authors with unique names, compared by domain.AuthorNameKey.
+type AuthorRepository struct {
+	mutex   sync.RWMutex
+	authors map[int64]*domain.Author
+	names   map[string]int64
+	store   IAuthorStore
+
+	sequenceId int64
+}
+
+// IAuthorStore persists the changes of the author repository.
+type IAuthorStore interface {
+	LoadAuthors() (map[int64]*domain.Author, int64, error)
+	PutAuthor(author *domain.Author, sequence int64) error
+	DeleteAuthor(id int64) error
+}
+
+func NewAuthorRepository() *AuthorRepository {
+	return &AuthorRepository{
+		authors: map[int64]*domain.Author{},
+		names:   map[string]int64{},
+	}
+}
+
+// NewPersistentAuthorRepository loads the authors of the store and writes every change to it.
+func NewPersistentAuthorRepository(store IAuthorStore) (*AuthorRepository, error) {
+	authors, sequence, err := store.LoadAuthors()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &AuthorRepository{
+		authors:    authors,
+		names:      make(map[string]int64, len(authors)),
+		store:      store,
+		sequenceId: sequence,
+	}
+	for id, a := range authors {
+		r.names[domain.AuthorNameKey(a.Name)] = id
+	}
+
+	return r, nil
+}
+
+func (r *AuthorRepository) GetAuthor(ctx context.Context, id int64) (*domain.Author, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	a, isIn := r.authors[id]
+	if isIn {
+		return a, nil
+	}
+
+	return nil, domain.ErrorAuthorNotFound
+}
+
+// GetAuthors returns all authors ordered by ID.
+func (r *AuthorRepository) GetAuthors(ctx context.Context) []*domain.Author {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	authors := make([]*domain.Author, 0, len(r.authors))
+	for _, a := range r.authors {
+		authors = append(authors, a)
+	}
+	sort.Slice(authors, func(i, j int) bool { return authors[i].ID < authors[j].ID })
+
+	return authors
+}
+
+func (r *AuthorRepository) CreateAuthor(ctx context.Context, author *domain.Author) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	return r.create(author)
+}
+
+// GetOrCreateAuthorByName returns the author with the name, creating it when there is none.
+func (r *AuthorRepository) GetOrCreateAuthorByName(ctx context.Context, name string) (*domain.Author, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if id, isIn := r.names[domain.AuthorNameKey(name)]; isIn {
+		return r.authors[id], nil
+	}
+
+	author := &domain.Author{Name: domain.NormalizeAuthorName(name)}
+	if _, err := r.create(author); err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+func (r *AuthorRepository) UpdateAuthor(ctx context.Context, author *domain.Author, id int64) error {
+	author.ID = id
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current, isIn := r.authors[id]
+	if !isIn {
+		return domain.ErrorAuthorNotFound
+	}
+
+	key := domain.AuthorNameKey(author.Name)
+	if other, isIn := r.names[key]; isIn && other != id {
+		return fmt.Errorf("%w: author %q already exists", domain.ErrorConflict, author.Name)
+	}
+
+	if r.store != nil {
+		if err := r.store.PutAuthor(author, r.sequenceId); err != nil {
+			return err
+		}
+	}
+
+	delete(r.names, domain.AuthorNameKey(current.Name))
+	r.names[key] = id
+	r.authors[id] = author
+	return nil
+}
+
+func (r *AuthorRepository) DeleteAuthor(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	author, isIn := r.authors[id]
+	if !isIn {
+		return domain.ErrorAuthorNotFound
+	}
+
+	if r.store != nil {
+		if err := r.store.DeleteAuthor(id); err != nil {
+			return err
+		}
+	}
+
+	delete(r.names, domain.AuthorNameKey(author.Name))
+	delete(r.authors, id)
+	return nil
+}
+
+// create adds the author under the lock.
+func (r *AuthorRepository) create(author *domain.Author) (int64, error) {
+	key := domain.AuthorNameKey(author.Name)
+	if _, isIn := r.names[key]; isIn {
+		return 0, fmt.Errorf("%w: author %q already exists", domain.ErrorConflict, author.Name)
+	}
+
+	author.ID = r.sequenceId + 1
+	if r.store != nil {
+		if err := r.store.PutAuthor(author, author.ID); err != nil {
+			return 0, err
+		}
+	}
+
+	r.sequenceId = author.ID
+	r.names[key] = author.ID
+	r.authors[author.ID] = author
+	return author.ID, nil
+}
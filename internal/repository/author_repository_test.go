@@ -0,0 +1,110 @@
+package repository_test
+
+import (
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateAuthor_ShouldSetIdSequentially(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAuthorRepository()
+
+	id, err := repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Anton"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+
+	id, err = repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Jonny"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, id)
+
+	authors := repo.GetAuthors(suite.ctx)
+	assert.Equal(t, []*domain.Author{{ID: 1, Name: "Anton"}, {ID: 2, Name: "Jonny"}}, authors)
+}
+
+func Test_CreateAuthor_SameNormalizedName_ShouldReturnConflict(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAuthorRepository()
+
+	_, err := repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Anton"})
+	assert.NoError(t, err)
+
+	_, err = repo.CreateAuthor(suite.ctx, &domain.Author{Name: "anton "})
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+}
+
+func Test_GetOrCreateAuthorByName_ShouldReturnExistingAuthor(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAuthorRepository()
+
+	created, err := repo.GetOrCreateAuthorByName(suite.ctx, "  Anton   Kondrushin ")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Author{ID: 1, Name: "Anton Kondrushin"}, created)
+
+	found, err := repo.GetOrCreateAuthorByName(suite.ctx, "anton kondrushin")
+	assert.NoError(t, err)
+	assert.Same(t, created, found)
+}
+
+func Test_UpdateAuthor_ShouldFreeOldName(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAuthorRepository()
+
+	_, err := repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Anton"})
+	assert.NoError(t, err)
+	_, err = repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Jonny"})
+	assert.NoError(t, err)
+
+	err = repo.UpdateAuthor(suite.ctx, &domain.Author{Name: "Jonny"}, 1)
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+
+	err = repo.UpdateAuthor(suite.ctx, &domain.Author{Name: "Anton K.", Bio: "Gopher"}, 1)
+	assert.NoError(t, err)
+
+	author, err := repo.GetOrCreateAuthorByName(suite.ctx, "Anton")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, author.ID)
+}
+
+func Test_DeleteAuthor_NotExisting_ShouldReturnNotFound(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewAuthorRepository()
+
+	err := repo.DeleteAuthor(suite.ctx, 1)
+	assert.ErrorIs(t, err, domain.ErrorAuthorNotFound)
+}
+
+func Test_PersistentAuthorRepository_ShouldKeepAuthorsAndSequenceAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentAuthorRepository(store)
+	assert.NoError(t, err)
+
+	_, err = repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Anton", Bio: "Gopher"})
+	assert.NoError(t, err)
+	_, err = repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Jonny"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeleteAuthor(suite.ctx, 2))
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentAuthorRepository(store)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*domain.Author{{ID: 1, Name: "Anton", Bio: "Gopher"}}, repo.GetAuthors(suite.ctx))
+
+	_, err = repo.CreateAuthor(suite.ctx, &domain.Author{Name: "anton"})
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+
+	id, err := repo.CreateAuthor(suite.ctx, &domain.Author{Name: "Jonny"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, id)
+}
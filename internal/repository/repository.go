@@ -2,19 +2,31 @@ package repository
 
 import (
 	"context"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/kondrushin/blog/internal/domain"
 )
 
+// Repository keeps deleted posts in the trash, where only the trash methods see
+// them, until they are restored or purged.
 type Repository struct {
 	mutex sync.RWMutex
 	posts map[int64]*domain.Post
+	store IPostStore
 
 	sequenceId *int64
 }
 
+// IPostStore persists the changes of the repository.
+type IPostStore interface {
+	Load() (map[int64]*domain.Post, int64, error)
+	Put(post *domain.Post, sequence int64) error
+	Delete(id int64) error
+}
+
 func NewRepository() *Repository {
 	var startId int64 = 0 // it will start with 1
 	return &Repository{
@@ -23,12 +35,32 @@ func NewRepository() *Repository {
 	}
 }
 
+// NewPersistentRepository loads the posts of the store and writes every change to it.
+func NewPersistentRepository(store IPostStore) (*Repository, error) {
+	posts, sequence, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, post := range posts {
+		// Posts stored before summaries were stored have no word count.
+		if post.WordCount == 0 {
+			post.Summarize()
+		}
+	}
+
+	return &Repository{
+		sequenceId: &sequence,
+		posts:      posts,
+		store:      store,
+	}, nil
+}
+
 func (r *Repository) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
 	r.mutex.RLock()
 	defer r.mutex.RUnlock()
 
 	p, isIn := r.posts[id]
-	if isIn {
+	if isIn && !p.IsDeleted() {
 		return p, nil
 	}
 
@@ -41,12 +73,57 @@ func (r *Repository) GetPosts(ctx context.Context) []*domain.Post {
 
 	posts := make([]*domain.Post, 0, len(r.posts))
 	for _, p := range r.posts {
-		posts = append(posts, p)
+		if !p.IsDeleted() {
+			posts = append(posts, p)
+		}
 	}
 
 	return posts
 }
 
+// eachPostBatchSize is the number of posts EachPost reads under one read lock.
+const eachPostBatchSize = 256
+
+// EachPost calls yield with the posts ordered by ID until it returns false. The
+// IDs are taken at the start, the posts are then read in batches, so that writers
+// are not blocked while yield handles them. Posts changed in the meantime are
+// read as they are when their batch is read, deleted ones are skipped.
+func (r *Repository) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	r.mutex.RLock()
+	ids := make([]int64, 0, len(r.posts))
+	for id, p := range r.posts {
+		if !p.IsDeleted() {
+			ids = append(ids, id)
+		}
+	}
+	r.mutex.RUnlock()
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	batch := make([]*domain.Post, 0, eachPostBatchSize)
+	for start := 0; start < len(ids); start += eachPostBatchSize {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+		r.mutex.RLock()
+		for _, id := range ids[start:min(start+eachPostBatchSize, len(ids))] {
+			if p, isIn := r.posts[id]; isIn && !p.IsDeleted() {
+				batch = append(batch, p)
+			}
+		}
+		r.mutex.RUnlock()
+
+		for _, p := range batch {
+			if !yield(p) {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
 func (r *Repository) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
 	nextPostId := r.getNextSequenceId()
 	post.ID = nextPostId
@@ -54,6 +131,12 @@ func (r *Repository) CreatePost(ctx context.Context, post *domain.Post) (int64,
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
+	if r.store != nil {
+		if err := r.store.Put(post, nextPostId); err != nil {
+			return 0, err
+		}
+	}
+
 	r.posts[nextPostId] = post
 	return nextPostId, nil
 }
@@ -64,20 +147,112 @@ func (r *Repository) UpdatePost(ctx context.Context, post *domain.Post, id int64
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	_, isIn := r.posts[id]
-	if !isIn {
+	current, isIn := r.posts[id]
+	if !isIn || current.IsDeleted() {
 		return domain.ErrorPostNotFound
 	}
 
-	r.posts[id] = post
-	return nil
+	return r.put(post)
 }
 
+// DeletePost moves the post to the trash.
 func (r *Repository) DeletePost(ctx context.Context, id int64) error {
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
 
-	delete(r.posts, id)
+	current, isIn := r.posts[id]
+	if !isIn || current.IsDeleted() {
+		return domain.ErrorPostNotFound
+	}
+
+	deleted := *current
+	deleted.DeletedAt = time.Now().UTC()
+	return r.put(&deleted)
+}
+
+// GetDeletedPost returns a post of the trash.
+func (r *Repository) GetDeletedPost(ctx context.Context, id int64) (*domain.Post, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	p, isIn := r.posts[id]
+	if isIn && p.IsDeleted() {
+		return p, nil
+	}
+
+	return nil, domain.ErrorPostNotFound
+}
+
+// GetDeletedPosts returns the posts of the trash, most recently deleted first.
+func (r *Repository) GetDeletedPosts(ctx context.Context) []*domain.Post {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	posts := []*domain.Post{}
+	for _, p := range r.posts {
+		if p.IsDeleted() {
+			posts = append(posts, p)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool {
+		if !posts[i].DeletedAt.Equal(posts[j].DeletedAt) {
+			return posts[i].DeletedAt.After(posts[j].DeletedAt)
+		}
+		return posts[i].ID > posts[j].ID
+	})
+
+	return posts
+}
+
+// RestorePost takes the post out of the trash.
+func (r *Repository) RestorePost(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current, isIn := r.posts[id]
+	if !isIn || !current.IsDeleted() {
+		return domain.ErrorPostNotFound
+	}
+
+	restored := *current
+	restored.DeletedAt = time.Time{}
+	return r.put(&restored)
+}
+
+// PurgePosts removes the posts that were deleted before deletedBefore for good
+// and returns them.
+func (r *Repository) PurgePosts(ctx context.Context, deletedBefore time.Time) ([]*domain.Post, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	purged := []*domain.Post{}
+	for id, p := range r.posts {
+		if !p.IsDeleted() || !p.DeletedAt.Before(deletedBefore) {
+			continue
+		}
+
+		if r.store != nil {
+			if err := r.store.Delete(id); err != nil {
+				return purged, err
+			}
+		}
+		delete(r.posts, id)
+		purged = append(purged, p)
+	}
+	sort.Slice(purged, func(i, j int) bool { return purged[i].ID < purged[j].ID })
+
+	return purged, nil
+}
+
+// put stores the post. The caller must hold the write lock.
+func (r *Repository) put(post *domain.Post) error {
+	if r.store != nil {
+		if err := r.store.Put(post, atomic.LoadInt64(r.sequenceId)); err != nil {
+			return err
+		}
+	}
+
+	r.posts[post.ID] = post
 	return nil
 }
 
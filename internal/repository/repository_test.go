@@ -3,9 +3,11 @@ package repository_test
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/kondrushin/blog/internal/domain"
 	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -122,12 +124,123 @@ func Test_DeletePost_ShouldDeletePostFromRepo(t *testing.T) {
 	assert.ErrorIs(t, domain.ErrorPostNotFound, err)
 }
 
-func Test_DeletePost_NoItemToDelete_ShouldNotReturnError(t *testing.T) {
+func Test_DeletePost_NoItemToDelete_ShouldReturnNotFound(t *testing.T) {
 	suite := SetSuite()
 	repo := repository.NewRepository()
 
 	err := repo.DeletePost(suite.ctx, int64(63))
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+}
+
+func Test_DeletePost_ShouldMovePostToTrashUntilRestored(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewRepository()
+
+	postId, err := repo.CreatePost(suite.ctx, &domain.Post{Author: "Anton", Title: "On trash", Content: "qwerty"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeletePost(suite.ctx, postId))
+
+	assert.ErrorIs(t, repo.DeletePost(suite.ctx, postId), domain.ErrorPostNotFound)
+	assert.ErrorIs(t, repo.UpdatePost(suite.ctx, &domain.Post{Title: "New"}, postId), domain.ErrorPostNotFound)
+	assert.Empty(t, repo.GetPosts(suite.ctx))
+
+	trash := repo.GetDeletedPosts(suite.ctx)
+	assert.Len(t, trash, 1)
+	assert.True(t, trash[0].IsDeleted())
+	deleted, err := repo.GetDeletedPost(suite.ctx, postId)
+	assert.NoError(t, err)
+	assert.Equal(t, "On trash", deleted.Title)
+
+	assert.NoError(t, repo.RestorePost(suite.ctx, postId))
+	assert.ErrorIs(t, repo.RestorePost(suite.ctx, postId), domain.ErrorPostNotFound)
+	post, err := repo.GetPost(suite.ctx, postId)
+	assert.NoError(t, err)
+	assert.False(t, post.IsDeleted())
+	assert.Empty(t, repo.GetDeletedPosts(suite.ctx))
+}
+
+func Test_PurgePosts_ShouldRemovePostsDeletedBeforeCutoff(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewRepository()
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.CreatePost(suite.ctx, &domain.Post{Author: "Anton", Title: "On trash", Content: "qwerty"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, repo.DeletePost(suite.ctx, 1))
+	assert.NoError(t, repo.DeletePost(suite.ctx, 2))
+
+	purged, err := repo.PurgePosts(suite.ctx, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, purged)
+
+	purged, err = repo.PurgePosts(suite.ctx, time.Now().Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, purged, 2)
+	assert.EqualValues(t, 1, purged[0].ID)
+	assert.Empty(t, repo.GetDeletedPosts(suite.ctx))
+	assert.ErrorIs(t, repo.RestorePost(suite.ctx, 1), domain.ErrorPostNotFound)
+	assert.Len(t, repo.GetPosts(suite.ctx), 1)
+}
+
+func Test_EachPost_ShouldYieldPostsOrderedByIdAcrossBatches(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewRepository()
+
+	for i := 0; i < 600; i++ {
+		_, err := repo.CreatePost(suite.ctx, &domain.Post{Author: "Anton", Title: "On batches", Content: "qwerty"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, repo.DeletePost(suite.ctx, 300))
+
+	var ids []int64
+	err := repo.EachPost(suite.ctx, func(post *domain.Post) bool {
+		if post.ID == 1 {
+			// Writers are not blocked while the posts are yielded, and the
+			// posts of later batches are read as they are then.
+			assert.NoError(t, repo.DeletePost(suite.ctx, 400))
+		}
+		ids = append(ids, post.ID)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, ids, 598)
+	assert.EqualValues(t, 1, ids[0])
+	assert.EqualValues(t, 600, ids[len(ids)-1])
+	assert.NotContains(t, ids, int64(300))
+	assert.NotContains(t, ids, int64(400))
+}
+
+func Test_EachPost_ShouldStopWhenYieldReturnsFalse(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewRepository()
+
+	for i := 0; i < 3; i++ {
+		_, err := repo.CreatePost(suite.ctx, &domain.Post{Author: "Anton", Title: "On stopping", Content: "qwerty"})
+		assert.NoError(t, err)
+	}
+
+	var count int
+	err := repo.EachPost(suite.ctx, func(post *domain.Post) bool {
+		count++
+		return false
+	})
+
 	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func Test_EachPost_CanceledContext_ShouldReturnError(t *testing.T) {
+	repo := repository.NewRepository()
+	_, err := repo.CreatePost(context.Background(), &domain.Post{Author: "Anton", Title: "On canceling", Content: "qwerty"})
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = repo.EachPost(ctx, func(post *domain.Post) bool { return true })
+	assert.ErrorIs(t, err, context.Canceled)
 }
 
 func Test_UpdatePost_ShouldUpdateRepo(t *testing.T) {
@@ -171,3 +284,57 @@ func Test_UpdatePost_NoItemToUpdate_ShouldReturnError(t *testing.T) {
 	err := repo.UpdatePost(suite.ctx, post, int64(34))
 	assert.ErrorIs(t, domain.ErrorPostNotFound, err)
 }
+
+func Test_PersistentRepository_ShouldSummarizePostsStoredWithoutSummary(t *testing.T) {
+	suite := SetSuite()
+
+	store, err := storage.Open(t.TempDir())
+	assert.NoError(t, err)
+	defer store.Close()
+	assert.NoError(t, store.Put(&domain.Post{ID: 1, Author: "Anton", Title: "On mockery", Content: "one two three"}, 1))
+
+	repo, err := repository.NewPersistentRepository(store)
+	assert.NoError(t, err)
+
+	post, err := repo.GetPost(suite.ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "one two three", post.Excerpt)
+	assert.Equal(t, 3, post.WordCount)
+	assert.Equal(t, 1, post.ReadingMinutes)
+}
+
+func Test_PersistentRepository_ShouldKeepPostsAndSequenceAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentRepository(store)
+	assert.NoError(t, err)
+
+	_, err = repo.CreatePost(suite.ctx, &domain.Post{Author: "Anton", Title: "On mockery", Content: "qwerty"})
+	assert.NoError(t, err)
+	_, err = repo.CreatePost(suite.ctx, &domain.Post{Author: "Jonny", Title: "On golang", Content: "asdf"})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.UpdatePost(suite.ctx, &domain.Post{Author: "Anton", Title: "On mocks", Content: "qwerty"}, 1))
+	assert.NoError(t, repo.DeletePost(suite.ctx, 2))
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentRepository(store)
+	assert.NoError(t, err)
+
+	post, err := repo.GetPost(suite.ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "On mocks", post.Title)
+
+	_, err = repo.GetPost(suite.ctx, 2)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+	assert.NoError(t, repo.RestorePost(suite.ctx, 2))
+
+	postId, err := repo.CreatePost(suite.ctx, &domain.Post{Author: "Jonny", Title: "On gin", Content: "zxcv"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, postId)
+}
@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// SessionRepository keeps sessions by the hashes of their tokens. It is the
+// revocation storage of the tokens: a revoked session stays until it expires.
+type SessionRepository struct {
+	mutex     sync.RWMutex
+	sessions  map[int64]*domain.Session
+	byAccess  map[string]int64
+	byRefresh map[string]int64
+	store     ISessionStore
+
+	sequenceId int64
+}
+
+// ISessionStore persists the changes of the session repository.
+type ISessionStore interface {
+	LoadSessions() (map[int64]*domain.Session, int64, error)
+	PutSession(session *domain.Session, sequence int64) error
+	DeleteSession(id int64) error
+}
+
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		sessions:  map[int64]*domain.Session{},
+		byAccess:  map[string]int64{},
+		byRefresh: map[string]int64{},
+	}
+}
+
+// NewPersistentSessionRepository loads the sessions of the store and writes every change to it.
+func NewPersistentSessionRepository(store ISessionStore) (*SessionRepository, error) {
+	sessions, sequence, err := store.LoadSessions()
+	if err != nil {
+		return nil, err
+	}
+
+	r := NewSessionRepository()
+	r.store = store
+	r.sequenceId = sequence
+	for _, s := range sessions {
+		r.put(s)
+	}
+
+	return r, nil
+}
+
+func (r *SessionRepository) CreateSession(ctx context.Context, session *domain.Session) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := r.sequenceId + 1
+	if r.store != nil {
+		created := *session
+		created.ID = id
+		if err := r.store.PutSession(&created, id); err != nil {
+			return 0, err
+		}
+	}
+
+	r.sequenceId = id
+	session.ID = id
+	r.put(session)
+	return session.ID, nil
+}
+
+func (r *SessionRepository) GetSessionByAccessToken(ctx context.Context, accessTokenHash string) (*domain.Session, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.get(r.byAccess, accessTokenHash)
+}
+
+func (r *SessionRepository) GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*domain.Session, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	return r.get(r.byRefresh, refreshTokenHash)
+}
+
+// RotateSession replaces the tokens of the session, but only if the session still
+// has the refresh token of refreshTokenHash and is not revoked. Of concurrent
+// refreshes with the same token, only one succeeds.
+func (r *SessionRepository) RotateSession(ctx context.Context, session *domain.Session, refreshTokenHash string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	current, isIn := r.sessions[session.ID]
+	if !isIn || current.RefreshTokenHash != refreshTokenHash || current.IsRevoked() {
+		return domain.ErrorSessionNotFound
+	}
+
+	if r.store != nil {
+		if err := r.store.PutSession(session, r.sequenceId); err != nil {
+			return err
+		}
+	}
+
+	r.remove(current)
+	r.put(session)
+	return nil
+}
+
+// RevokeSession makes the tokens of the session invalid.
+func (r *SessionRepository) RevokeSession(ctx context.Context, id int64, at time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	s, isIn := r.sessions[id]
+	if !isIn {
+		return domain.ErrorSessionNotFound
+	}
+
+	if s.IsRevoked() {
+		return nil
+	}
+
+	revoked := *s
+	revoked.RevokedAt = at
+	if r.store != nil {
+		if err := r.store.PutSession(&revoked, r.sequenceId); err != nil {
+			return err
+		}
+	}
+
+	r.put(&revoked)
+	return nil
+}
+
+// DeleteExpiredSessions forgets sessions whose refresh token expired before now
+// and returns their number. Sessions whose deletion the store fails to record
+// are kept for the next time.
+func (r *SessionRepository) DeleteExpiredSessions(ctx context.Context, now time.Time) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	deleted := 0
+	for _, s := range r.sessions {
+		if !now.Before(s.RefreshExpiresAt) {
+			if r.store != nil {
+				if err := r.store.DeleteSession(s.ID); err != nil {
+					break
+				}
+			}
+			r.remove(s)
+			deleted++
+		}
+	}
+
+	return deleted
+}
+
+func (r *SessionRepository) get(index map[string]int64, hash string) (*domain.Session, error) {
+	id, isIn := index[hash]
+	if !isIn {
+		return nil, domain.ErrorSessionNotFound
+	}
+
+	session := *r.sessions[id]
+	return &session, nil
+}
+
+// put and remove change the session and its indexes under the lock.
+func (r *SessionRepository) put(session *domain.Session) {
+	stored := *session
+	r.sessions[session.ID] = &stored
+	r.byAccess[session.AccessTokenHash] = session.ID
+	r.byRefresh[session.RefreshTokenHash] = session.ID
+}
+
+func (r *SessionRepository) remove(session *domain.Session) {
+	delete(r.byAccess, session.AccessTokenHash)
+	delete(r.byRefresh, session.RefreshTokenHash)
+	delete(r.sessions, session.ID)
+}
@@ -0,0 +1,131 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+var sessionTime = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func newSession(access, refresh string) *domain.Session {
+	return &domain.Session{
+		UserID:           1,
+		AccessTokenHash:  access,
+		AccessExpiresAt:  sessionTime.Add(time.Minute),
+		RefreshTokenHash: refresh,
+		RefreshExpiresAt: sessionTime.Add(time.Hour),
+		CreatedAt:        sessionTime,
+	}
+}
+
+func Test_CreateSession_ShouldBeFoundByBothTokens(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewSessionRepository()
+
+	id, err := repo.CreateSession(suite.ctx, newSession("access", "refresh"))
+	assert.NoError(t, err)
+
+	byAccess, err := repo.GetSessionByAccessToken(suite.ctx, "access")
+	assert.NoError(t, err)
+	assert.Equal(t, id, byAccess.ID)
+
+	byRefresh, err := repo.GetSessionByRefreshToken(suite.ctx, "refresh")
+	assert.NoError(t, err)
+	assert.Equal(t, id, byRefresh.ID)
+
+	_, err = repo.GetSessionByAccessToken(suite.ctx, "refresh")
+	assert.ErrorIs(t, err, domain.ErrorSessionNotFound)
+}
+
+func Test_RotateSession_ShouldReplaceTokensOnce(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewSessionRepository()
+	id, _ := repo.CreateSession(suite.ctx, newSession("access", "refresh"))
+
+	rotated := newSession("access 2", "refresh 2")
+	rotated.ID = id
+	assert.NoError(t, repo.RotateSession(suite.ctx, rotated, "refresh"))
+
+	_, err := repo.GetSessionByRefreshToken(suite.ctx, "refresh")
+	assert.ErrorIs(t, err, domain.ErrorSessionNotFound)
+	_, err = repo.GetSessionByAccessToken(suite.ctx, "access")
+	assert.ErrorIs(t, err, domain.ErrorSessionNotFound)
+	_, err = repo.GetSessionByAccessToken(suite.ctx, "access 2")
+	assert.NoError(t, err)
+
+	again := newSession("access 3", "refresh 3")
+	again.ID = id
+	assert.ErrorIs(t, repo.RotateSession(suite.ctx, again, "refresh"), domain.ErrorSessionNotFound)
+}
+
+func Test_RevokeSession_ShouldKeepSessionUntilExpired(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewSessionRepository()
+	id, _ := repo.CreateSession(suite.ctx, newSession("access", "refresh"))
+
+	assert.NoError(t, repo.RevokeSession(suite.ctx, id, sessionTime))
+
+	session, err := repo.GetSessionByAccessToken(suite.ctx, "access")
+	assert.NoError(t, err)
+	assert.True(t, session.IsRevoked())
+
+	rotated := newSession("access 2", "refresh 2")
+	rotated.ID = id
+	assert.ErrorIs(t, repo.RotateSession(suite.ctx, rotated, "refresh"), domain.ErrorSessionNotFound)
+
+	assert.Equal(t, 0, repo.DeleteExpiredSessions(suite.ctx, sessionTime.Add(time.Minute)))
+	assert.Equal(t, 1, repo.DeleteExpiredSessions(suite.ctx, sessionTime.Add(time.Hour)))
+
+	_, err = repo.GetSessionByAccessToken(suite.ctx, "access")
+	assert.ErrorIs(t, err, domain.ErrorSessionNotFound)
+}
+
+func Test_PersistentSessionRepository_ShouldKeepSessionsAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentSessionRepository(store)
+	assert.NoError(t, err)
+
+	rotatedID, _ := repo.CreateSession(suite.ctx, newSession("access", "refresh"))
+	rotated := newSession("access 2", "refresh 2")
+	rotated.ID = rotatedID
+	assert.NoError(t, repo.RotateSession(suite.ctx, rotated, "refresh"))
+	revokedID, _ := repo.CreateSession(suite.ctx, newSession("access 3", "refresh 3"))
+	assert.NoError(t, repo.RevokeSession(suite.ctx, revokedID, sessionTime))
+	expired := newSession("access 4", "refresh 4")
+	expired.RefreshExpiresAt = sessionTime
+	_, _ = repo.CreateSession(suite.ctx, expired)
+	assert.Equal(t, 1, repo.DeleteExpiredSessions(suite.ctx, sessionTime.Add(time.Minute)))
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentSessionRepository(store)
+	assert.NoError(t, err)
+
+	_, err = repo.GetSessionByRefreshToken(suite.ctx, "refresh")
+	assert.ErrorIs(t, err, domain.ErrorSessionNotFound)
+	session, err := repo.GetSessionByRefreshToken(suite.ctx, "refresh 2")
+	assert.NoError(t, err)
+	assert.Equal(t, rotated, session)
+
+	session, err = repo.GetSessionByAccessToken(suite.ctx, "access 3")
+	assert.NoError(t, err)
+	assert.True(t, session.IsRevoked())
+
+	_, err = repo.GetSessionByAccessToken(suite.ctx, "access 4")
+	assert.ErrorIs(t, err, domain.ErrorSessionNotFound)
+
+	id, err := repo.CreateSession(suite.ctx, newSession("access 5", "refresh 5"))
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, id)
+}
@@ -0,0 +1,189 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// UserRepository keeps users with unique emails, compared by domain.NormalizeEmail.
+// Users are returned as copies, login failures are counted by the repository so
+// that concurrent logins cannot lose them.
+type UserRepository struct {
+	mutex  sync.RWMutex
+	users  map[int64]*domain.User
+	emails map[string]int64
+	store  IUserStore
+
+	sequenceId int64
+}
+
+// IUserStore persists the changes of the user repository.
+type IUserStore interface {
+	LoadUsers() (map[int64]*domain.User, int64, error)
+	PutUser(user *domain.User, sequence int64) error
+}
+
+func NewUserRepository() *UserRepository {
+	return &UserRepository{
+		users:  map[int64]*domain.User{},
+		emails: map[string]int64{},
+	}
+}
+
+// NewPersistentUserRepository loads the users of the store and writes every change to it.
+func NewPersistentUserRepository(store IUserStore) (*UserRepository, error) {
+	users, sequence, err := store.LoadUsers()
+	if err != nil {
+		return nil, err
+	}
+
+	r := &UserRepository{
+		users:      users,
+		emails:     make(map[string]int64, len(users)),
+		store:      store,
+		sequenceId: sequence,
+	}
+	for id, u := range users {
+		r.emails[domain.NormalizeEmail(u.Email)] = id
+	}
+
+	return r, nil
+}
+
+func (r *UserRepository) CreateUser(ctx context.Context, user *domain.User) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	key := domain.NormalizeEmail(user.Email)
+	if _, isIn := r.emails[key]; isIn {
+		return 0, fmt.Errorf("%w: email %q is already registered", domain.ErrorConflict, user.Email)
+	}
+
+	stored := *user
+	stored.ID = r.sequenceId + 1
+	if err := r.save(&stored); err != nil {
+		return 0, err
+	}
+
+	r.sequenceId = stored.ID
+	r.emails[key] = stored.ID
+	user.ID = stored.ID
+	return user.ID, nil
+}
+
+func (r *UserRepository) GetUser(ctx context.Context, id int64) (*domain.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	u, isIn := r.users[id]
+	if !isIn {
+		return nil, domain.ErrorUserNotFound
+	}
+
+	user := *u
+	return &user, nil
+}
+
+func (r *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mutex.RLock()
+	id, isIn := r.emails[domain.NormalizeEmail(email)]
+	r.mutex.RUnlock()
+
+	if !isIn {
+		return nil, domain.ErrorUserNotFound
+	}
+
+	return r.GetUser(ctx, id)
+}
+
+// RecordFailedLogin counts a failed login and returns the number of failures
+// since the last successful login or lock.
+func (r *UserRepository) RecordFailedLogin(ctx context.Context, id int64) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, isIn := r.users[id]
+	if !isIn {
+		return 0, domain.ErrorUserNotFound
+	}
+
+	changed := *u
+	changed.FailedLogins++
+	if err := r.save(&changed); err != nil {
+		return 0, err
+	}
+
+	return changed.FailedLogins, nil
+}
+
+// RecordSuccessfulLogin forgets the failed logins of the user.
+func (r *UserRepository) RecordSuccessfulLogin(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, isIn := r.users[id]
+	if !isIn {
+		return domain.ErrorUserNotFound
+	}
+
+	if u.FailedLogins == 0 {
+		return nil
+	}
+
+	changed := *u
+	changed.FailedLogins = 0
+	return r.save(&changed)
+}
+
+// LockUser rejects logins of the user until the given time and starts counting failures anew.
+func (r *UserRepository) LockUser(ctx context.Context, id int64, until time.Time) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, isIn := r.users[id]
+	if !isIn {
+		return domain.ErrorUserNotFound
+	}
+
+	changed := *u
+	changed.FailedLogins = 0
+	changed.LockedUntil = until
+	return r.save(&changed)
+}
+
+// SetUserRole changes the role of the user and the author the user is linked to.
+func (r *UserRepository) SetUserRole(ctx context.Context, id int64, role domain.Role, authorID int64) (*domain.User, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	u, isIn := r.users[id]
+	if !isIn {
+		return nil, domain.ErrorUserNotFound
+	}
+
+	changed := *u
+	changed.Role = role
+	changed.AuthorID = authorID
+	if err := r.save(&changed); err != nil {
+		return nil, err
+	}
+
+	user := changed
+	return &user, nil
+}
+
+// save replaces the user under the lock once the store has recorded it.
+func (r *UserRepository) save(user *domain.User) error {
+	if r.store != nil {
+		if err := r.store.PutUser(user, max(r.sequenceId, user.ID)); err != nil {
+			return err
+		}
+	}
+
+	r.users[user.ID] = user
+	return nil
+}
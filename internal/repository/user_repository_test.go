@@ -0,0 +1,123 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateUser_SameNormalizedEmail_ShouldReturnConflict(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewUserRepository()
+
+	id, err := repo.CreateUser(suite.ctx, &domain.User{Email: "anton@example.com"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+
+	_, err = repo.CreateUser(suite.ctx, &domain.User{Email: " Anton@Example.com"})
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+
+	user, err := repo.GetUserByEmail(suite.ctx, "ANTON@example.com")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, user.ID)
+
+	_, err = repo.GetUserByEmail(suite.ctx, "jonny@example.com")
+	assert.ErrorIs(t, err, domain.ErrorUserNotFound)
+}
+
+func Test_RecordFailedLogin_ShouldCountUntilSuccessOrLock(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewUserRepository()
+	id, _ := repo.CreateUser(suite.ctx, &domain.User{Email: "anton@example.com"})
+
+	count, _ := repo.RecordFailedLogin(suite.ctx, id)
+	assert.Equal(t, 1, count)
+	count, _ = repo.RecordFailedLogin(suite.ctx, id)
+	assert.Equal(t, 2, count)
+
+	assert.NoError(t, repo.RecordSuccessfulLogin(suite.ctx, id))
+	count, _ = repo.RecordFailedLogin(suite.ctx, id)
+	assert.Equal(t, 1, count)
+
+	until := time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)
+	assert.NoError(t, repo.LockUser(suite.ctx, id, until))
+
+	user, err := repo.GetUser(suite.ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, user.FailedLogins)
+	assert.Equal(t, until, user.LockedUntil)
+}
+
+func Test_GetUser_ShouldReturnCopy(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewUserRepository()
+	id, _ := repo.CreateUser(suite.ctx, &domain.User{Email: "anton@example.com"})
+
+	user, _ := repo.GetUser(suite.ctx, id)
+	user.FailedLogins = 10
+
+	stored, _ := repo.GetUser(suite.ctx, id)
+	assert.Equal(t, 0, stored.FailedLogins)
+}
+
+func Test_SetUserRole_ShouldChangeRoleAndAuthor(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewUserRepository()
+	id, _ := repo.CreateUser(suite.ctx, &domain.User{Email: "anton@example.com", Role: domain.RoleReader})
+
+	user, err := repo.SetUserRole(suite.ctx, id, domain.RoleAuthor, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleAuthor, user.Role)
+	assert.EqualValues(t, 7, user.AuthorID)
+
+	_, err = repo.SetUserRole(suite.ctx, 2, domain.RoleAuthor, 7)
+	assert.ErrorIs(t, err, domain.ErrorUserNotFound)
+}
+
+func Test_PersistentUserRepository_ShouldKeepUsersAndLocksAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+	lockedUntil := time.Date(2024, 1, 1, 0, 15, 0, 0, time.UTC)
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentUserRepository(store)
+	assert.NoError(t, err)
+
+	id, err := repo.CreateUser(suite.ctx, &domain.User{Email: "anton@example.com", Role: domain.RoleReader, PasswordHash: []byte("hash")})
+	assert.NoError(t, err)
+	_, err = repo.SetUserRole(suite.ctx, id, domain.RoleAuthor, 3)
+	assert.NoError(t, err)
+	assert.NoError(t, repo.LockUser(suite.ctx, id, lockedUntil))
+	_, err = repo.RecordFailedLogin(suite.ctx, id)
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentUserRepository(store)
+	assert.NoError(t, err)
+
+	user, err := repo.GetUserByEmail(suite.ctx, "Anton@example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.User{
+		ID:           id,
+		Email:        "anton@example.com",
+		Role:         domain.RoleAuthor,
+		AuthorID:     3,
+		PasswordHash: []byte("hash"),
+		FailedLogins: 1,
+		LockedUntil:  lockedUntil,
+	}, user)
+
+	_, err = repo.CreateUser(suite.ctx, &domain.User{Email: "anton@example.com"})
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+	id, err = repo.CreateUser(suite.ctx, &domain.User{Email: "jonny@example.com"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, id)
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type WebhookRepository struct {
+	mutex      sync.RWMutex
+	webhooks   map[int64]*domain.Webhook
+	deliveries map[int64]*domain.Delivery
+	store      IWebhookStore
+
+	webhookSequenceId  int64
+	deliverySequenceId int64
+}
+
+// IWebhookStore persists the changes of the webhook repository.
+type IWebhookStore interface {
+	LoadWebhooks() (map[int64]*domain.Webhook, int64, error)
+	LoadDeliveries() (map[int64]*domain.Delivery, int64, error)
+	PutWebhook(webhook *domain.Webhook, sequence int64) error
+	DeleteWebhook(id int64) error
+	PutDelivery(delivery *domain.Delivery, sequence int64) error
+	DeleteDelivery(id int64) error
+}
+
+func NewWebhookRepository() *WebhookRepository {
+	return &WebhookRepository{
+		webhooks:   map[int64]*domain.Webhook{},
+		deliveries: map[int64]*domain.Delivery{},
+	}
+}
+
+// NewPersistentWebhookRepository loads the webhooks and deliveries of the store
+// and writes every change to it, so that pending deliveries are retried after a
+// restart. Deliveries left behind by a webhook whose deletion was interrupted
+// are dropped.
+func NewPersistentWebhookRepository(store IWebhookStore) (*WebhookRepository, error) {
+	webhooks, webhookSequence, err := store.LoadWebhooks()
+	if err != nil {
+		return nil, err
+	}
+	deliveries, deliverySequence, err := store.LoadDeliveries()
+	if err != nil {
+		return nil, err
+	}
+
+	for id, d := range deliveries {
+		if _, isIn := webhooks[d.WebhookID]; !isIn {
+			delete(deliveries, id)
+		}
+	}
+
+	return &WebhookRepository{
+		webhooks:           webhooks,
+		deliveries:         deliveries,
+		store:              store,
+		webhookSequenceId:  webhookSequence,
+		deliverySequenceId: deliverySequence,
+	}, nil
+}
+
+func (r *WebhookRepository) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	id := r.webhookSequenceId + 1
+	if r.store != nil {
+		created := *webhook
+		created.ID = id
+		if err := r.store.PutWebhook(&created, id); err != nil {
+			return 0, err
+		}
+	}
+
+	r.webhookSequenceId = id
+	webhook.ID = id
+	r.webhooks[webhook.ID] = webhook
+	return webhook.ID, nil
+}
+
+func (r *WebhookRepository) GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	w, isIn := r.webhooks[id]
+	if isIn {
+		return w, nil
+	}
+
+	return nil, domain.ErrorWebhookNotFound
+}
+
+func (r *WebhookRepository) GetWebhooks(ctx context.Context) []*domain.Webhook {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	webhooks := make([]*domain.Webhook, 0, len(r.webhooks))
+	for _, w := range r.webhooks {
+		webhooks = append(webhooks, w)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].ID < webhooks[j].ID })
+	return webhooks
+}
+
+// DeleteWebhook deletes the webhook with its deliveries. The deliveries are
+// deleted first, so that the webhook is kept when the store fails to record it.
+func (r *WebhookRepository) DeleteWebhook(ctx context.Context, id int64) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, isIn := r.webhooks[id]; !isIn {
+		return domain.ErrorWebhookNotFound
+	}
+
+	for deliveryID, d := range r.deliveries {
+		if d.WebhookID == id {
+			if err := r.deleteDelivery(deliveryID); err != nil {
+				return err
+			}
+		}
+	}
+	if r.store != nil {
+		if err := r.store.DeleteWebhook(id); err != nil {
+			return err
+		}
+	}
+
+	delete(r.webhooks, id)
+	return nil
+}
+
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, delivery *domain.Delivery) (int64, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	stored := *delivery
+	stored.ID = r.deliverySequenceId + 1
+	if err := r.saveDelivery(&stored); err != nil {
+		return 0, err
+	}
+
+	r.deliverySequenceId = stored.ID
+	delivery.ID = stored.ID
+	return delivery.ID, nil
+}
+
+// GetDelivery returns a copy, so that callers never race with the delivery worker.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, id int64) (*domain.Delivery, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	d, isIn := r.deliveries[id]
+	if !isIn {
+		return nil, domain.ErrorDeliveryNotFound
+	}
+
+	delivery := *d
+	return &delivery, nil
+}
+
+func (r *WebhookRepository) UpdateDelivery(ctx context.Context, delivery *domain.Delivery) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, isIn := r.deliveries[delivery.ID]; !isIn {
+		return domain.ErrorDeliveryNotFound
+	}
+
+	updated := *delivery
+	return r.saveDelivery(&updated)
+}
+
+// GetDeliveries returns copies of the deliveries matching the filter, oldest first.
+func (r *WebhookRepository) GetDeliveries(ctx context.Context, filter domain.DeliveryFilter) []*domain.Delivery {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	deliveries := []*domain.Delivery{}
+	for _, d := range r.deliveries {
+		if filter.WebhookID != 0 && d.WebhookID != filter.WebhookID {
+			continue
+		}
+		if len(filter.Status) > 0 && d.Status != filter.Status {
+			continue
+		}
+
+		delivery := *d
+		deliveries = append(deliveries, &delivery)
+	}
+
+	sort.Slice(deliveries, func(i, j int) bool { return deliveries[i].ID < deliveries[j].ID })
+	return deliveries
+}
+
+// GetDueDeliveries returns copies of pending deliveries whose next attempt is not after now.
+func (r *WebhookRepository) GetDueDeliveries(ctx context.Context, now time.Time) []*domain.Delivery {
+	pending := r.GetDeliveries(ctx, domain.DeliveryFilter{Status: domain.DeliveryPending})
+
+	due := pending[:0]
+	for _, d := range pending {
+		if !d.NextAttemptAt.After(now) {
+			due = append(due, d)
+		}
+	}
+
+	return due
+}
+
+// PruneDeliveries removes the completed deliveries that completed before
+// completedBefore and the oldest completed deliveries of webhooks that have more
+// than keep of them. Pending deliveries are kept. It returns the number of
+// removed deliveries. Deliveries whose removal the store fails to record are
+// kept for the next time.
+func (r *WebhookRepository) PruneDeliveries(ctx context.Context, completedBefore time.Time, keep int) int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var pruned []int64
+	completed := map[int64][]int64{}
+	for id, d := range r.deliveries {
+		if d.Status == domain.DeliveryPending {
+			continue
+		}
+		if d.CompletedAt.Before(completedBefore) {
+			pruned = append(pruned, id)
+			continue
+		}
+		completed[d.WebhookID] = append(completed[d.WebhookID], id)
+	}
+
+	for _, ids := range completed {
+		if len(ids) <= keep {
+			continue
+		}
+		slices.Sort(ids)
+		pruned = append(pruned, ids[:len(ids)-keep]...)
+	}
+
+	removed := 0
+	for _, id := range pruned {
+		if err := r.deleteDelivery(id); err != nil {
+			break
+		}
+		removed++
+	}
+
+	return removed
+}
+
+// saveDelivery replaces the delivery under the lock once the store has recorded it.
+func (r *WebhookRepository) saveDelivery(delivery *domain.Delivery) error {
+	if r.store != nil {
+		if err := r.store.PutDelivery(delivery, max(r.deliverySequenceId, delivery.ID)); err != nil {
+			return err
+		}
+	}
+
+	r.deliveries[delivery.ID] = delivery
+	return nil
+}
+
+// deleteDelivery removes the delivery under the lock once the store has recorded it.
+func (r *WebhookRepository) deleteDelivery(id int64) error {
+	if r.store != nil {
+		if err := r.store.DeleteDelivery(id); err != nil {
+			return err
+		}
+	}
+
+	delete(r.deliveries, id)
+	return nil
+}
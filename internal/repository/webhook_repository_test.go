@@ -0,0 +1,150 @@
+package repository_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CreateWebhookAndGetWebhook_ShouldAddWebhookToRepo(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewWebhookRepository()
+
+	webhook := &domain.Webhook{URL: "https://example.com/hook", Secret: "secret"}
+	id, err := repo.CreateWebhook(suite.ctx, webhook)
+	assert.EqualValues(t, 1, id)
+	assert.NoError(t, err)
+
+	found, err := repo.GetWebhook(suite.ctx, id)
+	assert.NoError(t, err)
+	assert.Equal(t, webhook, found)
+}
+
+func Test_DeleteWebhook_NoItemToDelete_ShouldReturnError(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewWebhookRepository()
+
+	err := repo.DeleteWebhook(suite.ctx, int64(5))
+	assert.ErrorIs(t, err, domain.ErrorWebhookNotFound)
+}
+
+func Test_GetDueDeliveries_ShouldReturnOnlyDuePendingDeliveries(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewWebhookRepository()
+	now := time.Now()
+
+	due := &domain.Delivery{WebhookID: 1, Status: domain.DeliveryPending, NextAttemptAt: now.Add(-time.Second)}
+	later := &domain.Delivery{WebhookID: 1, Status: domain.DeliveryPending, NextAttemptAt: now.Add(time.Hour)}
+	dead := &domain.Delivery{WebhookID: 1, Status: domain.DeliveryDead, NextAttemptAt: now.Add(-time.Second)}
+	for _, d := range []*domain.Delivery{due, later, dead} {
+		_, err := repo.CreateDelivery(suite.ctx, d)
+		assert.NoError(t, err)
+	}
+
+	deliveries := repo.GetDueDeliveries(suite.ctx, now)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, due.ID, deliveries[0].ID)
+}
+
+func Test_UpdateDelivery_ShouldNotShareStateWithCaller(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewWebhookRepository()
+
+	delivery := &domain.Delivery{WebhookID: 1, Status: domain.DeliveryPending}
+	id, _ := repo.CreateDelivery(suite.ctx, delivery)
+
+	delivery.Status = domain.DeliverySucceeded
+	found, _ := repo.GetDelivery(suite.ctx, id)
+	assert.Equal(t, domain.DeliveryPending, found.Status)
+
+	assert.NoError(t, repo.UpdateDelivery(suite.ctx, delivery))
+	found, _ = repo.GetDelivery(suite.ctx, id)
+	assert.Equal(t, domain.DeliverySucceeded, found.Status)
+}
+
+func Test_PruneDeliveries_ShouldRemoveOldAndExcessCompletedDeliveries(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewWebhookRepository()
+	now := time.Now()
+
+	for _, d := range []*domain.Delivery{
+		{WebhookID: 1, Status: domain.DeliverySucceeded, CompletedAt: now.Add(-2 * time.Hour)},
+		{WebhookID: 1, Status: domain.DeliveryPending},
+		{WebhookID: 1, Status: domain.DeliveryDead, CompletedAt: now},
+		{WebhookID: 1, Status: domain.DeliverySucceeded, CompletedAt: now},
+		{WebhookID: 1, Status: domain.DeliverySucceeded, CompletedAt: now},
+		{WebhookID: 2, Status: domain.DeliverySucceeded, CompletedAt: now},
+	} {
+		_, err := repo.CreateDelivery(suite.ctx, d)
+		assert.NoError(t, err)
+	}
+
+	removed := repo.PruneDeliveries(suite.ctx, now.Add(-time.Hour), 2)
+	assert.Equal(t, 2, removed)
+
+	var ids []int64
+	for _, d := range repo.GetDeliveries(suite.ctx, domain.DeliveryFilter{}) {
+		ids = append(ids, d.ID)
+	}
+	assert.Equal(t, []int64{2, 4, 5, 6}, ids)
+}
+
+func Test_DeleteWebhook_ShouldRemoveItsDeliveries(t *testing.T) {
+	suite := SetSuite()
+	repo := repository.NewWebhookRepository()
+
+	id, _ := repo.CreateWebhook(suite.ctx, &domain.Webhook{URL: "https://example.com/hook"})
+	repo.CreateDelivery(suite.ctx, &domain.Delivery{WebhookID: id, Status: domain.DeliveryPending})
+	repo.CreateDelivery(suite.ctx, &domain.Delivery{WebhookID: id + 1, Status: domain.DeliveryPending})
+
+	assert.NoError(t, repo.DeleteWebhook(suite.ctx, id))
+	assert.Empty(t, repo.GetDeliveries(suite.ctx, domain.DeliveryFilter{WebhookID: id}))
+	assert.Len(t, repo.GetDeliveries(suite.ctx, domain.DeliveryFilter{}), 1)
+}
+
+func Test_PersistentWebhookRepository_ShouldKeepWebhooksAndPendingDeliveriesAfterReopen(t *testing.T) {
+	suite := SetSuite()
+	dir := t.TempDir()
+	nextAttemptAt := time.Date(2024, 1, 1, 0, 0, 1, 0, time.UTC)
+
+	store, err := storage.Open(dir)
+	assert.NoError(t, err)
+	repo, err := repository.NewPersistentWebhookRepository(store)
+	assert.NoError(t, err)
+
+	webhookID, err := repo.CreateWebhook(suite.ctx, &domain.Webhook{URL: "https://example.com/hook", Secret: "secret"})
+	assert.NoError(t, err)
+	deletedID, err := repo.CreateWebhook(suite.ctx, &domain.Webhook{URL: "https://example.com/other", Secret: "other"})
+	assert.NoError(t, err)
+	delivery := &domain.Delivery{WebhookID: webhookID, Payload: []byte(`{}`), Status: domain.DeliveryPending}
+	_, err = repo.CreateDelivery(suite.ctx, delivery)
+	assert.NoError(t, err)
+	delivery.Attempts = 1
+	delivery.NextAttemptAt = nextAttemptAt
+	assert.NoError(t, repo.UpdateDelivery(suite.ctx, delivery))
+	_, err = repo.CreateDelivery(suite.ctx, &domain.Delivery{WebhookID: deletedID, Status: domain.DeliveryPending})
+	assert.NoError(t, err)
+	assert.NoError(t, repo.DeleteWebhook(suite.ctx, deletedID))
+	assert.NoError(t, store.Close())
+
+	store, err = storage.Open(dir)
+	assert.NoError(t, err)
+	defer store.Close()
+	repo, err = repository.NewPersistentWebhookRepository(store)
+	assert.NoError(t, err)
+
+	webhooks := repo.GetWebhooks(suite.ctx)
+	assert.Equal(t, []*domain.Webhook{{ID: webhookID, URL: "https://example.com/hook", Secret: "secret"}}, webhooks)
+	assert.Equal(t, []*domain.Delivery{delivery}, repo.GetDueDeliveries(suite.ctx, nextAttemptAt))
+
+	id, err := repo.CreateWebhook(suite.ctx, &domain.Webhook{URL: "https://example.com/new"})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, id)
+	id, err = repo.CreateDelivery(suite.ctx, &domain.Delivery{WebhookID: webhookID})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, id)
+}
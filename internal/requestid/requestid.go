@@ -0,0 +1,50 @@
+// Package requestid holds the ID of a request, which correlates the records of
+// the request, e.g. its audit entries, with the logs of the client.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header carries the request ID in HTTP requests and responses and, in lower
+// case, in gRPC metadata.
+const Header = "X-Request-ID"
+
+// maxLength bounds the IDs accepted from clients.
+const maxLength = 128
+
+type key struct{}
+
+func With(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext returns the request ID, or an empty string outside of requests.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(key{}).(string)
+	return id
+}
+
+// New generates a random request ID.
+func New() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// Resolve returns the ID given by the client, or a new one when it is missing or
+// is not made of up to 128 printable ASCII characters.
+func Resolve(given string) string {
+	if len(given) == 0 || len(given) > maxLength {
+		return New()
+	}
+	for i := 0; i < len(given); i++ {
+		if given[i] < '!' || given[i] > '~' {
+			return New()
+		}
+	}
+
+	return given
+}
@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/requestid"
+)
+
+type IAuthenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (*domain.User, error)
+}
+
+// authenticate puts the user of the bearer token in the authorization metadata
+// into the context, like the HTTP authentication middleware.
+func authenticate(ctx context.Context, authenticator IAuthenticator) (context.Context, error) {
+	values := metadata.ValueFromIncomingContext(ctx, "authorization")
+	if len(values) == 0 {
+		return ctx, nil
+	}
+
+	token, isBearer := auth.BearerToken(values[0])
+	if !isBearer {
+		return nil, status.Error(codes.Unauthenticated, auth.ErrorInvalidAuthorization.Error())
+	}
+
+	user, err := authenticator.Authenticate(ctx, token)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return auth.WithUser(ctx, user), nil
+}
+
+// withRequestID puts the request ID of the x-request-id metadata, or a generated
+// one, into the context, like the HTTP request ID middleware.
+func withRequestID(ctx context.Context) context.Context {
+	var given string
+	if values := metadata.ValueFromIncomingContext(ctx, strings.ToLower(requestid.Header)); len(values) > 0 {
+		given = values[0]
+	}
+
+	return requestid.With(ctx, requestid.Resolve(given))
+}
+
+func unaryAuthentication(authenticator IAuthenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := authenticate(withRequestID(ctx), authenticator)
+		if err != nil {
+			return nil, err
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+func streamAuthentication(authenticator IAuthenticator) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(withRequestID(stream.Context()), authenticator)
+		if err != nil {
+			return err
+		}
+
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,207 @@
+package rpc
+
+//go:generate protoc -I ../../api/proto --go_out=../.. --go_opt=module=github.com/kondrushin/blog --go-grpc_out=../.. --go-grpc_opt=module=github.com/kondrushin/blog blog/v1/blog.proto
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/rpc/blogpb"
+)
+
+type IBlogUseCase interface {
+	GetPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetPosts(ctx context.Context) []*domain.Post
+	CreatePost(ctx context.Context, p *domain.Post) (int64, error)
+	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
+	DeletePost(ctx context.Context, id int64) error
+}
+
+type IEventSubscriber interface {
+	Subscribe(ctx context.Context, filter events.Filter, lastEventId int64) (*events.Subscription, error)
+}
+
+// BlogServer implements the gRPC BlogService over the blog use case.
+type BlogServer struct {
+	blogpb.UnimplementedBlogServiceServer
+
+	UseCase    IBlogUseCase
+	Subscriber IEventSubscriber
+}
+
+// NewServer returns a gRPC server with the BlogService registered and tracing enabled.
+// Calls are authenticated by a bearer token in the authorization metadata.
+func NewServer(useCase IBlogUseCase, subscriber IEventSubscriber, authenticator IAuthenticator) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.UnaryInterceptor(unaryAuthentication(authenticator)),
+		grpc.StreamInterceptor(streamAuthentication(authenticator)),
+	)
+	blogpb.RegisterBlogServiceServer(s, &BlogServer{UseCase: useCase, Subscriber: subscriber})
+
+	return s
+}
+
+func (s *BlogServer) GetPost(ctx context.Context, req *blogpb.GetPostRequest) (*blogpb.Post, error) {
+	post, err := s.UseCase.GetPost(ctx, req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return toProtoPost(post), nil
+}
+
+func (s *BlogServer) ListPosts(ctx context.Context, _ *blogpb.ListPostsRequest) (*blogpb.ListPostsResponse, error) {
+	posts := s.UseCase.GetPosts(ctx)
+
+	resp := &blogpb.ListPostsResponse{Posts: make([]*blogpb.Post, 0, len(posts))}
+	for _, post := range posts {
+		resp.Posts = append(resp.Posts, toProtoPost(post))
+	}
+
+	return resp, nil
+}
+
+func (s *BlogServer) CreatePost(ctx context.Context, req *blogpb.CreatePostRequest) (*blogpb.CreatePostResponse, error) {
+	post := &domain.Post{AuthorID: req.GetAuthorId(), Author: req.GetAuthor(), Title: req.GetTitle(), Content: req.GetContent(), Draft: req.GetDraft()}
+	if err := validatePost(post); err != nil {
+		return nil, err
+	}
+
+	id, err := s.UseCase.CreatePost(ctx, post)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &blogpb.CreatePostResponse{Id: id}, nil
+}
+
+func (s *BlogServer) UpdatePost(ctx context.Context, req *blogpb.UpdatePostRequest) (*emptypb.Empty, error) {
+	post := &domain.Post{ID: req.GetId(), AuthorID: req.GetAuthorId(), Author: req.GetAuthor(), Title: req.GetTitle(), Content: req.GetContent(), Draft: req.GetDraft()}
+	if err := validatePost(post); err != nil {
+		return nil, err
+	}
+
+	if err := s.UseCase.UpdatePost(ctx, post, post.ID); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *BlogServer) DeletePost(ctx context.Context, req *blogpb.DeletePostRequest) (*emptypb.Empty, error) {
+	if err := s.UseCase.DeletePost(ctx, req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+// Watch sends the missed events first and then live events until the client goes away.
+// A client that falls too far behind gets Unavailable and should resume from its last event.
+func (s *BlogServer) Watch(req *blogpb.WatchRequest, stream blogpb.BlogService_WatchServer) error {
+	filter := events.Filter{PostIDs: req.GetPostIds(), AuthorIDs: req.GetAuthorIds(), Authors: req.GetAuthors()}
+	subscription, err := s.Subscriber.Subscribe(stream.Context(), filter, req.GetLastEventId())
+	if err != nil {
+		return toStatus(err)
+	}
+	defer subscription.Close()
+
+	for _, event := range subscription.Replay {
+		if err := stream.Send(toProtoEvent(event)); err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event, isOpen := <-subscription.Events():
+			if !isOpen {
+				return status.Error(codes.Unavailable, "subscriber fell behind")
+			}
+			if err := stream.Send(toProtoEvent(event)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// validatePost checks the post before the use case, with the same rules as the
+// other transports.
+func validatePost(post *domain.Post) error {
+	if err := post.Validate(); err != nil {
+		return toStatus(err)
+	}
+
+	return nil
+}
+
+// toStatus maps domain errors to the gRPC codes matching the HTTP statuses of the REST API.
+func toStatus(err error) error {
+	switch {
+	case isNotFound(err):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, domain.ErrorInvalidInput), errors.Is(err, domain.ErrorUnsupportedAttachment):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, domain.ErrorAttachmentTooLarge):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, domain.ErrorConflict):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, domain.ErrorUnauthorized):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, domain.ErrorAccountLocked):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, domain.ErrorForbidden):
+		return status.Error(codes.PermissionDenied, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, domain.ErrorPostNotFound) ||
+		errors.Is(err, domain.ErrorAuthorNotFound) ||
+		errors.Is(err, domain.ErrorUserNotFound) ||
+		errors.Is(err, domain.ErrorSessionNotFound) ||
+		errors.Is(err, domain.ErrorWebhookNotFound) ||
+		errors.Is(err, domain.ErrorDeliveryNotFound) ||
+		errors.Is(err, domain.ErrorAttachmentNotFound)
+}
+
+func toProtoPost(post *domain.Post) *blogpb.Post {
+	return &blogpb.Post{
+		Id:       post.ID,
+		AuthorId: post.AuthorID,
+		Author:   post.Author,
+		Title:    post.Title,
+		Content:  post.Content,
+		Draft:    post.Draft,
+	}
+}
+
+var eventTypes = map[domain.EventType]blogpb.EventType{
+	domain.EventPostCreated: blogpb.EventType_EVENT_TYPE_POST_CREATED,
+	domain.EventPostUpdated: blogpb.EventType_EVENT_TYPE_POST_UPDATED,
+	domain.EventPostDeleted: blogpb.EventType_EVENT_TYPE_POST_DELETED,
+}
+
+func toProtoEvent(event domain.PostEvent) *blogpb.PostEvent {
+	return &blogpb.PostEvent{
+		Id:         event.ID,
+		Type:       eventTypes[event.Type],
+		PostId:     event.PostID,
+		Post:       toProtoPost(&event.Post),
+		OccurredAt: timestamppb.New(event.OccurredAt),
+	}
+}
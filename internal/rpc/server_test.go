@@ -0,0 +1,328 @@
+package rpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/rpc"
+	"github.com/kondrushin/blog/internal/rpc/blogpb"
+	"github.com/kondrushin/blog/internal/rpc/mocks"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type ServerTestSuite struct {
+	useCase       *mocks.IBlogUseCase
+	authenticator *mocks.IAuthenticator
+	bus           *events.Bus
+	client        blogpb.BlogServiceClient
+	ctx           context.Context
+}
+
+func SetSuite(t *testing.T) *ServerTestSuite {
+	suite := &ServerTestSuite{
+		useCase:       new(mocks.IBlogUseCase),
+		authenticator: new(mocks.IAuthenticator),
+		bus:           events.NewBus(10),
+		ctx:           context.Background(),
+	}
+
+	listener := bufconn.Listen(1 << 20)
+	server := rpc.NewServer(suite.useCase, usecase.NewEventUseCase(suite.bus, policy.Default()), suite.authenticator)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	suite.client = blogpb.NewBlogServiceClient(conn)
+
+	return suite
+}
+
+func Test_GetPost_ShouldReturnPost(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.useCase.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(&domain.Post{ID: 1, Author: "Anton", Title: "Big post", Content: "something"}, nil)
+
+	post, err := suite.client.GetPost(suite.ctx, &blogpb.GetPostRequest{Id: 1})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Big post", post.GetTitle())
+	assert.Equal(t, int64(1), post.GetId())
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_GetPost_NotFound_ShouldReturnNotFoundCode(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.useCase.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(nil, domain.ErrorPostNotFound)
+
+	_, err := suite.client.GetPost(suite.ctx, &blogpb.GetPostRequest{Id: 1})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_ListPosts_ShouldReturnPosts(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.useCase.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{
+			{ID: 1, Author: "Anton", Title: "First", Content: "one"},
+			{ID: 2, Author: "Jonny", Title: "Second", Content: "two"},
+		})
+
+	resp, err := suite.client.ListPosts(suite.ctx, &blogpb.ListPostsRequest{})
+
+	assert.NoError(t, err)
+	assert.Len(t, resp.GetPosts(), 2)
+	assert.Equal(t, "Jonny", resp.GetPosts()[1].GetAuthor())
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_CreatePost_ShouldReturnId(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.useCase.
+		On("CreatePost", mock.Anything, &domain.Post{Author: "Anton", Title: "Big post", Content: "something"}).
+		Return(int64(5), nil)
+
+	resp, err := suite.client.CreatePost(suite.ctx, &blogpb.CreatePostRequest{Author: "Anton", Title: "Big post", Content: "something"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), resp.GetId())
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_CreatePost_BlankTitle_ShouldReturnInvalidArgument(t *testing.T) {
+	suite := SetSuite(t)
+
+	_, err := suite.client.CreatePost(suite.ctx, &blogpb.CreatePostRequest{Author: "Anton", Title: " ", Content: "something"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	suite.useCase.AssertNotCalled(t, "CreatePost")
+}
+
+func Test_CreatePost_InvalidFields_ShouldReturnInvalidArgument(t *testing.T) {
+	for name, req := range map[string]*blogpb.CreatePostRequest{
+		"long author":          {Author: strings.Repeat("a", 101), Title: "Big post", Content: "something"},
+		"long title":           {Author: "Anton", Title: strings.Repeat("a", 201), Content: "something"},
+		"long content":         {Author: "Anton", Title: "Big post", Content: strings.Repeat("a", 100001)},
+		"control in title":     {Author: "Anton", Title: "Big\x00post", Content: "something"},
+		"line break in author": {Author: "An\nton", Title: "Big post", Content: "something"},
+		"control in content":   {Author: "Anton", Title: "Big post", Content: "some\x1Bthing"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite := SetSuite(t)
+
+			_, err := suite.client.CreatePost(suite.ctx, req)
+
+			assert.Equal(t, codes.InvalidArgument, status.Code(err))
+			suite.useCase.AssertNotCalled(t, "CreatePost")
+		})
+	}
+}
+
+func Test_CreatePost_AuthorId_ShouldNotRequireAuthorName(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.useCase.
+		On("CreatePost", mock.Anything, &domain.Post{AuthorID: 7, Title: "Big post", Content: "something"}).
+		Return(int64(5), nil)
+
+	resp, err := suite.client.CreatePost(suite.ctx, &blogpb.CreatePostRequest{AuthorId: 7, Title: "Big post", Content: "something"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), resp.GetId())
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_CreatePost_WithoutAuthor_ShouldReturnInvalidArgument(t *testing.T) {
+	suite := SetSuite(t)
+
+	_, err := suite.client.CreatePost(suite.ctx, &blogpb.CreatePostRequest{Title: "Big post", Content: "something"})
+
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	suite.useCase.AssertNotCalled(t, "CreatePost")
+}
+
+func Test_UpdatePost_AuthorId_ShouldPassAuthorIdToUseCase(t *testing.T) {
+	suite := SetSuite(t)
+
+	post := &domain.Post{ID: 3, AuthorID: 7, Title: "Big post", Content: "something"}
+	suite.useCase.
+		On("UpdatePost", mock.Anything, post, int64(3)).
+		Return(nil)
+
+	_, err := suite.client.UpdatePost(suite.ctx, &blogpb.UpdatePostRequest{Id: 3, AuthorId: 7, Title: "Big post", Content: "something"})
+
+	assert.NoError(t, err)
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_UpdatePost_Draft_ShouldPassDraftToUseCase(t *testing.T) {
+	suite := SetSuite(t)
+
+	post := &domain.Post{ID: 3, Author: "Anton", Title: "Big post", Content: "something", Draft: true}
+	suite.useCase.
+		On("UpdatePost", mock.Anything, post, int64(3)).
+		Return(nil)
+
+	_, err := suite.client.UpdatePost(suite.ctx, &blogpb.UpdatePostRequest{Id: 3, Author: "Anton", Title: "Big post", Content: "something", Draft: true})
+
+	assert.NoError(t, err)
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_UpdatePost_NotFound_ShouldReturnNotFoundCode(t *testing.T) {
+	suite := SetSuite(t)
+
+	post := &domain.Post{ID: 3, Author: "Anton", Title: "Big post", Content: "something"}
+	suite.useCase.
+		On("UpdatePost", mock.Anything, post, int64(3)).
+		Return(domain.ErrorPostNotFound)
+
+	_, err := suite.client.UpdatePost(suite.ctx, &blogpb.UpdatePostRequest{Id: 3, Author: "Anton", Title: "Big post", Content: "something"})
+
+	assert.Equal(t, codes.NotFound, status.Code(err))
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_DeletePost_Error_ShouldReturnInternalCode(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.useCase.
+		On("DeletePost", mock.Anything, int64(1)).
+		Return(errors.New("DB error"))
+
+	_, err := suite.client.DeletePost(suite.ctx, &blogpb.DeletePostRequest{Id: 1})
+
+	assert.Equal(t, codes.Internal, status.Code(err))
+	assert.Equal(t, "DB error", status.Convert(err).Message())
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_DeletePost_DomainErrors_ShouldMapToCodes(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		code codes.Code
+	}{
+		{domain.ErrorPostNotFound, codes.NotFound},
+		{domain.ErrorAuthorNotFound, codes.NotFound},
+		{domain.ErrorUserNotFound, codes.NotFound},
+		{domain.ErrorSessionNotFound, codes.NotFound},
+		{domain.ErrorWebhookNotFound, codes.NotFound},
+		{domain.ErrorDeliveryNotFound, codes.NotFound},
+		{domain.ErrorAttachmentNotFound, codes.NotFound},
+		{fmt.Errorf("%w: title is required", domain.ErrorInvalidInput), codes.InvalidArgument},
+		{domain.ErrorUnsupportedAttachment, codes.InvalidArgument},
+		{domain.ErrorAttachmentTooLarge, codes.ResourceExhausted},
+		{fmt.Errorf("%w: author 1 has 2 posts", domain.ErrorConflict), codes.Aborted},
+		{domain.ErrorUnauthorized, codes.Unauthenticated},
+		{domain.ErrorAccountLocked, codes.FailedPrecondition},
+		{domain.ErrorForbidden, codes.PermissionDenied},
+		{errors.New("DB error"), codes.Internal},
+	} {
+		t.Run(tc.err.Error(), func(t *testing.T) {
+			suite := SetSuite(t)
+
+			suite.useCase.
+				On("DeletePost", mock.Anything, int64(1)).
+				Return(tc.err)
+
+			_, err := suite.client.DeletePost(suite.ctx, &blogpb.DeletePostRequest{Id: 1})
+
+			assert.Equal(t, tc.code, status.Code(err))
+			assert.Equal(t, tc.err.Error(), status.Convert(err).Message())
+		})
+	}
+}
+
+func Test_Watch_ShouldReplayAndStreamFilteredEvents(t *testing.T) {
+	suite := SetSuite(t)
+	ctx, cancel := context.WithTimeout(suite.ctx, 5*time.Second)
+	defer cancel()
+
+	suite.bus.Publish(ctx, domain.PostEvent{Type: domain.EventPostCreated, PostID: 1, Post: domain.Post{ID: 1, Author: "Anton"}})
+	suite.bus.Publish(ctx, domain.PostEvent{Type: domain.EventPostCreated, PostID: 2, Post: domain.Post{ID: 2, Author: "Anton"}})
+
+	stream, err := suite.client.Watch(ctx, &blogpb.WatchRequest{Authors: []string{"Anton"}, LastEventId: 1})
+	assert.NoError(t, err)
+
+	replayed, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), replayed.GetId())
+	assert.Equal(t, blogpb.EventType_EVENT_TYPE_POST_CREATED, replayed.GetType())
+
+	// The subscription is registered once the replay is received, so live events are not missed.
+	suite.bus.Publish(ctx, domain.PostEvent{Type: domain.EventPostUpdated, PostID: 3, Post: domain.Post{ID: 3, Author: "Jonny"}})
+	suite.bus.Publish(ctx, domain.PostEvent{Type: domain.EventPostDeleted, PostID: 2, Post: domain.Post{ID: 2, Author: "Anton"}})
+
+	live, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), live.GetId())
+	assert.Equal(t, blogpb.EventType_EVENT_TYPE_POST_DELETED, live.GetType())
+	assert.Equal(t, int64(2), live.GetPostId())
+}
+
+func Test_CreatePost_WithBearerToken_ShouldPassUserToUseCase(t *testing.T) {
+	suite := SetSuite(t)
+	user := &domain.User{ID: 1, Role: domain.RoleAuthor, AuthorID: 7}
+
+	suite.authenticator.On("Authenticate", mock.Anything, "access").Return(user, nil)
+	suite.useCase.
+		On("CreatePost", mock.MatchedBy(func(ctx context.Context) bool {
+			authenticated, isIn := auth.UserFromContext(ctx)
+			return isIn && authenticated == user
+		}), mock.Anything).
+		Return(int64(1), nil)
+
+	ctx := metadata.AppendToOutgoingContext(suite.ctx, "authorization", "Bearer access")
+	_, err := suite.client.CreatePost(ctx, &blogpb.CreatePostRequest{Author: "Anton", Title: "Big post", Content: "something"})
+
+	assert.NoError(t, err)
+	suite.useCase.AssertExpectations(t)
+}
+
+func Test_CreatePost_InvalidToken_ShouldReturnUnauthenticated(t *testing.T) {
+	suite := SetSuite(t)
+
+	suite.authenticator.
+		On("Authenticate", mock.Anything, "revoked").
+		Return(nil, fmt.Errorf("%w: access token was revoked", domain.ErrorUnauthorized))
+
+	ctx := metadata.AppendToOutgoingContext(suite.ctx, "authorization", "Bearer revoked")
+	_, err := suite.client.CreatePost(ctx, &blogpb.CreatePostRequest{Author: "Anton", Title: "Big post", Content: "something"})
+
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	suite.useCase.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+}
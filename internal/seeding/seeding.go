@@ -4,7 +4,9 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
+	"sort"
 
 	"github.com/kondrushin/blog/internal/domain"
 )
@@ -13,13 +15,19 @@ type Repository interface {
 	CreatePost(ctx context.Context, post *domain.Post) (int64, error)
 }
 
-func Seed(ctx context.Context, filePath string, repository Repository) error {
+type AuthorRepository interface {
+	GetOrCreateAuthorByName(ctx context.Context, name string) (*domain.Author, error)
+}
+
+// Seed adds the posts of the data file. Authors are given by name in data files
+// and are created when there is no author with the name yet.
+func Seed(ctx context.Context, filePath string, repository Repository, authors AuthorRepository) error {
 	posts, err := getPostsFromFile(filePath)
 	if err != nil {
 		return err
 	}
 
-	err = addPostsToRepository(ctx, posts, repository)
+	err = addPostsToRepository(ctx, posts, repository, authors)
 	if err != nil {
 		return err
 	}
@@ -27,15 +35,24 @@ func Seed(ctx context.Context, filePath string, repository Repository) error {
 	return nil
 }
 
-func addPostsToRepository(ctx context.Context, posts []PostFileModel, repository Repository) error {
+func addPostsToRepository(ctx context.Context, posts []PostFileModel, repository Repository, authors AuthorRepository) error {
 	for _, p := range posts {
-		_, err := repository.CreatePost(ctx, &domain.Post{
-			ID:      int64(p.ID),
-			Author:  p.Author,
-			Title:   p.Title,
-			Content: p.Content,
-		})
+		author, err := authors.GetOrCreateAuthorByName(ctx, p.Author)
+		if err != nil {
+			return fmt.Errorf("Could not seed author %q from a file. Error: %w", p.Author, err)
+		}
+
+		post := &domain.Post{
+			ID:       int64(p.ID),
+			AuthorID: author.ID,
+			Author:   author.Name,
+			Title:    p.Title,
+			Content:  p.Content,
+			Draft:    p.Draft,
+		}
+		post.Summarize()
 
+		_, err = repository.CreatePost(ctx, post)
 		if err != nil {
 			return fmt.Errorf("Could not seed data from a file. Error: %w", err)
 		}
@@ -44,6 +61,27 @@ func addPostsToRepository(ctx context.Context, posts []PostFileModel, repository
 	return nil
 }
 
+// Export writes the posts in the format of a data file, ordered by ID, so that
+// it can be seeded into another database.
+func Export(w io.Writer, posts []*domain.Post) error {
+	sorted := append([]*domain.Post(nil), posts...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+
+	blog := BlogFileModel{Posts: make([]PostFileModel, 0, len(sorted))}
+	for _, p := range sorted {
+		blog.Posts = append(blog.Posts, PostFileModel{
+			Author:  p.Author,
+			Title:   p.Title,
+			Content: p.Content,
+			Draft:   p.Draft,
+		})
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(blog)
+}
+
 func getPostsFromFile(filePath string) ([]PostFileModel, error) {
 	data, err := os.ReadFile(filePath)
 	if err != nil {
@@ -63,6 +101,7 @@ type PostFileModel struct {
 	Author  string `json:"author" `
 	Title   string `json:"title"`
 	Content string `json:"content"`
+	Draft   bool   `json:"draft,omitempty"`
 }
 
 type BlogFileModel struct {
@@ -1,6 +1,7 @@
 package seeding_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -19,25 +20,40 @@ func Test_Seed_Success(t *testing.T) {
 	ctx := context.Background()
 
 	repositoryMock := new(mocks.IBlogRepository)
+	authorsMock := new(mocks.IAuthorRepository)
 
 	blog := seeding.BlogFileModel{
 		Posts: []seeding.PostFileModel{
 			{ID: 1, Author: "Anton", Title: "Big title", Content: "Big Content"},
-			{ID: 2, Author: "Jonny", Title: "Small title", Content: "Small Content"},
+			{ID: 2, Author: "anton ", Title: "Small title", Content: "Small Content"},
 		}}
 
 	tempFile := writeDataToTestFile(blog)
 	defer os.Remove(tempFile.Name())
 
+	authorsMock.
+		On("GetOrCreateAuthorByName", mock.Anything, mock.Anything).
+		Twice().
+		Return(&domain.Author{ID: 1, Name: "Anton"}, nil)
+
 	post1 := &domain.Post{
-		Author:  "Anton",
-		Title:   "Big title",
-		Content: "Big Content",
+		AuthorID: 1,
+		Author:   "Anton",
+		Title:    "Big title",
+		Content:  "Big Content",
+		// Seeded posts are summarized like posts created through the use case.
+		Excerpt:        "Big Content",
+		WordCount:      2,
+		ReadingMinutes: 1,
 	}
 	post2 := &domain.Post{
-		Author:  "Jonny",
-		Title:   "Small title",
-		Content: "Small Content",
+		AuthorID:       1,
+		Author:         "Anton",
+		Title:          "Small title",
+		Content:        "Small Content",
+		Excerpt:        "Small Content",
+		WordCount:      2,
+		ReadingMinutes: 1,
 	}
 
 	repositoryMock.
@@ -50,15 +66,17 @@ func Test_Seed_Success(t *testing.T) {
 		Once().
 		Return(int64(2), nil)
 
-	err := seeding.Seed(ctx, tempFile.Name(), repositoryMock)
+	err := seeding.Seed(ctx, tempFile.Name(), repositoryMock, authorsMock)
 	assert.NoError(t, err)
 	repositoryMock.AssertExpectations(t)
+	authorsMock.AssertExpectations(t)
 }
 
 func Test_Seed_ErrorWhileCreatingPostInRepo(t *testing.T) {
 	ctx := context.Background()
 
 	repositoryMock := new(mocks.IBlogRepository)
+	authorsMock := new(mocks.IAuthorRepository)
 
 	blog := seeding.BlogFileModel{
 		Posts: []seeding.PostFileModel{
@@ -68,10 +86,18 @@ func Test_Seed_ErrorWhileCreatingPostInRepo(t *testing.T) {
 	tempFile := writeDataToTestFile(blog)
 	defer os.Remove(tempFile.Name())
 
+	authorsMock.
+		On("GetOrCreateAuthorByName", mock.Anything, "Anton").
+		Return(&domain.Author{ID: 1, Name: "Anton"}, nil)
+
 	post1 := &domain.Post{
-		Author:  "Anton",
-		Title:   "Big title",
-		Content: "Big Content",
+		AuthorID:       1,
+		Author:         "Anton",
+		Title:          "Big title",
+		Content:        "Big Content",
+		Excerpt:        "Big Content",
+		WordCount:      2,
+		ReadingMinutes: 1,
 	}
 
 	repositoryMock.
@@ -79,7 +105,7 @@ func Test_Seed_ErrorWhileCreatingPostInRepo(t *testing.T) {
 		Once().
 		Return(int64(0), errors.New("ERROR"))
 
-	err := seeding.Seed(ctx, tempFile.Name(), repositoryMock)
+	err := seeding.Seed(ctx, tempFile.Name(), repositoryMock, authorsMock)
 	assert.Error(t, err)
 	assert.Equal(t, "Could not seed data from a file. Error: ERROR", err.Error())
 	repositoryMock.AssertExpectations(t)
@@ -90,7 +116,7 @@ func Test_Seed_FileDoesNotExist(t *testing.T) {
 
 	repositoryMock := new(mocks.IBlogRepository)
 
-	err := seeding.Seed(ctx, "IdoNotExist.json", repositoryMock)
+	err := seeding.Seed(ctx, "IdoNotExist.json", repositoryMock, new(mocks.IAuthorRepository))
 	assert.Error(t, err)
 	assert.Equal(t, "open IdoNotExist.json: no such file or directory", err.Error())
 	repositoryMock.AssertExpectations(t)
@@ -102,3 +128,19 @@ func writeDataToTestFile(dt any) *os.File {
 	_, _ = tempFile.Write(data)
 	return tempFile
 }
+
+func Test_Export_ShouldWriteDataFileOrderedById(t *testing.T) {
+	var buffer bytes.Buffer
+	err := seeding.Export(&buffer, []*domain.Post{
+		{ID: 2, Author: "Jonny", Title: "Small title", Content: "Small Content"},
+		{ID: 1, Author: "Anton", Title: "Big title", Content: "Big Content"},
+	})
+	assert.NoError(t, err)
+
+	blog := seeding.BlogFileModel{}
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &blog))
+	assert.Equal(t, []seeding.PostFileModel{
+		{Author: "Anton", Title: "Big title", Content: "Big Content"},
+		{Author: "Jonny", Title: "Small title", Content: "Small Content"},
+	}, blog.Posts)
+}
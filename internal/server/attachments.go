@@ -0,0 +1,244 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/middleware"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+type IAttachmentUseCase interface {
+	CreateAttachment(ctx context.Context, postID int64, filename string, content io.Reader) (*domain.Attachment, error)
+	GetAttachments(ctx context.Context, postID int64) ([]*domain.Attachment, error)
+	OpenAttachment(ctx context.Context, postID int64, id int64) (*domain.Attachment, io.ReadSeekCloser, error)
+	OpenThumbnail(ctx context.Context, postID int64, id int64) (*domain.Attachment, io.ReadSeekCloser, error)
+	DeleteAttachment(ctx context.Context, postID int64, id int64) error
+}
+
+var ErrorMissingFile = errors.New("Multipart form field file is missing")
+
+type AttachmentController struct {
+	UseCase IAttachmentUseCase
+}
+
+// CreateAttachment streams the file field of the multipart body to the use case,
+// without holding the upload in memory.
+func (ctr *AttachmentController) CreateAttachment(c *gin.Context) {
+	var reqModel postIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	form, err := c.Request.MultipartReader()
+	if errors.Is(err, http.ErrNotMultipart) {
+		c.Error(response.SetHttpStatusCode(err, http.StatusUnsupportedMediaType))
+		return
+	}
+	if err != nil {
+		c.Error(response.SetHttpStatusCode(err, http.StatusBadRequest))
+		return
+	}
+
+	for {
+		part, err := form.NextPart()
+		if errors.Is(err, io.EOF) {
+			c.Error(response.SetHttpStatusCode(ErrorMissingFile, http.StatusBadRequest))
+			return
+		}
+		if err != nil {
+			c.Error(uploadError(err))
+			return
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		upload := &uploadReader{Reader: part}
+		attachment, err := ctr.UseCase.CreateAttachment(c.Request.Context(), reqModel.ID, part.FileName(), upload)
+		if upload.err != nil {
+			err = uploadError(upload.err)
+		}
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		model := newAttachmentModel(attachment, c.Request.URL.Path)
+		c.Header("Location", model.URL)
+		c.JSON(http.StatusCreated, model)
+		return
+	}
+}
+
+func (ctr *AttachmentController) GetAttachments(c *gin.Context) {
+	var reqModel postIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	attachments, err := ctr.UseCase.GetAttachments(c.Request.Context(), reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resModels := make([]attachmentModel, 0, len(attachments))
+	for _, a := range attachments {
+		resModels = append(resModels, newAttachmentModel(a, c.Request.URL.Path))
+	}
+
+	c.JSON(http.StatusOK, attachmentsResponse{Attachments: resModels})
+}
+
+func (ctr *AttachmentController) GetAttachment(c *gin.Context) {
+	var reqModel attachmentIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	attachment, content, err := ctr.UseCase.OpenAttachment(c.Request.Context(), reqModel.PostID, reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer content.Close()
+
+	serveBlob(c, attachment, attachment.ContentType, attachment.Hash, content)
+}
+
+func (ctr *AttachmentController) GetThumbnail(c *gin.Context) {
+	var reqModel attachmentIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	attachment, content, err := ctr.UseCase.OpenThumbnail(c.Request.Context(), reqModel.PostID, reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer content.Close()
+
+	serveBlob(c, attachment, attachment.ThumbnailType, attachment.Hash+"-thumbnail", content)
+}
+
+func (ctr *AttachmentController) DeleteAttachment(c *gin.Context) {
+	var reqModel attachmentIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctr.UseCase.DeleteAttachment(c.Request.Context(), reqModel.PostID, reqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// serveBlob answers conditional and range requests for the content. Only images
+// are shown inline and the type is never sniffed by browsers, so that an upload
+// cannot run as a page of the site.
+func serveBlob(c *gin.Context, attachment *domain.Attachment, contentType string, etag string, content io.ReadSeeker) {
+	disposition := "attachment"
+	if strings.HasPrefix(contentType, "image/") {
+		disposition = "inline"
+	}
+	if value := mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}); len(value) > 0 {
+		disposition = value
+	}
+
+	header := c.Writer.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Disposition", disposition)
+	header.Set("X-Content-Type-Options", "nosniff")
+	header.Set("ETag", `"`+etag+`"`)
+
+	http.ServeContent(c.Writer, c.Request, "", attachment.CreatedAt, content)
+}
+
+// uploadReader keeps the error of reading the request body, so that it is told
+// apart from errors of storing the upload.
+type uploadReader struct {
+	io.Reader
+	err error
+}
+
+func (r *uploadReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) {
+		r.err = err
+	}
+	return n, err
+}
+
+func uploadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return response.SetHttpStatusCode(middleware.ErrorBodyTooLarge, http.StatusRequestEntityTooLarge)
+	}
+	return response.SetHttpStatusCode(err, http.StatusBadRequest)
+}
+
+type attachmentIdRequest struct {
+	PostID int64 `uri:"id"`
+	ID     int64 `uri:"attachmentId"`
+}
+
+type attachmentUploadRequest struct {
+	File *multipart.FileHeader `json:"file" binding:"required" doc:"The uploaded file. Its type is detected from the content."`
+}
+
+type attachmentModel struct {
+	ID           int64     `json:"id"`
+	PostID       int64     `json:"post_id"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Hash         string    `json:"sha256"`
+	CreatedAt    time.Time `json:"created_at"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url,omitempty"`
+}
+
+// newAttachmentModel links the attachment below attachmentsPath, the path of the
+// attachments of its post in the requested version.
+func newAttachmentModel(attachment *domain.Attachment, attachmentsPath string) attachmentModel {
+	url := strings.TrimSuffix(attachmentsPath, "/") + "/" + strconv.FormatInt(attachment.ID, 10)
+
+	model := attachmentModel{
+		ID:          attachment.ID,
+		PostID:      attachment.PostID,
+		Filename:    attachment.Filename,
+		ContentType: attachment.ContentType,
+		Size:        attachment.Size,
+		Hash:        attachment.Hash,
+		CreatedAt:   attachment.CreatedAt,
+		URL:         url,
+	}
+	if len(attachment.ThumbnailType) > 0 {
+		model.ThumbnailURL = url + "/thumbnail"
+	}
+
+	return model
+}
+
+type attachmentsResponse struct {
+	Attachments []attachmentModel `json:"attachments"`
+}
@@ -0,0 +1,208 @@
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupAttachmentServer(t *testing.T, useCase *mocks.IAttachmentUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+	server.SetupLimits(ginRouter, server.LimitsConfig{
+		ReadRate:       100,
+		ReadBurst:      100,
+		WriteRate:      100,
+		WriteBurst:     100,
+		MaxBodyBytes:   64,
+		MaxUploadBytes: 1024,
+	})
+	server.SetupValidation(ginRouter)
+
+	server.RegisterAttachmentHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+
+	return httpexpect.Default(t, server.URL)
+}
+
+// readSeekNopCloser serves a string as the content of a blob.
+type readSeekNopCloser struct {
+	*strings.Reader
+}
+
+func (readSeekNopCloser) Close() error { return nil }
+
+var pdfAttachment = &domain.Attachment{
+	ID:          3,
+	PostID:      1,
+	Filename:    "Ünïcode report.pdf",
+	ContentType: "application/pdf",
+	Size:        17,
+	Hash:        "aaa",
+	CreatedAt:   time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+}
+
+func Test_CreateAttachment_ShouldStreamFileToUseCase(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	// The upload is larger than MaxBodyBytes but within MaxUploadBytes.
+	content := strings.Repeat("a", 200)
+	var received string
+	attachmentUseCaseMock.
+		On("CreateAttachment", mock.Anything, int64(1), "notes.txt", mock.Anything).
+		Once().
+		Run(func(args mock.Arguments) {
+			data, _ := io.ReadAll(args.Get(3).(io.Reader))
+			received = string(data)
+		}).
+		Return(&domain.Attachment{ID: 4, PostID: 1, Filename: "notes.txt", ContentType: "text/plain; charset=utf-8", Size: 200, Hash: "bbb"}, nil)
+
+	res := expect.POST("/v1/api/blog/posts/1/attachments").
+		WithMultipart().
+		WithFormField("note", "ignored").
+		WithFile("file", "notes.txt", strings.NewReader(content)).
+		Expect().
+		Status(http.StatusCreated)
+
+	res.Header("Location").IsEqual("/v1/api/blog/posts/1/attachments/4")
+	body := res.JSON().Object()
+	body.Value("url").IsEqual("/v1/api/blog/posts/1/attachments/4")
+	body.Value("sha256").IsEqual("bbb")
+	body.NotContainsKey("thumbnail_url")
+	assert.Equal(t, content, received)
+	attachmentUseCaseMock.AssertExpectations(t)
+}
+
+func Test_CreateAttachment_InvalidRequest_ShouldNotCallUseCase(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	expect.POST("/v1/api/blog/posts/1/attachments").
+		WithMultipart().
+		WithFormField("note", "no file").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	expect.POST("/v1/api/blog/posts/1/attachments").
+		WithJSON(map[string]string{"file": "notes"}).
+		Expect().
+		Status(http.StatusUnsupportedMediaType)
+
+	expect.POST("/v1/api/blog/posts/1/attachments").
+		WithMultipart().
+		WithFile("file", "big.txt", strings.NewReader(strings.Repeat("a", 2048))).
+		Expect().
+		Status(http.StatusRequestEntityTooLarge)
+
+	attachmentUseCaseMock.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_CreateAttachment_UnsupportedType_ShouldReturnUnsupportedMediaType(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	attachmentUseCaseMock.
+		On("CreateAttachment", mock.Anything, int64(1), "run.exe", mock.Anything).
+		Return(nil, domain.ErrorUnsupportedAttachment)
+
+	expect.POST("/v1/api/blog/posts/1/attachments").
+		WithMultipart().
+		WithFile("file", "run.exe", strings.NewReader("MZ")).
+		Expect().
+		Status(http.StatusUnsupportedMediaType)
+}
+
+func Test_GetAttachments_ShouldLinkContentAndThumbnail(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	attachmentUseCaseMock.
+		On("GetAttachments", mock.Anything, int64(1)).
+		Return([]*domain.Attachment{{ID: 5, PostID: 1, Filename: "cat.png", ContentType: "image/png", Hash: "ccc", ThumbnailType: "image/png"}}, nil)
+
+	attachment := expect.GET("/v1/api/blog/posts/1/attachments").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("attachments").Array().Value(0).Object()
+
+	attachment.Value("url").IsEqual("/v1/api/blog/posts/1/attachments/5")
+	attachment.Value("thumbnail_url").IsEqual("/v1/api/blog/posts/1/attachments/5/thumbnail")
+}
+
+func Test_GetAttachment_Range_ShouldReturnPartialContent(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	attachmentUseCaseMock.
+		On("OpenAttachment", mock.Anything, int64(1), int64(3)).
+		Return(pdfAttachment, readSeekNopCloser{strings.NewReader("%PDF-1.7 content")}, nil)
+
+	res := expect.GET("/v1/api/blog/posts/1/attachments/3").
+		WithHeader("Range", "bytes=5-7").
+		Expect().
+		Status(http.StatusPartialContent)
+
+	res.Body().IsEqual("1.7")
+	res.Header("Content-Range").IsEqual("bytes 5-7/16")
+	res.Header("Content-Type").IsEqual("application/pdf")
+	res.Header("X-Content-Type-Options").IsEqual("nosniff")
+	res.Header("ETag").IsEqual(`"aaa"`)
+	res.Header("Content-Disposition").IsEqual("attachment; filename*=utf-8''%C3%9Cn%C3%AFcode%20report.pdf")
+}
+
+func Test_GetAttachment_MatchingETag_ShouldReturnNotModified(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	attachmentUseCaseMock.
+		On("OpenAttachment", mock.Anything, int64(1), int64(3)).
+		Return(pdfAttachment, readSeekNopCloser{strings.NewReader("%PDF-1.7 content")}, nil)
+
+	expect.GET("/v1/api/blog/posts/1/attachments/3").
+		WithHeader("If-None-Match", `"aaa"`).
+		Expect().
+		Status(http.StatusNotModified)
+}
+
+func Test_GetThumbnail_Missing_ShouldReturnNotFound(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	attachmentUseCaseMock.
+		On("OpenThumbnail", mock.Anything, int64(1), int64(3)).
+		Return(nil, nil, domain.ErrorAttachmentNotFound)
+
+	expect.GET("/v1/api/blog/posts/1/attachments/3/thumbnail").
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func Test_DeleteAttachment_ShouldReturnNoContent(t *testing.T) {
+	var attachmentUseCaseMock = new(mocks.IAttachmentUseCase)
+	expect := SetupAttachmentServer(t, attachmentUseCaseMock)
+
+	attachmentUseCaseMock.
+		On("DeleteAttachment", mock.Anything, int64(1), int64(3)).
+		Once().
+		Return(nil)
+
+	expect.DELETE("/v1/api/blog/posts/1/attachments/3").
+		Expect().
+		Status(http.StatusNoContent)
+
+	attachmentUseCaseMock.AssertExpectations(t)
+}
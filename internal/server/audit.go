@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+type IAuditUseCase interface {
+	GetAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+}
+
+type AuditController struct {
+	UseCase IAuditUseCase
+}
+
+// GetAuditEntries returns the audit log as JSON or, with format=ndjson, exports
+// it as one entry per line.
+func (ctr *AuditController) GetAuditEntries(c *gin.Context) {
+	var reqModel auditRequest
+	if err := readQueryParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	entries, err := ctr.UseCase.GetAuditEntries(c.Request.Context(), reqModel.toDomainModel())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resModels := make([]auditEntryModel, 0, len(entries))
+	for _, e := range entries {
+		resModels = append(resModels, toAuditEntryModel(e))
+	}
+
+	if reqModel.Format == "ndjson" {
+		c.Header("Content-Type", ndjsonContentType)
+		c.Status(http.StatusOK)
+		encoder := json.NewEncoder(c.Writer)
+		for _, m := range resModels {
+			if err := encoder.Encode(m); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, auditResponse{Entries: resModels})
+}
+
+type auditRequest struct {
+	ActorID   int64     `form:"actor_id" binding:"omitempty,min=1"`
+	Action    string    `form:"action" binding:"omitempty,oneof=post.created post.updated post.deleted post.restored post.purged"`
+	PostID    int64     `form:"post_id" binding:"omitempty,min=1"`
+	RequestID string    `form:"request_id" binding:"max=128"`
+	Since     time.Time `form:"since" time_format:"2006-01-02T15:04:05Z07:00"`
+	Until     time.Time `form:"until" time_format:"2006-01-02T15:04:05Z07:00"`
+	Format    string    `form:"format" binding:"omitempty,oneof=json ndjson"`
+}
+
+func (a *auditRequest) toDomainModel() domain.AuditFilter {
+	return domain.AuditFilter{
+		ActorID:   a.ActorID,
+		Action:    domain.AuditAction(a.Action),
+		PostID:    a.PostID,
+		RequestID: a.RequestID,
+		Since:     a.Since,
+		Until:     a.Until,
+	}
+}
+
+type auditEntryModel struct {
+	ID         int64              `json:"id"`
+	OccurredAt time.Time          `json:"occurred_at"`
+	ActorID    int64              `json:"actor_id,omitempty"`
+	Actor      string             `json:"actor,omitempty"`
+	Action     string             `json:"action"`
+	PostID     int64              `json:"post_id"`
+	RequestID  string             `json:"request_id,omitempty"`
+	Before     *postModel         `json:"before,omitempty"`
+	After      *postModel         `json:"after,omitempty"`
+	Changes    []fieldChangeModel `json:"changes"`
+}
+
+type fieldChangeModel struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type auditResponse struct {
+	Entries []auditEntryModel `json:"entries"`
+}
+
+func toAuditEntryModel(entry *domain.AuditEntry) auditEntryModel {
+	m := auditEntryModel{
+		ID:         entry.ID,
+		OccurredAt: entry.OccurredAt,
+		ActorID:    entry.ActorID,
+		Actor:      entry.Actor,
+		Action:     string(entry.Action),
+		PostID:     entry.PostID,
+		RequestID:  entry.RequestID,
+		Changes:    []fieldChangeModel{},
+	}
+	if entry.Before != nil {
+		before := newPostModel(entry.Before)
+		m.Before = &before
+	}
+	if entry.After != nil {
+		after := newPostModel(entry.After)
+		m.After = &after
+	}
+	for _, change := range entry.Changes() {
+		m.Changes = append(m.Changes, fieldChangeModel{Field: change.Field, Before: change.Before, After: change.After})
+	}
+
+	return m
+}
@@ -0,0 +1,156 @@
+package server_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/requestid"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupAuditServer(t *testing.T, useCase *mocks.IAuditUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+
+	server.RegisterAuditHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+
+	return httpexpect.Default(t, server.URL)
+}
+
+var auditEntries = []*domain.AuditEntry{
+	{
+		ID:         1,
+		OccurredAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		ActorID:    7,
+		Actor:      "anton@example.com",
+		Action:     domain.AuditPostUpdated,
+		PostID:     1,
+		RequestID:  "req-1",
+		Before:     &domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "Old", Content: "qwerty"},
+		After:      &domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "New", Content: "qwerty"},
+	},
+	{
+		ID:         2,
+		OccurredAt: time.Date(2026, 3, 1, 12, 5, 0, 0, time.UTC),
+		Action:     domain.AuditPostDeleted,
+		PostID:     1,
+		Before:     &domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "New", Content: "qwerty"},
+	},
+}
+
+func Test_GetAuditEntries_ShouldPassFilterAndReturnChanges(t *testing.T) {
+	var auditUseCaseMock = new(mocks.IAuditUseCase)
+	expect := SetupAuditServer(t, auditUseCaseMock)
+
+	auditUseCaseMock.
+		On("GetAuditEntries", mock.Anything, domain.AuditFilter{
+			PostID: 1,
+			Action: domain.AuditPostUpdated,
+			Since:  time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		}).
+		Return(auditEntries[:1], nil)
+
+	body := expect.GET("/admin/audit").
+		WithQuery("post_id", 1).
+		WithQuery("action", "post.updated").
+		WithQuery("since", "2026-03-01T00:00:00Z").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	entry := body.Value("entries").Array().Value(0).Object()
+	entry.Value("actor").IsEqual("anton@example.com")
+	entry.Value("request_id").IsEqual("req-1")
+	entry.Value("before").Object().Value("title").IsEqual("Old")
+	entry.Value("changes").Array().IsEqual([]map[string]string{{"field": "title", "before": "Old", "after": "New"}})
+
+	auditUseCaseMock.AssertExpectations(t)
+}
+
+func Test_GetAuditEntries_NDJSON_ShouldWriteEntryPerLine(t *testing.T) {
+	var auditUseCaseMock = new(mocks.IAuditUseCase)
+	expect := SetupAuditServer(t, auditUseCaseMock)
+
+	auditUseCaseMock.
+		On("GetAuditEntries", mock.Anything, domain.AuditFilter{}).
+		Return(auditEntries, nil)
+
+	response := expect.GET("/admin/audit").
+		WithQuery("format", "ndjson").
+		Expect().
+		Status(http.StatusOK)
+
+	response.Header("Content-Type").IsEqual("application/x-ndjson")
+	lines := strings.Split(strings.TrimSuffix(response.Body().Raw(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[1], `"action":"post.deleted"`)
+}
+
+func Test_GetAuditEntries_InvalidFilter_ShouldReturnBadRequest(t *testing.T) {
+	var auditUseCaseMock = new(mocks.IAuditUseCase)
+	expect := SetupAuditServer(t, auditUseCaseMock)
+
+	expect.GET("/admin/audit").
+		WithQuery("action", "post.read").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	expect.GET("/admin/audit").
+		WithQuery("since", "yesterday").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	auditUseCaseMock.AssertNotCalled(t, "GetAuditEntries", mock.Anything, mock.Anything)
+}
+
+func Test_GetAuditEntries_Forbidden_ShouldReturnForbidden(t *testing.T) {
+	var auditUseCaseMock = new(mocks.IAuditUseCase)
+	expect := SetupAuditServer(t, auditUseCaseMock)
+
+	auditUseCaseMock.
+		On("GetAuditEntries", mock.Anything, mock.Anything).
+		Return(nil, domain.ErrorForbidden)
+
+	expect.GET("/admin/audit").
+		Expect().
+		Status(http.StatusForbidden)
+}
+
+func Test_RequestID_ShouldBeEchoedOrGenerated(t *testing.T) {
+	var auditUseCaseMock = new(mocks.IAuditUseCase)
+	expect := SetupAuditServer(t, auditUseCaseMock)
+
+	auditUseCaseMock.
+		On("GetAuditEntries", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			assert.Equal(t, "client-id", requestid.FromContext(args.Get(0).(context.Context)))
+		}).
+		Return([]*domain.AuditEntry{}, nil).
+		Once()
+	auditUseCaseMock.
+		On("GetAuditEntries", mock.Anything, mock.Anything).
+		Return([]*domain.AuditEntry{}, nil)
+
+	expect.GET("/admin/audit").
+		WithHeader("X-Request-ID", "client-id").
+		Expect().
+		Header("X-Request-ID").IsEqual("client-id")
+
+	expect.GET("/admin/audit").
+		WithHeader("X-Request-ID", "has spaces").
+		Expect().
+		Header("X-Request-ID").Length().IsEqual(32)
+}
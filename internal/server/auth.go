@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+type IAuthUseCase interface {
+	Register(ctx context.Context, email string, password string) (*domain.User, error)
+	Login(ctx context.Context, email string, password string) (*domain.Tokens, error)
+	Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error)
+	Logout(ctx context.Context, accessToken string) error
+	Authenticate(ctx context.Context, accessToken string) (*domain.User, error)
+	SetRole(ctx context.Context, id int64, role domain.Role, authorID int64) (*domain.User, error)
+}
+
+type AuthController struct {
+	UseCase IAuthUseCase
+}
+
+func (ctr *AuthController) Register(c *gin.Context) {
+	var reqModel registerRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := ctr.UseCase.Register(c.Request.Context(), reqModel.Email, reqModel.Password)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toUserModel(user))
+}
+
+func (ctr *AuthController) Login(c *gin.Context) {
+	var reqModel loginRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := ctr.UseCase.Login(c.Request.Context(), reqModel.Email, reqModel.Password)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTokensModel(tokens))
+}
+
+func (ctr *AuthController) Refresh(c *gin.Context) {
+	var reqModel refreshRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	tokens, err := ctr.UseCase.Refresh(c.Request.Context(), reqModel.RefreshToken)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toTokensModel(tokens))
+}
+
+// Logout revokes the access token of the Authorization header and its refresh token.
+func (ctr *AuthController) Logout(c *gin.Context) {
+	token, isBearer := auth.BearerToken(c.GetHeader("Authorization"))
+	if !isBearer {
+		c.Error(response.SetHttpStatusCodeWithHeaders(auth.ErrorInvalidAuthorization, http.StatusUnauthorized, map[string]string{"WWW-Authenticate": "Bearer"}))
+		return
+	}
+
+	if err := ctr.UseCase.Logout(c.Request.Context(), token); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *AuthController) SetRole(c *gin.Context) {
+	var idReqModel idRequest
+	if err := readPathParameters(c, &idReqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var reqModel roleRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	user, err := ctr.UseCase.SetRole(c.Request.Context(), idReqModel.ID, domain.Role(reqModel.Role), reqModel.AuthorID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toUserModel(user))
+}
+
+type registerRequest struct {
+	Email    string `json:"email" binding:"required,email,max=254"`
+	Password string `json:"password" binding:"required,min=8,max=72" doc:"At least 8 characters and at most 72 bytes"`
+}
+
+type loginRequest struct {
+	Email    string `json:"email" binding:"required,max=254"`
+	Password string `json:"password" binding:"required,max=72"`
+}
+
+type refreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required,max=100"`
+}
+
+type roleRequest struct {
+	Role     string `json:"role" binding:"required,oneof=reader author editor admin"`
+	AuthorID int64  `json:"author_id" binding:"omitempty,min=1" doc:"ID of the author whose posts are the user's own"`
+}
+
+type userModel struct {
+	ID        int64     `json:"id"`
+	Email     string    `json:"email"`
+	Role      string    `json:"role"`
+	AuthorID  int64     `json:"author_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func toUserModel(user *domain.User) userModel {
+	return userModel{
+		ID:        user.ID,
+		Email:     user.Email,
+		Role:      string(user.Role),
+		AuthorID:  user.AuthorID,
+		CreatedAt: user.CreatedAt,
+	}
+}
+
+type tokensModel struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	// ExpiresIn is the lifetime of the access token in seconds.
+	ExpiresIn int64 `json:"expires_in"`
+}
+
+func toTokensModel(tokens *domain.Tokens) tokensModel {
+	return tokensModel{
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tokens.ExpiresIn / time.Second),
+	}
+}
@@ -0,0 +1,195 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+// SetupAuthServer also serves /whoami, which returns the email of the authenticated user.
+func SetupAuthServer(t *testing.T, useCase *mocks.IAuthUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+	server.SetupAuthentication(ginRouter, useCase)
+
+	server.RegisterAuthHandlers(ginRouter, useCase)
+	ginRouter.GET("/whoami", func(c *gin.Context) {
+		if user, isIn := auth.UserFromContext(c.Request.Context()); isIn {
+			c.String(http.StatusOK, user.Email)
+			return
+		}
+		c.String(http.StatusOK, "anonymous")
+	})
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+	expect := httpexpect.Default(t, server.URL)
+
+	return expect
+}
+
+func Test_Register_ShouldReturnUser(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	authUseCaseMock.
+		On("Register", mock.Anything, "anton@example.com", "correct horse").
+		Return(&domain.User{ID: 1, Email: "anton@example.com", Role: domain.RoleReader, CreatedAt: createdAt}, nil)
+
+	expect.POST("/v1/api/blog/auth/register").
+		WithJSON(map[string]string{"email": "anton@example.com", "password": "correct horse"}).
+		Expect().
+		Status(http.StatusCreated).
+		JSON().Object().
+		IsEqual(map[string]any{"id": 1, "email": "anton@example.com", "role": "reader", "author_id": 0, "created_at": "2024-01-01T00:00:00Z"})
+
+	authUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Register_InvalidEmail_ShouldReturnBadRequest(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	expect.POST("/v1/api/blog/auth/register").
+		WithJSON(map[string]string{"email": "anton", "password": "correct horse"}).
+		Expect().
+		Status(http.StatusBadRequest)
+
+	authUseCaseMock.AssertNotCalled(t, "Register", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_Login_ShouldReturnTokens(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.
+		On("Login", mock.Anything, "anton@example.com", "correct horse").
+		Return(&domain.Tokens{AccessToken: "access", RefreshToken: "refresh", ExpiresIn: 15 * time.Minute}, nil)
+
+	expect.POST("/v1/api/blog/auth/login").
+		WithJSON(map[string]string{"email": "anton@example.com", "password": "correct horse"}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		IsEqual(map[string]any{"access_token": "access", "refresh_token": "refresh", "token_type": "Bearer", "expires_in": 900})
+}
+
+func Test_Login_Failures_ShouldReturnUnauthorizedOrLocked(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.
+		On("Login", mock.Anything, "anton@example.com", "wrong horse").
+		Return(nil, fmt.Errorf("%w: wrong email or password", domain.ErrorUnauthorized))
+	authUseCaseMock.
+		On("Login", mock.Anything, "jonny@example.com", "wrong horse").
+		Return(nil, domain.ErrorAccountLocked)
+
+	response := expect.POST("/v1/api/blog/auth/login").
+		WithJSON(map[string]string{"email": "anton@example.com", "password": "wrong horse"}).
+		Expect().
+		Status(http.StatusUnauthorized)
+	response.Header("WWW-Authenticate").IsEqual("Bearer")
+	response.JSON().Object().Value("error").IsEqual("Unauthorized: wrong email or password")
+
+	expect.POST("/v1/api/blog/auth/login").
+		WithJSON(map[string]string{"email": "jonny@example.com", "password": "wrong horse"}).
+		Expect().
+		Status(http.StatusLocked)
+}
+
+func Test_Refresh_ShouldReturnNewTokens(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.
+		On("Refresh", mock.Anything, "refresh").
+		Return(&domain.Tokens{AccessToken: "access 2", RefreshToken: "refresh 2", ExpiresIn: time.Minute}, nil)
+
+	expect.POST("/v1/api/blog/auth/refresh").
+		WithJSON(map[string]string{"refresh_token": "refresh"}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("refresh_token").IsEqual("refresh 2")
+}
+
+func Test_Logout_ShouldRevokeBearerToken(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.On("Authenticate", mock.Anything, "access").Return(&domain.User{ID: 1}, nil)
+	authUseCaseMock.On("Logout", mock.Anything, "access").Once().Return(nil)
+
+	expect.POST("/v1/api/blog/auth/logout").
+		Expect().
+		Status(http.StatusUnauthorized)
+
+	expect.POST("/v1/api/blog/auth/logout").
+		WithHeader("Authorization", "Bearer access").
+		Expect().
+		Status(http.StatusNoContent)
+
+	authUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Authentication_ShouldPutUserIntoContext(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.On("Authenticate", mock.Anything, "access").Return(&domain.User{ID: 1, Email: "anton@example.com"}, nil)
+	authUseCaseMock.
+		On("Authenticate", mock.Anything, "revoked").
+		Return(nil, fmt.Errorf("%w: access token was revoked", domain.ErrorUnauthorized))
+
+	expect.GET("/whoami").Expect().Status(http.StatusOK).Body().IsEqual("anonymous")
+	expect.GET("/whoami").WithHeader("Authorization", "Bearer access").Expect().Status(http.StatusOK).Body().IsEqual("anton@example.com")
+	expect.GET("/whoami").WithHeader("Authorization", "Bearer revoked").Expect().Status(http.StatusUnauthorized)
+	expect.GET("/whoami").WithHeader("Authorization", "Basic YW50b246").Expect().Status(http.StatusUnauthorized)
+}
+
+func Test_SetRole_ShouldReturnUpdatedUser(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.
+		On("SetRole", mock.Anything, int64(3), domain.RoleAuthor, int64(7)).
+		Return(&domain.User{ID: 3, Email: "jonny@example.com", Role: domain.RoleAuthor, AuthorID: 7}, nil)
+
+	expect.PUT("/v1/api/blog/users/3/role").
+		WithJSON(map[string]any{"role": "author", "author_id": 7}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().
+		ContainsSubset(map[string]any{"id": 3, "role": "author", "author_id": 7})
+
+	expect.PUT("/v1/api/blog/users/3/role").
+		WithJSON(map[string]any{"role": "owner"}).
+		Expect().
+		Status(http.StatusBadRequest)
+}
+
+func Test_SetRole_NotAdmin_ShouldReturnForbidden(t *testing.T) {
+	var authUseCaseMock = new(mocks.IAuthUseCase)
+	expect := SetupAuthServer(t, authUseCaseMock)
+
+	authUseCaseMock.
+		On("SetRole", mock.Anything, int64(3), domain.RoleAdmin, int64(0)).
+		Return(nil, fmt.Errorf("%w: role editor cannot perform user:manage", domain.ErrorForbidden))
+
+	expect.PUT("/v1/api/blog/users/3/role").
+		WithJSON(map[string]any{"role": "admin"}).
+		Expect().
+		Status(http.StatusForbidden).
+		JSON().Object().Value("error").IsEqual("Forbidden: role editor cannot perform user:manage")
+}
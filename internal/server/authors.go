@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type IAuthorUseCase interface {
+	GetAuthor(ctx context.Context, id int64) (*domain.Author, error)
+	GetAuthors(ctx context.Context) []*domain.Author
+	CreateAuthor(ctx context.Context, author *domain.Author) (int64, error)
+	UpdateAuthor(ctx context.Context, author *domain.Author, id int64) error
+	DeleteAuthor(ctx context.Context, id int64) error
+	GetAuthorPosts(ctx context.Context, id int64) ([]*domain.Post, error)
+}
+
+type AuthorController struct {
+	UseCase IAuthorUseCase
+}
+
+func (ctr *AuthorController) CreateAuthor(c *gin.Context) {
+	var reqModel authorRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	author := reqModel.toDomainModel()
+	if _, err := ctr.UseCase.CreateAuthor(c.Request.Context(), author); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toAuthorModel(author))
+}
+
+func (ctr *AuthorController) GetAuthor(c *gin.Context) {
+	var reqModel idRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	author, err := ctr.UseCase.GetAuthor(c.Request.Context(), reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuthorModel(author))
+}
+
+func (ctr *AuthorController) GetAuthors(c *gin.Context) {
+	authors := ctr.UseCase.GetAuthors(c.Request.Context())
+
+	resModels := make([]authorModel, 0, len(authors))
+	for _, a := range authors {
+		resModels = append(resModels, toAuthorModel(a))
+	}
+
+	c.JSON(http.StatusOK, authorsResponse{Authors: resModels})
+}
+
+func (ctr *AuthorController) UpdateAuthor(c *gin.Context) {
+	var idReqModel idRequest
+	if err := readPathParameters(c, &idReqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var reqModel authorRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	author := reqModel.toDomainModel()
+	if err := ctr.UseCase.UpdateAuthor(c.Request.Context(), author, idReqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toAuthorModel(author))
+}
+
+func (ctr *AuthorController) DeleteAuthor(c *gin.Context) {
+	var reqModel idRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctr.UseCase.DeleteAuthor(c.Request.Context(), reqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *AuthorController) GetAuthorPosts(c *gin.Context) {
+	var reqModel idRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var viewModel postsViewRequest
+	if err := readQueryParameters(c, &viewModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	posts, err := ctr.UseCase.GetAuthorPosts(c.Request.Context(), reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if viewModel.View == "summary" {
+		c.JSON(http.StatusOK, newPostSummariesResponse(posts))
+		return
+	}
+
+	c.JSON(http.StatusOK, postsResponse{Posts: posts})
+}
+
+type postsViewRequest struct {
+	View string `form:"view" binding:"omitempty,oneof=full summary" doc:"summary returns the excerpt, word count and reading time of posts instead of their content"`
+}
+
+type authorRequest struct {
+	Name      string `json:"name" binding:"required,max=100" pattern:"^[^\\x00-\\x1F\\x7F]*[^\\s\\x00-\\x1F\\x7F][^\\x00-\\x1F\\x7F]*$" patternMessage:"must contain a visible character and no control characters"`
+	Bio       string `json:"bio" binding:"max=2000"`
+	AvatarURL string `json:"avatar_url" binding:"max=2048"`
+}
+
+func (a *authorRequest) toDomainModel() *domain.Author {
+	return &domain.Author{
+		Name:      a.Name,
+		Bio:       a.Bio,
+		AvatarURL: a.AvatarURL,
+	}
+}
+
+type authorModel struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Bio       string `json:"bio"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type authorsResponse struct {
+	Authors []authorModel `json:"authors"`
+}
+
+func toAuthorModel(a *domain.Author) authorModel {
+	return authorModel{
+		ID:        a.ID,
+		Name:      a.Name,
+		Bio:       a.Bio,
+		AvatarURL: a.AvatarURL,
+	}
+}
@@ -0,0 +1,140 @@
+package server_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupAuthorServer(t *testing.T, useCase *mocks.IAuthorUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+
+	server.RegisterAuthorHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	expect := httpexpect.Default(t, server.URL)
+
+	return expect
+}
+
+func Test_CreateAuthor_ShouldReturnAuthor(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("CreateAuthor", mock.Anything, &domain.Author{Name: "Anton", Bio: "Gopher"}).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Author).ID = 1
+		}).
+		Return(int64(1), nil)
+
+	expect.POST("/v1/api/blog/authors").
+		WithJSON(map[string]string{"name": "Anton", "bio": "Gopher"}).
+		Expect().
+		Status(http.StatusCreated).
+		JSON().Object().
+		IsEqual(map[string]any{"id": 1, "name": "Anton", "bio": "Gopher", "avatar_url": ""})
+
+	authorUseCaseMock.AssertExpectations(t)
+}
+
+func Test_CreateAuthor_NameTaken_ShouldReturnConflict(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("CreateAuthor", mock.Anything, mock.Anything).
+		Return(int64(0), fmt.Errorf("%w: author \"Anton\" already exists", domain.ErrorConflict))
+
+	expect.POST("/v1/api/blog/authors").
+		WithJSON(map[string]string{"name": "Anton"}).
+		Expect().
+		Status(http.StatusConflict).
+		JSON().Object().Value("error").IsEqual("Conflict: author \"Anton\" already exists")
+}
+
+func Test_GetAuthor_NotFound_ShouldReturnNotFound(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("GetAuthor", mock.Anything, int64(1)).
+		Return(nil, domain.ErrorAuthorNotFound)
+
+	expect.GET("/v1/api/blog/authors/1").
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func Test_GetAuthors_ShouldReturnAuthors(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("GetAuthors", mock.Anything).
+		Return([]*domain.Author{{ID: 1, Name: "Anton", AvatarURL: "https://example.com/anton.png"}})
+
+	authors := expect.GET("/v1/api/blog/authors").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("authors").Array()
+
+	authors.Length().IsEqual(1)
+	authors.Value(0).Object().HasValue("avatar_url", "https://example.com/anton.png")
+}
+
+func Test_UpdateAuthor_ShouldReturnUpdatedAuthor(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("UpdateAuthor", mock.Anything, &domain.Author{Name: "Anton K."}, int64(1)).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Author).ID = 1
+		}).
+		Return(nil)
+
+	expect.PUT("/v1/api/blog/authors/1").
+		WithJSON(map[string]string{"name": "Anton K."}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().HasValue("id", 1).HasValue("name", "Anton K.")
+
+	authorUseCaseMock.AssertExpectations(t)
+}
+
+func Test_DeleteAuthor_WithPosts_ShouldReturnConflict(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("DeleteAuthor", mock.Anything, int64(1)).
+		Return(fmt.Errorf("%w: author 1 has 2 posts", domain.ErrorConflict))
+
+	expect.DELETE("/v1/api/blog/authors/1").
+		Expect().
+		Status(http.StatusConflict)
+}
+
+func Test_GetAuthorPosts_ShouldReturnPosts(t *testing.T) {
+	var authorUseCaseMock = new(mocks.IAuthorUseCase)
+	expect := SetupAuthorServer(t, authorUseCaseMock)
+
+	authorUseCaseMock.
+		On("GetAuthorPosts", mock.Anything, int64(1)).
+		Return([]*domain.Post{{ID: 4, AuthorID: 1, Author: "Anton", Title: "Big post", Content: "something"}}, nil)
+
+	expect.GET("/v1/api/blog/authors/1/posts").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("{\"posts\":[{\"ID\":4,\"AuthorID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Content\":\"something\"}]}")
+}
@@ -0,0 +1,131 @@
+package server_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupServerWithCompression(t *testing.T, useCase *mocks.IBlogUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+	server.SetupCompression(ginRouter, 1024)
+
+	server.RegisterHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+
+	return httpexpect.Default(t, server.URL)
+}
+
+func decompress(t *testing.T, coding string, body string) string {
+	var reader io.Reader
+	switch coding {
+	case "gzip":
+		gzipReader, err := gzip.NewReader(strings.NewReader(body))
+		assert.NoError(t, err)
+		reader = gzipReader
+	case "br":
+		reader = brotli.NewReader(strings.NewReader(body))
+	case "zstd":
+		zstdReader, err := zstd.NewReader(strings.NewReader(body))
+		assert.NoError(t, err)
+		defer zstdReader.Close()
+		reader = zstdReader
+	}
+
+	var buf bytes.Buffer
+	_, err := io.Copy(&buf, reader)
+	assert.NoError(t, err)
+	return buf.String()
+}
+
+func Test_Compression_ShouldUsePreferredCodingOfAcceptEncoding(t *testing.T) {
+	for acceptEncoding, coding := range map[string]string{
+		"gzip":                    "gzip",
+		"gzip, br":                "br",
+		"gzip, deflate, br, zstd": "zstd",
+	} {
+		t.Run(acceptEncoding, func(t *testing.T) {
+			var blogUseCaseMock = new(mocks.IBlogUseCase)
+			expect := SetupServerWithCompression(t, blogUseCaseMock)
+
+			blogUseCaseMock.On("GetPosts", mock.Anything).Return(manyPosts(50))
+
+			plain := expect.GET("/v1/api/blog/posts").
+				Expect().
+				Status(http.StatusOK)
+			plain.Header("Content-Encoding").IsEmpty()
+
+			resp := expect.GET("/v1/api/blog/posts").
+				WithHeader("Accept-Encoding", acceptEncoding).
+				Expect().
+				Status(http.StatusOK)
+			resp.Header("Content-Encoding").IsEqual(coding)
+			resp.Header("Content-Type").IsEqual("application/json; charset=utf-8")
+			resp.Headers().Value("Vary").Array().ContainsAll("Accept", "Accept-Encoding")
+
+			compressed := resp.Body().Raw()
+			assert.Less(t, len(compressed), len(plain.Body().Raw()))
+			assert.Equal(t, plain.Body().Raw(), decompress(t, coding, compressed))
+		})
+	}
+}
+
+func Test_Compression_SmallResponse_ShouldNotBeCompressed(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithCompression(t, blogUseCaseMock)
+
+	blogUseCaseMock.On("GetPosts", mock.Anything).Return(manyPosts(1))
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithHeader("Accept-Encoding", "gzip").
+		Expect().
+		Status(http.StatusOK)
+	resp.Header("Content-Encoding").IsEmpty()
+	resp.JSON().Object().Value("posts").Array().Length().IsEqual(1)
+}
+
+func Test_Compression_ErrorResponse_ShouldBeWritten(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithCompression(t, blogUseCaseMock)
+
+	expect.GET("/v1/api/blog/posts/1").
+		WithHeader("Accept", "text/csv").
+		WithHeader("Accept-Encoding", "gzip").
+		Expect().
+		Status(http.StatusNotAcceptable).
+		JSON().Object().Value("error").String().NotEmpty()
+}
+
+func Test_Compression_StreamedPosts_ShouldBeCompressed(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithCompression(t, blogUseCaseMock)
+
+	expectEachPost(blogUseCaseMock, manyPosts(250), nil)
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "ndjson").
+		WithHeader("Accept-Encoding", "gzip").
+		Expect().
+		Status(http.StatusOK)
+	resp.Header("Content-Encoding").IsEqual("gzip")
+	resp.Header("Content-Type").IsEqual("application/x-ndjson")
+
+	lines := strings.Split(strings.TrimSuffix(decompress(t, "gzip", resp.Body().Raw()), "\n"), "\n")
+	assert.Len(t, lines, 250)
+}
@@ -2,16 +2,22 @@ package server
 
 import (
 	"context"
+	"encoding/xml"
+	"errors"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/middleware"
+	"github.com/kondrushin/blog/internal/server/negotiation"
 	"github.com/kondrushin/blog/internal/server/response"
 )
 
 type IBlogUseCase interface {
 	GetPost(ctx context.Context, id int64) (*domain.Post, error)
 	GetPosts(ctx context.Context) []*domain.Post
+	EachPost(ctx context.Context, yield func(*domain.Post) bool) error
 	CreatePost(ctx context.Context, p *domain.Post) (int64, error)
 	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
 	DeletePost(ctx context.Context, id int64) error
@@ -22,6 +28,12 @@ type Controller struct {
 }
 
 func (ctr *Controller) GetPost(c *gin.Context) {
+	format, err := negotiate(c, postFormats)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	var reqModel postIdRequest
 
 	if err := readPathParameters(c, &reqModel); err != nil {
@@ -29,22 +41,82 @@ func (ctr *Controller) GetPost(c *gin.Context) {
 		return
 	}
 
+	fields, err := readPostFields(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	post, err := ctr.UseCase.GetPost(c.Request.Context(), reqModel.ID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, post)
+	if fields != nil {
+		render(c, format, http.StatusOK, newPostProjection(post, fields))
+		return
+	}
+
+	render(c, format, http.StatusOK, post)
 }
 
+// GetPosts returns all posts, or streams them with the stream query parameter.
+// The summary view leaves out the content, fields selects the returned fields.
 func (ctr *Controller) GetPosts(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"posts": ctr.UseCase.GetPosts(c)})
+	var reqModel postsListRequest
+	if err := readQueryParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	fields, err := reqModel.fieldSet()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	represent := reqModel.representation(fields)
+
+	switch reqModel.Stream {
+	case "json":
+		if _, err := negotiate(c, []negotiation.Format{negotiation.JSON}); err != nil {
+			c.Error(err)
+			return
+		}
+		ctr.streamPosts(c, reqModel.Stream, represent)
+		return
+	case "ndjson":
+		// Like the export of the audit log, NDJSON is chosen by the parameter alone.
+		ctr.streamPosts(c, reqModel.Stream, represent)
+		return
+	}
+
+	format, err := negotiate(c, postListFormats)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	posts := ctr.UseCase.GetPosts(c.Request.Context())
+	switch {
+	case fields != nil:
+		render(c, format, http.StatusOK, newPostProjectionsResponse(posts, fields))
+	case reqModel.View == "summary":
+		render(c, format, http.StatusOK, newPostSummariesResponse(posts))
+	default:
+		render(c, format, http.StatusOK, postsResponse{Posts: posts})
+	}
 }
 
 func (ctr *Controller) CreatePost(c *gin.Context) {
+	format, err := negotiate(c, postFormats)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	var reqModel postRequest
-	if err := readJSON(c, &reqModel); err != nil {
+	if err := readBody(c, &reqModel); err != nil {
 		c.Error(err)
 		return
 	}
@@ -55,10 +127,16 @@ func (ctr *Controller) CreatePost(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"Id": id})
+	render(c, format, http.StatusCreated, postIdResponse{ID: id})
 }
 
 func (ctr *Controller) UpdatePost(c *gin.Context) {
+	format, err := negotiate(c, postFormats)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
 	var idReqModel postIdRequest
 	if err := readPathParameters(c, &idReqModel); err != nil {
 		c.Error(err)
@@ -66,19 +144,19 @@ func (ctr *Controller) UpdatePost(c *gin.Context) {
 	}
 
 	var reqModel postRequest
-	if err := readJSON(c, &reqModel); err != nil {
+	if err := readBody(c, &reqModel); err != nil {
 		c.Error(err)
 		return
 	}
 	reqModel.ID = idReqModel.ID
 
-	err := ctr.UseCase.UpdatePost(c.Request.Context(), reqModel.toDomainModel(), reqModel.ID)
+	err = ctr.UseCase.UpdatePost(c.Request.Context(), reqModel.toDomainModel(), reqModel.ID)
 	if err != nil {
 		c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"Id": reqModel.ID})
+	render(c, format, http.StatusOK, postIdResponse{ID: reqModel.ID})
 }
 
 func (ctr *Controller) DeletePost(c *gin.Context) {
@@ -94,7 +172,7 @@ func (ctr *Controller) DeletePost(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusNoContent, gin.H{"status": "success"})
+	c.Status(http.StatusNoContent)
 }
 
 func readPathParameters(c *gin.Context, dst any) error {
@@ -107,9 +185,24 @@ func readPathParameters(c *gin.Context, dst any) error {
 	return nil
 }
 
+// readPostFields reads the fields query parameter of a post.
+func readPostFields(c *gin.Context) (postFieldSet, error) {
+	var reqModel postFieldsRequest
+	if err := readQueryParameters(c, &reqModel); err != nil {
+		return nil, err
+	}
+
+	return reqModel.fieldSet()
+}
+
 func readJSON(c *gin.Context, dst any) error {
-	err := c.BindJSON(dst)
+	err := c.ShouldBindJSON(dst)
 	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return response.SetHttpStatusCode(middleware.ErrorBodyTooLarge, http.StatusRequestEntityTooLarge)
+		}
+
 		err = response.SetHttpStatusCode(err, http.StatusBadRequest)
 		return err
 	}
@@ -117,22 +210,114 @@ func readJSON(c *gin.Context, dst any) error {
 	return nil
 }
 
+// Author and title are single line texts, content may contain tabs and line breaks.
+// All of them must have a visible character and no other control characters.
+// The author is given by name or by ID, the ID wins when both are given.
+// Draft posts are hidden from users who may not read drafts.
 type postRequest struct {
-	ID      int64  `json:"-" uri:"id"`
-	Author  string `json:"author" binding:"required"`
-	Title   string `json:"title" binding:"required"`
-	Content string `json:"content" binding:"required"`
+	ID       int64  `json:"-" xml:"-" uri:"id"`
+	AuthorID int64  `json:"author_id" xml:"author_id" binding:"omitempty,min=1" doc:"ID of the author, required without author"`
+	Author   string `json:"author" xml:"author" binding:"required_without=AuthorID,max=100" doc:"Name of the author, required without author_id. An unknown name creates the author." pattern:"^[^\\x00-\\x1F\\x7F]*[^\\s\\x00-\\x1F\\x7F][^\\x00-\\x1F\\x7F]*$" patternMessage:"must contain a visible character and no control characters"`
+	Title    string `json:"title" xml:"title" binding:"required,max=200" pattern:"^[^\\x00-\\x1F\\x7F]*[^\\s\\x00-\\x1F\\x7F][^\\x00-\\x1F\\x7F]*$" patternMessage:"must contain a visible character and no control characters"`
+	Content  string `json:"content" xml:"content" binding:"required,max=100000" pattern:"^[^\\x00-\\x08\\x0B\\x0C\\x0E-\\x1F\\x7F]*[^\\s\\x00-\\x1F\\x7F][^\\x00-\\x08\\x0B\\x0C\\x0E-\\x1F\\x7F]*$" patternMessage:"must contain a visible character and no control characters other than tabs and line breaks"`
+	Draft    bool   `json:"draft" xml:"draft" doc:"Whether the post is a draft, which only its author and editors can read"`
 }
 
 type postIdRequest struct {
 	ID int64 `uri:"id"`
 }
 
+type postsResponse struct {
+	XMLName xml.Name       `json:"-" xml:"posts"`
+	Posts   []*domain.Post `json:"posts" xml:"Post"`
+}
+
+func (p postsResponse) Header() []string {
+	return []string{"ID", "AuthorID", "Author", "Title", "Content"}
+}
+
+func (p postsResponse) Rows() [][]string {
+	rows := make([][]string, 0, len(p.Posts))
+	for _, post := range p.Posts {
+		rows = append(rows, []string{
+			strconv.FormatInt(post.ID, 10),
+			strconv.FormatInt(post.AuthorID, 10),
+			post.Author,
+			post.Title,
+			post.Content,
+		})
+	}
+	return rows
+}
+
+// postSummary is the v1 representation of a post in the summary view.
+type postSummary struct {
+	ID             int64
+	AuthorID       int64
+	Author         string
+	Title          string
+	Excerpt        string
+	WordCount      int
+	ReadingMinutes int
+}
+
+func newPostSummary(post *domain.Post) postSummary {
+	return postSummary{
+		ID:             post.ID,
+		AuthorID:       post.AuthorID,
+		Author:         post.Author,
+		Title:          post.Title,
+		Excerpt:        post.Excerpt,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+	}
+}
+
+type postSummariesResponse struct {
+	XMLName xml.Name      `json:"-" xml:"posts"`
+	Posts   []postSummary `json:"posts" xml:"Post"`
+}
+
+func newPostSummariesResponse(posts []*domain.Post) postSummariesResponse {
+	summaries := make([]postSummary, 0, len(posts))
+	for _, post := range posts {
+		summaries = append(summaries, newPostSummary(post))
+	}
+	return postSummariesResponse{Posts: summaries}
+}
+
+func (p postSummariesResponse) Header() []string {
+	return []string{"ID", "AuthorID", "Author", "Title", "Excerpt", "WordCount", "ReadingMinutes"}
+}
+
+func (p postSummariesResponse) Rows() [][]string {
+	rows := make([][]string, 0, len(p.Posts))
+	for _, post := range p.Posts {
+		rows = append(rows, []string{
+			strconv.FormatInt(post.ID, 10),
+			strconv.FormatInt(post.AuthorID, 10),
+			post.Author,
+			post.Title,
+			post.Excerpt,
+			strconv.Itoa(post.WordCount),
+			strconv.Itoa(post.ReadingMinutes),
+		})
+	}
+	return rows
+}
+
+type postIdResponse struct {
+	XMLName xml.Name `json:"-" xml:"Post"`
+	ID      int64    `json:"Id" xml:"Id"`
+}
+
 func (p *postRequest) toDomainModel() *domain.Post {
 	return &domain.Post{
-		ID:      p.ID,
-		Author:  p.Author,
-		Title:   p.Title,
-		Content: p.Content,
+		ID:       p.ID,
+		AuthorID: p.AuthorID,
+		Author:   p.Author,
+		Title:    p.Title,
+		Content:  p.Content,
+		Draft:    p.Draft,
 	}
 }
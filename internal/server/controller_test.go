@@ -34,16 +34,17 @@ func Test_GetPost_ShouldReturnPost(t *testing.T) {
 	blogUseCaseMock.
 		On("GetPost", mock.Anything, int64(1)).
 		Return(&domain.Post{
-			ID:      int64(1),
-			Author:  "Anton",
-			Title:   "Big post",
-			Content: "something",
+			ID:       int64(1),
+			AuthorID: int64(1),
+			Author:   "Anton",
+			Title:    "Big post",
+			Content:  "something",
 		}, nil)
 
 	expect.GET("/v1/api/blog/posts/1").
 		Expect().
 		Status(http.StatusOK).
-		Body().IsEqual("{\"ID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Content\":\"something\"}")
+		Body().IsEqual("{\"ID\":1,\"AuthorID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Content\":\"something\"}")
 
 	blogUseCaseMock.AssertExpectations(t)
 }
@@ -95,17 +96,19 @@ func Test_GetPosts_ShouldReturnPosts(t *testing.T) {
 	expect := SetupServer(t, blogUseCaseMock)
 
 	post1 := &domain.Post{
-		ID:      int64(1),
-		Author:  "Anton",
-		Title:   "Big post",
-		Content: "something",
+		ID:       int64(1),
+		AuthorID: int64(1),
+		Author:   "Anton",
+		Title:    "Big post",
+		Content:  "something",
 	}
 
 	post2 := &domain.Post{
-		ID:      int64(2),
-		Author:  "Jonny",
-		Title:   "Another post",
-		Content: "something but different",
+		ID:       int64(2),
+		AuthorID: int64(2),
+		Author:   "Jonny",
+		Title:    "Another post",
+		Content:  "something but different",
 	}
 
 	blogUseCaseMock.
@@ -115,11 +118,40 @@ func Test_GetPosts_ShouldReturnPosts(t *testing.T) {
 	expect.GET("/v1/api/blog/posts").
 		Expect().
 		Status(http.StatusOK).
-		Body().IsEqual("{\"posts\":[{\"ID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Content\":\"something\"},{\"ID\":2,\"Author\":\"Jonny\",\"Title\":\"Another post\",\"Content\":\"something but different\"}]}")
+		Body().IsEqual("{\"posts\":[{\"ID\":1,\"AuthorID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Content\":\"something\"},{\"ID\":2,\"AuthorID\":2,\"Author\":\"Jonny\",\"Title\":\"Another post\",\"Content\":\"something but different\"}]}")
 
 	blogUseCaseMock.AssertExpectations(t)
 }
 
+func Test_GetPosts_SummaryView_ShouldLeaveOutContent(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{{ID: 1, AuthorID: 1, Author: "Anton", Title: "Big post", Content: "something", Excerpt: "something", WordCount: 1, ReadingMinutes: 1}})
+
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("view", "summary").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("{\"posts\":[{\"ID\":1,\"AuthorID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Excerpt\":\"something\",\"WordCount\":1,\"ReadingMinutes\":1}]}")
+
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("view", "summary").
+		WithHeader("Accept", "text/csv").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("ID,AuthorID,Author,Title,Excerpt,WordCount,ReadingMinutes\n1,1,Anton,Big post,something,1,1\n")
+
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("view", "short").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	blogUseCaseMock.AssertNumberOfCalls(t, "GetPosts", 2)
+}
+
 func Test_CreatePost_ShouldReturnPost(t *testing.T) {
 	var blogUseCaseMock = new(mocks.IBlogUseCase)
 	expect := SetupServer(t, blogUseCaseMock)
@@ -0,0 +1,237 @@
+package server
+
+import (
+	"cmp"
+	"net/http"
+	"slices"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// ControllerV2 serves posts as snake_case DTOs wrapped in the response envelope.
+// It shares request models and the use case with Controller.
+type ControllerV2 struct {
+	UseCase IBlogUseCase
+}
+
+func (ctr *ControllerV2) GetPost(c *gin.Context) {
+	var reqModel postIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	fields, err := readPostFields(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	post, err := ctr.UseCase.GetPost(c.Request.Context(), reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	if fields != nil {
+		c.JSON(http.StatusOK, postProjectionEnvelope{Data: newPostProjectionModel(post, fields)})
+		return
+	}
+
+	c.JSON(http.StatusOK, postEnvelope{Data: newPostModel(post)})
+}
+
+// GetPosts returns posts ordered by ID. With limit, a page of posts after the
+// given ID is returned and meta.next_after points to the next page.
+func (ctr *ControllerV2) GetPosts(c *gin.Context) {
+	var reqModel postsRequest
+	if err := readQueryParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	fields, err := reqModel.fieldSet()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	posts := ctr.UseCase.GetPosts(c.Request.Context())
+	slices.SortFunc(posts, func(a, b *domain.Post) int { return cmp.Compare(a.ID, b.ID) })
+
+	start, _ := slices.BinarySearchFunc(posts, reqModel.After+1, func(post *domain.Post, id int64) int { return cmp.Compare(post.ID, id) })
+	page := posts[start:]
+
+	meta := listMeta{Total: len(posts)}
+	if reqModel.Limit > 0 && len(page) > reqModel.Limit {
+		page = page[:reqModel.Limit]
+		meta.NextAfter = &page[len(page)-1].ID
+	}
+
+	meta.Count = len(page)
+
+	if fields != nil {
+		models := make([]postProjectionModel, 0, len(page))
+		for _, post := range page {
+			models = append(models, newPostProjectionModel(post, fields))
+		}
+		c.JSON(http.StatusOK, postProjectionsEnvelope{Data: models, Meta: meta})
+		return
+	}
+
+	if reqModel.View == "summary" {
+		models := make([]postSummaryModel, 0, len(page))
+		for _, post := range page {
+			models = append(models, newPostSummaryModel(post))
+		}
+		c.JSON(http.StatusOK, postSummariesEnvelope{Data: models, Meta: meta})
+		return
+	}
+
+	models := make([]postModel, 0, len(page))
+	for _, post := range page {
+		models = append(models, newPostModel(post))
+	}
+
+	c.JSON(http.StatusOK, postsEnvelope{Data: models, Meta: meta})
+}
+
+func (ctr *ControllerV2) CreatePost(c *gin.Context) {
+	var reqModel postRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	post := reqModel.toDomainModel()
+	id, err := ctr.UseCase.CreatePost(c.Request.Context(), post)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	post.ID = id
+
+	c.Header("Location", c.FullPath()+"/"+strconv.FormatInt(id, 10))
+	c.JSON(http.StatusCreated, postEnvelope{Data: newPostModel(post)})
+}
+
+func (ctr *ControllerV2) UpdatePost(c *gin.Context) {
+	var idReqModel postIdRequest
+	if err := readPathParameters(c, &idReqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var reqModel postRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+	reqModel.ID = idReqModel.ID
+
+	post := reqModel.toDomainModel()
+	if err := ctr.UseCase.UpdatePost(c.Request.Context(), post, reqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, postEnvelope{Data: newPostModel(post)})
+}
+
+func (ctr *ControllerV2) DeletePost(c *gin.Context) {
+	var reqModel postIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctr.UseCase.DeletePost(c.Request.Context(), reqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type postModel struct {
+	ID             int64  `json:"id"`
+	AuthorID       int64  `json:"author_id"`
+	Author         string `json:"author"`
+	Title          string `json:"title"`
+	Content        string `json:"content"`
+	Draft          bool   `json:"draft"`
+	Excerpt        string `json:"excerpt"`
+	WordCount      int    `json:"word_count"`
+	ReadingMinutes int    `json:"reading_minutes"`
+}
+
+func newPostModel(post *domain.Post) postModel {
+	return postModel{
+		ID:             post.ID,
+		AuthorID:       post.AuthorID,
+		Author:         post.Author,
+		Title:          post.Title,
+		Content:        post.Content,
+		Draft:          post.Draft,
+		Excerpt:        post.Excerpt,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+	}
+}
+
+// postSummaryModel is a post in the summary view, without its content.
+type postSummaryModel struct {
+	ID             int64  `json:"id"`
+	AuthorID       int64  `json:"author_id"`
+	Author         string `json:"author"`
+	Title          string `json:"title"`
+	Draft          bool   `json:"draft"`
+	Excerpt        string `json:"excerpt"`
+	WordCount      int    `json:"word_count"`
+	ReadingMinutes int    `json:"reading_minutes"`
+}
+
+func newPostSummaryModel(post *domain.Post) postSummaryModel {
+	return postSummaryModel{
+		ID:             post.ID,
+		AuthorID:       post.AuthorID,
+		Author:         post.Author,
+		Title:          post.Title,
+		Draft:          post.Draft,
+		Excerpt:        post.Excerpt,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+	}
+}
+
+// postEnvelope and postsEnvelope are the shapes of response.Envelope for posts.
+type postEnvelope struct {
+	Data postModel `json:"data"`
+}
+
+type postsEnvelope struct {
+	Data []postModel `json:"data"`
+	Meta listMeta    `json:"meta"`
+}
+
+type postSummariesEnvelope struct {
+	Data []postSummaryModel `json:"data"`
+	Meta listMeta           `json:"meta"`
+}
+
+type postsRequest struct {
+	postFieldsRequest
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=100" doc:"Largest number of posts to return, all posts are returned without it"`
+	After int64  `form:"after" binding:"omitempty,min=0" doc:"Only posts with a greater ID are returned"`
+	View  string `form:"view" binding:"omitempty,oneof=full summary" doc:"summary returns the excerpt, word count and reading time of posts instead of their content. Selected fields take precedence."`
+}
+
+type listMeta struct {
+	// Count is the number of returned items, Total the number of all items.
+	Count int `json:"count"`
+	Total int `json:"total"`
+	// NextAfter is the value of the after parameter for the next page, when there is one.
+	NextAfter *int64 `json:"next_after,omitempty"`
+}
@@ -0,0 +1,259 @@
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_V2_GetPost_ShouldReturnEnvelope(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(&domain.Post{
+			ID:             int64(1),
+			AuthorID:       int64(1),
+			Author:         "Anton",
+			Title:          "Big post",
+			Content:        "something",
+			Excerpt:        "something",
+			WordCount:      1,
+			ReadingMinutes: 1,
+		}, nil)
+
+	expect.GET("/v2/api/blog/posts/1").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("{\"data\":{\"id\":1,\"author_id\":1,\"author\":\"Anton\",\"title\":\"Big post\",\"content\":\"something\",\"draft\":false,\"excerpt\":\"something\",\"word_count\":1,\"reading_minutes\":1}}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_GetPost_NotFound_ShouldReturnErrorEnvelope(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(nil, domain.ErrorPostNotFound)
+
+	expect.GET("/v2/api/blog/posts/1").
+		Expect().
+		Status(http.StatusNotFound).
+		JSON().IsEqual(map[string]any{
+		"errors": []map[string]any{
+			{"status": 404, "message": domain.ErrorPostNotFound.Error()},
+		},
+	})
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_GetPosts_ShouldReturnDataAndMeta(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{
+			{ID: 1, Author: "Anton", Title: "First", Content: "one"},
+			{ID: 2, Author: "Anton", Title: "Second", Content: "two"},
+		})
+
+	body := expect.GET("/v2/api/blog/posts").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	body.Value("meta").Object().HasValue("count", 2)
+	body.Value("data").Array().Value(1).Object().
+		HasValue("id", 2).
+		HasValue("title", "Second")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_GetPosts_SummaryView_ShouldLeaveOutContent(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{
+			{ID: 1, Author: "Anton", Title: "First", Content: "one two", Excerpt: "one two", WordCount: 2, ReadingMinutes: 1},
+		})
+
+	body := expect.GET("/v2/api/blog/posts").
+		WithQuery("view", "summary").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	body.Value("meta").Object().HasValue("count", 1)
+	body.Value("data").Array().Value(0).Object().
+		HasValue("excerpt", "one two").
+		HasValue("word_count", 2).
+		HasValue("reading_minutes", 1).
+		NotContainsKey("content")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_GetPosts_Empty_ShouldReturnEmptyArray(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{})
+
+	expect.GET("/v2/api/blog/posts").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("{\"data\":[],\"meta\":{\"count\":0,\"total\":0}}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_GetPosts_WithLimit_ShouldReturnPagesOrderedById(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{
+			{ID: 3, Author: "Anton", Title: "Third", Content: "three"},
+			{ID: 1, Author: "Anton", Title: "First", Content: "one"},
+			{ID: 2, Author: "Anton", Title: "Second", Content: "two"},
+		})
+
+	first := expect.GET("/v2/api/blog/posts").
+		WithQuery("limit", 2).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	first.Value("meta").Object().IsEqual(map[string]any{"count": 2, "total": 3, "next_after": 2})
+	first.Value("data").Array().Value(0).Object().HasValue("id", 1)
+
+	last := expect.GET("/v2/api/blog/posts").
+		WithQuery("limit", 2).
+		WithQuery("after", 2).
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	last.Value("meta").Object().IsEqual(map[string]any{"count": 1, "total": 3})
+	last.Value("data").Array().Value(0).Object().HasValue("id", 3)
+}
+
+func Test_V2_CreatePost_ShouldReturnCreatedPostAndLocation(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	post := domain.Post{
+		Author:  "Anton",
+		Title:   "Big post",
+		Content: "something",
+	}
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &post).
+		Return(int64(7), nil)
+
+	response := expect.POST("/v2/api/blog/posts").
+		WithJSON(map[string]string{"author": "Anton", "title": "Big post", "content": "something"}).
+		Expect().
+		Status(http.StatusCreated)
+
+	response.Header("Location").IsEqual("/v2/api/blog/posts/7")
+	response.JSON().Object().Value("data").Object().
+		HasValue("id", 7).
+		HasValue("author", "Anton")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_UpdatePost_ShouldReturnUpdatedPost(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	post := domain.Post{
+		ID:      3,
+		Author:  "Anton",
+		Title:   "Big post",
+		Content: "something",
+	}
+
+	blogUseCaseMock.
+		On("UpdatePost", mock.Anything, &post, int64(3)).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Post).AuthorID = 1
+			args.Get(1).(*domain.Post).Summarize()
+		}).
+		Return(nil)
+
+	expect.PUT("/v2/api/blog/posts/3").
+		WithJSON(map[string]string{"author": "Anton", "title": "Big post", "content": "something"}).
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("{\"data\":{\"id\":3,\"author_id\":1,\"author\":\"Anton\",\"title\":\"Big post\",\"content\":\"something\",\"draft\":false,\"excerpt\":\"something\",\"word_count\":1,\"reading_minutes\":1}}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_DeletePost_ShouldReturnNoContentWithoutBody(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Return(nil)
+
+	expect.DELETE("/v2/api/blog/posts/1").
+		Expect().
+		Status(http.StatusNoContent).
+		Body().IsEmpty()
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_DeletePost_Error_ShouldReturnErrorEnvelope(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Return(errors.New("DB error"))
+
+	expect.DELETE("/v2/api/blog/posts/1").
+		Expect().
+		Status(http.StatusInternalServerError).
+		Body().IsEqual("{\"errors\":[{\"status\":500,\"message\":\"DB error\"}]}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_V2_Validation_ShouldReturnErrorPerField(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	expect.POST("/v2/api/blog/posts").
+		WithJSON(map[string]string{"author": "Anton", "title": " "}).
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().IsEqual(map[string]any{
+		"errors": []map[string]any{
+			{"status": 400, "field": "body.content", "message": "is required"},
+			{"status": 400, "field": "body.title", "message": "must contain a visible character and no control characters"},
+		},
+	})
+
+	blogUseCaseMock.AssertExpectations(t)
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+const (
+	lastEventIdHeader = "Last-Event-ID"
+	heartbeatInterval = 15 * time.Second
+	writeTimeout      = 10 * time.Second
+)
+
+type IEventSubscriber interface {
+	Subscribe(ctx context.Context, filter events.Filter, lastEventId int64) (*events.Subscription, error)
+}
+
+type EventsController struct {
+	Subscriber IEventSubscriber
+	upgrader   websocket.Upgrader
+}
+
+// StreamEvents sends post events as Server-Sent Events. A reconnecting client
+// resumes from the Last-Event-ID header or the last_event_id query parameter.
+func (ctr *EventsController) StreamEvents(c *gin.Context) {
+	subscription, err := ctr.subscribe(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer subscription.Close()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, event := range subscription.Replay {
+		writeServerSentEvent(c, event)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case event, isOpen := <-subscription.Events():
+			if !isOpen {
+				return
+			}
+			writeServerSentEvent(c, event)
+		}
+		c.Writer.Flush()
+	}
+}
+
+// StreamEventsWebSocket sends post events as JSON text messages over a WebSocket.
+func (ctr *EventsController) StreamEventsWebSocket(c *gin.Context) {
+	subscription, err := ctr.subscribe(c)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer subscription.Close()
+
+	conn, err := ctr.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already replied to the client.
+		return
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for _, event := range subscription.Replay {
+		if err := writeWebSocketEvent(conn, event); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-closed:
+			return
+		case <-heartbeat.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+				return
+			}
+		case event, isOpen := <-subscription.Events():
+			if !isOpen {
+				conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "subscriber is too slow"),
+					time.Now().Add(writeTimeout))
+				return
+			}
+			if err := writeWebSocketEvent(conn, event); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (ctr *EventsController) subscribe(c *gin.Context) (*events.Subscription, error) {
+	var reqModel eventsRequest
+	if err := c.ShouldBindQuery(&reqModel); err != nil {
+		return nil, response.SetHttpStatusCode(err, http.StatusBadRequest)
+	}
+
+	if header := c.GetHeader(lastEventIdHeader); len(header) > 0 {
+		lastEventId, err := strconv.ParseInt(header, 10, 64)
+		if err != nil {
+			return nil, response.SetHttpStatusCode(fmt.Errorf("Invalid %s header: %w", lastEventIdHeader, err), http.StatusBadRequest)
+		}
+		reqModel.LastEventID = lastEventId
+	}
+
+	filter := events.Filter{PostIDs: reqModel.PostIDs, AuthorIDs: reqModel.AuthorIDs, Authors: reqModel.Authors}
+	return ctr.Subscriber.Subscribe(c.Request.Context(), filter, reqModel.LastEventID)
+}
+
+func writeServerSentEvent(c *gin.Context, event domain.PostEvent) {
+	data, _ := json.Marshal(toEventModel(event))
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+}
+
+func writeWebSocketEvent(conn *websocket.Conn, event domain.PostEvent) error {
+	conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return conn.WriteJSON(toEventModel(event))
+}
+
+type eventsRequest struct {
+	PostIDs     []int64  `form:"post_id"`
+	AuthorIDs   []int64  `form:"author_id"`
+	Authors     []string `form:"author"`
+	LastEventID int64    `form:"last_event_id"`
+}
+
+type eventModel struct {
+	ID         int64          `json:"id"`
+	Type       string         `json:"type"`
+	PostID     int64          `json:"post_id"`
+	OccurredAt time.Time      `json:"occurred_at"`
+	Post       eventPostModel `json:"post"`
+}
+
+type eventPostModel struct {
+	ID      int64  `json:"id"`
+	Author  string `json:"author"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+func toEventModel(event domain.PostEvent) eventModel {
+	return eventModel{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		PostID:     event.PostID,
+		OccurredAt: event.OccurredAt,
+		Post: eventPostModel{
+			ID:      event.Post.ID,
+			Author:  event.Post.Author,
+			Title:   event.Post.Title,
+			Content: event.Post.Content,
+		},
+	}
+}
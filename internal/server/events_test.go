@@ -0,0 +1,150 @@
+package server_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupEventsServer(t *testing.T, bus *events.Bus) *httptest.Server {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+
+	server.RegisterEventHandlers(ginRouter, usecase.NewEventUseCase(bus, policy.Default()))
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func publishPost(bus *events.Bus, eventType domain.EventType, id int64, author string) {
+	bus.Publish(context.Background(), domain.PostEvent{
+		Type:   eventType,
+		PostID: id,
+		Post:   domain.Post{ID: id, Author: author, Title: "Title", Content: "Content"},
+	})
+}
+
+func readServerSentEvent(t *testing.T, reader *bufio.Reader) map[string]string {
+	fields := map[string]string{}
+	for {
+		line, err := reader.ReadString('\n')
+		assert.NoError(t, err)
+
+		line = strings.TrimSuffix(line, "\n")
+		if len(line) == 0 {
+			return fields
+		}
+
+		name, value, _ := strings.Cut(line, ": ")
+		fields[name] = value
+	}
+}
+
+func Test_StreamEvents_ShouldResumeFromLastEventIdAndFilterByAuthor(t *testing.T) {
+	bus := events.NewBus(10)
+	testServer := SetupEventsServer(t, bus)
+
+	publishPost(bus, domain.EventPostCreated, 1, "Anton")
+	publishPost(bus, domain.EventPostCreated, 2, "Jonny")
+	publishPost(bus, domain.EventPostUpdated, 1, "Anton")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/v1/api/blog/events?author=Anton", nil)
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	replayed := readServerSentEvent(t, reader)
+	assert.Equal(t, "3", replayed["id"])
+	assert.Equal(t, "post.updated", replayed["event"])
+
+	publishPost(bus, domain.EventPostDeleted, 2, "Jonny")
+	publishPost(bus, domain.EventPostDeleted, 1, "Anton")
+
+	live := readServerSentEvent(t, reader)
+	assert.Equal(t, "5", live["id"])
+	assert.Equal(t, "post.deleted", live["event"])
+
+	var data map[string]any
+	assert.NoError(t, json.Unmarshal([]byte(live["data"]), &data))
+	assert.EqualValues(t, 1, data["post_id"])
+	assert.Equal(t, "Anton", data["post"].(map[string]any)["author"])
+}
+
+func Test_StreamEvents_Anonymous_ShouldNotSendDrafts(t *testing.T) {
+	bus := events.NewBus(10)
+	testServer := SetupEventsServer(t, bus)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, testServer.URL+"/v1/api/blog/events", nil)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	bus.Publish(ctx, domain.PostEvent{Type: domain.EventPostCreated, PostID: 1, Post: domain.Post{ID: 1, AuthorID: 1, Draft: true}})
+	publishPost(bus, domain.EventPostCreated, 2, "Anton")
+
+	live := readServerSentEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "2", live["id"])
+}
+
+func Test_StreamEvents_InvalidLastEventId_ShouldReturnBadRequest(t *testing.T) {
+	bus := events.NewBus(10)
+	testServer := SetupEventsServer(t, bus)
+
+	req, _ := http.NewRequest(http.MethodGet, testServer.URL+"/v1/api/blog/events", nil)
+	req.Header.Set("Last-Event-ID", "abc")
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func Test_StreamEventsWebSocket_ShouldFilterByPostId(t *testing.T) {
+	bus := events.NewBus(10)
+	testServer := SetupEventsServer(t, bus)
+
+	publishPost(bus, domain.EventPostCreated, 1, "Anton")
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http") + "/v1/api/blog/events/ws?post_id=2&last_event_id=0"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// The subscription is registered before the upgrade completes.
+	publishPost(bus, domain.EventPostCreated, 1, "Anton")
+	publishPost(bus, domain.EventPostCreated, 2, "Jonny")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var message map[string]any
+	assert.NoError(t, conn.ReadJSON(&message))
+	assert.EqualValues(t, 3, message["id"])
+	assert.Equal(t, "post.created", message["type"])
+	assert.EqualValues(t, 2, message["post_id"])
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/xml"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/openapi"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+// postFieldNames are the fields of posts that can be selected, by their v2 names
+// and in the order they are written.
+var postFieldNames = []string{"id", "author_id", "author", "title", "content", "excerpt", "word_count", "reading_minutes"}
+
+// postFieldColumns are the v1 names of the fields, which are also the CSV columns.
+var postFieldColumns = map[string]string{
+	"id":              "ID",
+	"author_id":       "AuthorID",
+	"author":          "Author",
+	"title":           "Title",
+	"content":         "Content",
+	"excerpt":         "Excerpt",
+	"word_count":      "WordCount",
+	"reading_minutes": "ReadingMinutes",
+}
+
+// postFieldsRequest selects the fields of the returned posts, so that clients
+// that list posts need not download their content.
+type postFieldsRequest struct {
+	Fields string `form:"fields" doc:"Comma-separated fields of posts to return: id, author_id, author, title, content, excerpt, word_count and reading_minutes. All fields of the view are returned without it."`
+}
+
+// postFieldSet is the set of selected fields. It is nil when all fields of the
+// view are returned.
+type postFieldSet map[string]bool
+
+// fieldSet parses the fields parameter. Unknown fields fail the validation of
+// the parameter.
+func (r postFieldsRequest) fieldSet() (postFieldSet, error) {
+	if len(strings.TrimSpace(r.Fields)) == 0 {
+		return nil, nil
+	}
+
+	fields := postFieldSet{}
+	var errs []openapi.FieldError
+	for _, name := range strings.Split(r.Fields, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(postFieldNames, name) {
+			errs = append(errs, openapi.FieldError{
+				Field:   "query.fields",
+				Message: fmt.Sprintf("unknown field %q, available fields are %s", name, strings.Join(postFieldNames, ", ")),
+			})
+			continue
+		}
+		fields[name] = true
+	}
+	if len(errs) > 0 {
+		return nil, &response.ValidationError{Fields: errs}
+	}
+
+	return fields, nil
+}
+
+// postProjection is the v1 representation of a post with the selected fields.
+// Fields that are not selected are nil and left out in every format.
+type postProjection struct {
+	XMLName        xml.Name `json:"-" xml:"Post"`
+	ID             *int64   `json:",omitempty" xml:",omitempty"`
+	AuthorID       *int64   `json:",omitempty" xml:",omitempty"`
+	Author         *string  `json:",omitempty" xml:",omitempty"`
+	Title          *string  `json:",omitempty" xml:",omitempty"`
+	Content        *string  `json:",omitempty" xml:",omitempty"`
+	Excerpt        *string  `json:",omitempty" xml:",omitempty"`
+	WordCount      *int     `json:",omitempty" xml:",omitempty"`
+	ReadingMinutes *int     `json:",omitempty" xml:",omitempty"`
+}
+
+func newPostProjection(post *domain.Post, fields postFieldSet) postProjection {
+	var p postProjection
+	if fields["id"] {
+		p.ID = &post.ID
+	}
+	if fields["author_id"] {
+		p.AuthorID = &post.AuthorID
+	}
+	if fields["author"] {
+		p.Author = &post.Author
+	}
+	if fields["title"] {
+		p.Title = &post.Title
+	}
+	if fields["content"] {
+		p.Content = &post.Content
+	}
+	if fields["excerpt"] {
+		p.Excerpt = &post.Excerpt
+	}
+	if fields["word_count"] {
+		p.WordCount = &post.WordCount
+	}
+	if fields["reading_minutes"] {
+		p.ReadingMinutes = &post.ReadingMinutes
+	}
+	return p
+}
+
+type postProjectionsResponse struct {
+	XMLName xml.Name         `json:"-" xml:"posts"`
+	Posts   []postProjection `json:"posts" xml:"Post"`
+
+	fields postFieldSet
+	posts  []*domain.Post
+}
+
+func newPostProjectionsResponse(posts []*domain.Post, fields postFieldSet) postProjectionsResponse {
+	projections := make([]postProjection, 0, len(posts))
+	for _, post := range posts {
+		projections = append(projections, newPostProjection(post, fields))
+	}
+	return postProjectionsResponse{Posts: projections, fields: fields, posts: posts}
+}
+
+func (p postProjectionsResponse) Header() []string {
+	var header []string
+	for _, name := range postFieldNames {
+		if p.fields[name] {
+			header = append(header, postFieldColumns[name])
+		}
+	}
+	return header
+}
+
+func (p postProjectionsResponse) Rows() [][]string {
+	rows := make([][]string, 0, len(p.posts))
+	for _, post := range p.posts {
+		values := map[string]string{
+			"id":              strconv.FormatInt(post.ID, 10),
+			"author_id":       strconv.FormatInt(post.AuthorID, 10),
+			"author":          post.Author,
+			"title":           post.Title,
+			"content":         post.Content,
+			"excerpt":         post.Excerpt,
+			"word_count":      strconv.Itoa(post.WordCount),
+			"reading_minutes": strconv.Itoa(post.ReadingMinutes),
+		}
+
+		var row []string
+		for _, name := range postFieldNames {
+			if p.fields[name] {
+				row = append(row, values[name])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+// postProjectionModel is the v2 representation of a post with the selected fields.
+type postProjectionModel struct {
+	ID             *int64  `json:"id,omitempty"`
+	AuthorID       *int64  `json:"author_id,omitempty"`
+	Author         *string `json:"author,omitempty"`
+	Title          *string `json:"title,omitempty"`
+	Content        *string `json:"content,omitempty"`
+	Excerpt        *string `json:"excerpt,omitempty"`
+	WordCount      *int    `json:"word_count,omitempty"`
+	ReadingMinutes *int    `json:"reading_minutes,omitempty"`
+}
+
+func newPostProjectionModel(post *domain.Post, fields postFieldSet) postProjectionModel {
+	p := newPostProjection(post, fields)
+	return postProjectionModel{
+		ID:             p.ID,
+		AuthorID:       p.AuthorID,
+		Author:         p.Author,
+		Title:          p.Title,
+		Content:        p.Content,
+		Excerpt:        p.Excerpt,
+		WordCount:      p.WordCount,
+		ReadingMinutes: p.ReadingMinutes,
+	}
+}
+
+// postProjectionEnvelope and postProjectionsEnvelope are the shapes of
+// response.Envelope for posts with selected fields.
+type postProjectionEnvelope struct {
+	Data postProjectionModel `json:"data"`
+}
+
+type postProjectionsEnvelope struct {
+	Data []postProjectionModel `json:"data"`
+	Meta listMeta              `json:"meta"`
+}
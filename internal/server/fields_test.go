@@ -0,0 +1,124 @@
+package server_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var summarizedPosts = []*domain.Post{
+	{ID: 1, AuthorID: 1, Author: "Anton", Title: "First", Content: "one two", Excerpt: "one two", WordCount: 2, ReadingMinutes: 1},
+	{ID: 2, AuthorID: 2, Author: "Jonny", Title: "Second", Content: "three", Excerpt: "three", WordCount: 1, ReadingMinutes: 1},
+}
+
+func Test_GetPost_Fields_ShouldReturnSelectedFields(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.On("GetPost", mock.Anything, int64(1)).Return(summarizedPosts[0], nil)
+
+	expect.GET("/v1/api/blog/posts/1").
+		WithQuery("fields", "title,word_count").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual(`{"Title":"First","WordCount":2}`)
+
+	expect.GET("/v1/api/blog/posts/1").
+		WithQuery("fields", "title").
+		WithHeader("Accept", "application/xml").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual(`<?xml version="1.0" encoding="UTF-8"?>` + "\n" + `<Post><Title>First</Title></Post>`)
+}
+
+func Test_GetPosts_Fields_ShouldReturnSelectedFieldsInOrder(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.On("GetPosts", mock.Anything).Return(summarizedPosts)
+
+	// Fields are written in their own order, whatever the order of the parameter.
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("fields", "title, id,title").
+		WithQuery("view", "summary").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual(`{"posts":[{"ID":1,"Title":"First"},{"ID":2,"Title":"Second"}]}`)
+
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("fields", "author,id").
+		WithHeader("Accept", "text/csv").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("ID,Author\n1,Anton\n2,Jonny\n")
+}
+
+func Test_GetPosts_StreamFields_ShouldReturnSelectedFields(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expectEachPost(blogUseCaseMock, summarizedPosts, nil)
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "ndjson").
+		WithQuery("fields", "id,reading_minutes").
+		Expect().
+		Status(http.StatusOK)
+
+	lines := strings.Split(strings.TrimSuffix(resp.Body().Raw(), "\n"), "\n")
+	assert.Equal(t, []string{`{"ID":1,"ReadingMinutes":1}`, `{"ID":2,"ReadingMinutes":1}`}, lines)
+}
+
+func Test_GetPosts_UnknownField_ShouldReturnBadRequest(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	for _, path := range []string{"/v1/api/blog/posts", "/v1/api/blog/posts/1"} {
+		fields := expect.GET(path).
+			WithQuery("fields", "title,Content,secret").
+			Expect().
+			Status(http.StatusBadRequest).
+			JSON().Object().Value("fields").Array()
+
+		fields.Length().IsEqual(2)
+		fields.Value(0).Object().
+			HasValue("field", "query.fields").
+			Value("message").String().HasPrefix(`unknown field "Content"`)
+	}
+
+	blogUseCaseMock.AssertNotCalled(t, "GetPosts", mock.Anything)
+	blogUseCaseMock.AssertNotCalled(t, "GetPost", mock.Anything, mock.Anything)
+}
+
+func Test_V2_GetPosts_Fields_ShouldReturnSelectedFields(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.On("GetPosts", mock.Anything).Return(summarizedPosts)
+
+	expect.GET("/v2/api/blog/posts").
+		WithQuery("fields", "id,author_id").
+		WithQuery("limit", 1).
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual(`{"data":[{"id":1,"author_id":1}],"meta":{"count":1,"total":2,"next_after":1}}`)
+}
+
+func Test_V2_GetPost_UnknownField_ShouldReturnErrorEnvelope(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.GET("/v2/api/blog/posts/1").
+		WithQuery("fields", "secret").
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().Object().Value("errors").Array().Value(0).Object().
+		HasValue("field", "query.fields")
+
+	blogUseCaseMock.AssertNotCalled(t, "GetPost", mock.Anything, mock.Anything)
+}
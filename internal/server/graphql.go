@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/gql"
+)
+
+type GraphQLConfig struct {
+	Limits gql.Limits
+	// GraphiQLAssets is a directory with the files of graphiQLAssets. The GraphiQL
+	// IDE is served at GET /graphql only when it is set, it is meant for development.
+	GraphiQLAssets string
+}
+
+// graphiQLAssets are the files the GraphiQL page loads, by the pinned package
+// versions they are taken from. They are served from the own origin, so that
+// the page runs no code of a CDN.
+var graphiQLAssets = map[string]string{
+	"graphiql.min.css":            "graphiql@3.7.1/graphiql.min.css",
+	"graphiql.min.js":             "graphiql@3.7.1/graphiql.min.js",
+	"react.production.min.js":     "react@18.3.1/umd/react.production.min.js",
+	"react-dom.production.min.js": "react-dom@18.3.1/umd/react-dom.production.min.js",
+}
+
+// graphiQLPolicy keeps the page from loading anything from other origins.
+const graphiQLPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data:"
+
+type GraphQLController struct {
+	Executor *gql.Executor
+}
+
+// Query executes a GraphQL request. Errors of the request are reported in the
+// GraphQL response with status 200, only malformed HTTP requests are rejected.
+func (ctr *GraphQLController) Query(c *gin.Context) {
+	var reqModel gql.Request
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ctr.Executor.Execute(c.Request.Context(), reqModel))
+}
+
+func RegisterGraphQL(r *gin.Engine, blogUseCase IBlogUseCase, cfg GraphQLConfig) error {
+	executor, err := gql.NewExecutor(blogUseCase, cfg.Limits)
+	if err != nil {
+		return err
+	}
+	s := GraphQLController{Executor: executor}
+
+	r.POST("/graphql", s.Query)
+	if len(cfg.GraphiQLAssets) > 0 {
+		if err := registerGraphiQL(r, cfg.GraphiQLAssets); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func registerGraphiQL(r *gin.Engine, assetsDir string) error {
+	for name, pkg := range graphiQLAssets {
+		path := filepath.Join(assetsDir, name)
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("GraphiQL asset %s of %s is missing: %w", name, pkg, err)
+		}
+		r.StaticFile("/graphql/assets/"+name, path)
+	}
+
+	r.GET("/graphql", func(c *gin.Context) {
+		c.Header("Content-Security-Policy", graphiQLPolicy)
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(graphiQLPage))
+	})
+	return nil
+}
+
+const graphiQLPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <title>Blog GraphiQL</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="/graphql/assets/graphiql.min.css" />
+  <script src="/graphql/assets/react.production.min.js"></script>
+  <script src="/graphql/assets/react-dom.production.min.js"></script>
+  <script src="/graphql/assets/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql"></div>
+  <script>
+    const fetcher = GraphiQL.createFetcher({ url: "/graphql" });
+    ReactDOM.createRoot(document.getElementById("graphiql")).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,97 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/gql"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupGraphQLServer(t *testing.T, useCase *mocks.IBlogUseCase, graphiQLAssets string) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+
+	if err := server.RegisterGraphQL(ginRouter, useCase, server.GraphQLConfig{Limits: gql.DefaultLimits, GraphiQLAssets: graphiQLAssets}); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(ginRouter)
+	expect := httpexpect.Default(t, server.URL)
+
+	return expect
+}
+
+func Test_GraphQL_Query_ShouldReturnData(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupGraphQLServer(t, blogUseCaseMock, "")
+
+	blogUseCaseMock.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(&domain.Post{ID: 1, Author: "Anton", Title: "Big post", Content: "something"}, nil)
+
+	expect.POST("/graphql").
+		WithJSON(map[string]any{"query": `query Post($id: ID!) { post(id: $id) { title author } }`, "variables": map[string]any{"id": "1"}}).
+		Expect().
+		Status(http.StatusOK).
+		JSON().IsEqual(map[string]any{"data": map[string]any{"post": map[string]any{"title": "Big post", "author": "Anton"}}})
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_GraphQL_MissingQuery_ShouldReturnBadRequest(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupGraphQLServer(t, blogUseCaseMock, "")
+
+	expect.POST("/graphql").
+		WithJSON(map[string]any{"variables": map[string]any{}}).
+		Expect().
+		Status(http.StatusBadRequest)
+}
+
+// graphiQLAssetsDir writes stand-ins of the GraphiQL assets.
+func graphiQLAssetsDir(t *testing.T) string {
+	dir := t.TempDir()
+	for _, name := range []string{"graphiql.min.css", "graphiql.min.js", "react.production.min.js", "react-dom.production.min.js"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("/* "+name+" */"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func Test_GraphQL_GraphiQL_ShouldBeServedOnlyWhenEnabled(t *testing.T) {
+	expect := SetupGraphQLServer(t, new(mocks.IBlogUseCase), graphiQLAssetsDir(t))
+	page := expect.GET("/graphql").
+		Expect().
+		Status(http.StatusOK)
+	page.ContentType("text/html")
+	page.Header("Content-Security-Policy").Contains("default-src 'self'")
+	page.Body().NotContains("https://")
+	expect.GET("/graphql/assets/graphiql.min.js").
+		Expect().
+		Status(http.StatusOK).
+		Body().IsEqual("/* graphiql.min.js */")
+
+	expect = SetupGraphQLServer(t, new(mocks.IBlogUseCase), "")
+	expect.GET("/graphql").
+		Expect().
+		Status(http.StatusNotFound)
+}
+
+func Test_GraphQL_GraphiQLAssetMissing_ShouldFail(t *testing.T) {
+	dir := graphiQLAssetsDir(t)
+	os.Remove(filepath.Join(dir, "graphiql.min.js"))
+
+	err := server.RegisterGraphQL(gin.New(), new(mocks.IBlogUseCase), server.GraphQLConfig{Limits: gql.DefaultLimits, GraphiQLAssets: dir})
+	assert.ErrorContains(t, err, "graphiql.min.js")
+}
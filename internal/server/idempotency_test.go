@@ -0,0 +1,191 @@
+package server_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/idempotency"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupServerWithIdempotency(t *testing.T, useCase *mocks.IBlogUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	if err := server.SetupTrustedProxies(ginRouter, nil); err != nil {
+		t.Fatal(err)
+	}
+	server.SetupMiddleware(ginRouter)
+	server.SetupIdempotency(ginRouter, idempotency.DefaultConfig())
+
+	server.RegisterHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+
+	return httpexpect.Default(t, server.URL)
+}
+
+var idempotentPost = domain.Post{Author: "Anton", Title: "Big post", Content: "something"}
+
+func Test_CreatePost_RetriedWithIdempotencyKey_ShouldReplayFirstResponse(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithIdempotency(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Return(int64(1), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusCreated).
+		Body().IsEqual("{\"Id\":1}")
+
+	resp := expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect()
+	resp.Status(http.StatusCreated).
+		Body().IsEqual("{\"Id\":1}")
+	resp.Header("Idempotent-Replayed").IsEqual("true")
+	resp.Header("Content-Type").IsEqual("application/json; charset=utf-8")
+
+	blogUseCaseMock.AssertNumberOfCalls(t, "CreatePost", 1)
+}
+
+func Test_CreatePost_RetriedWithSpoofedForwardedFor_ShouldReplayFirstResponse(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithIdempotency(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Return(int64(1), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithHeader("X-Forwarded-For", "203.0.113.1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusCreated)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithHeader("X-Forwarded-For", "203.0.113.2").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusCreated).
+		Header("Idempotent-Replayed").IsEqual("true")
+
+	blogUseCaseMock.AssertNumberOfCalls(t, "CreatePost", 1)
+}
+
+func Test_CreatePost_IdempotencyKeyReusedForOtherPayload_ShouldReturnUnprocessableEntity(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithIdempotency(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Return(int64(1), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusCreated)
+
+	other := idempotentPost
+	other.Title = "Other post"
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(other).
+		Expect().
+		Status(http.StatusUnprocessableEntity)
+
+	blogUseCaseMock.AssertNumberOfCalls(t, "CreatePost", 1)
+}
+
+func Test_CreatePost_FailedWithIdempotencyKey_ShouldBeRetried(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithIdempotency(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Return(int64(0), errors.New("DB error"))
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Return(int64(2), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusInternalServerError)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusCreated).
+		Body().IsEqual("{\"Id\":2}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_CreatePost_PanickedWithIdempotencyKey_ShouldBeRetried(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithIdempotency(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Panic("DB driver bug")
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Once().
+		Return(int64(2), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusInternalServerError)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Idempotency-Key", "key-1").
+		WithJSON(idempotentPost).
+		Expect().
+		Status(http.StatusCreated).
+		Body().IsEqual("{\"Id\":2}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_CreatePost_WithoutIdempotencyKey_ShouldCreateEveryTime(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithIdempotency(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &idempotentPost).
+		Return(int64(1), nil)
+
+	for i := 0; i < 2; i++ {
+		expect.POST("/v1/api/blog/posts").
+			WithJSON(idempotentPost).
+			Expect().
+			Status(http.StatusCreated)
+	}
+
+	blogUseCaseMock.AssertNumberOfCalls(t, "CreatePost", 2)
+}
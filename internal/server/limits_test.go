@@ -0,0 +1,230 @@
+package server_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupServerWithLimits(t *testing.T, useCase *mocks.IBlogUseCase, cfg server.LimitsConfig) *httpexpect.Expect {
+	return SetupServerWithLimitsBehindProxies(t, useCase, cfg, nil)
+}
+
+func SetupServerWithLimitsBehindProxies(t *testing.T, useCase *mocks.IBlogUseCase, cfg server.LimitsConfig, proxies []string) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	if err := server.SetupTrustedProxies(ginRouter, proxies); err != nil {
+		t.Fatal(err)
+	}
+	server.SetupMiddleware(ginRouter)
+	server.SetupLimits(ginRouter, cfg)
+
+	server.RegisterHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	expect := httpexpect.Default(t, server.URL)
+
+	return expect
+}
+
+var testLimits = server.LimitsConfig{
+	ReadRate:     1,
+	ReadBurst:    2,
+	WriteRate:    1,
+	WriteBurst:   1,
+	MaxBodyBytes: 64,
+}
+
+func Test_RateLimit_TooManyWrites_ShouldReturnTooManyRequests(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithLimits(t, blogUseCaseMock, testLimits)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Once().
+		Return(nil)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		Expect().
+		Status(http.StatusTooManyRequests).
+		Header("Retry-After").IsEqual("1")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RateLimit_ReadsAndWrites_ShouldHaveSeparateBudgets(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithLimits(t, blogUseCaseMock, testLimits)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Return(nil)
+	blogUseCaseMock.
+		On("GetPosts", mock.Anything).
+		Return([]*domain.Post{})
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.GET("/v1/api/blog/posts").
+		Expect().
+		Status(http.StatusOK)
+	expect.GET("/v1/api/blog/posts").
+		Expect().
+		Status(http.StatusOK)
+	expect.GET("/v1/api/blog/posts").
+		Expect().
+		Status(http.StatusTooManyRequests).
+		Body().IsEqual("{\"error\":\"Too many requests\"}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RateLimit_ApiKeys_ShouldHaveSeparateBudgets(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	cfg := testLimits
+	cfg.APIKeys = []string{"client-1", "client-2"}
+	expect := SetupServerWithLimits(t, blogUseCaseMock, cfg)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Twice().
+		Return(nil)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-API-Key", "client-1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-API-Key", "client-2").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-API-Key", "client-1").
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RateLimit_UnknownApiKeys_ShouldShareBudgetOfIP(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	cfg := testLimits
+	cfg.APIKeys = []string{"client-1"}
+	expect := SetupServerWithLimits(t, blogUseCaseMock, cfg)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Once().
+		Return(nil)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-API-Key", "made-up-1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-API-Key", "made-up-2").
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RateLimit_SpoofedForwardedFor_ShouldBeLimitedByPeer(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithLimits(t, blogUseCaseMock, testLimits)
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Once().
+		Return(nil)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-Forwarded-For", "203.0.113.1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	for _, ip := range []string{"203.0.113.2", "203.0.113.3", "203.0.113.4"} {
+		expect.DELETE("/v1/api/blog/posts/1").
+			WithHeader("X-Forwarded-For", ip).
+			Expect().
+			Status(http.StatusTooManyRequests)
+	}
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RateLimit_TrustedProxy_ShouldLimitByForwardedFor(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithLimitsBehindProxies(t, blogUseCaseMock, testLimits, []string{"127.0.0.1", "::1"})
+
+	blogUseCaseMock.
+		On("DeletePost", mock.Anything, int64(1)).
+		Twice().
+		Return(nil)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-Forwarded-For", "203.0.113.1").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-Forwarded-For", "203.0.113.2").
+		Expect().
+		Status(http.StatusNoContent)
+
+	expect.DELETE("/v1/api/blog/posts/1").
+		WithHeader("X-Forwarded-For", "203.0.113.1").
+		Expect().
+		Status(http.StatusTooManyRequests)
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_MaxBodySize_ContentLengthTooLarge_ShouldReturnRequestEntityTooLarge(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithLimits(t, blogUseCaseMock, testLimits)
+
+	expect.POST("/v1/api/blog/posts").
+		WithJSON(domain.Post{Author: "Anton", Title: "Big post", Content: strings.Repeat("a", 100)}).
+		Expect().
+		Status(http.StatusRequestEntityTooLarge).
+		Body().IsEqual("{\"error\":\"Request body is too large\"}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_MaxBodySize_ChunkedBodyTooLarge_ShouldReturnRequestEntityTooLarge(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServerWithLimits(t, blogUseCaseMock, testLimits)
+
+	body := `{"author":"Anton","title":"Big post","content":"` + strings.Repeat("a", 100) + `"}`
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Content-Type", "application/json").
+		WithChunked(io.NopCloser(strings.NewReader(body))).
+		Expect().
+		Status(http.StatusRequestEntityTooLarge)
+
+	blogUseCaseMock.AssertExpectations(t)
+}
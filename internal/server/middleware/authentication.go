@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+type IAuthenticator interface {
+	Authenticate(ctx context.Context, accessToken string) (*domain.User, error)
+}
+
+// AuthenticationMiddleware puts the user of the bearer token into the request context.
+// Requests without the Authorization header stay anonymous, requests with an invalid
+// token are rejected.
+func AuthenticationMiddleware(authenticator IAuthenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if len(header) == 0 {
+			c.Next()
+			return
+		}
+
+		token, isBearer := auth.BearerToken(header)
+		if !isBearer {
+			c.Error(response.SetHttpStatusCodeWithHeaders(auth.ErrorInvalidAuthorization, http.StatusUnauthorized, bearerChallenge))
+			c.Abort()
+			return
+		}
+
+		user, err := authenticator.Authenticate(c.Request.Context(), token)
+		if err != nil {
+			c.Error(err)
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(auth.WithUser(c.Request.Context(), user))
+		c.Next()
+	}
+}
+
+var bearerChallenge = map[string]string{"WWW-Authenticate": "Bearer"}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/kondrushin/blog/internal/server/negotiation"
+)
+
+// contentCodings are the supported values of Content-Encoding in the order the
+// server prefers them when the client accepts several equally.
+var contentCodings = []string{"zstd", "br", "gzip"}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encoders pools the encoders of every content coding, they are expensive to create.
+var encoders = map[string]*sync.Pool{
+	"zstd": {New: func() any {
+		e, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1), zstd.WithLowerEncoderMem(true))
+		return e
+	}},
+	"br":   {New: func() any { return brotli.NewWriterLevel(nil, 4) }},
+	"gzip": {New: func() any { return gzip.NewWriter(nil) }},
+}
+
+// CompressionMiddleware compresses responses of at least minBytes with the content
+// coding the Accept-Encoding header prefers among zstd, br and gzip. Smaller
+// responses, responses to HEAD, partial content and media that is compressed
+// already are sent as they are. A response that is flushed is compressed from
+// then on whatever its size, so that streamed responses are compressed too.
+func CompressionMiddleware(minBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+
+		coding, isIn := negotiation.Encoding(c.GetHeader("Accept-Encoding"), contentCodings)
+		if !isIn || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, coding: coding, minBytes: minBytes}
+		c.Writer = w
+		defer func() {
+			w.close()
+			c.Writer = w.ResponseWriter
+		}()
+
+		c.Next()
+	}
+}
+
+// compressWriter holds the body back until it has minBytes, or until it is
+// flushed or complete, and then decides whether to compress it. The status is not
+// held back, gin sends it with the first byte of the body.
+type compressWriter struct {
+	gin.ResponseWriter
+	coding   string
+	minBytes int
+
+	buf     []byte
+	decided bool
+	encoder encoder
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.encoder != nil {
+			return w.encoder.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buf = append(w.buf, data...)
+	if len(w.buf) >= w.minBytes {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.decide(false)
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Written() bool {
+	return w.ResponseWriter.Written() || len(w.buf) > 0
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if w.encoder != nil {
+		w.encoder.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// decide starts the compression when the body is large enough and compressible,
+// and writes the body held back so far.
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+
+	header := w.Header()
+	if len(header.Get("Content-Type")) == 0 && len(w.buf) > 0 {
+		// net/http would sniff the type from the compressed body.
+		header.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	var dst io.Writer = w.ResponseWriter
+	if large && w.compressible() {
+		header.Set("Content-Encoding", w.coding)
+		header.Del("Content-Length")
+		w.encoder = encoders[w.coding].Get().(encoder)
+		w.encoder.Reset(w.ResponseWriter)
+		dst = w.encoder
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	_, err := dst.Write(buf)
+	return err
+}
+
+func (w *compressWriter) compressible() bool {
+	switch status := w.Status(); {
+	case status < http.StatusOK, status == http.StatusNoContent, status == http.StatusPartialContent, status == http.StatusNotModified:
+		return false
+	}
+
+	header := w.Header()
+	if len(header.Get("Content-Encoding")) > 0 || len(header.Get("Content-Range")) > 0 {
+		return false
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(header.Get("Content-Type"))
+	switch {
+	case mediaType == "image/svg+xml":
+		return true
+	case mediaType == "text/event-stream",
+		strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "audio/"),
+		strings.HasPrefix(mediaType, "video/"),
+		mediaType == "application/zip",
+		mediaType == "application/gzip",
+		mediaType == "application/zstd":
+		return false
+	}
+	return true
+}
+
+// close writes a body that stayed below minBytes as it is, or ends the compressed one.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if len(w.buf) == 0 {
+			// Nothing was written, an error may still be answered.
+			return
+		}
+		w.decide(false)
+	}
+
+	if w.encoder != nil {
+		w.encoder.Close()
+		w.encoder.Reset(nil)
+		encoders[w.coding].Put(w.encoder)
+		w.encoder = nil
+	}
+}
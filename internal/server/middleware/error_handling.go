@@ -7,7 +7,9 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/openapi"
 	"github.com/kondrushin/blog/internal/server/response"
+	"github.com/kondrushin/blog/internal/server/versioning"
 )
 
 func HttpErrorHandlerMiddleware() gin.HandlerFunc {
@@ -18,17 +20,41 @@ func HttpErrorHandlerMiddleware() gin.HandlerFunc {
 		for _, err := range c.Errors {
 
 			var errorWithCode *response.HttpError
-			if errors.As(err, &errorWithCode) {
-				errInfo = errorInfo{code: errorWithCode.StatusCode, message: err.Error()}
-			} else if errors.Is(err, domain.ErrorPostNotFound) {
+			var validationErr *response.ValidationError
+			if errors.As(err, &validationErr) {
+				errInfo = errorInfo{code: http.StatusBadRequest, message: err.Error(), fields: validationErr.Fields}
+			} else if errors.As(err, &errorWithCode) {
+				errInfo = errorInfo{code: errorWithCode.StatusCode, message: err.Error(), headers: errorWithCode.Headers}
+			} else if isNotFound(err) {
 				errInfo = errorInfo{code: http.StatusNotFound, message: err.Error()}
+			} else if errors.Is(err, domain.ErrorInvalidInput) {
+				errInfo = errorInfo{code: http.StatusBadRequest, message: err.Error()}
+			} else if errors.Is(err, domain.ErrorConflict) {
+				errInfo = errorInfo{code: http.StatusConflict, message: err.Error()}
+			} else if errors.Is(err, domain.ErrorUnauthorized) {
+				errInfo = errorInfo{code: http.StatusUnauthorized, message: err.Error(), headers: bearerChallenge}
+			} else if errors.Is(err, domain.ErrorForbidden) {
+				errInfo = errorInfo{code: http.StatusForbidden, message: err.Error()}
+			} else if errors.Is(err, domain.ErrorAccountLocked) {
+				errInfo = errorInfo{code: http.StatusLocked, message: err.Error()}
+			} else if errors.Is(err, domain.ErrorAttachmentTooLarge) {
+				errInfo = errorInfo{code: http.StatusRequestEntityTooLarge, message: err.Error()}
+			} else if errors.Is(err, domain.ErrorUnsupportedAttachment) {
+				errInfo = errorInfo{code: http.StatusUnsupportedMediaType, message: err.Error()}
 			} else {
 				errInfo = errorInfo{code: http.StatusInternalServerError, message: err.Error()}
 			}
 		}
 
 		if errInfo.code != 0 {
-			c.JSON(errInfo.code, gin.H{"error": errInfo.message})
+			for name, value := range errInfo.headers {
+				c.Header(name, value)
+			}
+			if usesEnvelope(c) {
+				c.JSON(errInfo.code, response.NewErrorEnvelope(errInfo.code, errInfo.message, errInfo.fields))
+			} else {
+				c.JSON(errInfo.code, response.ErrorBody{Error: errInfo.message, Fields: errInfo.fields})
+			}
 		}
 	}
 }
@@ -36,4 +62,21 @@ func HttpErrorHandlerMiddleware() gin.HandlerFunc {
 type errorInfo struct {
 	code    int
 	message string
+	headers map[string]string
+	fields  []openapi.FieldError
+}
+
+// usesEnvelope reports whether errors are wrapped in the response envelope of the requested version.
+func usesEnvelope(c *gin.Context) bool {
+	version, isIn := versioning.FromContext(c)
+	return isIn && version.Envelope
+}
+
+func isNotFound(err error) bool {
+	return errors.Is(err, domain.ErrorPostNotFound) ||
+		errors.Is(err, domain.ErrorAuthorNotFound) ||
+		errors.Is(err, domain.ErrorUserNotFound) ||
+		errors.Is(err, domain.ErrorWebhookNotFound) ||
+		errors.Is(err, domain.ErrorDeliveryNotFound) ||
+		errors.Is(err, domain.ErrorAttachmentNotFound)
 }
@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/idempotency"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+var (
+	ErrorInvalidIdempotencyKey = errors.New("Idempotency-Key must be 1 to 255 printable ASCII characters")
+	ErrorIdempotencyKeyReused  = errors.New("Idempotency-Key was already used for a different request")
+	ErrorIdempotencyKeyInUse   = errors.New("A request with this Idempotency-Key is still in progress")
+	ErrorIdempotencyKeysFull   = errors.New("Too many requests with an Idempotency-Key are in progress")
+)
+
+// replayedHeaders are the response headers that are recorded and replayed with the body.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// IdempotencyMiddleware replays the first response to a POST request with an
+// Idempotency-Key to retries of the request. Keys are scoped to the user, or to
+// the client when anonymous. A key reused for a request with another method, path
+// or body is rejected with 422. Failed requests are not recorded, so that they
+// can be retried with the same key; this includes requests whose handler panics.
+func IdempotencyMiddleware(store *idempotency.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || len(key) == 0 {
+			c.Next()
+			return
+		}
+
+		if !isValidIdempotencyKey(key) {
+			c.Error(response.SetHttpStatusCode(ErrorInvalidIdempotencyKey, http.StatusBadRequest))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				err = response.SetHttpStatusCode(ErrorBodyTooLarge, http.StatusRequestEntityTooLarge)
+			}
+			c.Error(err)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := idempotencyScope(c)
+		outcome, recorded := store.Begin(scope, key, fingerprint(c.Request, body))
+		switch outcome {
+		case idempotency.Mismatched:
+			c.Error(response.SetHttpStatusCode(ErrorIdempotencyKeyReused, http.StatusUnprocessableEntity))
+			c.Abort()
+			return
+		case idempotency.InProgress:
+			c.Error(response.SetHttpStatusCode(ErrorIdempotencyKeyInUse, http.StatusConflict))
+			c.Abort()
+			return
+		case idempotency.Full:
+			c.Error(response.SetHttpStatusCode(ErrorIdempotencyKeysFull, http.StatusTooManyRequests))
+			c.Abort()
+			return
+		case idempotency.Replayed:
+			for name, values := range recorded.Header {
+				for _, value := range values {
+					c.Writer.Header().Add(name, value)
+				}
+			}
+			c.Header(IdempotentReplayedHeader, "true")
+			c.Writer.WriteHeader(recorded.Status)
+			c.Writer.Write(recorded.Body)
+			c.Abort()
+			return
+		}
+
+		completed := false
+		defer func() {
+			if !completed {
+				store.Release(scope, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		if len(c.Errors) > 0 || recorder.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); len(value) > 0 {
+				header.Set(name, value)
+			}
+		}
+		store.Complete(scope, key, &idempotency.Response{Status: recorder.Status(), Header: header, Body: recorder.body.Bytes()})
+		completed = true
+	}
+}
+
+func isValidIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// idempotencyScope is the user, else the API key, else the client IP, which is
+// only taken from X-Forwarded-For when the peer is a trusted proxy.
+func idempotencyScope(c *gin.Context) string {
+	if user, isIn := auth.UserFromContext(c.Request.Context()); isIn {
+		return "user:" + strconv.FormatInt(user.ID, 10)
+	}
+	// Unlike the rate limit, any API key scopes the keys, since a made-up one
+	// only separates the keys of the client.
+	if apiKey := c.GetHeader(ApiKeyHeader); len(apiKey) > 0 {
+		return "key:" + apiKey
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder keeps a copy of the response body.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.body.Write(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.body.WriteString(s)
+	return r.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/ratelimit"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+const ApiKeyHeader = "X-API-Key"
+
+var (
+	ErrorTooManyRequests = errors.New("Too many requests")
+	ErrorBodyTooLarge    = errors.New("Request body is too large")
+)
+
+// RateLimitMiddleware limits requests per client, identified by the API key header
+// when the key is one of apiKeys or by the client IP otherwise, so that clients
+// cannot get fresh budgets by making keys up. Reads (GET, HEAD, OPTIONS) and
+// writes use separate budgets.
+func RateLimitMiddleware(reads *ratelimit.Limiter, writes *ratelimit.Limiter, apiKeys []string) gin.HandlerFunc {
+	known := make(map[string]bool, len(apiKeys))
+	for _, key := range apiKeys {
+		known[key] = true
+	}
+
+	return func(c *gin.Context) {
+		limiter := writes
+		if isReadMethod(c.Request.Method) {
+			limiter = reads
+		}
+
+		allowed, retryAfter := limiter.Allow(clientKey(c, known))
+		if !allowed {
+			seconds := strconv.Itoa(int(math.Ceil(retryAfter.Seconds())))
+			c.Error(response.SetHttpStatusCodeWithHeaders(ErrorTooManyRequests, http.StatusTooManyRequests, map[string]string{"Retry-After": seconds}))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// MaxBodySizeMiddleware rejects requests with a declared body larger than maxBytes,
+// or maxUploadBytes for multipart uploads, and caps the body reader for requests
+// without Content-Length.
+func MaxBodySizeMiddleware(maxBytes int64, maxUploadBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		maxBytes := maxBytes
+		if isMultipart(c) {
+			maxBytes = max(maxBytes, maxUploadBytes)
+		}
+
+		if c.Request.ContentLength > maxBytes {
+			c.Error(response.SetHttpStatusCode(ErrorBodyTooLarge, http.StatusRequestEntityTooLarge))
+			c.Abort()
+			return
+		}
+
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+		c.Next()
+	}
+}
+
+func clientKey(c *gin.Context, apiKeys map[string]bool) string {
+	if apiKey := c.GetHeader(ApiKeyHeader); apiKeys[apiKey] {
+		return "key:" + apiKey
+	}
+
+	return "ip:" + c.ClientIP()
+}
+
+func isReadMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+// TraceResponseHeaderMiddleware writes the trace context of the current request span
+// (traceparent) to the response headers so clients can correlate their calls.
+// It must be registered after the span has been started.
+func TraceResponseHeaderMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		otel.GetTextMapPropagator().Inject(c.Request.Context(), propagation.HeaderCarrier(c.Writer.Header()))
+		c.Next()
+	}
+}
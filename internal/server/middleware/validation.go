@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/server/openapi"
+	"github.com/kondrushin/blog/internal/server/response"
+	"github.com/kondrushin/blog/internal/server/versioning"
+)
+
+// RequestValidationMiddleware validates path, query and JSON body of documented
+// routes against the API contract before they reach the handlers. Multipart
+// bodies are left to the handlers, which stream the uploads. Requests to
+// unversioned paths are validated against the negotiated version. The validator
+// is obtained lazily, so that the contract can be generated once all routes are registered.
+func RequestValidationMiddleware(validator func() *openapi.Validator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if len(c.FullPath()) == 0 {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if !isMultipart(c) {
+			var err error
+			if body, err = readBody(c); err != nil {
+				c.Error(err)
+				c.Abort()
+				return
+			}
+		}
+
+		pathParams := map[string]string{}
+		for _, p := range c.Params {
+			pathParams[p.Key] = p.Value
+		}
+
+		fieldErrors := validator().Validate(openapi.Request{
+			Method:      c.Request.Method,
+			Path:        openapi.PathTemplate(versioning.FullPath(c)),
+			PathParams:  pathParams,
+			Query:       c.Request.URL.Query(),
+			Body:        body,
+			ContentType: c.GetHeader("Content-Type"),
+		})
+		if len(fieldErrors) > 0 {
+			c.Error(&response.ValidationError{Fields: fieldErrors})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isMultipart(c *gin.Context) bool {
+	mediaType, _, _ := mime.ParseMediaType(c.GetHeader("Content-Type"))
+	return strings.HasPrefix(mediaType, "multipart/")
+}
+
+// readBody reads the request body and puts it back for the handlers.
+func readBody(c *gin.Context) ([]byte, error) {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody {
+		return nil, nil
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return nil, response.SetHttpStatusCode(ErrorBodyTooLarge, http.StatusRequestEntityTooLarge)
+		}
+		return nil, response.SetHttpStatusCode(err, http.StatusBadRequest)
+	}
+
+	c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
@@ -61,6 +61,24 @@ func (_m *IBlogUseCase) DeletePost(ctx context.Context, id int64) error {
 	return r0
 }
 
+// EachPost provides a mock function with given fields: ctx, yield
+func (_m *IBlogUseCase) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	ret := _m.Called(ctx, yield)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EachPost")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(*domain.Post) bool) error); ok {
+		r0 = rf(ctx, yield)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // GetPost provides a mock function with given fields: ctx, id
 func (_m *IBlogUseCase) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
 	ret := _m.Called(ctx, id)
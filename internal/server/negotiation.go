@@ -0,0 +1,82 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/kondrushin/blog/internal/server/middleware"
+	"github.com/kondrushin/blog/internal/server/negotiation"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+var (
+	// postFormats are the response formats of a post, postListFormats those of a list of posts.
+	postFormats     = []negotiation.Format{negotiation.JSON, negotiation.XML, negotiation.YAML, negotiation.MsgPack}
+	postListFormats = []negotiation.Format{negotiation.JSON, negotiation.XML, negotiation.YAML, negotiation.MsgPack, negotiation.CSV}
+)
+
+// negotiate returns the format of the Accept header among the offered ones.
+// It is called before a handler has any effect, so that a request that cannot
+// be answered changes nothing.
+func negotiate(c *gin.Context, offered []negotiation.Format) (negotiation.Format, error) {
+	c.Writer.Header().Add("Vary", "Accept")
+
+	format, err := negotiation.Negotiate(c.GetHeader("Accept"), offered)
+	if err != nil {
+		err = fmt.Errorf("%w, available types are %s", err, strings.Join(negotiation.MediaTypes(offered), ", "))
+		return format, response.SetHttpStatusCode(err, http.StatusNotAcceptable)
+	}
+
+	return format, nil
+}
+
+// render writes the body in the negotiated format.
+func render(c *gin.Context, format negotiation.Format, status int, body any) {
+	if format == negotiation.JSON {
+		c.JSON(status, body)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := negotiation.Encode(&buf, format, body); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Data(status, format.ContentType(), buf.Bytes())
+}
+
+// readBody decodes and validates the request body in the format of its Content-Type.
+func readBody(c *gin.Context, dst any) error {
+	format, err := negotiation.BodyFormat(c.GetHeader("Content-Type"))
+	if err != nil {
+		return response.SetHttpStatusCode(err, http.StatusUnsupportedMediaType)
+	}
+	if format == negotiation.JSON {
+		return readJSON(c, dst)
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return response.SetHttpStatusCode(middleware.ErrorBodyTooLarge, http.StatusRequestEntityTooLarge)
+		}
+		return response.SetHttpStatusCode(err, http.StatusBadRequest)
+	}
+
+	if err := negotiation.Decode(format, body, dst); err != nil {
+		return response.SetHttpStatusCode(err, http.StatusBadRequest)
+	}
+	if err := binding.Validator.ValidateStruct(dst); err != nil {
+		return response.SetHttpStatusCode(err, http.StatusBadRequest)
+	}
+
+	return nil
+}
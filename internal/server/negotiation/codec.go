@@ -0,0 +1,278 @@
+package negotiation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/ugorji/go/codec"
+	"gopkg.in/yaml.v3"
+)
+
+// Table is a value that can be written as CSV.
+type Table interface {
+	Header() []string
+	Rows() [][]string
+}
+
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{WriteExt: true}
+	h.RawToString = true
+	h.MapType = reflect.TypeOf(map[string]any(nil))
+	return h
+}()
+
+// Encode writes the value in the format.
+func Encode(w io.Writer, f Format, v any) error {
+	switch f {
+	case JSON:
+		return json.NewEncoder(w).Encode(v)
+	case XML:
+		if _, err := io.WriteString(w, xml.Header); err != nil {
+			return err
+		}
+		return xml.NewEncoder(w).Encode(v)
+	case YAML:
+		return encodeYAML(w, v)
+	case MsgPack:
+		return codec.NewEncoder(w, msgpackHandle).Encode(v)
+	case CSV:
+		table, ok := v.(Table)
+		if !ok {
+			return fmt.Errorf("negotiation: %T cannot be written as CSV", v)
+		}
+		return encodeCSV(w, table)
+	default:
+		return fmt.Errorf("negotiation: unknown format %d", f)
+	}
+}
+
+// encodeYAML writes the JSON representation of the value as YAML, so that both
+// have the same field names in the same order.
+func encodeYAML(w io.Writer, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return err
+	}
+	resetStyle(&node)
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// resetStyle turns the flow style of JSON into the block style of YAML. Strings
+// are quoted only where YAML would read them as another type.
+func resetStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetStyle(child)
+	}
+}
+
+func encodeCSV(w io.Writer, table Table) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(table.Header()); err != nil {
+		return err
+	}
+	for _, row := range table.Rows() {
+		cells := make([]string, len(row))
+		for i, cell := range row {
+			cells[i] = escapeFormula(cell)
+		}
+		if err := writer.Write(cells); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// escapeFormula prefixes cells that spreadsheets would run as formulas with a
+// quote, so that they are shown as text.
+func escapeFormula(cell string) string {
+	if len(cell) > 0 && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// Decode decodes the body in the format into dst.
+func Decode(f Format, body []byte, dst any) error {
+	switch f {
+	case JSON:
+		return json.Unmarshal(body, dst)
+	case XML:
+		return xml.Unmarshal(body, dst)
+	case YAML, MsgPack:
+		value, err := DecodeValue(f, body)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(data, dst)
+	default:
+		return ErrorUnsupportedMediaType
+	}
+}
+
+// DecodeValue decodes the body into the values encoding/json decodes into an
+// empty interface, with numbers as json.Number. XML has no types, so its
+// elements are decoded as strings, or as objects when they have child elements.
+// Repeated elements are decoded as arrays.
+func DecodeValue(f Format, body []byte) (any, error) {
+	switch f {
+	case JSON:
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.UseNumber()
+		var value any
+		err := decoder.Decode(&value)
+		return value, err
+	case XML:
+		return decodeXMLValue(body)
+	case YAML:
+		var value any
+		if err := yaml.Unmarshal(body, &value); err != nil {
+			return nil, err
+		}
+		return normalize(value)
+	case MsgPack:
+		var value any
+		if err := codec.NewDecoderBytes(body, msgpackHandle).Decode(&value); err != nil {
+			return nil, err
+		}
+		return normalize(value)
+	default:
+		return nil, ErrorUnsupportedMediaType
+	}
+}
+
+// normalize converts decoded YAML and MessagePack values to JSON values.
+func normalize(value any) (any, error) {
+	switch v := value.(type) {
+	case nil, bool, string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case int:
+		return json.Number(strconv.FormatInt(int64(v), 10)), nil
+	case int64:
+		return json.Number(strconv.FormatInt(v, 10)), nil
+	case uint64:
+		return json.Number(strconv.FormatUint(v, 10)), nil
+	case float32:
+		return json.Number(strconv.FormatFloat(float64(v), 'g', -1, 32)), nil
+	case float64:
+		return json.Number(strconv.FormatFloat(v, 'g', -1, 64)), nil
+	case []any:
+		array := make([]any, 0, len(v))
+		for _, item := range v {
+			normalized, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, normalized)
+		}
+		return array, nil
+	case map[string]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			normalized, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = normalized
+		}
+		return object, nil
+	case map[any]any:
+		object := make(map[string]any, len(v))
+		for key, item := range v {
+			name, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("negotiation: object key %v is not a string", key)
+			}
+			normalized, err := normalize(item)
+			if err != nil {
+				return nil, err
+			}
+			object[name] = normalized
+		}
+		return object, nil
+	default:
+		return nil, fmt.Errorf("negotiation: unsupported value %T", value)
+	}
+}
+
+// decodeXMLValue decodes the content of the root element.
+func decodeXMLValue(body []byte) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("negotiation: XML document has no root element")
+			}
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return decodeXMLElement(decoder, start)
+		}
+	}
+}
+
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement) (any, error) {
+	var text strings.Builder
+	var object map[string]any
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.CharData:
+			text.Write(t)
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t)
+			if err != nil {
+				return nil, err
+			}
+			if object == nil {
+				object = map[string]any{}
+			}
+			name := t.Name.Local
+			switch existing := object[name].(type) {
+			case nil:
+				object[name] = child
+			case []any:
+				object[name] = append(existing, child)
+			default:
+				object[name] = []any{existing, child}
+			}
+		case xml.EndElement:
+			if object != nil {
+				return object, nil
+			}
+			return text.String(), nil
+		}
+	}
+}
@@ -0,0 +1,208 @@
+// Package negotiation picks the format of responses by the Accept header and their
+// content coding by the Accept-Encoding header, and decodes request bodies by
+// their Content-Type. JSON is the reference format:
+// YAML and MessagePack use the JSON field names, XML uses the xml struct tags.
+package negotiation
+
+import (
+	"errors"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrorNotAcceptable        = errors.New("Not acceptable")
+	ErrorUnsupportedMediaType = errors.New("Unsupported media type")
+)
+
+type Format int
+
+const (
+	JSON Format = iota
+	XML
+	YAML
+	MsgPack
+	// CSV is offered for lists only and is not accepted in request bodies.
+	CSV
+)
+
+type formatInfo struct {
+	name        string
+	contentType string
+	// mediaTypes are matched against Accept and Content-Type, the first one is preferred.
+	mediaTypes []string
+}
+
+var formats = map[Format]formatInfo{
+	JSON:    {name: "JSON", contentType: "application/json; charset=utf-8", mediaTypes: []string{"application/json"}},
+	XML:     {name: "XML", contentType: "application/xml; charset=utf-8", mediaTypes: []string{"application/xml", "text/xml"}},
+	YAML:    {name: "YAML", contentType: "application/yaml; charset=utf-8", mediaTypes: []string{"application/yaml", "application/x-yaml", "text/yaml"}},
+	MsgPack: {name: "MessagePack", contentType: "application/msgpack", mediaTypes: []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}},
+	CSV:     {name: "CSV", contentType: "text/csv; charset=utf-8", mediaTypes: []string{"text/csv"}},
+}
+
+func (f Format) String() string {
+	return formats[f].name
+}
+
+// ContentType is the Content-Type of responses in the format.
+func (f Format) ContentType() string {
+	return formats[f].contentType
+}
+
+// MediaTypes lists the preferred media type of every format.
+func MediaTypes(offered []Format) []string {
+	types := make([]string, 0, len(offered))
+	for _, f := range offered {
+		types = append(types, formats[f].mediaTypes[0])
+	}
+	return types
+}
+
+// BodyFormat returns the format of a request body with the Content-Type. A body
+// without Content-Type is JSON.
+func BodyFormat(contentType string) (Format, error) {
+	if len(strings.TrimSpace(contentType)) == 0 {
+		return JSON, nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return JSON, ErrorUnsupportedMediaType
+	}
+
+	for _, f := range []Format{JSON, XML, YAML, MsgPack} {
+		for _, t := range formats[f].mediaTypes {
+			if mediaType == t {
+				return f, nil
+			}
+		}
+	}
+
+	return JSON, ErrorUnsupportedMediaType
+}
+
+// Negotiate returns the offered format the Accept header prefers. Formats with
+// the same quality are preferred in the offered order, and a missing Accept
+// header accepts the first one.
+func Negotiate(accept string, offered []Format) (Format, error) {
+	if len(strings.TrimSpace(accept)) == 0 {
+		return offered[0], nil
+	}
+
+	ranges := parseAccept(accept)
+
+	best, bestQuality := offered[0], 0.0
+	for _, f := range offered {
+		if quality := qualityOf(f, ranges); quality > bestQuality {
+			best, bestQuality = f, quality
+		}
+	}
+
+	if bestQuality == 0 {
+		return offered[0], ErrorNotAcceptable
+	}
+
+	return best, nil
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, isIn := params["q"]; isIn {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				quality = parsed
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, quality: quality})
+	}
+
+	// The most specific range that matches a media type decides its quality.
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+	return ranges
+}
+
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+func qualityOf(f Format, ranges []mediaRange) float64 {
+	best := 0.0
+	for _, t := range formats[f].mediaTypes {
+		for _, r := range ranges {
+			if matches(r.mediaType, t) {
+				if r.quality > best {
+					best = r.quality
+				}
+				break
+			}
+		}
+	}
+	return best
+}
+
+func matches(mediaRange, mediaType string) bool {
+	if mediaRange == "*/*" || mediaRange == mediaType {
+		return true
+	}
+
+	prefix, isWildcard := strings.CutSuffix(mediaRange, "/*")
+	return isWildcard && strings.HasPrefix(mediaType, prefix+"/")
+}
+
+// Encoding returns the offered content coding the Accept-Encoding header prefers,
+// or false when it accepts none of them. Codings with the same quality are
+// preferred in the offered order.
+func Encoding(acceptEncoding string, offered []string) (string, bool) {
+	qualities := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 {
+			continue
+		}
+
+		quality := 1.0
+		if q, isQuality := strings.CutPrefix(strings.TrimSpace(params), "q="); isQuality {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil && parsed >= 0 && parsed <= 1 {
+				quality = parsed
+			}
+		}
+		qualities[name] = quality
+	}
+
+	best, bestQuality := "", 0.0
+	for _, coding := range offered {
+		quality, isIn := qualities[coding]
+		if !isIn {
+			quality = qualities["*"]
+		}
+		if quality > bestQuality {
+			best, bestQuality = coding, quality
+		}
+	}
+
+	return best, bestQuality > 0
+}
@@ -0,0 +1,134 @@
+package negotiation_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/server/negotiation"
+	"github.com/stretchr/testify/assert"
+)
+
+var offered = []negotiation.Format{negotiation.JSON, negotiation.XML, negotiation.YAML, negotiation.MsgPack}
+
+func Test_Negotiate_ShouldPickFormatOfAcceptHeader(t *testing.T) {
+	for accept, expected := range map[string]negotiation.Format{
+		"":         negotiation.JSON,
+		"*/*":      negotiation.JSON,
+		"text/xml": negotiation.XML,
+		"application/yaml, application/json;q=0.5":   negotiation.YAML,
+		"application/*;q=0.2, application/x-msgpack": negotiation.MsgPack,
+		"application/json;q=0, */*;q=0.1":            negotiation.XML,
+	} {
+		t.Run(accept, func(t *testing.T) {
+			format, err := negotiation.Negotiate(accept, offered)
+			assert.NoError(t, err)
+			assert.Equal(t, expected, format)
+		})
+	}
+}
+
+func Test_Negotiate_NothingAcceptable_ShouldReturnNotAcceptable(t *testing.T) {
+	_, err := negotiation.Negotiate("text/csv, text/html", offered)
+	assert.ErrorIs(t, err, negotiation.ErrorNotAcceptable)
+}
+
+func Test_BodyFormat_ShouldRejectUnsupportedMediaType(t *testing.T) {
+	format, err := negotiation.BodyFormat("application/x-yaml; charset=utf-8")
+	assert.NoError(t, err)
+	assert.Equal(t, negotiation.YAML, format)
+
+	_, err = negotiation.BodyFormat("text/csv")
+	assert.ErrorIs(t, err, negotiation.ErrorUnsupportedMediaType)
+}
+
+type post struct {
+	ID    int64    `json:"id" xml:"id"`
+	Title string   `json:"title" xml:"title"`
+	Tags  []string `json:"tags" xml:"tag"`
+}
+
+func Test_EncodeDecode_ShouldRoundTripEveryFormat(t *testing.T) {
+	original := post{ID: 7, Title: "2024", Tags: []string{"go", "yaml"}}
+
+	for _, format := range offered {
+		t.Run(format.String(), func(t *testing.T) {
+			var buf bytes.Buffer
+			assert.NoError(t, negotiation.Encode(&buf, format, original))
+
+			var decoded post
+			assert.NoError(t, negotiation.Decode(format, buf.Bytes(), &decoded))
+			assert.Equal(t, original, decoded)
+		})
+	}
+}
+
+func Test_Encode_YAML_ShouldUseJSONFieldNamesAndQuoteAmbiguousStrings(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, negotiation.Encode(&buf, negotiation.YAML, post{ID: 7, Title: "2024", Tags: []string{"go"}}))
+
+	assert.Equal(t, "id: 7\ntitle: \"2024\"\ntags:\n  - go\n", buf.String())
+}
+
+func Test_DecodeValue_XML_ShouldDecodeElementsAsStrings(t *testing.T) {
+	value, err := negotiation.DecodeValue(negotiation.XML, []byte(`<post><id>7</id><title>On XML</title><tag>a</tag><tag>b</tag></post>`))
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": "7", "title": "On XML", "tag": []any{"a", "b"}}, value)
+}
+
+func Test_DecodeValue_MsgPack_ShouldDecodeNumbersAsJSONNumbers(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, negotiation.Encode(&buf, negotiation.MsgPack, map[string]any{"id": 7, "title": "On msgpack"}))
+
+	value, err := negotiation.DecodeValue(negotiation.MsgPack, buf.Bytes())
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]any{"id": json.Number("7"), "title": "On msgpack"}, value)
+}
+
+func Test_Encode_CSV_ShouldWriteHeaderAndRows(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, negotiation.Encode(&buf, negotiation.CSV, table{}))
+
+	assert.Equal(t, "id,title\n1,\"On CSV, quoted\"\n", buf.String())
+}
+
+type table struct{}
+
+func (table) Header() []string { return []string{"id", "title"} }
+func (table) Rows() [][]string { return [][]string{{"1", "On CSV, quoted"}} }
+
+func Test_Encode_CSV_ShouldEscapeFormulas(t *testing.T) {
+	var buf bytes.Buffer
+	assert.NoError(t, negotiation.Encode(&buf, negotiation.CSV, formulaTable{}))
+
+	assert.Equal(t, "title\n'=cmd|' /C calc'!A0\n'+1\n'-1\n'@SUM(A1)\na=b\n", buf.String())
+}
+
+type formulaTable struct{}
+
+func (formulaTable) Header() []string { return []string{"title"} }
+func (formulaTable) Rows() [][]string {
+	return [][]string{{"=cmd|' /C calc'!A0"}, {"+1"}, {"-1"}, {"@SUM(A1)"}, {"a=b"}}
+}
+
+func Test_Encoding_ShouldPickPreferredCodingOfAcceptEncoding(t *testing.T) {
+	offered := []string{"zstd", "br", "gzip"}
+
+	for acceptEncoding, expected := range map[string]string{
+		"gzip, deflate, br, zstd": "zstd",
+		"gzip;q=1, br;q=0.5":      "gzip",
+		"*;q=0.1, zstd;q=0":       "br",
+		"GZIP":                    "gzip",
+	} {
+		coding, isIn := negotiation.Encoding(acceptEncoding, offered)
+		assert.True(t, isIn, acceptEncoding)
+		assert.Equal(t, expected, coding, acceptEncoding)
+	}
+
+	for _, acceptEncoding := range []string{"", "identity", "deflate", "gzip;q=0"} {
+		_, isIn := negotiation.Encoding(acceptEncoding, offered)
+		assert.False(t, isIn, acceptEncoding)
+	}
+}
@@ -0,0 +1,134 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+var negotiatedPosts = []*domain.Post{
+	{ID: 1, AuthorID: 1, Author: "Anton", Title: "On XML", Content: "first, second"},
+	{ID: 2, AuthorID: 2, Author: "Jonny", Title: "2024", Content: "qwerty"},
+}
+
+func Test_GetPosts_ShouldRenderFormatOfAcceptHeader(t *testing.T) {
+	for accept, expected := range map[string]struct {
+		contentType string
+		body        string
+	}{
+		"application/xml": {
+			contentType: "application/xml",
+			body: `<?xml version="1.0" encoding="UTF-8"?>` + "\n" +
+				`<posts><Post><ID>1</ID><AuthorID>1</AuthorID><Author>Anton</Author><Title>On XML</Title><Content>first, second</Content></Post>` +
+				`<Post><ID>2</ID><AuthorID>2</AuthorID><Author>Jonny</Author><Title>2024</Title><Content>qwerty</Content></Post></posts>`,
+		},
+		"application/yaml": {
+			contentType: "application/yaml",
+			body: "posts:\n" +
+				"  - ID: 1\n    AuthorID: 1\n    Author: Anton\n    Title: On XML\n    Content: first, second\n" +
+				"  - ID: 2\n    AuthorID: 2\n    Author: Jonny\n    Title: \"2024\"\n    Content: qwerty\n",
+		},
+		"text/csv": {
+			contentType: "text/csv",
+			body:        "ID,AuthorID,Author,Title,Content\n1,1,Anton,On XML,\"first, second\"\n2,2,Jonny,2024,qwerty\n",
+		},
+	} {
+		t.Run(accept, func(t *testing.T) {
+			var blogUseCaseMock = new(mocks.IBlogUseCase)
+			expect := SetupServer(t, blogUseCaseMock)
+
+			blogUseCaseMock.
+				On("GetPosts", mock.Anything).
+				Return(negotiatedPosts)
+
+			resp := expect.GET("/v1/api/blog/posts").
+				WithHeader("Accept", accept).
+				Expect().
+				Status(http.StatusOK)
+			resp.Header("Content-Type").HasPrefix(expected.contentType)
+			resp.Header("Vary").IsEqual("Accept")
+			resp.Body().IsEqual(expected.body)
+		})
+	}
+}
+
+func Test_GetPost_AsCSV_ShouldReturnNotAcceptable(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.GET("/v1/api/blog/posts/1").
+		WithHeader("Accept", "text/csv").
+		Expect().
+		Status(http.StatusNotAcceptable).
+		JSON().Object().Value("error").String().Contains("application/msgpack")
+
+	blogUseCaseMock.AssertNotCalled(t, "GetPost", mock.Anything, mock.Anything)
+}
+
+func Test_CreatePost_NotAcceptable_ShouldNotCreatePost(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Accept", "text/html").
+		WithJSON(domain.Post{Author: "Anton", Title: "Big post", Content: "something"}).
+		Expect().
+		Status(http.StatusNotAcceptable)
+
+	blogUseCaseMock.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+}
+
+func Test_CreatePost_BodyInOtherFormats_ShouldBeDecodedByContentType(t *testing.T) {
+	for contentType, body := range map[string]string{
+		"application/xml":  `<post><author_id>1</author_id><title>Big post</title><content>something</content></post>`,
+		"application/yaml": "author_id: 1\ntitle: Big post\ncontent: something\n",
+	} {
+		t.Run(contentType, func(t *testing.T) {
+			var blogUseCaseMock = new(mocks.IBlogUseCase)
+			expect := SetupServer(t, blogUseCaseMock)
+
+			blogUseCaseMock.
+				On("CreatePost", mock.Anything, &domain.Post{AuthorID: 1, Title: "Big post", Content: "something"}).
+				Return(int64(3), nil)
+
+			expect.POST("/v1/api/blog/posts").
+				WithHeader("Content-Type", contentType).
+				WithHeader("Accept", contentType).
+				WithText(body).
+				Expect().
+				Status(http.StatusCreated).
+				Header("Content-Type").HasPrefix(contentType)
+
+			blogUseCaseMock.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_CreatePost_MissingFieldInYAML_ShouldReturnBadRequest(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.POST("/v1/api/blog/posts").
+		WithHeader("Content-Type", "application/yaml").
+		WithText("author: Anton\ntitle: Big post\n").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	blogUseCaseMock.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+}
+
+func Test_UpdatePost_UnsupportedContentType_ShouldReturnUnsupportedMediaType(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.PUT("/v1/api/blog/posts/1").
+		WithHeader("Content-Type", "text/csv").
+		WithText("author,title,content\nAnton,Big post,something\n").
+		Expect().
+		Status(http.StatusUnsupportedMediaType)
+
+	blogUseCaseMock.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything, mock.Anything)
+}
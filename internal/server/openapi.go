@@ -0,0 +1,485 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files/v2"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/openapi"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+var apiPrefixes = []string{"/v1/", "/v2/"}
+
+var apiInfo = openapi.Info{
+	Title:       "Blog API",
+	Description: "CRUD API for blog posts with change notifications.",
+	Version:     "1.0.0",
+}
+
+const (
+	postsTag       = "posts"
+	postsV2Tag     = "posts-v2"
+	eventsTag      = "events"
+	webhooksTag    = "webhooks"
+	authorsTag     = "authors"
+	authTag        = "auth"
+	usersTag       = "users"
+	trashTag       = "trash"
+	attachmentsTag = "attachments"
+)
+
+var (
+	badRequest       = openapi.ResponseSpec{Status: http.StatusBadRequest, Body: response.ErrorBody{}}
+	notFound         = openapi.ResponseSpec{Status: http.StatusNotFound, Body: response.ErrorBody{}}
+	conflict         = openapi.ResponseSpec{Status: http.StatusConflict, Body: response.ErrorBody{}}
+	unauthorized     = openapi.ResponseSpec{Status: http.StatusUnauthorized, Body: response.ErrorBody{}}
+	forbidden        = openapi.ResponseSpec{Status: http.StatusForbidden, Body: response.ErrorBody{}}
+	locked           = openapi.ResponseSpec{Status: http.StatusLocked, Body: response.ErrorBody{}}
+	keyReused        = openapi.ResponseSpec{Status: http.StatusUnprocessableEntity, Body: response.ErrorBody{}}
+	notAcceptable    = openapi.ResponseSpec{Status: http.StatusNotAcceptable, Body: response.ErrorBody{}}
+	unsupportedMedia = openapi.ResponseSpec{Status: http.StatusUnsupportedMediaType, Body: response.ErrorBody{}}
+	tooLarge         = openapi.ResponseSpec{Status: http.StatusRequestEntityTooLarge, Body: response.ErrorBody{}}
+	serverErr        = openapi.ResponseSpec{Status: http.StatusInternalServerError, Body: response.ErrorBody{}}
+
+	badRequestV2   = openapi.ResponseSpec{Status: http.StatusBadRequest, Body: response.ErrorEnvelope{}}
+	notFoundV2     = openapi.ResponseSpec{Status: http.StatusNotFound, Body: response.ErrorEnvelope{}}
+	unauthorizedV2 = openapi.ResponseSpec{Status: http.StatusUnauthorized, Body: response.ErrorEnvelope{}}
+	forbiddenV2    = openapi.ResponseSpec{Status: http.StatusForbidden, Body: response.ErrorEnvelope{}}
+	conflictV2     = openapi.ResponseSpec{Status: http.StatusConflict, Body: response.ErrorEnvelope{}}
+	keyReusedV2    = openapi.ResponseSpec{Status: http.StatusUnprocessableEntity, Body: response.ErrorEnvelope{}}
+	serverErrV2    = openapi.ResponseSpec{Status: http.StatusInternalServerError, Body: response.ErrorEnvelope{}}
+)
+
+const idempotencyDescription = "Send an Idempotency-Key header to retry safely: a retry with the same key and body " +
+	"gets the first response with Idempotent-Replayed: true, the same key with another body gets 422, " +
+	"and a retry while the first request is running gets 409."
+
+const negotiationDescription = "The response is JSON, XML, YAML or MessagePack as requested by the Accept header."
+
+const bodyFormatsDescription = "The body may also be XML, YAML or MessagePack as declared by the Content-Type header."
+
+const fieldsDescription = "With fields, posts only have the selected fields; an unknown field gets 400."
+
+// apiOperations documents every route under /v1 and /v2. A route that is missing here,
+// or an entry without a route, is reported as drift by OpenAPIDocument.
+var apiOperations = []openapi.Operation{
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/posts/:id", ID: "getPost", Tags: []string{postsTag},
+		Summary:     "Get a post by ID",
+		Description: negotiationDescription + " " + fieldsDescription,
+		PathParams:  postIdRequest{},
+		Query:       postFieldsRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: domain.Post{}},
+			badRequest, notFound, unauthorized, forbidden, notAcceptable, serverErr,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/posts", ID: "getPosts", Tags: []string{postsTag},
+		Summary: "Get all posts",
+		Description: "The response is JSON, XML, YAML, MessagePack or CSV as requested by the Accept header. " +
+			"With stream, the posts are written while they are read, as the same JSON document or as " +
+			"application/x-ndjson with one post per line; a stream that fails midway ends early. " + fieldsDescription,
+		Query: postsListRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postsResponse{}},
+			badRequest, notAcceptable,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/posts", ID: "createPost", Tags: []string{postsTag},
+		Summary:     "Create a new post",
+		Description: idempotencyDescription + " " + bodyFormatsDescription + " " + negotiationDescription,
+		Body:        postRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: postIdResponse{}},
+			badRequest, unauthorized, forbidden, notAcceptable, conflict, unsupportedMedia, keyReused, serverErr,
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/api/blog/posts/:id", ID: "updatePost", Tags: []string{postsTag},
+		Summary:     "Update post details",
+		Description: bodyFormatsDescription + " " + negotiationDescription,
+		PathParams:  postIdRequest{},
+		Body:        postRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postIdResponse{}},
+			badRequest, notFound, unauthorized, forbidden, notAcceptable, unsupportedMedia, serverErr,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/api/blog/posts/:id", ID: "deletePost", Tags: []string{postsTag},
+		Summary:     "Delete a post",
+		Description: "The post is moved to the trash, from which it can be restored until it is purged.",
+		PathParams:  postIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusNoContent},
+			badRequest, unauthorized, forbidden, notFound, serverErr,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/trash", ID: "getDeletedPosts", Tags: []string{trashTag},
+		Summary:     "Get the deleted posts",
+		Description: "Lists the posts of the trash the user may restore, most recently deleted first.",
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: trashResponse{}},
+			unauthorized, forbidden,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/posts/:id/restore", ID: "restorePost", Tags: []string{trashTag},
+		Summary:    "Restore a deleted post",
+		PathParams: postIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postModel{}},
+			badRequest, unauthorized, forbidden, notFound, conflict,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/posts/:id/attachments", ID: "createAttachment", Tags: []string{attachmentsTag},
+		Summary: "Attach a file to a post",
+		Description: "The file is uploaded as the file field of a multipart/form-data body. Its type is detected " +
+			"from the content and must be PNG, JPEG, GIF, WebP, PDF or plain text. Images get a thumbnail. " +
+			"The Location header contains the URL of the content.",
+		PathParams:      postIdRequest{},
+		Body:            attachmentUploadRequest{},
+		BodyContentType: "multipart/form-data",
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: attachmentModel{}, Headers: map[string]string{"Location": "URL of the content"}},
+			badRequest, unauthorized, forbidden, notFound, tooLarge, unsupportedMedia, serverErr,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/posts/:id/attachments", ID: "getAttachments", Tags: []string{attachmentsTag},
+		Summary:    "Get the attachments of a post",
+		PathParams: postIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: attachmentsResponse{}},
+			badRequest, unauthorized, forbidden, notFound,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/posts/:id/attachments/:attachmentId", ID: "getAttachment", Tags: []string{attachmentsTag},
+		Summary:     "Download an attachment",
+		Description: "Supports conditional and range requests. Only images are shown inline.",
+		PathParams:  attachmentIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: []byte{}, ContentType: "application/octet-stream"},
+			{Status: http.StatusPartialContent, Body: []byte{}, ContentType: "application/octet-stream"},
+			{Status: http.StatusNotModified},
+			badRequest, unauthorized, forbidden, notFound, serverErr,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/posts/:id/attachments/:attachmentId/thumbnail", ID: "getAttachmentThumbnail", Tags: []string{attachmentsTag},
+		Summary:     "Download the thumbnail of an image attachment",
+		Description: "The thumbnail of a JPEG is a JPEG, of other images a PNG. Other attachments have no thumbnail.",
+		PathParams:  attachmentIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: []byte{}, ContentType: "image/png"},
+			{Status: http.StatusNotModified},
+			badRequest, unauthorized, forbidden, notFound, serverErr,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/api/blog/posts/:id/attachments/:attachmentId", ID: "deleteAttachment", Tags: []string{attachmentsTag},
+		Summary:     "Delete an attachment",
+		Description: "The content is removed once no attachment has the same content.",
+		PathParams:  attachmentIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusNoContent},
+			badRequest, unauthorized, forbidden, notFound, serverErr,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v2/api/blog/posts/:id", ID: "getPostV2", Tags: []string{postsV2Tag},
+		Summary:     "Get a post by ID",
+		Description: fieldsDescription,
+		PathParams:  postIdRequest{},
+		Query:       postFieldsRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postEnvelope{}},
+			badRequestV2, notFoundV2, unauthorizedV2, forbiddenV2, serverErrV2,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v2/api/blog/posts", ID: "getPostsV2", Tags: []string{postsV2Tag},
+		Summary:     "Get posts ordered by ID",
+		Description: fieldsDescription,
+		Query:       postsRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postsEnvelope{}},
+			badRequestV2,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v2/api/blog/posts", ID: "createPostV2", Tags: []string{postsV2Tag},
+		Summary:     "Create a new post",
+		Description: "The Location header contains the URL of the created post. " + idempotencyDescription,
+		Body:        postRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: postEnvelope{}},
+			badRequestV2, unauthorizedV2, forbiddenV2, conflictV2, keyReusedV2, serverErrV2,
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/v2/api/blog/posts/:id", ID: "updatePostV2", Tags: []string{postsV2Tag},
+		Summary:    "Update post details",
+		PathParams: postIdRequest{},
+		Body:       postRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postEnvelope{}},
+			badRequestV2, notFoundV2, unauthorizedV2, forbiddenV2, serverErrV2,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v2/api/blog/posts/:id", ID: "deletePostV2", Tags: []string{postsV2Tag},
+		Summary:     "Delete a post",
+		Description: "The post is moved to the trash, from which it can be restored until it is purged.",
+		PathParams:  postIdRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusNoContent},
+			badRequestV2, unauthorizedV2, forbiddenV2, notFoundV2, serverErrV2,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/events", ID: "streamEvents", Tags: []string{eventsTag},
+		Summary:     "Stream post changes as Server-Sent Events",
+		Description: "Every SSE message has the event ID as id, the event type as event and the JSON encoded event as data. Send the Last-Event-ID header to resume.",
+		Query:       eventsRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: eventModel{}, ContentType: "text/event-stream"},
+			badRequest,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/events/ws", ID: "streamEventsWebSocket", Tags: []string{eventsTag},
+		Summary:     "Stream post changes over a WebSocket",
+		Description: "Every event is sent as a JSON text message.",
+		Query:       eventsRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusSwitchingProtocols, Body: eventModel{}},
+			badRequest,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/webhooks", ID: "createWebhook", Tags: []string{webhooksTag},
+		Summary: "Create a webhook",
+		Body:    webhookRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: webhookModel{}},
+			badRequest, serverErr,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/webhooks", ID: "getWebhooks", Tags: []string{webhooksTag},
+		Summary: "Get all webhooks",
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: webhooksResponse{}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/webhooks/:id", ID: "getWebhook", Tags: []string{webhooksTag},
+		Summary:    "Get a webhook by ID",
+		PathParams: idRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: webhookModel{}},
+			badRequest, notFound,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/api/blog/webhooks/:id", ID: "deleteWebhook", Tags: []string{webhooksTag},
+		Summary:    "Delete a webhook",
+		PathParams: idRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusNoContent},
+			badRequest, notFound,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/webhooks/:id/deliveries", ID: "getWebhookDeliveries", Tags: []string{webhooksTag},
+		Summary:    "Get the delivery log of a webhook",
+		PathParams: idRequest{},
+		Query:      deliveriesRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: deliveriesResponse{}},
+			badRequest, notFound,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/deliveries", ID: "getDeliveries", Tags: []string{webhooksTag},
+		Summary: "Get the delivery log of all webhooks",
+		Query:   deliveriesRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: deliveriesResponse{}},
+			badRequest,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/deliveries/:id/redeliver", ID: "redeliverDelivery", Tags: []string{webhooksTag},
+		Summary:    "Queue a dead delivery again",
+		PathParams: idRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusAccepted, Body: idResponse{}},
+			badRequest, notFound,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/authors", ID: "createAuthor", Tags: []string{authorsTag},
+		Summary: "Create an author",
+		Body:    authorRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: authorModel{}},
+			badRequest, conflict, unauthorized, forbidden,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/authors", ID: "getAuthors", Tags: []string{authorsTag},
+		Summary: "Get all authors",
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: authorsResponse{}},
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/authors/:id", ID: "getAuthor", Tags: []string{authorsTag},
+		Summary:    "Get an author by ID",
+		PathParams: idRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: authorModel{}},
+			badRequest, notFound,
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/api/blog/authors/:id", ID: "updateAuthor", Tags: []string{authorsTag},
+		Summary:     "Update author details",
+		Description: "Renaming an author updates the author name of all their posts.",
+		PathParams:  idRequest{},
+		Body:        authorRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: authorModel{}},
+			badRequest, notFound, conflict, unauthorized, forbidden,
+		},
+	},
+	{
+		Method: http.MethodDelete, Path: "/v1/api/blog/authors/:id", ID: "deleteAuthor", Tags: []string{authorsTag},
+		Summary:     "Delete an author",
+		Description: "Only authors without posts can be deleted.",
+		PathParams:  idRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusNoContent},
+			badRequest, notFound, conflict, unauthorized, forbidden,
+		},
+	},
+	{
+		Method: http.MethodGet, Path: "/v1/api/blog/authors/:id/posts", ID: "getAuthorPosts", Tags: []string{authorsTag},
+		Summary:    "Get the posts of an author ordered by ID",
+		PathParams: idRequest{},
+		Query:      postsViewRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: postsResponse{}},
+			badRequest, notFound,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/auth/register", ID: "register", Tags: []string{authTag},
+		Summary: "Register a user",
+		Body:    registerRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: userModel{}},
+			badRequest, conflict,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/auth/login", ID: "login", Tags: []string{authTag},
+		Summary:     "Log in and get an access and a refresh token",
+		Description: "Repeated failed logins lock the account for a while.",
+		Body:        loginRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: tokensModel{}},
+			badRequest, unauthorized, locked,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/auth/refresh", ID: "refresh", Tags: []string{authTag},
+		Summary:     "Exchange a refresh token for new tokens",
+		Description: "The refresh token can be used once, the previous tokens of the session stop working.",
+		Body:        refreshRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: tokensModel{}},
+			badRequest, unauthorized,
+		},
+	},
+	{
+		Method: http.MethodPost, Path: "/v1/api/blog/auth/logout", ID: "logout", Tags: []string{authTag},
+		Summary: "Revoke the access token of the Authorization header and its refresh token",
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusNoContent},
+			unauthorized,
+		},
+	},
+	{
+		Method: http.MethodPut, Path: "/v1/api/blog/users/:id/role", ID: "setUserRole", Tags: []string{usersTag},
+		Summary:     "Assign a role to a user",
+		Description: "Users with the author role own the posts of the linked author. Only admins can assign roles.",
+		PathParams:  idRequest{},
+		Body:        roleRequest{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: userModel{}},
+			badRequest, unauthorized, forbidden, notFound,
+		},
+	},
+}
+
+// OpenAPIDocument generates the API contract from the routes registered on the engine.
+func OpenAPIDocument(r *gin.Engine) (*openapi.Document, openapi.Drift) {
+	var routes []openapi.Route
+	for _, route := range r.Routes() {
+		for _, prefix := range apiPrefixes {
+			if strings.HasPrefix(route.Path, prefix) {
+				routes = append(routes, openapi.Route{Method: route.Method, Path: route.Path})
+			}
+		}
+	}
+
+	return openapi.Build(apiInfo, routes, apiOperations)
+}
+
+// RegisterOpenAPI serves the API contract at /openapi.json and Swagger UI at /docs.
+// The document is generated on the first request, when all routes are registered.
+func RegisterOpenAPI(r *gin.Engine) {
+	var once sync.Once
+	var document *openapi.Document
+
+	r.GET("/openapi.json", func(c *gin.Context) {
+		once.Do(func() {
+			document, _ = OpenAPIDocument(r)
+		})
+		c.JSON(http.StatusOK, document)
+	})
+
+	fileServer := http.StripPrefix("/docs", http.FileServer(http.FS(swaggerFiles.FS)))
+	r.GET("/docs/*filepath", func(c *gin.Context) {
+		if c.Param("filepath") == "/swagger-initializer.js" {
+			c.Data(http.StatusOK, "application/javascript", []byte(swaggerInitializer))
+			return
+		}
+		fileServer.ServeHTTP(c.Writer, c.Request)
+	})
+}
+
+const swaggerInitializer = `window.onload = function() {
+  window.ui = SwaggerUIBundle({
+    url: "/openapi.json",
+    dom_id: "#swagger-ui",
+    deepLinking: true,
+    presets: [SwaggerUIBundle.presets.apis, SwaggerUIStandalonePreset],
+    plugins: [SwaggerUIBundle.plugins.DownloadUrl],
+    layout: "StandaloneLayout"
+  });
+};
+`
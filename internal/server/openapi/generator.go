@@ -0,0 +1,428 @@
+package openapi
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const jsonContentType = "application/json"
+
+// Operation describes a route for the generator. Go values are reflected into
+// schemas: PathParams from uri tags, Query from form tags and Body and response
+// bodies from json tags. Validation rules are read from binding tags, and
+// regular expressions from pattern tags with a patternMessage for violations.
+// Fields of type multipart.FileHeader are documented as uploaded files.
+type Operation struct {
+	Method      string
+	Path        string
+	ID          string
+	Summary     string
+	Description string
+	Tags        []string
+	PathParams  any
+	Query       any
+	Body        any
+	// BodyContentType defaults to application/json. Other bodies are not validated.
+	BodyContentType string
+	Responses       []ResponseSpec
+	Deprecated      bool
+}
+
+type ResponseSpec struct {
+	Status      int
+	Description string
+	// Body is reflected into the schema of the response. No content is documented when it is nil.
+	Body any
+	// ContentType defaults to application/json. A []byte body of another content
+	// type is documented as binary content.
+	ContentType string
+	// Headers maps response header names to their descriptions.
+	Headers map[string]string
+}
+
+// Route is a registered method and path in gin syntax, e.g. "/posts/:id".
+type Route struct {
+	Method string
+	Path   string
+}
+
+// Drift lists the differences between the registered routes and the documented operations.
+type Drift struct {
+	Undocumented []Route
+	Unrouted     []Route
+}
+
+func (d Drift) Empty() bool {
+	return len(d.Undocumented) == 0 && len(d.Unrouted) == 0
+}
+
+// Build generates a document with an operation for every route. Routes without
+// a documented operation and operations without a route are reported in Drift.
+func Build(info Info, routes []Route, operations []Operation) (*Document, Drift) {
+	g := &generator{
+		doc: &Document{
+			OpenAPI:    "3.0.3",
+			Info:       info,
+			Paths:      map[string]*PathItem{},
+			Components: Components{Schemas: map[string]*Schema{}},
+		},
+		names: map[reflect.Type]string{},
+	}
+
+	documented := map[Route]Operation{}
+	for _, op := range operations {
+		documented[Route{Method: op.Method, Path: op.Path}] = op
+	}
+
+	var drift Drift
+	routed := map[Route]bool{}
+	for _, route := range routes {
+		routed[route] = true
+
+		op, isIn := documented[route]
+		if !isIn {
+			drift.Undocumented = append(drift.Undocumented, route)
+			continue
+		}
+
+		g.addOperation(op)
+	}
+
+	for _, op := range operations {
+		route := Route{Method: op.Method, Path: op.Path}
+		if !routed[route] {
+			drift.Unrouted = append(drift.Unrouted, route)
+		}
+	}
+
+	return g.doc, drift
+}
+
+// PathTemplate converts a gin path ("/posts/:id") to an OpenAPI path template ("/posts/{id}").
+func PathTemplate(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, s := range segments {
+		if strings.HasPrefix(s, ":") || strings.HasPrefix(s, "*") {
+			segments[i] = "{" + s[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
+
+type generator struct {
+	doc   *Document
+	names map[reflect.Type]string
+}
+
+func (g *generator) addOperation(op Operation) {
+	path := PathTemplate(op.Path)
+	item, isIn := g.doc.Paths[path]
+	if !isIn {
+		item = &PathItem{}
+		g.doc.Paths[path] = item
+	}
+
+	operation := &OperationObject{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   map[string]*Response{},
+		Deprecated:  op.Deprecated,
+	}
+
+	if op.PathParams != nil {
+		operation.Parameters = append(operation.Parameters, g.parameters(op.PathParams, "path", "uri")...)
+	}
+	if op.Query != nil {
+		operation.Parameters = append(operation.Parameters, g.parameters(op.Query, "query", "form")...)
+	}
+
+	if op.Body != nil {
+		contentType := op.BodyContentType
+		if len(contentType) == 0 {
+			contentType = jsonContentType
+		}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{contentType: {Schema: g.schema(reflect.TypeOf(op.Body))}},
+		}
+	}
+
+	for _, r := range op.Responses {
+		description := r.Description
+		if len(description) == 0 {
+			description = http.StatusText(r.Status)
+		}
+
+		resp := &Response{Description: description}
+		if r.Body != nil {
+			contentType := r.ContentType
+			if len(contentType) == 0 {
+				contentType = jsonContentType
+			}
+			schema := g.schema(reflect.TypeOf(r.Body))
+			if contentType != jsonContentType && schema.Format == "byte" {
+				schema.Format = "binary"
+			}
+			resp.Content = map[string]*MediaType{contentType: {Schema: schema}}
+		}
+
+		for name, headerDescription := range r.Headers {
+			if resp.Headers == nil {
+				resp.Headers = map[string]*Header{}
+			}
+			resp.Headers[name] = &Header{Description: headerDescription, Schema: &Schema{Type: "string"}}
+		}
+
+		operation.Responses[strconv.Itoa(r.Status)] = resp
+	}
+
+	switch op.Method {
+	case http.MethodGet:
+		item.Get = operation
+	case http.MethodPut:
+		item.Put = operation
+	case http.MethodPost:
+		item.Post = operation
+	case http.MethodDelete:
+		item.Delete = operation
+	case http.MethodPatch:
+		item.Patch = operation
+	case http.MethodHead:
+		item.Head = operation
+	}
+}
+
+func (g *generator) parameters(value any, in string, tagName string) []Parameter {
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var params []Parameter
+	for _, field := range reflect.VisibleFields(t) {
+		name, _, _ := strings.Cut(field.Tag.Get(tagName), ",")
+		if len(name) == 0 || name == "-" || field.Anonymous {
+			continue
+		}
+
+		rules := parseBinding(field.Tag.Get("binding"))
+		schema := g.schema(field.Type)
+		rules.apply(schema)
+		schema.Description = field.Tag.Get("doc")
+		schema.Pattern = field.Tag.Get("pattern")
+		schema.PatternMessage = field.Tag.Get("patternMessage")
+
+		param := Parameter{
+			Name:     name,
+			In:       in,
+			Required: in == "path" || rules.required,
+			Schema:   schema,
+		}
+		if schema.Type == "array" {
+			explode := true
+			param.Style = "form"
+			param.Explode = &explode
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	fileHeaderType = reflect.TypeOf(multipart.FileHeader{})
+)
+
+func (g *generator) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == fileHeaderType:
+		return &Schema{Type: "string", Format: "binary"}
+	case t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8:
+		return &Schema{Type: "string", Format: "byte"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		return g.structRef(t)
+	default:
+		return &Schema{}
+	}
+}
+
+// structRef registers named structs as components and returns a reference to them.
+func (g *generator) structRef(t reflect.Type) *Schema {
+	if len(t.Name()) == 0 {
+		return g.structSchema(t)
+	}
+
+	name, isIn := g.names[t]
+	if !isIn {
+		name = g.componentName(t)
+		g.names[t] = name
+		g.doc.Components.Schemas[name] = g.structSchema(t)
+	}
+
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func (g *generator) componentName(t reflect.Type) string {
+	runes := []rune(t.Name())
+	runes[0] = unicode.ToUpper(runes[0])
+	name := string(runes)
+
+	if _, taken := g.doc.Components.Schemas[name]; !taken {
+		return name
+	}
+
+	pkg := t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]
+	return fmt.Sprintf("%s%s", strings.ToUpper(pkg[:1])+pkg[1:], name)
+}
+
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+
+	// Structs validated with binding tags are requests: only fields with "required" are required.
+	// Other structs are responses, where every field without omitempty is always present.
+	isRequest := false
+	for _, field := range reflect.VisibleFields(t) {
+		if _, isIn := field.Tag.Lookup("binding"); isIn {
+			isRequest = true
+		}
+	}
+
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+
+		rules := parseBinding(field.Tag.Get("binding"))
+		property := g.schema(field.Type)
+		if len(property.Ref) == 0 {
+			rules.apply(property)
+		}
+		if len(property.Ref) == 0 {
+			property.Description = field.Tag.Get("doc")
+			property.Pattern = field.Tag.Get("pattern")
+			property.PatternMessage = field.Tag.Get("patternMessage")
+		}
+		schema.Properties[name] = property
+
+		omitEmpty := strings.Contains(options, "omitempty")
+		if rules.required || (!isRequest && !omitEmpty && field.Type.Kind() != reflect.Pointer) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	sort.Strings(schema.Required)
+	return schema
+}
+
+type bindingRules struct {
+	required bool
+	min      *float64
+	max      *float64
+	oneOf    []string
+	format   string
+}
+
+func parseBinding(tag string) bindingRules {
+	var rules bindingRules
+	for _, rule := range strings.Split(tag, ",") {
+		name, value, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			rules.required = true
+		case "min", "gte":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				rules.min = &n
+			}
+		case "max", "lte":
+			if n, err := strconv.ParseFloat(value, 64); err == nil {
+				rules.max = &n
+			}
+		case "oneof":
+			rules.oneOf = strings.Fields(value)
+		case "email":
+			rules.format = "email"
+		}
+	}
+
+	return rules
+}
+
+func (r bindingRules) apply(schema *Schema) {
+	target := schema
+	if schema.Type == "array" && schema.Items != nil {
+		target = schema.Items
+	}
+
+	switch target.Type {
+	case "string":
+		if r.min != nil {
+			n := int(*r.min)
+			target.MinLength = &n
+		}
+		if r.max != nil {
+			n := int(*r.max)
+			target.MaxLength = &n
+		}
+		if len(r.format) > 0 {
+			target.Format = r.format
+		}
+	case "integer", "number":
+		target.Minimum = r.min
+		target.Maximum = r.max
+	}
+
+	for _, value := range r.oneOf {
+		if target.Type == "integer" {
+			if n, err := strconv.ParseInt(value, 10, 64); err == nil {
+				target.Enum = append(target.Enum, n)
+				continue
+			}
+		}
+		target.Enum = append(target.Enum, value)
+	}
+}
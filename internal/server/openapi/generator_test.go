@@ -0,0 +1,113 @@
+package openapi_test
+
+import (
+	"mime/multipart"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/server/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+type itemRequest struct {
+	Name  string   `json:"name" binding:"required,max=10"`
+	Kind  string   `json:"kind" binding:"omitempty,oneof=a b"`
+	Tags  []string `json:"tags"`
+	Label string   `json:"-"`
+}
+
+type itemResponse struct {
+	ID        int64     `json:"id"`
+	Note      string    `json:"note,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type itemQuery struct {
+	IDs   []int64 `form:"id"`
+	Limit int     `form:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+var itemOperations = []openapi.Operation{
+	{
+		Method: http.MethodPost, Path: "/items/:id", ID: "createItem",
+		Body:  itemRequest{},
+		Query: itemQuery{},
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusCreated, Body: itemResponse{}},
+		},
+	},
+	{Method: http.MethodDelete, Path: "/items/:id", ID: "deleteItem"},
+}
+
+func Test_Build_ShouldReportDrift(t *testing.T) {
+	routes := []openapi.Route{
+		{Method: http.MethodPost, Path: "/items/:id"},
+		{Method: http.MethodGet, Path: "/items"},
+	}
+
+	doc, drift := openapi.Build(openapi.Info{Title: "Items"}, routes, itemOperations)
+
+	assert.Equal(t, []openapi.Route{{Method: http.MethodGet, Path: "/items"}}, drift.Undocumented)
+	assert.Equal(t, []openapi.Route{{Method: http.MethodDelete, Path: "/items/:id"}}, drift.Unrouted)
+	assert.False(t, drift.Empty())
+	assert.NotNil(t, doc.Paths["/items/{id}"].Post)
+	assert.Nil(t, doc.Paths["/items/{id}"].Delete)
+}
+
+func Test_Build_ShouldReflectSchemasAndBindingRules(t *testing.T) {
+	routes := []openapi.Route{{Method: http.MethodPost, Path: "/items/:id"}}
+
+	doc, drift := openapi.Build(openapi.Info{Title: "Items"}, routes, itemOperations)
+	assert.Len(t, drift.Unrouted, 1)
+
+	request := doc.Components.Schemas["ItemRequest"]
+	assert.Equal(t, []string{"name"}, request.Required)
+	assert.Equal(t, 10, *request.Properties["name"].MaxLength)
+	assert.Equal(t, []any{"a", "b"}, request.Properties["kind"].Enum)
+	assert.Equal(t, "array", request.Properties["tags"].Type)
+	assert.NotContains(t, request.Properties, "Label")
+
+	response := doc.Components.Schemas["ItemResponse"]
+	assert.Equal(t, []string{"created_at", "id"}, response.Required)
+	assert.Equal(t, "date-time", response.Properties["created_at"].Format)
+
+	params := doc.Paths["/items/{id}"].Post.Parameters
+	assert.Len(t, params, 2)
+	assert.Equal(t, "id", params[0].Name)
+	assert.Equal(t, "array", params[0].Schema.Type)
+	assert.Equal(t, "limit", params[1].Name)
+	assert.Equal(t, 100.0, *params[1].Schema.Maximum)
+}
+
+type uploadRequest struct {
+	File *multipart.FileHeader `json:"file" binding:"required"`
+}
+
+func Test_Build_MultipartBody_ShouldDocumentFileUpload(t *testing.T) {
+	routes := []openapi.Route{{Method: http.MethodPost, Path: "/uploads"}}
+	operations := []openapi.Operation{{
+		Method: http.MethodPost, Path: "/uploads", ID: "upload",
+		Body: uploadRequest{}, BodyContentType: "multipart/form-data",
+		Responses: []openapi.ResponseSpec{
+			{Status: http.StatusOK, Body: []byte{}, ContentType: "application/octet-stream"},
+		},
+	}}
+
+	doc, _ := openapi.Build(openapi.Info{Title: "Uploads"}, routes, operations)
+
+	content := doc.Paths["/uploads"].Post.RequestBody.Content
+	assert.NotContains(t, content, "application/json")
+	assert.Contains(t, content, "multipart/form-data")
+
+	upload := doc.Components.Schemas["UploadRequest"]
+	assert.Equal(t, []string{"file"}, upload.Required)
+	assert.Equal(t, "binary", upload.Properties["file"].Format)
+
+	content = doc.Paths["/uploads"].Post.Responses["200"].Content
+	assert.Equal(t, "binary", content["application/octet-stream"].Schema.Format)
+}
+
+func Test_PathTemplate_ShouldConvertGinParameters(t *testing.T) {
+	assert.Equal(t, "/posts/{id}/attachments/{name}", openapi.PathTemplate("/posts/:id/attachments/*name"))
+}
@@ -0,0 +1,433 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/kondrushin/blog/internal/server/negotiation"
+)
+
+// FieldError describes a value that violates the contract. Field is prefixed
+// with the location of the value: "path.", "query." or "body.".
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Validator checks requests against the operations of a document. It supports
+// the schema keywords the generator emits.
+type Validator struct {
+	doc        *Document
+	operations map[Route]*OperationObject
+
+	mutex    sync.Mutex
+	patterns map[string]*regexp.Regexp
+}
+
+func NewValidator(doc *Document) *Validator {
+	v := &Validator{
+		doc:        doc,
+		operations: map[Route]*OperationObject{},
+		patterns:   map[string]*regexp.Regexp{},
+	}
+
+	for path, item := range doc.Paths {
+		for method, op := range item.operations() {
+			v.operations[Route{Method: method, Path: path}] = op
+		}
+	}
+
+	return v
+}
+
+// Request is the part of an HTTP request that is validated.
+type Request struct {
+	Method string
+	// Path is the OpenAPI path template of the matched route, e.g. "/posts/{id}".
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Body       []byte
+	// ContentType selects the format the body is decoded from. It is JSON when
+	// empty or not supported, the way handlers read bodies by default.
+	ContentType string
+}
+
+// Validate returns the violations of the request, or nothing when the route is not documented.
+func (v *Validator) Validate(req Request) []FieldError {
+	op, isIn := v.operations[Route{Method: req.Method, Path: req.Path}]
+	if !isIn {
+		return nil
+	}
+
+	var errs []FieldError
+	for _, param := range op.Parameters {
+		switch param.In {
+		case "path":
+			value, isIn := req.PathParams[param.Name]
+			errs = append(errs, v.validateParameter(param, "path."+param.Name, []string{value}, isIn)...)
+		case "query":
+			values, isIn := req.Query[param.Name]
+			errs = append(errs, v.validateParameter(param, "query."+param.Name, values, isIn)...)
+		}
+	}
+
+	if op.RequestBody != nil {
+		errs = append(errs, v.validateBody(op.RequestBody, req.Body, req.ContentType)...)
+	}
+
+	return errs
+}
+
+func (v *Validator) validateParameter(param Parameter, field string, values []string, isIn bool) []FieldError {
+	if !isIn || (len(values) == 1 && len(values[0]) == 0) {
+		if param.Required {
+			return []FieldError{{Field: field, Message: "is required"}}
+		}
+		return nil
+	}
+
+	schema := v.resolve(param.Schema)
+	if schema.Type != "array" {
+		values = values[:1]
+	}
+
+	items := make([]any, 0, len(values))
+	for _, raw := range values {
+		itemSchema := schema
+		if schema.Type == "array" && schema.Items != nil {
+			itemSchema = v.resolve(schema.Items)
+		}
+
+		value, err := parseScalar(raw, itemSchema.Type)
+		if err != nil {
+			return []FieldError{{Field: field, Message: err.Error()}}
+		}
+		items = append(items, value)
+	}
+
+	if schema.Type == "array" {
+		return v.validateValue(field, items, schema)
+	}
+
+	return v.validateValue(field, items[0], schema)
+}
+
+func (v *Validator) validateBody(body *RequestBody, raw []byte, contentType string) []FieldError {
+	media, isIn := body.Content[jsonContentType]
+	if !isIn {
+		return nil
+	}
+
+	if len(bytes.TrimSpace(raw)) == 0 {
+		if body.Required {
+			return []FieldError{{Field: "body", Message: "is required"}}
+		}
+		return nil
+	}
+
+	format, err := negotiation.BodyFormat(contentType)
+	if err != nil {
+		format = negotiation.JSON
+	}
+
+	value, err := negotiation.DecodeValue(format, raw)
+	if err != nil {
+		return []FieldError{{Field: "body", Message: "must be valid " + format.String()}}
+	}
+	if format == negotiation.XML {
+		value = v.coerce(value, media.Schema)
+	}
+
+	return v.validateValue("body", value, media.Schema)
+}
+
+// coerce converts the strings of an XML body to the types of the schema, and
+// single elements to arrays where the schema expects them. Values that cannot
+// be converted are left for validateValue to report.
+func (v *Validator) coerce(value any, schema *Schema) any {
+	schema = v.resolve(schema)
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		for key, item := range object {
+			for name, property := range schema.Properties {
+				if strings.EqualFold(key, name) {
+					object[key] = v.coerce(item, property)
+					break
+				}
+			}
+		}
+		return object
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			array = []any{value}
+		}
+		for i, item := range array {
+			array[i] = v.coerce(item, schema.Items)
+		}
+		return array
+	case "integer", "number", "boolean":
+		s, ok := value.(string)
+		if !ok {
+			return value
+		}
+		if parsed, err := parseScalar(strings.TrimSpace(s), schema.Type); err == nil {
+			return parsed
+		}
+	}
+
+	return value
+}
+
+func (v *Validator) validateValue(field string, value any, schema *Schema) []FieldError {
+	schema = v.resolve(schema)
+
+	if value == nil {
+		if schema.Nullable || len(schema.Type) == 0 {
+			return nil
+		}
+		return []FieldError{{Field: field, Message: "must not be null"}}
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]any)
+		if !ok {
+			return typeError(field, "an object")
+		}
+		return v.validateObject(field, object, schema)
+	case "array":
+		array, ok := value.([]any)
+		if !ok {
+			return typeError(field, "an array")
+		}
+		var errs []FieldError
+		for i, item := range array {
+			if schema.Items != nil {
+				errs = append(errs, v.validateValue(fmt.Sprintf("%s[%d]", field, i), item, schema.Items)...)
+			}
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return typeError(field, "a string")
+		}
+		return v.validateString(field, s, schema)
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(field, "an integer")
+		}
+		if _, err := n.Int64(); err != nil {
+			return typeError(field, "an integer")
+		}
+		return validateNumber(field, n, schema)
+	case "number":
+		n, ok := value.(json.Number)
+		if !ok {
+			return typeError(field, "a number")
+		}
+		return validateNumber(field, n, schema)
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return typeError(field, "a boolean")
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) validateObject(field string, object map[string]any, schema *Schema) []FieldError {
+	var errs []FieldError
+	for _, name := range schema.Required {
+		if _, isIn := lookupProperty(object, name); !isIn {
+			errs = append(errs, FieldError{Field: field + "." + name, Message: "is required"})
+		}
+	}
+
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value, isIn := lookupProperty(object, name)
+		if isIn {
+			errs = append(errs, v.validateValue(field+"."+name, value, schema.Properties[name])...)
+		}
+	}
+
+	return errs
+}
+
+// lookupProperty matches keys case-insensitively, the same way encoding/json binds them.
+func lookupProperty(object map[string]any, name string) (any, bool) {
+	if value, isIn := object[name]; isIn {
+		return value, true
+	}
+
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+
+	return nil, false
+}
+
+func (v *Validator) validateString(field string, s string, schema *Schema) []FieldError {
+	length := utf8.RuneCountInString(s)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at least %d characters long", *schema.MinLength)}}
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at most %d characters long", *schema.MaxLength)}}
+	}
+
+	if len(schema.Enum) > 0 && !containsValue(schema.Enum, s) {
+		return []FieldError{{Field: field, Message: "must be one of " + formatEnum(schema.Enum)}}
+	}
+
+	if len(schema.Pattern) > 0 {
+		pattern, err := v.compile(schema.Pattern)
+		if err != nil {
+			return []FieldError{{Field: field, Message: "cannot be validated: " + err.Error()}}
+		}
+		if !pattern.MatchString(s) {
+			message := schema.PatternMessage
+			if len(message) == 0 {
+				message = "must match pattern " + schema.Pattern
+			}
+			return []FieldError{{Field: field, Message: message}}
+		}
+	}
+
+	return nil
+}
+
+func validateNumber(field string, n json.Number, schema *Schema) []FieldError {
+	f, err := n.Float64()
+	if err != nil {
+		return typeError(field, "a number")
+	}
+
+	if schema.Minimum != nil && f < *schema.Minimum {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at least %v", *schema.Minimum)}}
+	}
+	if schema.Maximum != nil && f > *schema.Maximum {
+		return []FieldError{{Field: field, Message: fmt.Sprintf("must be at most %v", *schema.Maximum)}}
+	}
+
+	if len(schema.Enum) > 0 {
+		i, err := n.Int64()
+		if err != nil || !containsValue(schema.Enum, i) {
+			return []FieldError{{Field: field, Message: "must be one of " + formatEnum(schema.Enum)}}
+		}
+	}
+
+	return nil
+}
+
+func (v *Validator) resolve(schema *Schema) *Schema {
+	for schema != nil && len(schema.Ref) > 0 {
+		schema = v.doc.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+
+	if schema == nil {
+		return &Schema{}
+	}
+
+	return schema
+}
+
+func (v *Validator) compile(pattern string) (*regexp.Regexp, error) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	if re, isIn := v.patterns[pattern]; isIn {
+		return re, nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	v.patterns[pattern] = re
+	return re, nil
+}
+
+func parseScalar(raw string, schemaType string) (any, error) {
+	switch schemaType {
+	case "integer":
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return nil, fmt.Errorf("must be an integer")
+		}
+		return json.Number(raw), nil
+	case "number":
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return nil, fmt.Errorf("must be a number")
+		}
+		return json.Number(raw), nil
+	case "boolean":
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("must be a boolean")
+		}
+		return b, nil
+	default:
+		return raw, nil
+	}
+}
+
+func typeError(field string, expected string) []FieldError {
+	return []FieldError{{Field: field, Message: "must be " + expected}}
+}
+
+func containsValue(values []any, value any) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatEnum(values []any) string {
+	formatted := make([]string, 0, len(values))
+	for _, v := range values {
+		formatted = append(formatted, fmt.Sprint(v))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func (p *PathItem) operations() map[string]*OperationObject {
+	operations := map[string]*OperationObject{}
+	for method, op := range map[string]*OperationObject{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete, "PATCH": p.Patch, "HEAD": p.Head,
+	} {
+		if op != nil {
+			operations[method] = op
+		}
+	}
+
+	return operations
+}
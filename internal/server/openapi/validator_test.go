@@ -0,0 +1,119 @@
+package openapi_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/server/openapi"
+	"github.com/stretchr/testify/assert"
+)
+
+type noteRequest struct {
+	Text     string `json:"text" binding:"required,max=5" pattern:"^[a-z]+$" patternMessage:"must be lowercase letters"`
+	Priority int    `json:"priority" binding:"omitempty,min=1"`
+}
+
+type noteParams struct {
+	ID int64 `uri:"id" binding:"required"`
+}
+
+type noteQuery struct {
+	Kind  string `form:"kind" binding:"omitempty,oneof=a b"`
+	Limit int    `form:"limit" binding:"omitempty,min=1,max=10"`
+}
+
+func newNoteValidator() *openapi.Validator {
+	routes := []openapi.Route{{Method: http.MethodPost, Path: "/notes/:id"}}
+	ops := []openapi.Operation{
+		{Method: http.MethodPost, Path: "/notes/:id", ID: "createNote", PathParams: noteParams{}, Body: noteRequest{}, Query: noteQuery{}},
+	}
+	doc, _ := openapi.Build(openapi.Info{Title: "Notes"}, routes, ops)
+
+	return openapi.NewValidator(doc)
+}
+
+func Test_Validate_ValidRequest_ShouldReturnNoErrors(t *testing.T) {
+	validator := newNoteValidator()
+
+	errs := validator.Validate(openapi.Request{
+		Method:     http.MethodPost,
+		Path:       "/notes/{id}",
+		PathParams: map[string]string{"id": "7"},
+		Query:      url.Values{"kind": {"a"}, "limit": {"3"}},
+		Body:       []byte(`{"text":"hey"}`),
+	})
+
+	assert.Empty(t, errs)
+}
+
+func Test_Validate_InvalidRequest_ShouldReturnFieldErrors(t *testing.T) {
+	validator := newNoteValidator()
+
+	errs := validator.Validate(openapi.Request{
+		Method:     http.MethodPost,
+		Path:       "/notes/{id}",
+		PathParams: map[string]string{"id": "x"},
+		Query:      url.Values{"kind": {"c"}, "limit": {"11"}},
+		Body:       []byte(`{"text":"HEY"}`),
+	})
+
+	fields := []string{}
+	for _, err := range errs {
+		fields = append(fields, err.Field)
+	}
+	assert.Equal(t, []string{"path.id", "query.kind", "query.limit", "body.text"}, fields)
+	assert.Equal(t, "must be lowercase letters", errs[3].Message)
+}
+
+func Test_Validate_BodyTooLongAndMissing_ShouldReturnFieldErrors(t *testing.T) {
+	validator := newNoteValidator()
+	request := openapi.Request{
+		Method:     http.MethodPost,
+		Path:       "/notes/{id}",
+		PathParams: map[string]string{"id": "1"},
+	}
+
+	request.Body = []byte(`{"text":"toolong"}`)
+	assert.Equal(t, []openapi.FieldError{{Field: "body.text", Message: "must be at most 5 characters long"}}, validator.Validate(request))
+
+	request.Body = []byte(`{}`)
+	assert.Equal(t, []openapi.FieldError{{Field: "body.text", Message: "is required"}}, validator.Validate(request))
+
+	request.Body = []byte(`not json`)
+	assert.Equal(t, "body", validator.Validate(request)[0].Field)
+}
+
+func Test_Validate_BodyInOtherFormats_ShouldBeValidatedLikeJSON(t *testing.T) {
+	validator := newNoteValidator()
+	request := openapi.Request{
+		Method:     http.MethodPost,
+		Path:       "/notes/{id}",
+		PathParams: map[string]string{"id": "1"},
+	}
+
+	request.ContentType = "application/xml"
+	request.Body = []byte(`<note><text>hey</text><priority>2</priority></note>`)
+	assert.Empty(t, validator.Validate(request))
+
+	request.Body = []byte(`<note><text>HEY</text><priority>high</priority></note>`)
+	assert.Equal(t, []openapi.FieldError{
+		{Field: "body.priority", Message: "must be an integer"},
+		{Field: "body.text", Message: "must be lowercase letters"},
+	}, validator.Validate(request))
+
+	request.ContentType = "application/yaml"
+	request.Body = []byte("text: hey\npriority: 0\n")
+	assert.Equal(t, []openapi.FieldError{{Field: "body.priority", Message: "must be at least 1"}}, validator.Validate(request))
+
+	request.Body = []byte("text: [hey")
+	assert.Equal(t, []openapi.FieldError{{Field: "body", Message: "must be valid YAML"}}, validator.Validate(request))
+}
+
+func Test_Validate_UndocumentedRoute_ShouldReturnNoErrors(t *testing.T) {
+	validator := newNoteValidator()
+
+	errs := validator.Validate(openapi.Request{Method: http.MethodGet, Path: "/notes"})
+
+	assert.Empty(t, errs)
+}
@@ -0,0 +1,85 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func SetupFullRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.New()
+	server.SetupMiddleware(ginRouter)
+
+	server.RegisterEventHandlers(ginRouter, new(mocks.IEventSubscriber))
+	server.RegisterWebhookHandlers(ginRouter, new(mocks.IWebhookUseCase))
+	server.RegisterHandlers(ginRouter, new(mocks.IBlogUseCase))
+	server.RegisterTrashHandlers(ginRouter, new(mocks.ITrashUseCase))
+	server.RegisterAttachmentHandlers(ginRouter, new(mocks.IAttachmentUseCase))
+	server.RegisterAuthorHandlers(ginRouter, new(mocks.IAuthorUseCase))
+	server.RegisterAuthHandlers(ginRouter, new(mocks.IAuthUseCase))
+	server.RegisterOpenAPI(ginRouter)
+
+	return ginRouter
+}
+
+func Test_OpenAPIDocument_ShouldMatchRegisteredRoutes(t *testing.T) {
+	_, drift := server.OpenAPIDocument(SetupFullRouter())
+
+	assert.Empty(t, drift.Undocumented, "routes without an operation in apiOperations")
+	assert.Empty(t, drift.Unrouted, "operations in apiOperations without a route")
+}
+
+func Test_OpenAPI_ShouldServeDocument(t *testing.T) {
+	testServer := httptest.NewServer(SetupFullRouter())
+	defer testServer.Close()
+	expect := httpexpect.Default(t, testServer.URL)
+
+	document := expect.GET("/openapi.json").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	document.Value("openapi").IsEqual("3.0.3")
+
+	getPost := document.Value("paths").Object().Value("/v1/api/blog/posts/{id}").Object().Value("get").Object()
+	getPost.Value("operationId").IsEqual("getPost")
+	getPost.Value("parameters").Array().Value(0).Object().
+		HasValue("name", "id").
+		HasValue("in", "path").
+		HasValue("required", true)
+	getPost.Value("responses").Object().Value("200").Object().
+		Value("content").Object().Value("application/json").Object().
+		Value("schema").Object().HasValue("$ref", "#/components/schemas/Post")
+
+	schemas := document.Value("components").Object().Value("schemas").Object()
+	schemas.Value("PostRequest").Object().Value("required").Array().IsEqual([]string{"content", "title"})
+	schemas.Value("Post").Object().Value("properties").Object().Keys().ContainsOnly("ID", "AuthorID", "Author", "Title", "Content", "Draft")
+	schemas.Value("PostIdResponse").Object().Value("properties").Object().ContainsKey("Id")
+}
+
+func Test_OpenAPI_ShouldServeSwaggerUI(t *testing.T) {
+	testServer := httptest.NewServer(SetupFullRouter())
+	defer testServer.Close()
+	expect := httpexpect.Default(t, testServer.URL)
+
+	expect.GET("/docs/").
+		Expect().
+		Status(http.StatusOK).
+		ContentType("text/html")
+
+	expect.GET("/docs/swagger-initializer.js").
+		Expect().
+		Status(http.StatusOK).
+		Body().Contains(`url: "/openapi.json"`)
+
+	expect.GET("/docs/swagger-ui-bundle.js").
+		Expect().
+		Status(http.StatusOK)
+}
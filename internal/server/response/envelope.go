@@ -0,0 +1,39 @@
+package response
+
+import (
+	"github.com/kondrushin/blog/internal/server/openapi"
+)
+
+// Envelope is the body of every v2 response. Successful responses carry data and,
+// for lists, meta. Failed responses carry errors only.
+type Envelope struct {
+	Data   any           `json:"data,omitempty"`
+	Meta   any           `json:"meta,omitempty"`
+	Errors []ErrorObject `json:"errors,omitempty"`
+}
+
+// ErrorObject describes a single error. Field is set when a value violates the API contract.
+type ErrorObject struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+// ErrorEnvelope documents the envelope of failed v2 responses.
+type ErrorEnvelope struct {
+	Errors []ErrorObject `json:"errors"`
+}
+
+// NewErrorEnvelope returns an error per violated field, or a single error when there are none.
+func NewErrorEnvelope(status int, message string, fields []openapi.FieldError) Envelope {
+	if len(fields) == 0 {
+		return Envelope{Errors: []ErrorObject{{Status: status, Message: message}}}
+	}
+
+	errs := make([]ErrorObject, 0, len(fields))
+	for _, field := range fields {
+		errs = append(errs, ErrorObject{Status: status, Message: field.Message, Field: field.Field})
+	}
+
+	return Envelope{Errors: errs}
+}
@@ -0,0 +1,28 @@
+package response
+
+import (
+	"errors"
+
+	"github.com/kondrushin/blog/internal/server/openapi"
+)
+
+// ErrorBody is the body of every error response.
+type ErrorBody struct {
+	Error  string               `json:"error"`
+	Fields []openapi.FieldError `json:"fields,omitempty"`
+}
+
+var ErrorValidation = errors.New("Request validation failed")
+
+// ValidationError carries field-level violations of the API contract.
+type ValidationError struct {
+	Fields []openapi.FieldError
+}
+
+func (e *ValidationError) Error() string {
+	return ErrorValidation.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return ErrorValidation
+}
@@ -3,8 +3,13 @@ package response
 type HttpError struct {
 	error
 	StatusCode int
+	Headers    map[string]string
 }
 
 func SetHttpStatusCode(err error, code int) error {
 	return &HttpError{error: err, StatusCode: code}
 }
+
+func SetHttpStatusCodeWithHeaders(err error, code int, headers map[string]string) error {
+	return &HttpError{error: err, StatusCode: code, Headers: headers}
+}
@@ -1,14 +1,34 @@
 package server
 
 import (
+	"context"
+	"net/http"
+	"sync"
+
 	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/cache"
+	"github.com/kondrushin/blog/internal/idempotency"
+	"github.com/kondrushin/blog/internal/ratelimit"
 	"github.com/kondrushin/blog/internal/server/middleware"
+	"github.com/kondrushin/blog/internal/server/openapi"
+	"github.com/kondrushin/blog/internal/server/versioning"
+	"github.com/kondrushin/blog/internal/tracing"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+)
+
+// apiVersions lists the versions the API is served in. A version is retired by
+// setting its Deprecation and Sunset dates.
+var apiVersions = versioning.NewSet("/api/blog", "v1",
+	versioning.Version{Name: "v1"},
+	versioning.Version{Name: "v2", Envelope: true},
 )
 
 func RegisterHandlers(r *gin.Engine, blogUseCase IBlogUseCase) {
 	s := Controller{UseCase: blogUseCase}
 
-	blogGroup := r.Group("/v1/api/blog")
+	router := versioning.NewRouter(r, apiVersions)
+
+	blogGroup := router.Version("v1")
 	{
 		blogGroup.GET("/posts/:id", s.GetPost)
 		blogGroup.GET("/posts", s.GetPosts)
@@ -16,9 +36,193 @@ func RegisterHandlers(r *gin.Engine, blogUseCase IBlogUseCase) {
 		blogGroup.DELETE("/posts/:id", s.DeletePost)
 		blogGroup.PUT("/posts/:id", s.UpdatePost)
 	}
+
+	v2 := ControllerV2{UseCase: blogUseCase}
+
+	blogGroupV2 := router.Version("v2")
+	{
+		blogGroupV2.GET("/posts/:id", v2.GetPost)
+		blogGroupV2.GET("/posts", v2.GetPosts)
+		blogGroupV2.POST("/posts", v2.CreatePost)
+		blogGroupV2.DELETE("/posts/:id", v2.DeletePost)
+		blogGroupV2.PUT("/posts/:id", v2.UpdatePost)
+	}
+}
+
+// RegisterTrashHandlers serves the trash of deleted posts.
+func RegisterTrashHandlers(r *gin.Engine, trashUseCase ITrashUseCase) {
+	s := TrashController{UseCase: trashUseCase}
+
+	blogGroup := versioning.NewRouter(r, apiVersions).Version("v1")
+	{
+		blogGroup.GET("/trash", s.GetDeletedPosts)
+		blogGroup.POST("/posts/:id/restore", s.RestorePost)
+	}
+}
+
+// RegisterAttachmentHandlers serves the attachments of posts.
+func RegisterAttachmentHandlers(r *gin.Engine, attachmentUseCase IAttachmentUseCase) {
+	s := AttachmentController{UseCase: attachmentUseCase}
+
+	blogGroup := versioning.NewRouter(r, apiVersions).Version("v1")
+	{
+		blogGroup.POST("/posts/:id/attachments", s.CreateAttachment)
+		blogGroup.GET("/posts/:id/attachments", s.GetAttachments)
+		blogGroup.GET("/posts/:id/attachments/:attachmentId", s.GetAttachment)
+		blogGroup.GET("/posts/:id/attachments/:attachmentId/thumbnail", s.GetThumbnail)
+		blogGroup.DELETE("/posts/:id/attachments/:attachmentId", s.DeleteAttachment)
+	}
+}
+
+func RegisterEventHandlers(r *gin.Engine, subscriber IEventSubscriber) {
+	s := EventsController{Subscriber: subscriber}
+
+	blogGroup := versioning.NewRouter(r, apiVersions).Version("v1")
+	{
+		blogGroup.GET("/events", s.StreamEvents)
+		blogGroup.GET("/events/ws", s.StreamEventsWebSocket)
+	}
+}
+
+func RegisterWebhookHandlers(r *gin.Engine, webhookUseCase IWebhookUseCase) {
+	s := WebhookController{UseCase: webhookUseCase}
+
+	blogGroup := versioning.NewRouter(r, apiVersions).Version("v1")
+	{
+		blogGroup.POST("/webhooks", s.CreateWebhook)
+		blogGroup.GET("/webhooks", s.GetWebhooks)
+		blogGroup.GET("/webhooks/:id", s.GetWebhook)
+		blogGroup.DELETE("/webhooks/:id", s.DeleteWebhook)
+		blogGroup.GET("/webhooks/:id/deliveries", s.GetWebhookDeliveries)
+		blogGroup.GET("/deliveries", s.GetDeliveries)
+		blogGroup.POST("/deliveries/:id/redeliver", s.RedeliverDelivery)
+	}
+}
+
+func RegisterAuthorHandlers(r *gin.Engine, authorUseCase IAuthorUseCase) {
+	s := AuthorController{UseCase: authorUseCase}
+
+	blogGroup := versioning.NewRouter(r, apiVersions).Version("v1")
+	{
+		blogGroup.POST("/authors", s.CreateAuthor)
+		blogGroup.GET("/authors", s.GetAuthors)
+		blogGroup.GET("/authors/:id", s.GetAuthor)
+		blogGroup.PUT("/authors/:id", s.UpdateAuthor)
+		blogGroup.DELETE("/authors/:id", s.DeleteAuthor)
+		blogGroup.GET("/authors/:id/posts", s.GetAuthorPosts)
+	}
+}
+
+func RegisterAuthHandlers(r *gin.Engine, authUseCase IAuthUseCase) {
+	s := AuthController{UseCase: authUseCase}
+
+	blogGroup := versioning.NewRouter(r, apiVersions).Version("v1")
+	{
+		blogGroup.POST("/auth/register", s.Register)
+		blogGroup.POST("/auth/login", s.Login)
+		blogGroup.POST("/auth/refresh", s.Refresh)
+		blogGroup.POST("/auth/logout", s.Logout)
+		blogGroup.PUT("/users/:id/role", s.SetRole)
+	}
+}
+
+// RegisterAuditHandlers serves the audit log at /admin/audit.
+func RegisterAuditHandlers(r *gin.Engine, auditUseCase IAuditUseCase) {
+	s := AuditController{UseCase: auditUseCase}
+
+	r.GET("/admin/audit", s.GetAuditEntries)
+}
+
+type ICacheStatsUseCase interface {
+	GetCacheStats(ctx context.Context) (cache.Stats, error)
+}
+
+// RegisterCacheStats serves the statistics of the post cache at /admin/cache/stats.
+func RegisterCacheStats(r *gin.Engine, statsUseCase ICacheStatsUseCase) {
+	r.GET("/admin/cache/stats", func(c *gin.Context) {
+		stats, err := statsUseCase.GetCacheStats(c.Request.Context())
+		if err != nil {
+			c.Error(err)
+			return
+		}
+
+		c.JSON(http.StatusOK, stats)
+	})
 }
 
 func SetupMiddleware(r *gin.Engine) {
+	r.Use(otelgin.Middleware(tracing.ServiceName))
+	r.Use(middleware.TraceResponseHeaderMiddleware())
+	r.Use(middleware.RequestIDMiddleware())
 	r.Use(middleware.HttpErrorHandlerMiddleware())
 	r.Use(gin.Recovery())
+	r.Use(apiVersions.Middleware())
+}
+
+// SetupTrustedProxies trusts the X-Forwarded-For header only of requests from the
+// proxies, given as IPs or CIDRs. Without proxies the client IP is the address of
+// the peer, so that clients cannot pick the IP that rate limits and idempotency
+// keys are scoped to. It must be called before SetupLimits and SetupIdempotency.
+func SetupTrustedProxies(r *gin.Engine, proxies []string) error {
+	return r.SetTrustedProxies(proxies)
+}
+
+// SetupCompression compresses responses of at least minBytes as negotiated by the
+// Accept-Encoding header. It must be called after SetupMiddleware.
+func SetupCompression(r *gin.Engine, minBytes int) {
+	r.Use(middleware.CompressionMiddleware(minBytes))
+}
+
+// SetupAuthentication authenticates requests with a bearer token. It must be called
+// after SetupMiddleware and before the handlers are registered.
+func SetupAuthentication(r *gin.Engine, authenticator middleware.IAuthenticator) {
+	r.Use(middleware.AuthenticationMiddleware(authenticator))
+}
+
+// SetupIdempotency replays responses to POST requests retried with the same
+// Idempotency-Key for the TTL of the config. It must be called after SetupAuthentication, so
+// that keys are scoped to the user, and before the handlers are registered.
+func SetupIdempotency(r *gin.Engine, cfg idempotency.Config) {
+	r.Use(middleware.IdempotencyMiddleware(idempotency.NewStore(cfg)))
+}
+
+type LimitsConfig struct {
+	// ReadRate and WriteRate are the sustained number of requests per second per client.
+	ReadRate  float64
+	WriteRate float64
+	// ReadBurst and WriteBurst are the number of requests a client can make at once.
+	ReadBurst  int
+	WriteBurst int
+	// MaxBodyBytes is the largest accepted request body.
+	MaxBodyBytes int64
+	// MaxUploadBytes is the largest accepted multipart body, MaxBodyBytes when smaller.
+	MaxUploadBytes int64
+	// APIKeys are the keys of the X-API-Key header that get budgets of their own.
+	// Requests with other keys are limited by the client IP.
+	APIKeys []string
+}
+
+// SetupValidation validates requests against the OpenAPI contract. It must be called after
+// SetupMiddleware and before the handlers are registered.
+func SetupValidation(r *gin.Engine) {
+	var once sync.Once
+	var validator *openapi.Validator
+
+	r.Use(middleware.RequestValidationMiddleware(func() *openapi.Validator {
+		once.Do(func() {
+			document, _ := OpenAPIDocument(r)
+			validator = openapi.NewValidator(document)
+		})
+		return validator
+	}))
+}
+
+// SetupLimits must be called after SetupMiddleware so that rejected requests are
+// reported by the error handler.
+func SetupLimits(r *gin.Engine, cfg LimitsConfig) {
+	reads := ratelimit.NewLimiter(cfg.ReadRate, cfg.ReadBurst)
+	writes := ratelimit.NewLimiter(cfg.WriteRate, cfg.WriteBurst)
+
+	r.Use(middleware.RateLimitMiddleware(reads, writes, cfg.APIKeys))
+	r.Use(middleware.MaxBodySizeMiddleware(cfg.MaxBodyBytes, cfg.MaxUploadBytes))
 }
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/negotiation"
+)
+
+// streamFlushInterval is the number of posts written between two flushes of a
+// streamed list.
+const streamFlushInterval = 100
+
+// postsListRequest selects the view or the fields and a streamed list of posts.
+// json writes the same document as the list that is not streamed, ndjson writes
+// one post per line.
+type postsListRequest struct {
+	postFieldsRequest
+	Stream string `form:"stream" binding:"omitempty,oneof=json ndjson" doc:"Write the posts while they are read, as one JSON document (json) or one post per line (ndjson). A JSON Accept header is required for json."`
+	View   string `form:"view" binding:"omitempty,oneof=full summary" doc:"summary returns the excerpt, word count and reading time of posts instead of their content. Selected fields take precedence."`
+}
+
+// representation returns the v1 representation of a listed post: the selected
+// fields when there are any, otherwise the view.
+func (r postsListRequest) representation(fields postFieldSet) func(*domain.Post) any {
+	switch {
+	case fields != nil:
+		return func(post *domain.Post) any { return newPostProjection(post, fields) }
+	case r.View == "summary":
+		return func(post *domain.Post) any { return newPostSummary(post) }
+	default:
+		return func(post *domain.Post) any { return post }
+	}
+}
+
+// streamPosts writes the posts in their representation while they are read, so
+// that the list is never held in memory. The status is sent with the first flush,
+// an error after it can only end the response early: a JSON document is then
+// left without its end.
+func (ctr *Controller) streamPosts(c *gin.Context, mode string, represent func(*domain.Post) any) {
+	contentType, prefix, separator, suffix := negotiation.JSON.ContentType(), `{"posts":[`, ",", "]}"
+	if mode == "ndjson" {
+		contentType, prefix, separator, suffix = ndjsonContentType, "", "", ""
+	}
+	c.Header("Content-Type", contentType)
+	c.Status(http.StatusOK)
+
+	w := bufio.NewWriter(c.Writer)
+	w.WriteString(prefix)
+
+	var writeErr error
+	count := 0
+	err := ctr.UseCase.EachPost(c.Request.Context(), func(post *domain.Post) bool {
+		data, err := json.Marshal(represent(post))
+		if err != nil {
+			writeErr = err
+			return false
+		}
+
+		if count > 0 {
+			w.WriteString(separator)
+		}
+		w.Write(data)
+		if mode == "ndjson" {
+			w.WriteByte('\n')
+		}
+
+		count++
+		if count%streamFlushInterval == 0 {
+			if writeErr = w.Flush(); writeErr != nil {
+				return false
+			}
+			c.Writer.Flush()
+		}
+		return true
+	})
+	if err == nil {
+		err = writeErr
+	}
+
+	if err != nil {
+		if !c.Writer.Written() {
+			// Nothing was sent yet, so the error can still be answered.
+			c.Writer.Header().Del("Content-Type")
+			c.Error(err)
+		}
+		return
+	}
+
+	w.WriteString(suffix)
+	w.Flush()
+}
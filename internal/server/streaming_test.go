@@ -0,0 +1,128 @@
+package server_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func manyPosts(count int) []*domain.Post {
+	posts := make([]*domain.Post, 0, count)
+	for i := 1; i <= count; i++ {
+		posts = append(posts, &domain.Post{ID: int64(i), AuthorID: 1, Author: "Anton", Title: fmt.Sprintf("Post %d", i), Content: "<b>streamed</b>"})
+	}
+	return posts
+}
+
+func expectEachPost(useCase *mocks.IBlogUseCase, posts []*domain.Post, err error) {
+	useCase.
+		On("EachPost", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			yield := args.Get(1).(func(*domain.Post) bool)
+			for _, post := range posts {
+				if !yield(post) {
+					return
+				}
+			}
+		}).
+		Return(err)
+}
+
+func Test_GetPosts_StreamJSON_ShouldWriteSameDocumentAsList(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	posts := manyPosts(250)
+	blogUseCaseMock.On("GetPosts", mock.Anything).Return(posts)
+	expectEachPost(blogUseCaseMock, posts, nil)
+
+	listed := expect.GET("/v1/api/blog/posts").
+		Expect().
+		Status(http.StatusOK).
+		Body().Raw()
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "json").
+		Expect().
+		Status(http.StatusOK)
+	resp.Header("Content-Type").IsEqual("application/json; charset=utf-8")
+	resp.Body().IsEqual(listed)
+	blogUseCaseMock.AssertNumberOfCalls(t, "GetPosts", 1)
+}
+
+func Test_GetPosts_StreamNDJSON_ShouldWriteOnePostPerLine(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expectEachPost(blogUseCaseMock, manyPosts(3), nil)
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "ndjson").
+		Expect().
+		Status(http.StatusOK)
+	resp.Header("Content-Type").IsEqual("application/x-ndjson")
+
+	lines := strings.Split(strings.TrimSuffix(resp.Body().Raw(), "\n"), "\n")
+	assert.Len(t, lines, 3)
+	assert.JSONEq(t, `{"ID":2,"AuthorID":1,"Author":"Anton","Title":"Post 2","Content":"<b>streamed</b>"}`, lines[1])
+}
+
+func Test_GetPosts_StreamSummaryView_ShouldLeaveOutContent(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	posts := manyPosts(2)
+	for _, post := range posts {
+		post.Summarize()
+	}
+	expectEachPost(blogUseCaseMock, posts, nil)
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "ndjson").
+		WithQuery("view", "summary").
+		Expect().
+		Status(http.StatusOK)
+
+	lines := strings.Split(strings.TrimSuffix(resp.Body().Raw(), "\n"), "\n")
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{"ID":2,"AuthorID":1,"Author":"Anton","Title":"Post 2","Excerpt":"<b>streamed</b>","WordCount":1,"ReadingMinutes":1}`, lines[1])
+}
+
+func Test_GetPosts_StreamFailsBeforeFirstPost_ShouldReturnError(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expectEachPost(blogUseCaseMock, nil, errors.New("storage is gone"))
+
+	resp := expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "ndjson").
+		Expect().
+		Status(http.StatusInternalServerError)
+	resp.Header("Content-Type").IsEqual("application/json; charset=utf-8")
+	resp.JSON().Object().Value("error").IsEqual("storage is gone")
+}
+
+func Test_GetPosts_StreamJSONNotAcceptable_ShouldNotReadPosts(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "json").
+		WithHeader("Accept", "text/csv").
+		Expect().
+		Status(http.StatusNotAcceptable)
+
+	expect.GET("/v1/api/blog/posts").
+		WithQuery("stream", "xml").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	blogUseCaseMock.AssertNotCalled(t, "EachPost", mock.Anything, mock.Anything)
+}
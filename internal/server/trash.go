@@ -0,0 +1,74 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type ITrashUseCase interface {
+	GetDeletedPosts(ctx context.Context) ([]*domain.Post, error)
+	RestorePost(ctx context.Context, id int64) (*domain.Post, error)
+}
+
+type TrashController struct {
+	UseCase ITrashUseCase
+}
+
+func (ctr *TrashController) GetDeletedPosts(c *gin.Context) {
+	posts, err := ctr.UseCase.GetDeletedPosts(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resModels := make([]deletedPostModel, 0, len(posts))
+	for _, p := range posts {
+		resModels = append(resModels, toDeletedPostModel(p))
+	}
+
+	c.JSON(http.StatusOK, trashResponse{Posts: resModels})
+}
+
+func (ctr *TrashController) RestorePost(c *gin.Context) {
+	var reqModel postIdRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	post, err := ctr.UseCase.RestorePost(c.Request.Context(), reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, newPostModel(post))
+}
+
+type deletedPostModel struct {
+	ID        int64     `json:"id"`
+	AuthorID  int64     `json:"author_id"`
+	Author    string    `json:"author"`
+	Title     string    `json:"title"`
+	Content   string    `json:"content"`
+	DeletedAt time.Time `json:"deleted_at"`
+}
+
+func toDeletedPostModel(post *domain.Post) deletedPostModel {
+	return deletedPostModel{
+		ID:        post.ID,
+		AuthorID:  post.AuthorID,
+		Author:    post.Author,
+		Title:     post.Title,
+		Content:   post.Content,
+		DeletedAt: post.DeletedAt,
+	}
+}
+
+type trashResponse struct {
+	Posts []deletedPostModel `json:"posts"`
+}
@@ -0,0 +1,90 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupTrashServer(t *testing.T, useCase *mocks.ITrashUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+
+	server.RegisterTrashHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	t.Cleanup(server.Close)
+
+	return httpexpect.Default(t, server.URL)
+}
+
+func Test_GetDeletedPosts_ShouldReturnPostsWithDeletionTime(t *testing.T) {
+	var trashUseCaseMock = new(mocks.ITrashUseCase)
+	expect := SetupTrashServer(t, trashUseCaseMock)
+
+	deletedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	trashUseCaseMock.
+		On("GetDeletedPosts", mock.Anything).
+		Return([]*domain.Post{{ID: 2, AuthorID: 1, Author: "Anton", Title: "On trash", Content: "qwerty", DeletedAt: deletedAt}}, nil)
+
+	post := expect.GET("/v1/api/blog/trash").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("posts").Array().Value(0).Object()
+
+	post.Value("id").IsEqual(2)
+	post.Value("title").IsEqual("On trash")
+	post.Value("deleted_at").IsEqual("2026-03-01T12:00:00Z")
+}
+
+func Test_GetDeletedPosts_Forbidden_ShouldReturnForbidden(t *testing.T) {
+	var trashUseCaseMock = new(mocks.ITrashUseCase)
+	expect := SetupTrashServer(t, trashUseCaseMock)
+
+	trashUseCaseMock.
+		On("GetDeletedPosts", mock.Anything).
+		Return(nil, domain.ErrorForbidden)
+
+	expect.GET("/v1/api/blog/trash").
+		Expect().
+		Status(http.StatusForbidden)
+}
+
+func Test_RestorePost_ShouldReturnRestoredPost(t *testing.T) {
+	var trashUseCaseMock = new(mocks.ITrashUseCase)
+	expect := SetupTrashServer(t, trashUseCaseMock)
+
+	trashUseCaseMock.
+		On("RestorePost", mock.Anything, int64(2)).
+		Return(&domain.Post{ID: 2, AuthorID: 1, Author: "Anton", Title: "On trash", Content: "qwerty"}, nil)
+
+	post := expect.POST("/v1/api/blog/posts/2/restore").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object()
+
+	post.Value("id").IsEqual(2)
+	post.NotContainsKey("deleted_at")
+	trashUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RestorePost_NotInTrash_ShouldReturnNotFound(t *testing.T) {
+	var trashUseCaseMock = new(mocks.ITrashUseCase)
+	expect := SetupTrashServer(t, trashUseCaseMock)
+
+	trashUseCaseMock.
+		On("RestorePost", mock.Anything, int64(3)).
+		Return(nil, domain.ErrorPostNotFound)
+
+	expect.POST("/v1/api/blog/posts/3/restore").
+		Expect().
+		Status(http.StatusNotFound)
+}
@@ -0,0 +1,158 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupValidatingServer(t *testing.T, useCase *mocks.IBlogUseCase, webhookUseCase *mocks.IWebhookUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+	server.SetupValidation(ginRouter)
+
+	server.RegisterHandlers(ginRouter, useCase)
+	server.RegisterWebhookHandlers(ginRouter, webhookUseCase)
+	server := httptest.NewServer(ginRouter)
+	expect := httpexpect.Default(t, server.URL)
+
+	return expect
+}
+
+func Test_Validation_ValidPost_ShouldReachUseCase(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	post := domain.Post{
+		Author:  "Anton",
+		Title:   "Big post",
+		Content: "first line\n\tsecond line",
+	}
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &post).
+		Return(int64(1), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithJSON(post).
+		Expect().
+		Status(http.StatusCreated)
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Validation_PostWithAuthorId_ShouldReachUseCase(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	blogUseCaseMock.
+		On("CreatePost", mock.Anything, &domain.Post{AuthorID: 1, Title: "Big post", Content: "something"}).
+		Return(int64(1), nil)
+
+	expect.POST("/v1/api/blog/posts").
+		WithJSON(map[string]any{"author_id": 1, "title": "Big post", "content": "something"}).
+		Expect().
+		Status(http.StatusCreated)
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Validation_BlankTitle_ShouldReturnFieldError(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	expect.POST("/v1/api/blog/posts").
+		WithJSON(map[string]string{"author": "Anton", "title": "   ", "content": "something"}).
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().IsEqual(map[string]any{
+		"error": "Request validation failed",
+		"fields": []map[string]string{
+			{"field": "body.title", "message": "must contain a visible character and no control characters"},
+		},
+	})
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Validation_ControlCharactersAndTooLongContent_ShouldReturnAllFieldErrors(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	fields := expect.PUT("/v1/api/blog/posts/1").
+		WithJSON(map[string]string{"author": "An\x07ton", "title": "Big post", "content": strings.Repeat("a", 100001)}).
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().Object().Value("fields").Array()
+
+	fields.Length().IsEqual(2)
+	fields.Value(0).Object().HasValue("field", "body.author")
+	fields.Value(1).Object().
+		HasValue("field", "body.content").
+		HasValue("message", "must be at most 100000 characters long")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Validation_MissingFieldsAndWrongTypes_ShouldReturnFieldErrors(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	fields := expect.POST("/v1/api/blog/posts").
+		WithJSON(map[string]any{"author": 42, "title": "Big post"}).
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().Object().Value("fields").Array()
+
+	fields.IsEqual([]map[string]string{
+		{"field": "body.content", "message": "is required"},
+		{"field": "body.author", "message": "must be a string"},
+	})
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Validation_InvalidPathParameter_ShouldReturnFieldError(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	expect.GET("/v1/api/blog/posts/abc").
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().Object().Value("fields").Array().Value(0).Object().
+		HasValue("field", "path.id").
+		HasValue("message", "must be an integer")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Validation_QueryEnumAndArrayEnum_ShouldReturnFieldErrors(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupValidatingServer(t, new(mocks.IBlogUseCase), webhookUseCaseMock)
+
+	expect.GET("/v1/api/blog/deliveries").
+		WithQuery("status", "lost").
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().Object().Value("fields").Array().Value(0).Object().
+		HasValue("field", "query.status").
+		HasValue("message", "must be one of pending, succeeded, dead")
+
+	expect.POST("/v1/api/blog/webhooks").
+		WithJSON(map[string]any{"url": "https://example.com", "events": []string{"post.created", "post.liked"}}).
+		Expect().
+		Status(http.StatusBadRequest).
+		JSON().Object().Value("fields").Array().Value(0).Object().
+		HasValue("field", "body.events[1]")
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
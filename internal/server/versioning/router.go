@@ -0,0 +1,97 @@
+package versioning
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+// Router registers the handlers of every version of a route. A handler is served
+// under its version prefix, and under the unversioned base path when its version
+// is negotiated.
+type Router struct {
+	set    *Set
+	engine *gin.Engine
+
+	// handlers maps "METHOD path" to the handler of every version.
+	handlers map[string]map[string]gin.HandlerFunc
+}
+
+func NewRouter(r *gin.Engine, set *Set) *Router {
+	return &Router{
+		set:      set,
+		engine:   r,
+		handlers: map[string]map[string]gin.HandlerFunc{},
+	}
+}
+
+// Group is the routes of one version.
+type Group struct {
+	router  *Router
+	version string
+	group   *gin.RouterGroup
+}
+
+func (rt *Router) Version(name string) *Group {
+	if _, isIn := rt.set.versions[name]; !isIn {
+		panic("versioning: unknown version " + name)
+	}
+
+	return &Group{
+		router:  rt,
+		version: name,
+		group:   rt.engine.Group("/" + name + rt.set.BasePath),
+	}
+}
+
+func (g *Group) GET(path string, handler gin.HandlerFunc) {
+	g.Handle(http.MethodGet, path, handler)
+}
+
+func (g *Group) POST(path string, handler gin.HandlerFunc) {
+	g.Handle(http.MethodPost, path, handler)
+}
+
+func (g *Group) PUT(path string, handler gin.HandlerFunc) {
+	g.Handle(http.MethodPut, path, handler)
+}
+
+func (g *Group) DELETE(path string, handler gin.HandlerFunc) {
+	g.Handle(http.MethodDelete, path, handler)
+}
+
+func (g *Group) Handle(method string, path string, handler gin.HandlerFunc) {
+	g.group.Handle(method, path, handler)
+	g.router.addNegotiated(method, path, g.version, handler)
+}
+
+func (rt *Router) addNegotiated(method string, path string, version string, handler gin.HandlerFunc) {
+	key := method + " " + path
+	versions, isIn := rt.handlers[key]
+	if !isIn {
+		versions = map[string]gin.HandlerFunc{}
+		rt.handlers[key] = versions
+		rt.engine.Handle(method, rt.set.BasePath+path, rt.negotiated(versions))
+	}
+
+	versions[version] = handler
+}
+
+func (rt *Router) negotiated(versions map[string]gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := rt.set.Default
+		if version, isIn := FromContext(c); isIn {
+			name = version.Name
+		}
+
+		handler, isIn := versions[name]
+		if !isIn {
+			c.Error(response.SetHttpStatusCode(ErrorRouteNotInVersion, http.StatusNotFound))
+			return
+		}
+
+		handler(c)
+	}
+}
@@ -0,0 +1,147 @@
+package versioning
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+// Header selects the version of requests to unversioned paths, e.g. "API-Version: v2".
+const Header = "API-Version"
+
+const contextKey = "versioning.version"
+
+var (
+	ErrorUnsupportedVersion = errors.New("Unsupported API version")
+	ErrorVersionRetired     = errors.New("API version is retired")
+	ErrorRouteNotInVersion  = errors.New("Route is not available in this API version")
+)
+
+// Version is one version of the API. Its name is also its path prefix.
+type Version struct {
+	Name string
+	// Envelope reports whether responses are wrapped in response.Envelope.
+	Envelope bool
+	// Deprecation and Sunset are announced in the headers of every response when set.
+	// Requests after Sunset are rejected with 410 Gone.
+	Deprecation time.Time
+	Sunset      time.Time
+}
+
+// Set is the list of versions an API is served in. Every version is served under
+// /<name><BasePath>, and the base path itself serves the version negotiated by the
+// API-Version header, or the default version without it.
+type Set struct {
+	BasePath string
+	Default  string
+
+	versions map[string]Version
+	now      func() time.Time
+}
+
+func NewSet(basePath string, defaultVersion string, versions ...Version) *Set {
+	s := &Set{
+		BasePath: basePath,
+		Default:  defaultVersion,
+		versions: map[string]Version{},
+		now:      time.Now,
+	}
+
+	for _, v := range versions {
+		s.versions[v.Name] = v
+	}
+	if _, isIn := s.versions[defaultVersion]; !isIn {
+		panic(fmt.Sprintf("versioning: default version %q is not in the set", defaultVersion))
+	}
+
+	return s
+}
+
+// WithClock sets the clock that tells whether a version is past its sunset.
+func (s *Set) WithClock(now func() time.Time) *Set {
+	s.now = now
+	return s
+}
+
+// Middleware resolves the version of the request from its path or from the
+// API-Version header and adds the version and lifecycle headers to the response.
+func (s *Set) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		path := c.Request.URL.Path
+
+		var version Version
+		var isIn bool
+		if prefix, rest, found := strings.Cut(strings.TrimPrefix(path, "/"), "/"); found && strings.HasPrefix("/"+rest, s.BasePath) {
+			version, isIn = s.versions[prefix]
+		} else if strings.HasPrefix(path, s.BasePath) {
+			version, isIn = s.negotiate(c.GetHeader(Header))
+			if !isIn {
+				c.Error(response.SetHttpStatusCode(ErrorUnsupportedVersion, http.StatusBadRequest))
+				c.Abort()
+				return
+			}
+		}
+		if !isIn {
+			c.Next()
+			return
+		}
+
+		c.Set(contextKey, version)
+		c.Header(Header, version.Name)
+		if !version.Deprecation.IsZero() {
+			c.Header("Deprecation", fmt.Sprintf("@%d", version.Deprecation.Unix()))
+		}
+		if !version.Sunset.IsZero() {
+			c.Header("Sunset", version.Sunset.UTC().Format(http.TimeFormat))
+
+			if !s.now().Before(version.Sunset) {
+				c.Error(response.SetHttpStatusCode(ErrorVersionRetired, http.StatusGone))
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// negotiate accepts versions with or without the "v" prefix.
+func (s *Set) negotiate(header string) (Version, bool) {
+	name := strings.TrimSpace(header)
+	if len(name) == 0 {
+		name = s.Default
+	} else if !strings.HasPrefix(name, "v") {
+		name = "v" + name
+	}
+
+	version, isIn := s.versions[name]
+	return version, isIn
+}
+
+// FromContext returns the version resolved by the middleware.
+func FromContext(c *gin.Context) (Version, bool) {
+	value, isIn := c.Get(contextKey)
+	if !isIn {
+		return Version{}, false
+	}
+
+	return value.(Version), true
+}
+
+// FullPath returns the route of the request with the version prefix, also when
+// the request was made to an unversioned path.
+func FullPath(c *gin.Context) string {
+	fullPath := c.FullPath()
+	version, isIn := FromContext(c)
+	if !isIn || strings.HasPrefix(fullPath, "/"+version.Name+"/") {
+		return fullPath
+	}
+
+	return "/" + version.Name + fullPath
+}
@@ -0,0 +1,121 @@
+package versioning_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/server/middleware"
+	"github.com/kondrushin/blog/internal/server/versioning"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	deprecation = time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	sunset      = time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func SetupEngine(now time.Time) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	set := versioning.NewSet("/api", "v1",
+		versioning.Version{Name: "v1", Deprecation: deprecation, Sunset: sunset},
+		versioning.Version{Name: "v2", Envelope: true},
+	).WithClock(func() time.Time { return now })
+
+	engine := gin.New()
+	engine.Use(middleware.HttpErrorHandlerMiddleware())
+	engine.Use(set.Middleware())
+
+	router := versioning.NewRouter(engine, set)
+	router.Version("v1").GET("/items", func(c *gin.Context) { c.String(http.StatusOK, "v1 items") })
+	router.Version("v2").GET("/items", func(c *gin.Context) { c.String(http.StatusOK, "v2 items") })
+	router.Version("v2").GET("/items/:id", func(c *gin.Context) { c.String(http.StatusOK, versioning.FullPath(c)) })
+
+	return engine
+}
+
+func serve(engine *gin.Engine, path string, version string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if len(version) > 0 {
+		req.Header.Set(versioning.Header, version)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func Test_Middleware_VersionedPath_ShouldServeVersion(t *testing.T) {
+	engine := SetupEngine(deprecation)
+
+	rec := serve(engine, "/v2/api/items", "v1")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "v2 items", rec.Body.String())
+	assert.Equal(t, "v2", rec.Header().Get(versioning.Header))
+	assert.Empty(t, rec.Header().Get("Deprecation"))
+	assert.Empty(t, rec.Header().Get("Sunset"))
+}
+
+func Test_Middleware_UnversionedPath_ShouldNegotiateVersion(t *testing.T) {
+	engine := SetupEngine(deprecation)
+
+	for header, expected := range map[string]string{"": "v1 items", "v1": "v1 items", "2": "v2 items", " v2 ": "v2 items"} {
+		rec := serve(engine, "/api/items", header)
+
+		assert.Equal(t, http.StatusOK, rec.Code, header)
+		assert.Equal(t, expected, rec.Body.String(), header)
+	}
+}
+
+func Test_Middleware_UnsupportedVersion_ShouldReturnBadRequest(t *testing.T) {
+	engine := SetupEngine(deprecation)
+
+	rec := serve(engine, "/api/items", "v3")
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.JSONEq(t, `{"error":"Unsupported API version"}`, rec.Body.String())
+}
+
+func Test_Middleware_DeprecatedVersion_ShouldAnnounceDeprecationAndSunset(t *testing.T) {
+	engine := SetupEngine(deprecation)
+
+	rec := serve(engine, "/v1/api/items", "")
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "@1767225600", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Fri, 01 Jan 2027 00:00:00 GMT", rec.Header().Get("Sunset"))
+}
+
+func Test_Middleware_AfterSunset_ShouldReturnGone(t *testing.T) {
+	engine := SetupEngine(sunset)
+
+	rec := serve(engine, "/api/items", "")
+
+	assert.Equal(t, http.StatusGone, rec.Code)
+	assert.JSONEq(t, `{"error":"API version is retired"}`, rec.Body.String())
+}
+
+func Test_Router_RouteMissingInVersion_ShouldReturnNotFound(t *testing.T) {
+	engine := SetupEngine(deprecation)
+
+	rec := serve(engine, "/api/items/1", "v1")
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.JSONEq(t, `{"error":"Route is not available in this API version"}`, rec.Body.String())
+}
+
+func Test_FullPath_UnversionedPath_ShouldHaveVersionPrefix(t *testing.T) {
+	engine := SetupEngine(deprecation)
+
+	assert.Equal(t, "/v2/api/items/:id", serve(engine, "/api/items/1", "v2").Body.String())
+	assert.Equal(t, "/v2/api/items/:id", serve(engine, "/v2/api/items/1", "").Body.String())
+}
+
+func Test_NewSet_UnknownDefault_ShouldPanic(t *testing.T) {
+	assert.Panics(t, func() {
+		versioning.NewSet("/api", "v3", versioning.Version{Name: "v1"})
+	})
+}
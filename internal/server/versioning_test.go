@@ -0,0 +1,97 @@
+package server_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/kondrushin/blog/internal/server/versioning"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_Unversioned_WithoutHeader_ShouldServeV1(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(&domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "Big post", Content: "something"}, nil)
+
+	response := expect.GET("/api/blog/posts/1").
+		Expect().
+		Status(http.StatusOK)
+
+	response.Header(versioning.Header).IsEqual("v1")
+	response.Body().IsEqual("{\"ID\":1,\"AuthorID\":1,\"Author\":\"Anton\",\"Title\":\"Big post\",\"Content\":\"something\"}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Unversioned_WithHeader_ShouldServeNegotiatedVersion(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	blogUseCaseMock.
+		On("GetPost", mock.Anything, int64(1)).
+		Return(&domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "Big post", Content: "something", Excerpt: "something", WordCount: 1, ReadingMinutes: 1}, nil)
+
+	response := expect.GET("/api/blog/posts/1").
+		WithHeader(versioning.Header, "v2").
+		Expect().
+		Status(http.StatusOK)
+
+	response.Header(versioning.Header).IsEqual("v2")
+	response.Body().IsEqual("{\"data\":{\"id\":1,\"author_id\":1,\"author\":\"Anton\",\"title\":\"Big post\",\"content\":\"something\",\"draft\":false,\"excerpt\":\"something\",\"word_count\":1,\"reading_minutes\":1}}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Unversioned_UnsupportedVersion_ShouldReturnBadRequest(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupServer(t, blogUseCaseMock)
+
+	expect.GET("/api/blog/posts").
+		WithHeader(versioning.Header, "v7").
+		Expect().
+		Status(http.StatusBadRequest).
+		Body().IsEqual("{\"error\":\"Unsupported API version\"}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Unversioned_Validation_ShouldUseNegotiatedVersion(t *testing.T) {
+	var blogUseCaseMock = new(mocks.IBlogUseCase)
+	expect := SetupValidatingServer(t, blogUseCaseMock, new(mocks.IWebhookUseCase))
+
+	expect.POST("/api/blog/posts").
+		WithHeader(versioning.Header, "2").
+		WithJSON(map[string]string{"author": "Anton", "title": "Big post"}).
+		Expect().
+		Status(http.StatusBadRequest).
+		Body().IsEqual("{\"errors\":[{\"status\":400,\"message\":\"is required\",\"field\":\"body.content\"}]}")
+
+	blogUseCaseMock.AssertExpectations(t)
+}
+
+func Test_Unversioned_RouteOnlyInV1_ShouldReturnNotFoundForV2(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupValidatingServer(t, new(mocks.IBlogUseCase), webhookUseCaseMock)
+
+	webhookUseCaseMock.
+		On("GetWebhooks", mock.Anything).
+		Return([]*domain.Webhook{}, nil)
+
+	expect.GET("/api/blog/webhooks").
+		Expect().
+		Status(http.StatusOK)
+
+	expect.GET("/api/blog/webhooks").
+		WithHeader(versioning.Header, "v2").
+		Expect().
+		Status(http.StatusNotFound).
+		JSON().Object().Value("errors").Array().Value(0).Object().
+		HasValue("message", versioning.ErrorRouteNotInVersion.Error())
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
@@ -0,0 +1,260 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server/response"
+)
+
+type IWebhookUseCase interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) (int64, error)
+	GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context) ([]*domain.Webhook, error)
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetDeliveries(ctx context.Context, filter domain.DeliveryFilter) ([]*domain.Delivery, error)
+	RedeliverDelivery(ctx context.Context, id int64) error
+}
+
+type WebhookController struct {
+	UseCase IWebhookUseCase
+}
+
+func (ctr *WebhookController) CreateWebhook(c *gin.Context) {
+	var reqModel webhookRequest
+	if err := readJSON(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhook := reqModel.toDomainModel()
+	id, err := ctr.UseCase.CreateWebhook(c.Request.Context(), webhook)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	// The secret is only revealed once, when the webhook is created.
+	resModel := toWebhookModel(webhook)
+	resModel.ID = id
+	resModel.Secret = webhook.Secret
+	c.JSON(http.StatusCreated, resModel)
+}
+
+func (ctr *WebhookController) GetWebhook(c *gin.Context) {
+	var reqModel idRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	webhook, err := ctr.UseCase.GetWebhook(c.Request.Context(), reqModel.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toWebhookModel(webhook))
+}
+
+func (ctr *WebhookController) GetWebhooks(c *gin.Context) {
+	webhooks, err := ctr.UseCase.GetWebhooks(c.Request.Context())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resModels := make([]webhookModel, 0, len(webhooks))
+	for _, w := range webhooks {
+		resModels = append(resModels, toWebhookModel(w))
+	}
+
+	c.JSON(http.StatusOK, webhooksResponse{Webhooks: resModels})
+}
+
+func (ctr *WebhookController) DeleteWebhook(c *gin.Context) {
+	var reqModel idRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctr.UseCase.DeleteWebhook(c.Request.Context(), reqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (ctr *WebhookController) GetWebhookDeliveries(c *gin.Context) {
+	var idReqModel idRequest
+	if err := readPathParameters(c, &idReqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	var reqModel deliveriesRequest
+	if err := readQueryParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+	reqModel.WebhookID = idReqModel.ID
+
+	ctr.writeDeliveries(c, reqModel)
+}
+
+// GetDeliveries returns the delivery log of all webhooks. With status=dead it is the dead-letter list.
+func (ctr *WebhookController) GetDeliveries(c *gin.Context) {
+	var reqModel deliveriesRequest
+	if err := readQueryParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	ctr.writeDeliveries(c, reqModel)
+}
+
+func (ctr *WebhookController) RedeliverDelivery(c *gin.Context) {
+	var reqModel idRequest
+	if err := readPathParameters(c, &reqModel); err != nil {
+		c.Error(err)
+		return
+	}
+
+	if err := ctr.UseCase.RedeliverDelivery(c.Request.Context(), reqModel.ID); err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, idResponse{ID: reqModel.ID})
+}
+
+func (ctr *WebhookController) writeDeliveries(c *gin.Context, reqModel deliveriesRequest) {
+	deliveries, err := ctr.UseCase.GetDeliveries(c.Request.Context(), domain.DeliveryFilter{
+		WebhookID: reqModel.WebhookID,
+		Status:    domain.DeliveryStatus(reqModel.Status),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	resModels := make([]deliveryModel, 0, len(deliveries))
+	for _, d := range deliveries {
+		resModels = append(resModels, toDeliveryModel(d))
+	}
+
+	c.JSON(http.StatusOK, deliveriesResponse{Deliveries: resModels})
+}
+
+func readQueryParameters(c *gin.Context, dst any) error {
+	err := c.ShouldBindQuery(dst)
+	if err != nil {
+		err = response.SetHttpStatusCode(err, http.StatusBadRequest)
+		return err
+	}
+
+	return nil
+}
+
+type idRequest struct {
+	ID int64 `uri:"id"`
+}
+
+type webhookRequest struct {
+	URL    string   `json:"url" binding:"required,max=2048"`
+	Secret string   `json:"secret" binding:"max=256"`
+	Events []string `json:"events" binding:"omitempty,dive,oneof=post.created post.updated post.deleted"`
+}
+
+type deliveriesRequest struct {
+	WebhookID int64  `form:"webhook_id"`
+	Status    string `form:"status" binding:"omitempty,oneof=pending succeeded dead"`
+}
+
+func (w *webhookRequest) toDomainModel() *domain.Webhook {
+	eventTypes := make([]domain.EventType, 0, len(w.Events))
+	for _, e := range w.Events {
+		eventTypes = append(eventTypes, domain.EventType(e))
+	}
+
+	return &domain.Webhook{
+		URL:    w.URL,
+		Secret: w.Secret,
+		Events: eventTypes,
+	}
+}
+
+type webhookModel struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret,omitempty"`
+	Events    []string  `json:"events"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type deliveryModel struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+type webhooksResponse struct {
+	Webhooks []webhookModel `json:"webhooks"`
+}
+
+type deliveriesResponse struct {
+	Deliveries []deliveryModel `json:"deliveries"`
+}
+
+type idResponse struct {
+	ID int64 `json:"id"`
+}
+
+func toWebhookModel(w *domain.Webhook) webhookModel {
+	eventTypes := make([]string, 0, len(w.Events))
+	for _, e := range w.Events {
+		eventTypes = append(eventTypes, string(e))
+	}
+
+	return webhookModel{
+		ID:        w.ID,
+		URL:       w.URL,
+		Events:    eventTypes,
+		CreatedAt: w.CreatedAt,
+	}
+}
+
+func toDeliveryModel(d *domain.Delivery) deliveryModel {
+	resModel := deliveryModel{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		EventType:      string(d.EventType),
+		Status:         string(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+	}
+
+	if d.Status == domain.DeliveryPending {
+		resModel.NextAttemptAt = &d.NextAttemptAt
+	} else {
+		resModel.CompletedAt = &d.CompletedAt
+	}
+
+	return resModel
+}
@@ -0,0 +1,152 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/server/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func SetupWebhookServer(t *testing.T, useCase *mocks.IWebhookUseCase) *httpexpect.Expect {
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.Default()
+	server.SetupMiddleware(ginRouter)
+
+	server.RegisterWebhookHandlers(ginRouter, useCase)
+	server := httptest.NewServer(ginRouter)
+	expect := httpexpect.Default(t, server.URL)
+
+	return expect
+}
+
+func Test_CreateWebhook_ShouldReturnWebhookWithSecret(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	webhookUseCaseMock.
+		On("CreateWebhook", mock.Anything, &domain.Webhook{URL: "https://example.com/hook", Events: []domain.EventType{domain.EventPostCreated}}).
+		Run(func(args mock.Arguments) {
+			args.Get(1).(*domain.Webhook).Secret = "generated"
+		}).
+		Return(int64(1), nil)
+
+	body := expect.POST("/v1/api/blog/webhooks").
+		WithJSON(map[string]any{"url": "https://example.com/hook", "events": []string{"post.created"}}).
+		Expect().
+		Status(http.StatusCreated).
+		JSON().Object()
+
+	body.Value("id").IsEqual(1)
+	body.Value("secret").IsEqual("generated")
+	body.Value("events").Array().IsEqual([]string{"post.created"})
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
+
+func Test_CreateWebhook_InvalidInput_ShouldReturnBadRequest(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	webhookUseCaseMock.
+		On("CreateWebhook", mock.Anything, mock.Anything).
+		Return(int64(0), domain.ErrorInvalidInput)
+
+	expect.POST("/v1/api/blog/webhooks").
+		WithJSON(map[string]any{"url": "example.com"}).
+		Expect().
+		Status(http.StatusBadRequest)
+
+	expect.POST("/v1/api/blog/webhooks").
+		WithJSON(map[string]any{"events": []string{"post.created"}}).
+		Expect().
+		Status(http.StatusBadRequest)
+
+	webhookUseCaseMock.AssertNumberOfCalls(t, "CreateWebhook", 1)
+}
+
+func Test_GetWebhook_ShouldNotRevealSecret(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	webhookUseCaseMock.
+		On("GetWebhook", mock.Anything, int64(1)).
+		Return(&domain.Webhook{ID: 1, URL: "https://example.com/hook", Secret: "secret"}, nil)
+
+	expect.GET("/v1/api/blog/webhooks/1").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().NotContainsKey("secret")
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
+
+func Test_DeleteWebhook_NoWebhook_ShouldReturnNotFound(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	webhookUseCaseMock.
+		On("DeleteWebhook", mock.Anything, int64(1)).
+		Return(domain.ErrorWebhookNotFound)
+
+	expect.DELETE("/v1/api/blog/webhooks/1").
+		Expect().
+		Status(http.StatusNotFound)
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
+
+func Test_GetDeliveries_DeadLetters_ShouldFilterByStatus(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	completedAt := time.Date(2024, 7, 1, 10, 0, 0, 0, time.UTC)
+	webhookUseCaseMock.
+		On("GetDeliveries", mock.Anything, domain.DeliveryFilter{Status: domain.DeliveryDead}).
+		Return([]*domain.Delivery{{ID: 4, WebhookID: 1, EventID: 9, EventType: domain.EventPostDeleted, Status: domain.DeliveryDead, Attempts: 6, CompletedAt: completedAt}}, nil)
+
+	delivery := expect.GET("/v1/api/blog/deliveries").
+		WithQuery("status", "dead").
+		Expect().
+		Status(http.StatusOK).
+		JSON().Object().Value("deliveries").Array().Value(0).Object()
+
+	delivery.Value("id").IsEqual(4)
+	delivery.Value("status").IsEqual("dead")
+	delivery.Value("completed_at").IsEqual("2024-07-01T10:00:00Z")
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
+
+func Test_GetDeliveries_UnknownStatus_ShouldReturnBadRequest(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	expect.GET("/v1/api/blog/webhooks/1/deliveries").
+		WithQuery("status", "lost").
+		Expect().
+		Status(http.StatusBadRequest)
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
+
+func Test_RedeliverDelivery_ShouldReturnAccepted(t *testing.T) {
+	var webhookUseCaseMock = new(mocks.IWebhookUseCase)
+	expect := SetupWebhookServer(t, webhookUseCaseMock)
+
+	webhookUseCaseMock.
+		On("RedeliverDelivery", mock.Anything, int64(4)).
+		Return(nil)
+
+	expect.POST("/v1/api/blog/deliveries/4/redeliver").
+		Expect().
+		Status(http.StatusAccepted)
+
+	webhookUseCaseMock.AssertExpectations(t)
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// auditRecord is a line of the audit log.
+type auditRecord struct {
+	ID         int64       `json:"id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	ActorID    int64       `json:"actor_id,omitempty"`
+	Actor      string      `json:"actor,omitempty"`
+	Action     string      `json:"action"`
+	PostID     int64       `json:"post_id"`
+	RequestID  string      `json:"request_id,omitempty"`
+	Before     *postRecord `json:"before,omitempty"`
+	After      *postRecord `json:"after,omitempty"`
+}
+
+// LoadAudit reads the entries of the audit log in the order they were appended.
+// An incomplete last entry, left by a crash, is dropped and overwritten by the next one.
+func (s *Store) LoadAudit() ([]*domain.AuditEntry, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.audit.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var entries []*domain.AuditEntry
+	var size int64
+	reader := bufio.NewReader(s.audit)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var rec auditRecord
+			if err := json.Unmarshal(line, &rec); err != nil {
+				return nil, fmt.Errorf("audit log record at %d: %w", size, err)
+			}
+			entries = append(entries, rec.toDomain())
+			size += int64(len(line))
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	s.auditSize = size
+
+	return entries, nil
+}
+
+// AppendAudit appends the entry to the audit log.
+func (s *Store) AppendAudit(entry *domain.AuditEntry) error {
+	line, err := json.Marshal(newAuditRecord(entry))
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.audit.WriteAt(line, s.auditSize); err != nil {
+		return err
+	}
+	s.auditSize += int64(len(line))
+
+	return s.written()
+}
+
+func newAuditRecord(entry *domain.AuditEntry) *auditRecord {
+	rec := &auditRecord{
+		ID:         entry.ID,
+		OccurredAt: entry.OccurredAt,
+		ActorID:    entry.ActorID,
+		Actor:      entry.Actor,
+		Action:     string(entry.Action),
+		PostID:     entry.PostID,
+		RequestID:  entry.RequestID,
+	}
+	if entry.Before != nil {
+		rec.Before = newPostRecord(entry.Before)
+	}
+	if entry.After != nil {
+		rec.After = newPostRecord(entry.After)
+	}
+
+	return rec
+}
+
+func (a *auditRecord) toDomain() *domain.AuditEntry {
+	entry := &domain.AuditEntry{
+		ID:         a.ID,
+		OccurredAt: a.OccurredAt,
+		ActorID:    a.ActorID,
+		Actor:      a.Actor,
+		Action:     domain.AuditAction(a.Action),
+		PostID:     a.PostID,
+		RequestID:  a.RequestID,
+	}
+	if a.Before != nil {
+		entry.Before = a.Before.toDomain()
+	}
+	if a.After != nil {
+		entry.After = a.After.toDomain()
+	}
+
+	return entry
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package storage
+
+import (
+	"os"
+)
+
+// lockDir only creates the lock file on platforms without flock.
+func lockDir(path string) (*os.File, error) {
+	return os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+}
+
+func unlockDir(file *os.File) {
+	file.Close()
+}
@@ -0,0 +1,351 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Report lists the integrity problems found by Verify.
+type Report struct {
+	Posts       int
+	Authors     int
+	Attachments int
+	Users       int
+	Sessions    int
+	Webhooks    int
+	Deliveries  int
+	Sequence    int64
+	Records     int
+	Problems    []string
+}
+
+func (r *Report) OK() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *Report) problem(format string, args ...any) {
+	r.Problems = append(r.Problems, fmt.Sprintf(format, args...))
+}
+
+// Verify checks the journal, the index and the ID sequence of the store in dir
+// without changing it. The store must not be open.
+func Verify(dir string) (*Report, error) {
+	lock, err := lockDir(filepath.Join(dir, lockFile))
+	if err != nil {
+		return nil, err
+	}
+	defer unlockDir(lock)
+
+	report := &Report{}
+	scanned := newIndex()
+
+	journal, err := os.Open(filepath.Join(dir, journalFile))
+	if errors.Is(err, os.ErrNotExist) {
+		report.problem("journal is missing")
+		return report, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer journal.Close()
+
+	err = scan(journal, 0, func(offset int64, r record) error {
+		report.Records++
+		if r.Op == opPut && r.Sequence > 0 && r.Post.ID > r.Sequence {
+			report.problem("post %d at %d is ahead of the sequence %d", r.Post.ID, offset, r.Sequence)
+		}
+		scanned.apply(offset, r)
+		return nil
+	})
+	if err != nil {
+		report.problem("%v", err)
+		return report, nil
+	}
+
+	info, err := journal.Stat()
+	if err != nil {
+		return nil, err
+	}
+	scanned.JournalSize = info.Size()
+	report.Posts = len(scanned.Posts)
+	report.Authors = len(scanned.Authors)
+	report.Attachments = len(scanned.Attachments)
+	report.Users = len(scanned.Users)
+	report.Sessions = len(scanned.Sessions)
+	report.Webhooks = len(scanned.Webhooks)
+	report.Deliveries = len(scanned.Deliveries)
+	report.Sequence = scanned.Sequence
+
+	for id := range scanned.Posts {
+		if id > scanned.Sequence {
+			report.problem("post %d is ahead of the sequence %d", id, scanned.Sequence)
+		}
+	}
+	for _, id := range authorsOfPosts(journal, scanned) {
+		report.problem("posts reference deleted or unknown author %d", id)
+	}
+	for _, id := range postsOfAttachments(journal, scanned) {
+		report.problem("attachments reference purged or unknown post %d", id)
+	}
+
+	idx, err := readIndex(dir)
+	if err != nil {
+		report.problem("%v", err)
+		return report, nil
+	}
+	if idx.JournalSize == 0 && len(idx.Posts) == 0 {
+		return report, nil
+	}
+	if idx.JournalSize != scanned.JournalSize {
+		report.problem("index covers %d bytes of a %d bytes journal", idx.JournalSize, scanned.JournalSize)
+	}
+	if idx.Sequence != scanned.Sequence {
+		report.problem("index sequence %d differs from journal sequence %d", idx.Sequence, scanned.Sequence)
+	}
+	compareIndexed(report, "post", scanned.Posts, idx.Posts)
+	compareIndexed(report, "author", scanned.Authors, idx.Authors)
+	compareIndexed(report, "attachment", scanned.Attachments, idx.Attachments)
+	compareIndexed(report, "user", scanned.Users, idx.Users)
+	compareIndexed(report, "session", scanned.Sessions, idx.Sessions)
+	compareIndexed(report, "webhook", scanned.Webhooks, idx.Webhooks)
+	compareIndexed(report, "delivery", scanned.Deliveries, idx.Deliveries)
+
+	return report, nil
+}
+
+// compareIndexed reports the differences of the indexed offsets of the kind of
+// records from the scanned ones.
+func compareIndexed(report *Report, kind string, scanned, indexed map[int64]int64) {
+	for id, offset := range scanned {
+		if at, isIn := indexed[id]; !isIn {
+			report.problem("%s %d is missing in the index", kind, id)
+		} else if at != offset {
+			report.problem("index points %s %d to %d instead of %d", kind, id, at, offset)
+		}
+	}
+	for id := range indexed {
+		if _, isIn := scanned[id]; !isIn {
+			report.problem("index has deleted or unknown %s %d", kind, id)
+		}
+	}
+}
+
+// authorsOfPosts returns the IDs of authors which are referenced by current posts
+// but do not exist. Posts stored before authors were introduced have no author ID.
+func authorsOfPosts(journal *os.File, idx index) []int64 {
+	missing := map[int64]bool{}
+	for _, offset := range idx.Posts {
+		r, err := readRecord(journal, offset)
+		if err != nil || r.Post.AuthorID == 0 {
+			continue
+		}
+		if _, isIn := idx.Authors[r.Post.AuthorID]; !isIn {
+			missing[r.Post.AuthorID] = true
+		}
+	}
+
+	ids := make([]int64, 0, len(missing))
+	for id := range missing {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// postsOfAttachments returns the IDs of posts which are referenced by current
+// attachments but do not exist.
+func postsOfAttachments(journal *os.File, idx index) []int64 {
+	missing := map[int64]bool{}
+	for _, offset := range idx.Attachments {
+		r, err := readRecord(journal, offset)
+		if err != nil {
+			continue
+		}
+		if _, isIn := idx.Posts[r.Attachment.PostID]; !isIn {
+			missing[r.Attachment.PostID] = true
+		}
+	}
+
+	ids := make([]int64, 0, len(missing))
+	for id := range missing {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+// Reindex rebuilds the index of the store in dir from its journal. An incomplete
+// last record is cut off.
+func Reindex(dir string) error {
+	lock, err := lockDir(filepath.Join(dir, lockFile))
+	if err != nil {
+		return err
+	}
+	defer unlockDir(lock)
+
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	info, err := journal.Stat()
+	if err != nil {
+		return err
+	}
+
+	idx := newIndex()
+	err = scan(journal, 0, func(offset int64, r record) error {
+		idx.apply(offset, r)
+		return nil
+	})
+	idx.JournalSize, err = truncateTorn(journal, info.Size(), err)
+	if err != nil {
+		return err
+	}
+
+	return writeIndex(dir, idx)
+}
+
+// Compact rewrites the journal of the store in dir with only the latest record
+// of every author, post, attachment, user, session, webhook and delivery,
+// dropping overwritten and deleted ones and deliveries of deleted webhooks.
+// The ID sequences are kept, so IDs of deleted records are not reused.
+func Compact(dir string) error {
+	// The store is only read, so it needs no periodic sync, which release
+	// would not stop.
+	s, err := OpenWithConfig(dir, Config{Sync: SyncOnClose})
+	if err != nil {
+		return err
+	}
+	defer s.release()
+
+	posts, sequence, err := s.Load()
+	if err != nil {
+		return err
+	}
+	authors, authorSequence, err := s.LoadAuthors()
+	if err != nil {
+		return err
+	}
+	attachments, attachmentSequence, err := s.LoadAttachments()
+	if err != nil {
+		return err
+	}
+	users, userSequence, err := s.LoadUsers()
+	if err != nil {
+		return err
+	}
+	sessions, sessionSequence, err := s.LoadSessions()
+	if err != nil {
+		return err
+	}
+	webhooks, webhookSequence, err := s.LoadWebhooks()
+	if err != nil {
+		return err
+	}
+	deliveries, deliverySequence, err := s.LoadDeliveries()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := filepath.Join(dir, journalFile+".tmp")
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	compacted := &Store{dir: dir, journal: tmp, index: newIndex()}
+	for id := int64(1); id <= authorSequence; id++ {
+		if author, isIn := authors[id]; isIn {
+			if err := compacted.PutAuthor(author, authorSequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	for id := int64(1); id <= sequence; id++ {
+		if post, isIn := posts[id]; isIn {
+			if err := compacted.Put(post, sequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	for id := int64(1); id <= attachmentSequence; id++ {
+		if attachment, isIn := attachments[id]; isIn {
+			if err := compacted.PutAttachment(attachment, attachmentSequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	for id := int64(1); id <= userSequence; id++ {
+		if user, isIn := users[id]; isIn {
+			if err := compacted.PutUser(user, userSequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	for id := int64(1); id <= sessionSequence; id++ {
+		if session, isIn := sessions[id]; isIn {
+			if err := compacted.PutSession(session, sessionSequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	for id := int64(1); id <= webhookSequence; id++ {
+		if webhook, isIn := webhooks[id]; isIn {
+			if err := compacted.PutWebhook(webhook, webhookSequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	for id := int64(1); id <= deliverySequence; id++ {
+		if delivery, isIn := deliveries[id]; isIn && webhooks[delivery.WebhookID] != nil {
+			if err := compacted.PutDelivery(delivery, deliverySequence); err != nil {
+				tmp.Close()
+				return err
+			}
+		}
+	}
+	sequences := record{
+		Op:                 opSequence,
+		Sequence:           sequence,
+		AuthorSequence:     authorSequence,
+		AttachmentSequence: attachmentSequence,
+		UserSequence:       userSequence,
+		SessionSequence:    sessionSequence,
+		WebhookSequence:    webhookSequence,
+		DeliverySequence:   deliverySequence,
+	}
+	if err := compacted.append(sequences); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	// The index of the old journal must not outlive it.
+	if err := os.Remove(filepath.Join(dir, indexFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmpPath, filepath.Join(dir, journalFile)); err != nil {
+		return err
+	}
+
+	return writeIndex(dir, compacted.index)
+}
@@ -0,0 +1,776 @@
+// Package storage persists posts, authors, attachments, users, sessions,
+// webhooks and webhook deliveries in a directory with an append-only journal of
+// changes and an index of the latest record of each of them in the journal.
+//
+// The index is written when the store is closed. A store that was not closed
+// properly is recovered on open by replaying the journal after the indexed part.
+// An incomplete last record, left by a crash in the middle of a write, is cut
+// off, so only the change that was being written is lost.
+//
+// How many acknowledged changes a power failure can lose depends on the Sync
+// policy of the Config: none with SyncAlways, those of the last SyncInterval
+// with SyncPeriodic and all since the store was opened with SyncOnClose. A
+// crash of the process alone loses nothing, as written records are in the
+// page cache of the operating system.
+//
+// Audit entries are appended to a log of their own, which is never compacted.
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+const (
+	journalFile = "journal.log"
+	indexFile   = "index.json"
+	auditFile   = "audit.log"
+	lockFile    = "LOCK"
+)
+
+var ErrorStoreLocked = errors.New("Store is used by another process")
+
+// Sync policies tell when written records are flushed to the disk.
+const (
+	SyncAlways   = "always"
+	SyncPeriodic = "periodic"
+	SyncOnClose  = "close"
+)
+
+type Config struct {
+	// Sync is SyncAlways to flush every record before the change is
+	// acknowledged, SyncPeriodic to flush every SyncInterval or SyncOnClose to
+	// flush only when the store is closed.
+	Sync         string
+	SyncInterval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{Sync: SyncPeriodic, SyncInterval: time.Second}
+}
+
+func (c Config) validate() error {
+	switch c.Sync {
+	case SyncAlways, SyncOnClose:
+	case SyncPeriodic:
+		if c.SyncInterval <= 0 {
+			return fmt.Errorf("sync interval must be positive, got %v", c.SyncInterval)
+		}
+	default:
+		return fmt.Errorf("unknown sync policy %q", c.Sync)
+	}
+
+	return nil
+}
+
+const (
+	opPut          = "put"
+	opDelete       = "delete"
+	opSequence     = "sequence"
+	opPutAuthor    = "put_author"
+	opDeleteAuthor = "delete_author"
+
+	opPutAttachment    = "put_attachment"
+	opDeleteAttachment = "delete_attachment"
+
+	opPutUser       = "put_user"
+	opPutSession    = "put_session"
+	opDeleteSession = "delete_session"
+
+	opPutWebhook     = "put_webhook"
+	opDeleteWebhook  = "delete_webhook"
+	opPutDelivery    = "put_delivery"
+	opDeleteDelivery = "delete_delivery"
+)
+
+// record is a line of the journal. Put records carry the post, the author, the
+// attachment, the user, the session, the webhook or the delivery and the ID
+// sequence at the time of the change, delete records the ID, sequence records
+// only the ID sequences.
+type record struct {
+	Op                 string            `json:"op"`
+	ID                 int64             `json:"id,omitempty"`
+	Post               *postRecord       `json:"post,omitempty"`
+	Author             *authorRecord     `json:"author,omitempty"`
+	Attachment         *attachmentRecord `json:"attachment,omitempty"`
+	User               *userRecord       `json:"user,omitempty"`
+	Session            *sessionRecord    `json:"session,omitempty"`
+	Webhook            *webhookRecord    `json:"webhook,omitempty"`
+	Delivery           *deliveryRecord   `json:"delivery,omitempty"`
+	Sequence           int64             `json:"seq,omitempty"`
+	AuthorSequence     int64             `json:"author_seq,omitempty"`
+	AttachmentSequence int64             `json:"attachment_seq,omitempty"`
+	UserSequence       int64             `json:"user_seq,omitempty"`
+	SessionSequence    int64             `json:"session_seq,omitempty"`
+	WebhookSequence    int64             `json:"webhook_seq,omitempty"`
+	DeliverySequence   int64             `json:"delivery_seq,omitempty"`
+}
+
+type postRecord struct {
+	ID             int64      `json:"id"`
+	AuthorID       int64      `json:"author_id,omitempty"`
+	Author         string     `json:"author"`
+	Title          string     `json:"title"`
+	Content        string     `json:"content"`
+	Draft          bool       `json:"draft,omitempty"`
+	Excerpt        string     `json:"excerpt,omitempty"`
+	WordCount      int        `json:"word_count,omitempty"`
+	ReadingMinutes int        `json:"reading_minutes,omitempty"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
+}
+
+type authorRecord struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Bio       string `json:"bio,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+type attachmentRecord struct {
+	ID            int64     `json:"id"`
+	PostID        int64     `json:"post_id"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	Hash          string    `json:"sha256"`
+	ThumbnailType string    `json:"thumbnail_type,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// index maps post, author, attachment, user, session, webhook and delivery IDs
+// to the offset of their latest put record. JournalSize is the length of the
+// journal the index covers.
+type index struct {
+	Sequence           int64           `json:"sequence"`
+	AuthorSequence     int64           `json:"author_sequence"`
+	AttachmentSequence int64           `json:"attachment_sequence"`
+	UserSequence       int64           `json:"user_sequence"`
+	SessionSequence    int64           `json:"session_sequence"`
+	WebhookSequence    int64           `json:"webhook_sequence"`
+	DeliverySequence   int64           `json:"delivery_sequence"`
+	JournalSize        int64           `json:"journal_size"`
+	Posts              map[int64]int64 `json:"posts"`
+	Authors            map[int64]int64 `json:"authors"`
+	Attachments        map[int64]int64 `json:"attachments"`
+	Users              map[int64]int64 `json:"users"`
+	Sessions           map[int64]int64 `json:"sessions"`
+	Webhooks           map[int64]int64 `json:"webhooks"`
+	Deliveries         map[int64]int64 `json:"deliveries"`
+}
+
+type Store struct {
+	mutex  sync.Mutex
+	dir    string
+	lock   *os.File
+	config Config
+
+	// dirty is set when records were written after the last sync.
+	dirty    bool
+	stopSync chan struct{}
+	syncDone chan struct{}
+
+	journal *os.File
+	size    int64
+	index   index
+
+	audit     *os.File
+	auditSize int64
+}
+
+// Open opens the store in dir with the default config.
+func Open(dir string) (*Store, error) {
+	return OpenWithConfig(dir, DefaultConfig())
+}
+
+// OpenWithConfig opens the store in dir, creating it when it does not exist.
+// Only one process can open a store at a time.
+func OpenWithConfig(dir string, config Config) (*Store, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	lock, err := lockDir(filepath.Join(dir, lockFile))
+	if err != nil {
+		return nil, err
+	}
+
+	journal, err := os.OpenFile(filepath.Join(dir, journalFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		unlockDir(lock)
+		return nil, err
+	}
+
+	audit, err := os.OpenFile(filepath.Join(dir, auditFile), os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		journal.Close()
+		unlockDir(lock)
+		return nil, err
+	}
+
+	s := &Store{dir: dir, lock: lock, config: config, journal: journal, audit: audit}
+	if err := s.recover(); err != nil {
+		s.release()
+		return nil, err
+	}
+	if config.Sync == SyncPeriodic {
+		s.stopSync = make(chan struct{})
+		s.syncDone = make(chan struct{})
+		go s.syncPeriodically()
+	}
+
+	return s, nil
+}
+
+func (s *Store) syncPeriodically() {
+	defer close(s.syncDone)
+
+	ticker := time.NewTicker(s.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopSync:
+			return
+		case <-ticker.C:
+			s.mutex.Lock()
+			if err := s.sync(); err != nil {
+				slog.Error("Could not sync the store.", "error", err)
+			}
+			s.mutex.Unlock()
+		}
+	}
+}
+
+// sync flushes the journal and the audit log when records were written since
+// the last sync. The mutex must be held.
+func (s *Store) sync() error {
+	if !s.dirty {
+		return nil
+	}
+	if err := errors.Join(s.journal.Sync(), s.audit.Sync()); err != nil {
+		return err
+	}
+	s.dirty = false
+
+	return nil
+}
+
+// written marks records as written and flushes them when every record must be.
+// The mutex must be held.
+func (s *Store) written() error {
+	s.dirty = true
+	if s.config.Sync == SyncAlways {
+		return s.sync()
+	}
+
+	return nil
+}
+
+// recover reads the index and replays the journal records it does not cover.
+// An incomplete last record is cut off.
+func (s *Store) recover() error {
+	info, err := s.journal.Stat()
+	if err != nil {
+		return err
+	}
+	s.size = info.Size()
+
+	auditInfo, err := s.audit.Stat()
+	if err != nil {
+		return err
+	}
+	s.auditSize = auditInfo.Size()
+
+	s.index, err = readIndex(s.dir)
+	if err != nil {
+		return err
+	}
+	if s.index.JournalSize > s.size {
+		return fmt.Errorf("index covers %d bytes of a %d bytes journal, run reindex", s.index.JournalSize, s.size)
+	}
+
+	err = scan(io.NewSectionReader(s.journal, s.index.JournalSize, s.size-s.index.JournalSize), s.index.JournalSize, func(offset int64, r record) error {
+		s.index.apply(offset, r)
+		return nil
+	})
+	size, err := truncateTorn(s.journal, s.size, err)
+	if err != nil {
+		return err
+	}
+	s.size = size
+
+	return nil
+}
+
+// Load reads the current posts and the ID sequence.
+func (s *Store) Load() (map[int64]*domain.Post, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	posts := make(map[int64]*domain.Post, len(s.index.Posts))
+	err := s.readIndexed(s.index.Posts, opPut, func(id int64, r record) bool {
+		if r.Post == nil || r.Post.ID != id {
+			return false
+		}
+		posts[id] = r.Post.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return posts, s.index.Sequence, nil
+}
+
+// LoadAuthors reads the current authors and the author ID sequence.
+func (s *Store) LoadAuthors() (map[int64]*domain.Author, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	authors := make(map[int64]*domain.Author, len(s.index.Authors))
+	err := s.readIndexed(s.index.Authors, opPutAuthor, func(id int64, r record) bool {
+		if r.Author == nil || r.Author.ID != id {
+			return false
+		}
+		authors[id] = r.Author.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return authors, s.index.AuthorSequence, nil
+}
+
+// LoadAttachments reads the current attachments and the attachment ID sequence.
+func (s *Store) LoadAttachments() (map[int64]*domain.Attachment, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	attachments := make(map[int64]*domain.Attachment, len(s.index.Attachments))
+	err := s.readIndexed(s.index.Attachments, opPutAttachment, func(id int64, r record) bool {
+		if r.Attachment == nil || r.Attachment.ID != id {
+			return false
+		}
+		attachments[id] = r.Attachment.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return attachments, s.index.AttachmentSequence, nil
+}
+
+// readIndexed reads the records of the offsets, which must be of the op kind
+// and accepted by f.
+func (s *Store) readIndexed(offsets map[int64]int64, op string, f func(id int64, r record) bool) error {
+	for id, offset := range offsets {
+		r, err := readRecord(s.journal, offset)
+		if err != nil {
+			return err
+		}
+		if r.Op != op || !f(id, r) {
+			return fmt.Errorf("index points %d to a wrong record at %d, run reindex", id, offset)
+		}
+	}
+
+	return nil
+}
+
+// Put records a created, updated, deleted or restored post. Deleted posts stay
+// in the store with their deletion time until they are purged.
+func (s *Store) Put(post *domain.Post, sequence int64) error {
+	return s.append(record{Op: opPut, Post: newPostRecord(post), Sequence: sequence})
+}
+
+// Delete records a purged post.
+func (s *Store) Delete(id int64) error {
+	return s.append(record{Op: opDelete, ID: id})
+}
+
+// PutAuthor records a created or updated author.
+func (s *Store) PutAuthor(author *domain.Author, sequence int64) error {
+	return s.append(record{Op: opPutAuthor, Author: newAuthorRecord(author), Sequence: sequence})
+}
+
+func (s *Store) DeleteAuthor(id int64) error {
+	return s.append(record{Op: opDeleteAuthor, ID: id})
+}
+
+// PutAttachment records a created attachment.
+func (s *Store) PutAttachment(attachment *domain.Attachment, sequence int64) error {
+	return s.append(record{Op: opPutAttachment, Attachment: newAttachmentRecord(attachment), Sequence: sequence})
+}
+
+func (s *Store) DeleteAttachment(id int64) error {
+	return s.append(record{Op: opDeleteAttachment, ID: id})
+}
+
+func (s *Store) append(r record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, err := s.journal.WriteAt(line, s.size); err != nil {
+		return err
+	}
+	s.index.apply(s.size, r)
+	s.size += int64(len(line))
+	s.index.JournalSize = s.size
+
+	return s.written()
+}
+
+// Close writes the index and releases the store.
+func (s *Store) Close() error {
+	if s.stopSync != nil {
+		close(s.stopSync)
+		<-s.syncDone
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	err := errors.Join(s.journal.Sync(), s.audit.Sync())
+	if err == nil {
+		err = writeIndex(s.dir, s.index)
+	}
+	s.release()
+
+	return err
+}
+
+func (s *Store) release() {
+	s.journal.Close()
+	s.audit.Close()
+	unlockDir(s.lock)
+}
+
+func (i *index) apply(offset int64, r record) {
+	switch r.Op {
+	case opPut:
+		i.Posts[r.Post.ID] = offset
+		i.Sequence = max(i.Sequence, r.Sequence, r.Post.ID)
+	case opDelete:
+		delete(i.Posts, r.ID)
+	case opSequence:
+		i.Sequence = max(i.Sequence, r.Sequence)
+		i.AuthorSequence = max(i.AuthorSequence, r.AuthorSequence)
+		i.AttachmentSequence = max(i.AttachmentSequence, r.AttachmentSequence)
+		i.UserSequence = max(i.UserSequence, r.UserSequence)
+		i.SessionSequence = max(i.SessionSequence, r.SessionSequence)
+		i.WebhookSequence = max(i.WebhookSequence, r.WebhookSequence)
+		i.DeliverySequence = max(i.DeliverySequence, r.DeliverySequence)
+	case opPutAuthor:
+		i.Authors[r.Author.ID] = offset
+		i.AuthorSequence = max(i.AuthorSequence, r.Sequence, r.Author.ID)
+	case opDeleteAuthor:
+		delete(i.Authors, r.ID)
+	case opPutAttachment:
+		i.Attachments[r.Attachment.ID] = offset
+		i.AttachmentSequence = max(i.AttachmentSequence, r.Sequence, r.Attachment.ID)
+	case opDeleteAttachment:
+		delete(i.Attachments, r.ID)
+	case opPutUser:
+		i.Users[r.User.ID] = offset
+		i.UserSequence = max(i.UserSequence, r.Sequence, r.User.ID)
+	case opPutSession:
+		i.Sessions[r.Session.ID] = offset
+		i.SessionSequence = max(i.SessionSequence, r.Sequence, r.Session.ID)
+	case opDeleteSession:
+		delete(i.Sessions, r.ID)
+	case opPutWebhook:
+		i.Webhooks[r.Webhook.ID] = offset
+		i.WebhookSequence = max(i.WebhookSequence, r.Sequence, r.Webhook.ID)
+	case opDeleteWebhook:
+		delete(i.Webhooks, r.ID)
+	case opPutDelivery:
+		i.Deliveries[r.Delivery.ID] = offset
+		i.DeliverySequence = max(i.DeliverySequence, r.Sequence, r.Delivery.ID)
+	case opDeleteDelivery:
+		delete(i.Deliveries, r.ID)
+	}
+}
+
+func newIndex() index {
+	return index{
+		Posts:       map[int64]int64{},
+		Authors:     map[int64]int64{},
+		Attachments: map[int64]int64{},
+		Users:       map[int64]int64{},
+		Sessions:    map[int64]int64{},
+		Webhooks:    map[int64]int64{},
+		Deliveries:  map[int64]int64{},
+	}
+}
+
+func readIndex(dir string) (index, error) {
+	idx := newIndex()
+
+	data, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return idx, nil
+	}
+	if err != nil {
+		return idx, err
+	}
+
+	if err := json.Unmarshal(data, &idx); err != nil {
+		return idx, fmt.Errorf("index is corrupted, run reindex: %w", err)
+	}
+	if idx.Posts == nil {
+		idx.Posts = map[int64]int64{}
+	}
+	if idx.Authors == nil {
+		idx.Authors = map[int64]int64{}
+	}
+	if idx.Attachments == nil {
+		idx.Attachments = map[int64]int64{}
+	}
+	if idx.Users == nil {
+		idx.Users = map[int64]int64{}
+	}
+	if idx.Sessions == nil {
+		idx.Sessions = map[int64]int64{}
+	}
+	if idx.Webhooks == nil {
+		idx.Webhooks = map[int64]int64{}
+	}
+	if idx.Deliveries == nil {
+		idx.Deliveries = map[int64]int64{}
+	}
+
+	return idx, nil
+}
+
+// writeIndex replaces the index atomically. The index is flushed before it
+// replaces the old one and the directory after, so that a crash cannot leave an
+// index that covers more of the journal than is on the disk.
+func writeIndex(dir string, idx index) error {
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(dir, indexFile+".tmp")
+	file, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, filepath.Join(dir, indexFile)); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+// tornRecordError tells that the journal ends with a record without its line
+// break, which is left by a crash in the middle of a write.
+type tornRecordError struct {
+	offset int64
+}
+
+func (e *tornRecordError) Error() string {
+	return fmt.Sprintf("journal ends with an incomplete record at %d", e.offset)
+}
+
+// truncateTorn cuts off the incomplete last record of the journal of size when
+// err of scanning it is a tornRecordError and returns the new size. Other
+// errors are returned as they are.
+func truncateTorn(journal *os.File, size int64, err error) (int64, error) {
+	var torn *tornRecordError
+	if !errors.As(err, &torn) {
+		return size, err
+	}
+
+	slog.Warn("Cutting off an incomplete journal record.", "offset", torn.offset, "bytes", size-torn.offset)
+	if err := journal.Truncate(torn.offset); err != nil {
+		return size, err
+	}
+
+	return torn.offset, nil
+}
+
+// scan calls f for every record of the journal part in r, which starts at base.
+// It fails with a tornRecordError when the last record has no line break.
+func scan(r io.Reader, base int64, f func(offset int64, r record) error) error {
+	reader := bufio.NewReader(r)
+	offset := base
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			if line[len(line)-1] != '\n' {
+				return &tornRecordError{offset: offset}
+			}
+
+			var rec record
+			if err := decodeRecord(line, &rec); err != nil {
+				return fmt.Errorf("journal record at %d: %w", offset, err)
+			}
+			if err := f(offset, rec); err != nil {
+				return err
+			}
+			offset += int64(len(line))
+		}
+
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readRecord(journal io.ReaderAt, offset int64) (record, error) {
+	line, err := bufio.NewReader(io.NewSectionReader(journal, offset, 1<<62)).ReadBytes('\n')
+	if err != nil {
+		return record{}, fmt.Errorf("reading journal record at %d: %w", offset, err)
+	}
+
+	var rec record
+	err = decodeRecord(line, &rec)
+	return rec, err
+}
+
+func decodeRecord(line []byte, rec *record) error {
+	if err := json.Unmarshal(bytes.TrimSpace(line), rec); err != nil {
+		return err
+	}
+
+	switch {
+	case rec.Op == opPut && rec.Post != nil && rec.Post.ID > 0:
+	case rec.Op == opDelete && rec.ID > 0:
+	case rec.Op == opSequence:
+	case rec.Op == opPutAuthor && rec.Author != nil && rec.Author.ID > 0:
+	case rec.Op == opDeleteAuthor && rec.ID > 0:
+	case rec.Op == opPutAttachment && rec.Attachment != nil && rec.Attachment.ID > 0:
+	case rec.Op == opDeleteAttachment && rec.ID > 0:
+	case rec.Op == opPutUser && rec.User != nil && rec.User.ID > 0:
+	case rec.Op == opPutSession && rec.Session != nil && rec.Session.ID > 0:
+	case rec.Op == opDeleteSession && rec.ID > 0:
+	case rec.Op == opPutWebhook && rec.Webhook != nil && rec.Webhook.ID > 0:
+	case rec.Op == opDeleteWebhook && rec.ID > 0:
+	case rec.Op == opPutDelivery && rec.Delivery != nil && rec.Delivery.ID > 0:
+	case rec.Op == opDeleteDelivery && rec.ID > 0:
+	default:
+		return fmt.Errorf("invalid %q record", rec.Op)
+	}
+
+	return nil
+}
+
+func newPostRecord(post *domain.Post) *postRecord {
+	rec := &postRecord{
+		ID:             post.ID,
+		AuthorID:       post.AuthorID,
+		Author:         post.Author,
+		Title:          post.Title,
+		Content:        post.Content,
+		Draft:          post.Draft,
+		Excerpt:        post.Excerpt,
+		WordCount:      post.WordCount,
+		ReadingMinutes: post.ReadingMinutes,
+	}
+	if post.IsDeleted() {
+		deletedAt := post.DeletedAt
+		rec.DeletedAt = &deletedAt
+	}
+
+	return rec
+}
+
+func (p *postRecord) toDomain() *domain.Post {
+	post := &domain.Post{
+		ID:             p.ID,
+		AuthorID:       p.AuthorID,
+		Author:         p.Author,
+		Title:          p.Title,
+		Content:        p.Content,
+		Draft:          p.Draft,
+		Excerpt:        p.Excerpt,
+		WordCount:      p.WordCount,
+		ReadingMinutes: p.ReadingMinutes,
+	}
+	if p.DeletedAt != nil {
+		post.DeletedAt = *p.DeletedAt
+	}
+
+	return post
+}
+
+func newAuthorRecord(author *domain.Author) *authorRecord {
+	return &authorRecord{
+		ID:        author.ID,
+		Name:      author.Name,
+		Bio:       author.Bio,
+		AvatarURL: author.AvatarURL,
+	}
+}
+
+func (a *authorRecord) toDomain() *domain.Author {
+	return &domain.Author{
+		ID:        a.ID,
+		Name:      a.Name,
+		Bio:       a.Bio,
+		AvatarURL: a.AvatarURL,
+	}
+}
+
+func newAttachmentRecord(attachment *domain.Attachment) *attachmentRecord {
+	return &attachmentRecord{
+		ID:            attachment.ID,
+		PostID:        attachment.PostID,
+		Filename:      attachment.Filename,
+		ContentType:   attachment.ContentType,
+		Size:          attachment.Size,
+		Hash:          attachment.Hash,
+		ThumbnailType: attachment.ThumbnailType,
+		CreatedAt:     attachment.CreatedAt,
+	}
+}
+
+func (a *attachmentRecord) toDomain() *domain.Attachment {
+	return &domain.Attachment{
+		ID:            a.ID,
+		PostID:        a.PostID,
+		Filename:      a.Filename,
+		ContentType:   a.ContentType,
+		Size:          a.Size,
+		Hash:          a.Hash,
+		ThumbnailType: a.ThumbnailType,
+		CreatedAt:     a.CreatedAt,
+	}
+}
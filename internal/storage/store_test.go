@@ -0,0 +1,388 @@
+package storage_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func openStore(t *testing.T, dir string) *storage.Store {
+	store, err := storage.Open(dir)
+	require.NoError(t, err)
+	return store
+}
+
+func fillStore(t *testing.T, dir string) {
+	store := openStore(t, dir)
+	require.NoError(t, store.PutAuthor(&domain.Author{ID: 1, Name: "Anton"}, 1))
+	require.NoError(t, store.Put(&domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "One", Content: "1"}, 1))
+	require.NoError(t, store.Put(&domain.Post{ID: 2, AuthorID: 1, Author: "Anton", Title: "Two", Content: "2", Draft: true}, 2))
+	require.NoError(t, store.Put(&domain.Post{ID: 1, AuthorID: 1, Author: "Anton", Title: "One updated", Content: "1"}, 2))
+	require.NoError(t, store.Put(&domain.Post{ID: 3, Author: "Jonny", Title: "Three", Content: "3"}, 3))
+	require.NoError(t, store.Delete(3))
+	require.NoError(t, store.Close())
+}
+
+func Test_Store_ShouldLoadPostsAfterReopen(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	store := openStore(t, dir)
+	defer store.Close()
+
+	posts, sequence, err := store.Load()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, sequence)
+	assert.Equal(t, map[int64]*domain.Post{
+		1: {ID: 1, AuthorID: 1, Author: "Anton", Title: "One updated", Content: "1"},
+		2: {ID: 2, AuthorID: 1, Author: "Anton", Title: "Two", Content: "2", Draft: true},
+	}, posts)
+
+	authors, authorSequence, err := store.LoadAuthors()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, authorSequence)
+	assert.Equal(t, map[int64]*domain.Author{1: {ID: 1, Name: "Anton"}}, authors)
+}
+
+func Test_Store_ShouldRecoverJournalNotCoveredByIndex(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	store := openStore(t, dir)
+	require.NoError(t, store.Put(&domain.Post{ID: 4, Author: "Jonny", Title: "Four", Content: "4"}, 4))
+	// The index is not written without Close, as after a crash.
+	require.NoError(t, os.Remove(filepath.Join(dir, "LOCK")))
+
+	store = openStore(t, dir)
+	defer store.Close()
+
+	posts, sequence, err := store.Load()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, sequence)
+	assert.Len(t, posts, 3)
+	assert.Equal(t, "Four", posts[4].Title)
+}
+
+func appendToJournal(t *testing.T, dir string, data string) {
+	journal, err := os.OpenFile(filepath.Join(dir, "journal.log"), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = journal.WriteString(data)
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+}
+
+func Test_Store_ShouldCutOffIncompleteLastRecord(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	store, err := storage.OpenWithConfig(dir, storage.Config{Sync: storage.SyncAlways})
+	require.NoError(t, err)
+	require.NoError(t, store.Put(&domain.Post{ID: 4, Author: "Jonny", Title: "Four", Content: "4"}, 4))
+	// A crash in the middle of the next write leaves a part of its record.
+	appendToJournal(t, dir, `{"op":"put","post":{"id":5`)
+	require.NoError(t, os.Remove(filepath.Join(dir, "LOCK")))
+
+	store = openStore(t, dir)
+	posts, sequence, err := store.Load()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, sequence)
+	assert.Len(t, posts, 3)
+	require.NoError(t, store.Put(&domain.Post{ID: 5, Author: "Jonny", Title: "Five", Content: "5"}, 5))
+	require.NoError(t, store.Close())
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 4, report.Posts)
+}
+
+func Test_Open_ShouldFailOnCorruptedRecordBeforeTheLast(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+	require.NoError(t, os.Remove(filepath.Join(dir, "index.json")))
+	appendToJournal(t, dir, `{"op":"put"}`+"\n"+`{"op":"delete","id":2}`+"\n")
+
+	_, err := storage.Open(dir)
+	assert.ErrorContains(t, err, "journal record at")
+}
+
+func Test_OpenWithConfig_ShouldFailOnUnknownSyncPolicy(t *testing.T) {
+	_, err := storage.OpenWithConfig(t.TempDir(), storage.Config{Sync: "sometimes"})
+	assert.Error(t, err)
+
+	_, err = storage.OpenWithConfig(t.TempDir(), storage.Config{Sync: storage.SyncPeriodic})
+	assert.Error(t, err)
+}
+
+func Test_Reindex_ShouldCutOffIncompleteLastRecord(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+	appendToJournal(t, dir, `{"op":"delete","id":2}`+"\n"+`{"op":"del`)
+
+	require.NoError(t, storage.Reindex(dir))
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 1, report.Posts)
+}
+
+func Test_Open_ShouldFailWhenStoreIsOpen(t *testing.T) {
+	dir := t.TempDir()
+	store := openStore(t, dir)
+	defer store.Close()
+
+	_, err := storage.Open(dir)
+	assert.ErrorIs(t, err, storage.ErrorStoreLocked)
+}
+
+func Test_Verify_ShouldReportConsistentStore(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 6, report.Records)
+	assert.Equal(t, 2, report.Posts)
+	assert.Equal(t, 1, report.Authors)
+	assert.EqualValues(t, 3, report.Sequence)
+}
+
+func Test_Verify_ShouldReportPostsOfDeletedAuthor(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	store := openStore(t, dir)
+	require.NoError(t, store.DeleteAuthor(1))
+	require.NoError(t, store.Close())
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"posts reference deleted or unknown author 1"}, report.Problems)
+}
+
+func Test_Verify_ShouldReportStaleIndex(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	journal, err := os.OpenFile(filepath.Join(dir, "journal.log"), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = journal.WriteString(`{"op":"delete","id":2}` + "\n")
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+	assert.Contains(t, report.Problems, "index has deleted or unknown post 2")
+
+	require.NoError(t, storage.Reindex(dir))
+
+	report, err = storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 1, report.Posts)
+}
+
+func Test_Verify_ShouldReportCorruptedJournal(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	journal, err := os.OpenFile(filepath.Join(dir, "journal.log"), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = journal.WriteString(`{"op":"put"`)
+	require.NoError(t, err)
+	require.NoError(t, journal.Close())
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.False(t, report.OK())
+}
+
+func Test_Compact_ShouldKeepPostsAndSequence(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+	before, err := os.Stat(filepath.Join(dir, "journal.log"))
+	require.NoError(t, err)
+
+	require.NoError(t, storage.Compact(dir))
+
+	after, err := os.Stat(filepath.Join(dir, "journal.log"))
+	require.NoError(t, err)
+	assert.Less(t, after.Size(), before.Size())
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 4, report.Records)
+
+	store := openStore(t, dir)
+	defer store.Close()
+	posts, sequence, err := store.Load()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 3, sequence)
+	assert.Equal(t, "One updated", posts[1].Title)
+	assert.Len(t, posts, 2)
+}
+
+func Test_Compact_ShouldNotLeaveGoroutinesBehind(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, storage.Compact(dir))
+	}
+
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+}
+
+func Test_AuditLog_ShouldDropIncompleteEntryAndSurviveCompact(t *testing.T) {
+	dir := t.TempDir()
+	occurredAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	entry := &domain.AuditEntry{
+		ID:         1,
+		OccurredAt: occurredAt,
+		ActorID:    7,
+		Actor:      "anton@example.com",
+		Action:     domain.AuditPostDeleted,
+		PostID:     3,
+		RequestID:  "req-1",
+		Before:     &domain.Post{ID: 3, Author: "Jonny", Title: "Three", Content: "3"},
+	}
+
+	store := openStore(t, dir)
+	require.NoError(t, store.AppendAudit(entry))
+	require.NoError(t, store.Close())
+
+	file, err := os.OpenFile(filepath.Join(dir, "audit.log"), os.O_APPEND|os.O_WRONLY, 0o644)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"id":2,"occurred`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	require.NoError(t, storage.Compact(dir))
+
+	store = openStore(t, dir)
+	defer store.Close()
+	entries, err := store.LoadAudit()
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.AuditEntry{entry}, entries)
+
+	second := &domain.AuditEntry{ID: 2, OccurredAt: occurredAt, Action: domain.AuditPostCreated, PostID: 4}
+	require.NoError(t, store.AppendAudit(second))
+	entries, err = store.LoadAudit()
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.AuditEntry{entry, second}, entries)
+}
+
+func Test_Compact_ShouldKeepAttachmentsAndReportOrphans(t *testing.T) {
+	dir := t.TempDir()
+	fillStore(t, dir)
+
+	store := openStore(t, dir)
+	require.NoError(t, store.PutAttachment(&domain.Attachment{ID: 1, PostID: 1, Filename: "a.png", Hash: "aaa"}, 1))
+	require.NoError(t, store.PutAttachment(&domain.Attachment{ID: 2, PostID: 2, Filename: "b.png", Hash: "bbb"}, 2))
+	require.NoError(t, store.DeleteAttachment(1))
+	require.NoError(t, store.Delete(2))
+	require.NoError(t, store.Close())
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Attachments)
+	assert.Equal(t, []string{"attachments reference purged or unknown post 2"}, report.Problems)
+
+	require.NoError(t, storage.Compact(dir))
+
+	store = openStore(t, dir)
+	defer store.Close()
+	attachments, sequence, err := store.LoadAttachments()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, sequence)
+	assert.Equal(t, map[int64]*domain.Attachment{2: {ID: 2, PostID: 2, Filename: "b.png", Hash: "bbb"}}, attachments)
+}
+
+func Test_Compact_ShouldKeepUsersAndSessions(t *testing.T) {
+	dir := t.TempDir()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := openStore(t, dir)
+	require.NoError(t, store.PutUser(&domain.User{ID: 1, Email: "anton@example.com", Role: domain.RoleAdmin, PasswordHash: []byte("hash"), CreatedAt: createdAt}, 1))
+	require.NoError(t, store.PutSession(&domain.Session{ID: 1, UserID: 1, AccessTokenHash: "a1", RefreshTokenHash: "r1"}, 1))
+	require.NoError(t, store.PutSession(&domain.Session{ID: 2, UserID: 1, AccessTokenHash: "a2", RefreshTokenHash: "r2", RevokedAt: createdAt}, 2))
+	require.NoError(t, store.DeleteSession(1))
+	require.NoError(t, store.Close())
+
+	require.NoError(t, storage.Compact(dir))
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 1, report.Users)
+	assert.Equal(t, 1, report.Sessions)
+
+	store = openStore(t, dir)
+	defer store.Close()
+	users, userSequence, err := store.LoadUsers()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, userSequence)
+	assert.Equal(t, map[int64]*domain.User{
+		1: {ID: 1, Email: "anton@example.com", Role: domain.RoleAdmin, PasswordHash: []byte("hash"), CreatedAt: createdAt},
+	}, users)
+
+	sessions, sessionSequence, err := store.LoadSessions()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, sessionSequence)
+	assert.Equal(t, map[int64]*domain.Session{
+		2: {ID: 2, UserID: 1, AccessTokenHash: "a2", RefreshTokenHash: "r2", RevokedAt: createdAt},
+	}, sessions)
+}
+
+func Test_Compact_ShouldKeepWebhooksAndDeliveries(t *testing.T) {
+	dir := t.TempDir()
+	createdAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := openStore(t, dir)
+	require.NoError(t, store.PutWebhook(&domain.Webhook{ID: 1, URL: "https://example.com/hook", Secret: "secret", Events: []domain.EventType{domain.EventPostCreated}, CreatedAt: createdAt}, 1))
+	require.NoError(t, store.PutWebhook(&domain.Webhook{ID: 2, URL: "https://example.com/other", Secret: "other"}, 2))
+	require.NoError(t, store.PutDelivery(&domain.Delivery{ID: 1, WebhookID: 1, EventID: 5, Payload: []byte(`{"id":5}`), Status: domain.DeliveryPending, NextAttemptAt: createdAt}, 1))
+	require.NoError(t, store.PutDelivery(&domain.Delivery{ID: 1, WebhookID: 1, EventID: 5, Payload: []byte(`{"id":5}`), Status: domain.DeliveryPending, Attempts: 1, LastError: "timeout", NextAttemptAt: createdAt.Add(time.Second)}, 1))
+	require.NoError(t, store.PutDelivery(&domain.Delivery{ID: 2, WebhookID: 1, Status: domain.DeliveryDead, CompletedAt: createdAt}, 2))
+	require.NoError(t, store.PutDelivery(&domain.Delivery{ID: 3, WebhookID: 1, Status: domain.DeliverySucceeded, CompletedAt: createdAt}, 3))
+	require.NoError(t, store.DeleteDelivery(3))
+	// A deletion of webhook 2 that was interrupted after the webhook.
+	require.NoError(t, store.PutDelivery(&domain.Delivery{ID: 4, WebhookID: 2, Status: domain.DeliveryPending}, 4))
+	require.NoError(t, store.DeleteWebhook(2))
+	require.NoError(t, store.Close())
+
+	require.NoError(t, storage.Compact(dir))
+
+	report, err := storage.Verify(dir)
+	assert.NoError(t, err)
+	assert.True(t, report.OK(), report.Problems)
+	assert.Equal(t, 1, report.Webhooks)
+	assert.Equal(t, 2, report.Deliveries)
+
+	store = openStore(t, dir)
+	defer store.Close()
+	webhooks, webhookSequence, err := store.LoadWebhooks()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, webhookSequence)
+	assert.Equal(t, map[int64]*domain.Webhook{
+		1: {ID: 1, URL: "https://example.com/hook", Secret: "secret", Events: []domain.EventType{domain.EventPostCreated}, CreatedAt: createdAt},
+	}, webhooks)
+
+	deliveries, deliverySequence, err := store.LoadDeliveries()
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, deliverySequence)
+	assert.Equal(t, map[int64]*domain.Delivery{
+		1: {ID: 1, WebhookID: 1, EventID: 5, Payload: []byte(`{"id":5}`), Status: domain.DeliveryPending, Attempts: 1, LastError: "timeout", NextAttemptAt: createdAt.Add(time.Second)},
+		2: {ID: 2, WebhookID: 1, Status: domain.DeliveryDead, CompletedAt: createdAt},
+	}, deliveries)
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package storage
+
+// syncDir does nothing where directories cannot be opened for syncing, renames
+// are made durable by the file system there.
+func syncDir(dir string) error {
+	return nil
+}
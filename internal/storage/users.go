@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type userRecord struct {
+	ID           int64      `json:"id"`
+	Email        string     `json:"email"`
+	Role         string     `json:"role"`
+	AuthorID     int64      `json:"author_id,omitempty"`
+	PasswordHash []byte     `json:"password_hash"`
+	CreatedAt    time.Time  `json:"created_at"`
+	FailedLogins int        `json:"failed_logins,omitempty"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"`
+}
+
+// sessionRecord keeps only the hashes of the tokens, like the session itself.
+type sessionRecord struct {
+	ID               int64      `json:"id"`
+	UserID           int64      `json:"user_id"`
+	AccessTokenHash  string     `json:"access_token_hash"`
+	AccessExpiresAt  time.Time  `json:"access_expires_at"`
+	RefreshTokenHash string     `json:"refresh_token_hash"`
+	RefreshExpiresAt time.Time  `json:"refresh_expires_at"`
+	CreatedAt        time.Time  `json:"created_at"`
+	RevokedAt        *time.Time `json:"revoked_at,omitempty"`
+}
+
+// LoadUsers reads the users and the user ID sequence.
+func (s *Store) LoadUsers() (map[int64]*domain.User, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	users := make(map[int64]*domain.User, len(s.index.Users))
+	err := s.readIndexed(s.index.Users, opPutUser, func(id int64, r record) bool {
+		if r.User == nil || r.User.ID != id {
+			return false
+		}
+		users[id] = r.User.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, s.index.UserSequence, nil
+}
+
+// LoadSessions reads the current sessions and the session ID sequence.
+func (s *Store) LoadSessions() (map[int64]*domain.Session, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	sessions := make(map[int64]*domain.Session, len(s.index.Sessions))
+	err := s.readIndexed(s.index.Sessions, opPutSession, func(id int64, r record) bool {
+		if r.Session == nil || r.Session.ID != id {
+			return false
+		}
+		sessions[id] = r.Session.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return sessions, s.index.SessionSequence, nil
+}
+
+// PutUser records a registered or changed user. Users are never deleted.
+func (s *Store) PutUser(user *domain.User, sequence int64) error {
+	return s.append(record{Op: opPutUser, User: newUserRecord(user), Sequence: sequence})
+}
+
+// PutSession records a created, rotated or revoked session.
+func (s *Store) PutSession(session *domain.Session, sequence int64) error {
+	return s.append(record{Op: opPutSession, Session: newSessionRecord(session), Sequence: sequence})
+}
+
+// DeleteSession records an expired session.
+func (s *Store) DeleteSession(id int64) error {
+	return s.append(record{Op: opDeleteSession, ID: id})
+}
+
+func newUserRecord(user *domain.User) *userRecord {
+	rec := &userRecord{
+		ID:           user.ID,
+		Email:        user.Email,
+		Role:         string(user.Role),
+		AuthorID:     user.AuthorID,
+		PasswordHash: user.PasswordHash,
+		CreatedAt:    user.CreatedAt,
+		FailedLogins: user.FailedLogins,
+	}
+	if !user.LockedUntil.IsZero() {
+		lockedUntil := user.LockedUntil
+		rec.LockedUntil = &lockedUntil
+	}
+
+	return rec
+}
+
+func (u *userRecord) toDomain() *domain.User {
+	user := &domain.User{
+		ID:           u.ID,
+		Email:        u.Email,
+		Role:         domain.Role(u.Role),
+		AuthorID:     u.AuthorID,
+		PasswordHash: u.PasswordHash,
+		CreatedAt:    u.CreatedAt,
+		FailedLogins: u.FailedLogins,
+	}
+	if u.LockedUntil != nil {
+		user.LockedUntil = *u.LockedUntil
+	}
+
+	return user
+}
+
+func newSessionRecord(session *domain.Session) *sessionRecord {
+	rec := &sessionRecord{
+		ID:               session.ID,
+		UserID:           session.UserID,
+		AccessTokenHash:  session.AccessTokenHash,
+		AccessExpiresAt:  session.AccessExpiresAt,
+		RefreshTokenHash: session.RefreshTokenHash,
+		RefreshExpiresAt: session.RefreshExpiresAt,
+		CreatedAt:        session.CreatedAt,
+	}
+	if session.IsRevoked() {
+		revokedAt := session.RevokedAt
+		rec.RevokedAt = &revokedAt
+	}
+
+	return rec
+}
+
+func (s *sessionRecord) toDomain() *domain.Session {
+	session := &domain.Session{
+		ID:               s.ID,
+		UserID:           s.UserID,
+		AccessTokenHash:  s.AccessTokenHash,
+		AccessExpiresAt:  s.AccessExpiresAt,
+		RefreshTokenHash: s.RefreshTokenHash,
+		RefreshExpiresAt: s.RefreshExpiresAt,
+		CreatedAt:        s.CreatedAt,
+	}
+	if s.RevokedAt != nil {
+		session.RevokedAt = *s.RevokedAt
+	}
+
+	return session
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+// webhookRecord keeps the secret, which signs the deliveries and cannot be derived.
+type webhookRecord struct {
+	ID        int64     `json:"id"`
+	URL       string    `json:"url"`
+	Secret    string    `json:"secret"`
+	Events    []string  `json:"events,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type deliveryRecord struct {
+	ID             int64      `json:"id"`
+	WebhookID      int64      `json:"webhook_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts,omitempty"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	CompletedAt    *time.Time `json:"completed_at,omitempty"`
+}
+
+// LoadWebhooks reads the current webhooks and the webhook ID sequence.
+func (s *Store) LoadWebhooks() (map[int64]*domain.Webhook, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	webhooks := make(map[int64]*domain.Webhook, len(s.index.Webhooks))
+	err := s.readIndexed(s.index.Webhooks, opPutWebhook, func(id int64, r record) bool {
+		if r.Webhook == nil || r.Webhook.ID != id {
+			return false
+		}
+		webhooks[id] = r.Webhook.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return webhooks, s.index.WebhookSequence, nil
+}
+
+// LoadDeliveries reads the current deliveries and the delivery ID sequence.
+func (s *Store) LoadDeliveries() (map[int64]*domain.Delivery, int64, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	deliveries := make(map[int64]*domain.Delivery, len(s.index.Deliveries))
+	err := s.readIndexed(s.index.Deliveries, opPutDelivery, func(id int64, r record) bool {
+		if r.Delivery == nil || r.Delivery.ID != id {
+			return false
+		}
+		deliveries[id] = r.Delivery.toDomain()
+		return true
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, s.index.DeliverySequence, nil
+}
+
+// PutWebhook records a created webhook.
+func (s *Store) PutWebhook(webhook *domain.Webhook, sequence int64) error {
+	return s.append(record{Op: opPutWebhook, Webhook: newWebhookRecord(webhook), Sequence: sequence})
+}
+
+// DeleteWebhook records a deleted webhook. Its deliveries are deleted one by one.
+func (s *Store) DeleteWebhook(id int64) error {
+	return s.append(record{Op: opDeleteWebhook, ID: id})
+}
+
+// PutDelivery records a created delivery or an attempt of it.
+func (s *Store) PutDelivery(delivery *domain.Delivery, sequence int64) error {
+	return s.append(record{Op: opPutDelivery, Delivery: newDeliveryRecord(delivery), Sequence: sequence})
+}
+
+// DeleteDelivery records a pruned delivery or one of a deleted webhook.
+func (s *Store) DeleteDelivery(id int64) error {
+	return s.append(record{Op: opDeleteDelivery, ID: id})
+}
+
+func newWebhookRecord(webhook *domain.Webhook) *webhookRecord {
+	rec := &webhookRecord{
+		ID:        webhook.ID,
+		URL:       webhook.URL,
+		Secret:    webhook.Secret,
+		CreatedAt: webhook.CreatedAt,
+	}
+	for _, e := range webhook.Events {
+		rec.Events = append(rec.Events, string(e))
+	}
+
+	return rec
+}
+
+func (w *webhookRecord) toDomain() *domain.Webhook {
+	webhook := &domain.Webhook{
+		ID:        w.ID,
+		URL:       w.URL,
+		Secret:    w.Secret,
+		CreatedAt: w.CreatedAt,
+	}
+	for _, e := range w.Events {
+		webhook.Events = append(webhook.Events, domain.EventType(e))
+	}
+
+	return webhook
+}
+
+func newDeliveryRecord(delivery *domain.Delivery) *deliveryRecord {
+	rec := &deliveryRecord{
+		ID:             delivery.ID,
+		WebhookID:      delivery.WebhookID,
+		EventID:        delivery.EventID,
+		EventType:      string(delivery.EventType),
+		Payload:        delivery.Payload,
+		Status:         string(delivery.Status),
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		CreatedAt:      delivery.CreatedAt,
+		NextAttemptAt:  delivery.NextAttemptAt,
+	}
+	if !delivery.CompletedAt.IsZero() {
+		completedAt := delivery.CompletedAt
+		rec.CompletedAt = &completedAt
+	}
+
+	return rec
+}
+
+func (d *deliveryRecord) toDomain() *domain.Delivery {
+	delivery := &domain.Delivery{
+		ID:             d.ID,
+		WebhookID:      d.WebhookID,
+		EventID:        d.EventID,
+		EventType:      domain.EventType(d.EventType),
+		Payload:        d.Payload,
+		Status:         domain.DeliveryStatus(d.Status),
+		Attempts:       d.Attempts,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		CreatedAt:      d.CreatedAt,
+		NextAttemptAt:  d.NextAttemptAt,
+	}
+	if d.CompletedAt != nil {
+		delivery.CompletedAt = *d.CompletedAt
+	}
+
+	return delivery
+}
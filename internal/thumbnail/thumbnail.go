@@ -0,0 +1,118 @@
+// Package thumbnail scales images down to previews.
+package thumbnail
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+var (
+	ErrorUnsupportedFormat = errors.New("thumbnail: unsupported image format")
+	ErrorTooManyPixels     = errors.New("thumbnail: image has too many pixels")
+)
+
+// maxPixels bounds the size of decoded images, so that a small file declaring a
+// huge image cannot exhaust the memory.
+const maxPixels = 40_000_000
+
+// Generate writes a thumbnail of the PNG, JPEG or GIF image that fits into a
+// square of size pixels. Smaller images keep their size. The thumbnail of a JPEG
+// is a JPEG, other images give a PNG, so that transparency is kept. It returns
+// the content type of the thumbnail.
+func Generate(w io.Writer, r io.ReadSeeker, size int) (string, error) {
+	cfg, format, err := image.DecodeConfig(r)
+	if err != nil {
+		return "", ErrorUnsupportedFormat
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return "", ErrorTooManyPixels
+	}
+	if _, err := r.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	var src image.Image
+	switch format {
+	case "jpeg":
+		src, err = jpeg.Decode(r)
+	case "png":
+		src, err = png.Decode(r)
+	case "gif":
+		src, err = gif.Decode(r)
+	default:
+		return "", ErrorUnsupportedFormat
+	}
+	if err != nil {
+		return "", err
+	}
+
+	thumbnail := scale(src, size)
+	if format == "jpeg" {
+		return "image/jpeg", jpeg.Encode(w, thumbnail, &jpeg.Options{Quality: 85})
+	}
+	return "image/png", png.Encode(w, thumbnail)
+}
+
+// ContentType returns the content type of the thumbnail Generate writes for an
+// image of the content type.
+func ContentType(imageType string) string {
+	if imageType == "image/jpeg" {
+		return "image/jpeg"
+	}
+	return "image/png"
+}
+
+// samples is the number of source pixels per axis averaged into a pixel of the
+// thumbnail. Averaging a grid instead of every covered pixel keeps the cost
+// independent of the size of the source.
+const samples = 4
+
+// scale fits the image into a square of size pixels, keeping its aspect ratio.
+func scale(src image.Image, size int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return src
+	}
+
+	dstWidth, dstHeight := size, height*size/width
+	if height > width {
+		dstWidth, dstHeight = width*size/height, size
+	}
+	dstWidth, dstHeight = max(dstWidth, 1), max(dstHeight, 1)
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		for x := 0; x < dstWidth; x++ {
+			var r, g, b, a uint32
+			for sy := 0; sy < samples; sy++ {
+				for sx := 0; sx < samples; sx++ {
+					srcX := bounds.Min.X + (x*samples+sx)*width/(dstWidth*samples)
+					srcY := bounds.Min.Y + (y*samples+sy)*height/(dstHeight*samples)
+					pr, pg, pb, pa := src.At(srcX, srcY).RGBA()
+					r, g, b, a = r+pr, g+pg, b+pb, a+pa
+				}
+			}
+
+			// The sums are premultiplied by alpha, NRGBA is not.
+			n := uint32(samples * samples)
+			if a == 0 {
+				dst.SetNRGBA(x, y, color.NRGBA{})
+				continue
+			}
+			dst.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(r * 0xff / a),
+				G: uint8(g * 0xff / a),
+				B: uint8(b * 0xff / a),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
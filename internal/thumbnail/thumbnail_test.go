@@ -0,0 +1,71 @@
+package thumbnail_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/thumbnail"
+	"github.com/stretchr/testify/assert"
+)
+
+func encodedImage(t *testing.T, width, height int, encode func(*bytes.Buffer, image.Image) error) *bytes.Reader {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: 200, G: 10, B: 10, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, encode(&buf, img))
+	return bytes.NewReader(buf.Bytes())
+}
+
+func encodePNG(buf *bytes.Buffer, img image.Image) error  { return png.Encode(buf, img) }
+func encodeJPEG(buf *bytes.Buffer, img image.Image) error { return jpeg.Encode(buf, img, nil) }
+
+func Test_Generate_ShouldFitImageIntoSquareKeepingAspectRatio(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := thumbnail.Generate(&buf, encodedImage(t, 1000, 500, encodePNG), 256)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	thumb, err := png.Decode(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 256, 128), thumb.Bounds())
+	assert.Equal(t, color.NRGBA{R: 200, G: 10, B: 10, A: 255}, color.NRGBAModel.Convert(thumb.At(100, 60)))
+}
+
+func Test_Generate_JPEG_ShouldGiveJPEG(t *testing.T) {
+	var buf bytes.Buffer
+	contentType, err := thumbnail.Generate(&buf, encodedImage(t, 300, 600, encodeJPEG), 256)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	cfg, err := jpeg.DecodeConfig(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 128, cfg.Width)
+	assert.Equal(t, 256, cfg.Height)
+}
+
+func Test_Generate_SmallImage_ShouldKeepSize(t *testing.T) {
+	var buf bytes.Buffer
+	_, err := thumbnail.Generate(&buf, encodedImage(t, 40, 30, encodePNG), 256)
+	assert.NoError(t, err)
+
+	cfg, err := png.DecodeConfig(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 40, cfg.Width)
+}
+
+func Test_Generate_NotAnImage_ShouldReturnUnsupportedFormat(t *testing.T) {
+	_, err := thumbnail.Generate(&bytes.Buffer{}, strings.NewReader("%PDF-1.7"), 256)
+	assert.ErrorIs(t, err, thumbnail.ErrorUnsupportedFormat)
+}
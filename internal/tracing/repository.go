@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/usecase"
+)
+
+const repositoryTracerName = "github.com/kondrushin/blog/internal/repository"
+
+const (
+	postIdKey    = "blog.post.id"
+	postCountKey = "blog.post.count"
+)
+
+// Repository wraps an IBlogRepository and records a span for each call.
+type Repository struct {
+	next usecase.IBlogRepository
+}
+
+func NewRepository(next usecase.IBlogRepository) *Repository {
+	return &Repository{next: next}
+}
+
+func (r *Repository) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.GetPost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	post, err := r.next.GetPost(ctx, id)
+	return post, recordError(span, err)
+}
+
+func (r *Repository) GetPosts(ctx context.Context) []*domain.Post {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.GetPosts")
+	defer span.End()
+
+	posts := r.next.GetPosts(ctx)
+	span.SetAttributes(attribute.Int(postCountKey, len(posts)))
+	return posts
+}
+
+func (r *Repository) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.EachPost")
+	defer span.End()
+
+	count := 0
+	err := r.next.EachPost(ctx, func(post *domain.Post) bool {
+		count++
+		return yield(post)
+	})
+	span.SetAttributes(attribute.Int(postCountKey, count))
+	return recordError(span, err)
+}
+
+func (r *Repository) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.CreatePost")
+	defer span.End()
+
+	id, err := r.next.CreatePost(ctx, post)
+	span.SetAttributes(attribute.Int64(postIdKey, id))
+	return id, recordError(span, err)
+}
+
+func (r *Repository) UpdatePost(ctx context.Context, post *domain.Post, id int64) error {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.UpdatePost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	return recordError(span, r.next.UpdatePost(ctx, post, id))
+}
+
+func (r *Repository) DeletePost(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.DeletePost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	return recordError(span, r.next.DeletePost(ctx, id))
+}
+
+func (r *Repository) GetDeletedPost(ctx context.Context, id int64) (*domain.Post, error) {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.GetDeletedPost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	post, err := r.next.GetDeletedPost(ctx, id)
+	return post, recordError(span, err)
+}
+
+func (r *Repository) GetDeletedPosts(ctx context.Context) []*domain.Post {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.GetDeletedPosts")
+	defer span.End()
+
+	posts := r.next.GetDeletedPosts(ctx)
+	span.SetAttributes(attribute.Int(postCountKey, len(posts)))
+	return posts
+}
+
+func (r *Repository) RestorePost(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, repositoryTracerName, "Repository.RestorePost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	return recordError(span, r.next.RestorePost(ctx, id))
+}
+
+// recordError marks the span as failed. A missing post is an expected outcome
+// and is recorded as an event only.
+func recordError(span trace.Span, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	span.RecordError(err)
+	if !errors.Is(err, domain.ErrorPostNotFound) {
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return err
+}
@@ -0,0 +1,126 @@
+package tracing_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gavv/httpexpect/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy/policytest"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/server"
+	"github.com/kondrushin/blog/internal/tracing"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+const (
+	incomingTraceId     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	incomingParentId    = "00f067aa0ba902b7"
+	incomingTraceparent = "00-" + incomingTraceId + "-" + incomingParentId + "-01"
+)
+
+// openPolicy lets anonymous requests write posts, so that the tests need no login.
+func SetupServer(t *testing.T) (*httpexpect.Expect, *tracetest.SpanRecorder) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterNone})
+	assert.NoError(t, err)
+
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	gin.SetMode(gin.TestMode)
+	ginRouter := gin.New()
+	server.SetupMiddleware(ginRouter)
+
+	repo := repository.NewRepository()
+	_, err = repo.CreatePost(context.Background(), &domain.Post{Author: "Anton", Title: "Big post", Content: "something"})
+	assert.NoError(t, err)
+
+	blogUseCase := usecase.NewBlogUseCase(tracing.NewRepository(repo), repository.NewAuthorRepository(), events.NewBus(0), policytest.Open())
+	server.RegisterHandlers(ginRouter, tracing.NewUseCase(blogUseCase))
+
+	testServer := httptest.NewServer(ginRouter)
+	t.Cleanup(testServer.Close)
+
+	return httpexpect.Default(t, testServer.URL), recorder
+}
+
+func Test_GetPost_ShouldCreateNestedSpansInIncomingTrace(t *testing.T) {
+	expect, recorder := SetupServer(t)
+
+	expect.GET("/v1/api/blog/posts/1").
+		WithHeader("traceparent", incomingTraceparent).
+		Expect().
+		Status(http.StatusOK).
+		Header("traceparent").Contains(incomingTraceId)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+
+	repoSpan, useCaseSpan, httpSpan := spans[0], spans[1], spans[2]
+	assert.Equal(t, "Repository.GetPost", repoSpan.Name())
+	assert.Equal(t, "BlogUseCase.GetPost", useCaseSpan.Name())
+	assert.Equal(t, "/v1/api/blog/posts/:id", httpSpan.Name())
+
+	for _, s := range spans {
+		assert.Equal(t, incomingTraceId, s.SpanContext().TraceID().String())
+	}
+	assert.Equal(t, incomingParentId, httpSpan.Parent().SpanID().String())
+	assert.Equal(t, httpSpan.SpanContext().SpanID(), useCaseSpan.Parent().SpanID())
+	assert.Equal(t, useCaseSpan.SpanContext().SpanID(), repoSpan.Parent().SpanID())
+}
+
+func Test_GetPosts_ShouldCreateNestedSpans(t *testing.T) {
+	expect, recorder := SetupServer(t)
+
+	expect.GET("/v1/api/blog/posts").
+		Expect().
+		Status(http.StatusOK)
+
+	spans := recorder.Ended()
+	assert.Len(t, spans, 3)
+	assert.Equal(t, "Repository.GetPosts", spans[0].Name())
+	assert.Equal(t, "BlogUseCase.GetPosts", spans[1].Name())
+	assert.Equal(t, spans[1].SpanContext().SpanID(), spans[0].Parent().SpanID())
+	assert.Equal(t, spans[2].SpanContext().SpanID(), spans[1].Parent().SpanID())
+}
+
+func Test_UpdatePost_NoPost_ShouldRecordErrorWithoutFailingSpan(t *testing.T) {
+	expect, recorder := SetupServer(t)
+
+	expect.PUT("/v1/api/blog/posts/42").
+		WithJSON(map[string]string{"author": "Anton", "title": "New", "content": "new"}).
+		Expect().
+		Status(http.StatusNotFound)
+
+	repoSpan := recorder.Ended()[0]
+	assert.Equal(t, "Repository.UpdatePost", repoSpan.Name())
+	assert.Equal(t, codes.Unset, repoSpan.Status().Code)
+	assert.Len(t, repoSpan.Events(), 1)
+}
+
+func Test_Setup_StdoutExporter_ShouldWriteSpans(t *testing.T) {
+	var out bytes.Buffer
+	shutdown, err := tracing.Setup(context.Background(), tracing.Config{Exporter: tracing.ExporterStdout, Writer: &out})
+	assert.NoError(t, err)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "stdout-span")
+	span.End()
+
+	assert.NoError(t, shutdown(context.Background()))
+	assert.Contains(t, out.String(), "stdout-span")
+}
+
+func Test_Setup_UnknownExporter_ShouldReturnError(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), tracing.Config{Exporter: "zipkin"})
+	assert.Error(t, err)
+}
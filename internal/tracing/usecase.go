@@ -0,0 +1,107 @@
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+const useCaseTracerName = "github.com/kondrushin/blog/internal/usecase"
+
+type IBlogUseCase interface {
+	GetPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetPosts(ctx context.Context) []*domain.Post
+	EachPost(ctx context.Context, yield func(*domain.Post) bool) error
+	CreatePost(ctx context.Context, p *domain.Post) (int64, error)
+	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
+	DeletePost(ctx context.Context, id int64) error
+	GetDeletedPosts(ctx context.Context) ([]*domain.Post, error)
+	RestorePost(ctx context.Context, id int64) (*domain.Post, error)
+}
+
+// UseCase wraps an IBlogUseCase and records a span for each of its methods.
+type UseCase struct {
+	next IBlogUseCase
+}
+
+func NewUseCase(next IBlogUseCase) *UseCase {
+	return &UseCase{next: next}
+}
+
+func (u *UseCase) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.GetPost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	post, err := u.next.GetPost(ctx, id)
+	return post, recordError(span, err)
+}
+
+func (u *UseCase) GetPosts(ctx context.Context) []*domain.Post {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.GetPosts")
+	defer span.End()
+
+	posts := u.next.GetPosts(ctx)
+	span.SetAttributes(attribute.Int(postCountKey, len(posts)))
+	return posts
+}
+
+func (u *UseCase) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.EachPost")
+	defer span.End()
+
+	count := 0
+	err := u.next.EachPost(ctx, func(post *domain.Post) bool {
+		count++
+		return yield(post)
+	})
+	span.SetAttributes(attribute.Int(postCountKey, count))
+	return recordError(span, err)
+}
+
+func (u *UseCase) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.CreatePost")
+	defer span.End()
+
+	id, err := u.next.CreatePost(ctx, post)
+	span.SetAttributes(attribute.Int64(postIdKey, id))
+	return id, recordError(span, err)
+}
+
+func (u *UseCase) UpdatePost(ctx context.Context, post *domain.Post, id int64) error {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.UpdatePost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	return recordError(span, u.next.UpdatePost(ctx, post, id))
+}
+
+func (u *UseCase) DeletePost(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.DeletePost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	return recordError(span, u.next.DeletePost(ctx, id))
+}
+
+func (u *UseCase) GetDeletedPosts(ctx context.Context) ([]*domain.Post, error) {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.GetDeletedPosts")
+	defer span.End()
+
+	posts, err := u.next.GetDeletedPosts(ctx)
+	span.SetAttributes(attribute.Int(postCountKey, len(posts)))
+	return posts, recordError(span, err)
+}
+
+func (u *UseCase) RestorePost(ctx context.Context, id int64) (*domain.Post, error) {
+	ctx, span := startSpan(ctx, useCaseTracerName, "BlogUseCase.RestorePost", attribute.Int64(postIdKey, id))
+	defer span.End()
+
+	post, err := u.next.RestorePost(ctx, id)
+	return post, recordError(span, err)
+}
+
+func startSpan(ctx context.Context, tracerName string, spanName string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, spanName, trace.WithAttributes(attrs...))
+}
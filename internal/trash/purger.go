@@ -0,0 +1,112 @@
+// Package trash removes deleted posts for good once they have been in the
+// trash for longer than the retention period.
+package trash
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+type IPurgeRepository interface {
+	PurgePosts(ctx context.Context, deletedBefore time.Time) ([]*domain.Post, error)
+}
+
+type ILog interface {
+	AppendEntry(ctx context.Context, entry *domain.AuditEntry) error
+}
+
+// IAttachments deletes the attachments of purged posts.
+type IAttachments interface {
+	DeletePostAttachments(ctx context.Context, postID int64) error
+}
+
+type Config struct {
+	// Retention is how long a deleted post can be restored.
+	Retention time.Duration
+	// Interval is how often the trash is purged.
+	Interval time.Duration
+}
+
+func DefaultConfig() Config {
+	return Config{
+		Retention: 30 * 24 * time.Hour,
+		Interval:  time.Hour,
+	}
+}
+
+// Purger hard-deletes the posts whose retention period is over and records
+// them in the audit log. Their attachments are deleted with them.
+type Purger struct {
+	repository  IPurgeRepository
+	log         ILog
+	attachments IAttachments
+	cfg         Config
+	now         func() time.Time
+}
+
+func NewPurger(repository IPurgeRepository, log ILog, cfg Config) *Purger {
+	return &Purger{repository: repository, log: log, cfg: cfg, now: time.Now}
+}
+
+// WithClock replaces the clock deciding which posts are due, for tests.
+func (p *Purger) WithClock(now func() time.Time) *Purger {
+	p.now = now
+	return p
+}
+
+// WithAttachments deletes the attachments of the purged posts too.
+func (p *Purger) WithAttachments(attachments IAttachments) *Purger {
+	p.attachments = attachments
+	return p
+}
+
+// Purge removes the posts deleted more than the retention period ago and returns them.
+func (p *Purger) Purge(ctx context.Context) ([]*domain.Post, error) {
+	now := p.now().UTC()
+	purged, err := p.repository.PurgePosts(ctx, now.Add(-p.cfg.Retention))
+
+	for _, post := range purged {
+		entry := &domain.AuditEntry{
+			OccurredAt: now,
+			Action:     domain.AuditPostPurged,
+			PostID:     post.ID,
+			Before:     post,
+		}
+		if err := p.log.AppendEntry(ctx, entry); err != nil {
+			slog.Error("Could not record purged post.", "post_id", post.ID, "error", err)
+		}
+		if p.attachments == nil {
+			continue
+		}
+		if err := p.attachments.DeletePostAttachments(ctx, post.ID); err != nil {
+			slog.Error("Could not delete attachments of purged post.", "post_id", post.ID, "error", err)
+		}
+	}
+
+	return purged, err
+}
+
+// Run purges the trash every interval until the context is cancelled.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := p.Purge(ctx)
+		if err != nil {
+			slog.Error("Could not purge the trash.", "error", err)
+		}
+		if len(purged) > 0 {
+			slog.Info("Trash purged.", "posts", len(purged))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
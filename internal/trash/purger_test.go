@@ -0,0 +1,74 @@
+package trash_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/trash"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Purge_ShouldRemovePostsAfterRetentionAndRecordThem(t *testing.T) {
+	ctx := context.Background()
+	posts := repository.NewRepository()
+	log := repository.NewAuditRepository()
+
+	kept, _ := posts.CreatePost(ctx, &domain.Post{Author: "Anton", Title: "Kept", Content: "qwerty"})
+	trashed, _ := posts.CreatePost(ctx, &domain.Post{Author: "Anton", Title: "Trashed", Content: "qwerty"})
+	assert.NoError(t, posts.DeletePost(ctx, trashed))
+
+	cfg := trash.Config{Retention: time.Hour, Interval: time.Hour}
+	purger := trash.NewPurger(posts, log, cfg)
+
+	purged, err := purger.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, purged, "retention is not over yet")
+
+	later := time.Now().Add(2 * time.Hour)
+	purged, err = purger.WithClock(func() time.Time { return later }).Purge(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, trashed, purged[0].ID)
+
+	assert.Empty(t, posts.GetDeletedPosts(ctx))
+	_, err = posts.GetPost(ctx, kept)
+	assert.NoError(t, err)
+
+	entries := log.GetEntries(ctx, domain.AuditFilter{Action: domain.AuditPostPurged})
+	assert.Len(t, entries, 1)
+	assert.Equal(t, trashed, entries[0].PostID)
+	assert.Zero(t, entries[0].ActorID)
+	assert.Equal(t, "Trashed", entries[0].Before.Title)
+	assert.Nil(t, entries[0].After)
+}
+
+type attachmentsRecorder struct {
+	postIDs []int64
+}
+
+func (r *attachmentsRecorder) DeletePostAttachments(ctx context.Context, postID int64) error {
+	r.postIDs = append(r.postIDs, postID)
+	return nil
+}
+
+func Test_Purge_ShouldDeleteAttachmentsOfPurgedPosts(t *testing.T) {
+	ctx := context.Background()
+	posts := repository.NewRepository()
+
+	_, _ = posts.CreatePost(ctx, &domain.Post{Author: "Anton", Title: "Kept", Content: "qwerty"})
+	trashed, _ := posts.CreatePost(ctx, &domain.Post{Author: "Anton", Title: "Trashed", Content: "qwerty"})
+	assert.NoError(t, posts.DeletePost(ctx, trashed))
+
+	attachments := &attachmentsRecorder{}
+	later := time.Now().Add(2 * time.Hour)
+	purger := trash.NewPurger(posts, repository.NewAuditRepository(), trash.Config{Retention: time.Hour, Interval: time.Hour}).
+		WithClock(func() time.Time { return later }).
+		WithAttachments(attachments)
+
+	_, err := purger.Purge(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{trashed}, attachments.postIDs)
+}
@@ -0,0 +1,403 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/thumbnail"
+)
+
+type IAttachmentRepository interface {
+	CreateAttachment(ctx context.Context, attachment *domain.Attachment) (int64, error)
+	GetAttachment(ctx context.Context, postID int64, id int64) (*domain.Attachment, error)
+	GetAttachments(ctx context.Context, postID int64) []*domain.Attachment
+	DeleteAttachment(ctx context.Context, id int64) error
+	HasHash(ctx context.Context, hash string) bool
+}
+
+// IBlobStore keeps the content of attachments by key.
+type IBlobStore interface {
+	Put(ctx context.Context, key string, content io.Reader, size int64) error
+	Exists(ctx context.Context, key string) (bool, error)
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// IPostReader reads the posts attachments belong to, without authorization.
+type IPostReader interface {
+	GetPost(ctx context.Context, id int64) (*domain.Post, error)
+}
+
+type AttachmentConfig struct {
+	// MaxBytes is the largest accepted attachment.
+	MaxBytes int64
+	// ContentTypes are the accepted media types. The type is sniffed from the
+	// content, the type declared by the client is ignored.
+	ContentTypes []string
+	// ThumbnailSize is the size of the square thumbnails of images fit into.
+	ThumbnailSize int
+	// TempDir is where uploads are kept while they are checked, the default
+	// directory for temporary files when empty.
+	TempDir string
+}
+
+func DefaultAttachmentConfig() AttachmentConfig {
+	return AttachmentConfig{
+		MaxBytes:      10 << 20,
+		ContentTypes:  []string{"image/png", "image/jpeg", "image/gif", "image/webp", "application/pdf", "text/plain"},
+		ThumbnailSize: 256,
+	}
+}
+
+// maxFilenameLength bounds the length of kept filenames in bytes.
+const maxFilenameLength = 255
+
+// AttachmentUseCase uploads, serves and deletes the attachments of posts. The
+// content is stored once per SHA-256 hash and a blob is deleted with the last
+// attachment referring to it. Attachments are read by who may read the post and
+// changed by who may update it.
+type AttachmentUseCase struct {
+	repository IAttachmentRepository
+	blobs      IBlobStore
+	posts      IPostReader
+	policy     *policy.Policy
+	cfg        AttachmentConfig
+	now        func() time.Time
+
+	// mutex guards uploads and deletions, which keep a blob from being deleted
+	// while an equal upload is added. Uploads counts the uploads of every hash in
+	// progress, deletions are closed when the blobs of their hash are deleted.
+	// Blobs are stored and deleted without holding the mutex.
+	mutex     sync.Mutex
+	uploads   map[string]int
+	deletions map[string]chan struct{}
+}
+
+func NewAttachmentUseCase(repository IAttachmentRepository, blobs IBlobStore, posts IPostReader, policy *policy.Policy, cfg AttachmentConfig) *AttachmentUseCase {
+	return &AttachmentUseCase{
+		repository: repository,
+		blobs:      blobs,
+		posts:      posts,
+		policy:     policy,
+		cfg:        cfg,
+		now:        func() time.Time { return time.Now().UTC() },
+		uploads:    map[string]int{},
+		deletions:  map[string]chan struct{}{},
+	}
+}
+
+// WithClock sets the clock that stamps the creation of attachments.
+func (a *AttachmentUseCase) WithClock(now func() time.Time) *AttachmentUseCase {
+	a.now = now
+	return a
+}
+
+// CreateAttachment reads the content to a temporary file while hashing it,
+// checks its size and sniffed type, and stores it unless an equal content is
+// stored already. Images get a thumbnail when they can be decoded.
+func (a *AttachmentUseCase) CreateAttachment(ctx context.Context, postID int64, filename string, content io.Reader) (*domain.Attachment, error) {
+	if err := a.authorize(ctx, policy.UpdatePost, postID); err != nil {
+		return nil, err
+	}
+
+	upload, err := os.CreateTemp(a.cfg.TempDir, "upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(upload.Name())
+	defer upload.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(io.MultiWriter(upload, hash), io.LimitReader(content, a.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if size > a.cfg.MaxBytes {
+		return nil, fmt.Errorf("%w: attachments are limited to %d bytes", domain.ErrorAttachmentTooLarge, a.cfg.MaxBytes)
+	}
+	if size == 0 {
+		return nil, fmt.Errorf("%w: attachment is empty", domain.ErrorInvalidInput)
+	}
+
+	head := make([]byte, min(size, 512))
+	if _, err := upload.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	contentType := http.DetectContentType(head)
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if !slices.Contains(a.cfg.ContentTypes, mediaType) {
+		return nil, fmt.Errorf("%w: %s", domain.ErrorUnsupportedAttachment, mediaType)
+	}
+
+	attachment := &domain.Attachment{
+		PostID:      postID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        size,
+		Hash:        hex.EncodeToString(hash.Sum(nil)),
+		CreatedAt:   a.now(),
+	}
+
+	if err := a.beginUpload(ctx, attachment.Hash); err != nil {
+		return nil, err
+	}
+	err = a.storeBlob(ctx, attachment.BlobKey(), upload, size)
+	if err == nil {
+		if strings.HasPrefix(mediaType, "image/") {
+			attachment.ThumbnailType = a.storeThumbnail(ctx, attachment, mediaType, upload)
+		}
+		_, err = a.repository.CreateAttachment(ctx, attachment)
+	}
+	a.endUpload(attachment.Hash)
+
+	if err != nil {
+		a.removeBlobs(ctx, attachment)
+		return nil, err
+	}
+
+	return attachment, nil
+}
+
+func (a *AttachmentUseCase) GetAttachments(ctx context.Context, postID int64) ([]*domain.Attachment, error) {
+	if err := a.authorize(ctx, policy.ReadPost, postID); err != nil {
+		return nil, err
+	}
+
+	return a.repository.GetAttachments(ctx, postID), nil
+}
+
+func (a *AttachmentUseCase) GetAttachment(ctx context.Context, postID int64, id int64) (*domain.Attachment, error) {
+	if err := a.authorize(ctx, policy.ReadPost, postID); err != nil {
+		return nil, err
+	}
+
+	return a.repository.GetAttachment(ctx, postID, id)
+}
+
+// OpenAttachment returns the attachment with a reader of its content.
+func (a *AttachmentUseCase) OpenAttachment(ctx context.Context, postID int64, id int64) (*domain.Attachment, io.ReadSeekCloser, error) {
+	attachment, err := a.GetAttachment(ctx, postID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := a.blobs.Open(ctx, attachment.BlobKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+// OpenThumbnail returns the attachment with a reader of its thumbnail. Attachments
+// without a thumbnail are not found.
+func (a *AttachmentUseCase) OpenThumbnail(ctx context.Context, postID int64, id int64) (*domain.Attachment, io.ReadSeekCloser, error) {
+	attachment, err := a.GetAttachment(ctx, postID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(attachment.ThumbnailType) == 0 {
+		return nil, nil, fmt.Errorf("%w: attachment %d has no thumbnail", domain.ErrorAttachmentNotFound, id)
+	}
+
+	content, err := a.blobs.Open(ctx, attachment.ThumbnailKey())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, content, nil
+}
+
+func (a *AttachmentUseCase) DeleteAttachment(ctx context.Context, postID int64, id int64) error {
+	if err := a.authorize(ctx, policy.UpdatePost, postID); err != nil {
+		return err
+	}
+
+	attachment, err := a.repository.GetAttachment(ctx, postID, id)
+	if err != nil {
+		return err
+	}
+	if err := a.repository.DeleteAttachment(ctx, id); err != nil {
+		return err
+	}
+
+	a.removeBlobs(ctx, attachment)
+	return nil
+}
+
+// DeletePostAttachments deletes the attachments of a post that is gone for good.
+// It is not authorized, the trash calls it for purged posts.
+func (a *AttachmentUseCase) DeletePostAttachments(ctx context.Context, postID int64) error {
+	var errs []error
+	for _, attachment := range a.repository.GetAttachments(ctx, postID) {
+		if err := a.repository.DeleteAttachment(ctx, attachment.ID); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		a.removeBlobs(ctx, attachment)
+	}
+
+	return errors.Join(errs...)
+}
+
+// authorize authorizes the action on the post the attachments belong to. Like
+// BlogUseCase.GetPost, a draft the subject may not read is not found.
+func (a *AttachmentUseCase) authorize(ctx context.Context, action policy.Action, postID int64) error {
+	post, err := a.posts.GetPost(ctx, postID)
+	if err != nil {
+		return err
+	}
+
+	subject := a.policy.Subject(ctx)
+	if err := a.policy.Authorize(subject, action, post.AuthorID); err != nil {
+		return err
+	}
+	if post.Draft && !a.policy.Allows(subject, policy.ReadDraft, post.AuthorID) {
+		return domain.ErrorPostNotFound
+	}
+
+	return nil
+}
+
+// beginUpload counts an upload of the hash, waiting for the blobs of the hash
+// to be deleted first when they are being deleted.
+func (a *AttachmentUseCase) beginUpload(ctx context.Context, hash string) error {
+	for {
+		a.mutex.Lock()
+		deleted, isDeleting := a.deletions[hash]
+		if !isDeleting {
+			a.uploads[hash]++
+			a.mutex.Unlock()
+			return nil
+		}
+		a.mutex.Unlock()
+
+		select {
+		case <-deleted:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (a *AttachmentUseCase) endUpload(hash string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+
+	if a.uploads[hash]--; a.uploads[hash] == 0 {
+		delete(a.uploads, hash)
+	}
+}
+
+// storeBlob puts the content under the key unless it is there already.
+func (a *AttachmentUseCase) storeBlob(ctx context.Context, key string, content io.ReadSeeker, size int64) error {
+	exists, err := a.blobs.Exists(ctx, key)
+	if err != nil || exists {
+		return err
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return a.blobs.Put(ctx, key, content, size)
+}
+
+// storeThumbnail stores the thumbnail of the image unless it is there already
+// and returns its content type. An image that cannot be decoded gets no thumbnail.
+func (a *AttachmentUseCase) storeThumbnail(ctx context.Context, attachment *domain.Attachment, mediaType string, image io.ReadSeeker) string {
+	key := attachment.ThumbnailKey()
+	exists, err := a.blobs.Exists(ctx, key)
+	if err != nil {
+		slog.Error("Could not look up thumbnail.", "key", key, "error", err)
+		return ""
+	}
+	if exists {
+		return thumbnail.ContentType(mediaType)
+	}
+
+	if _, err := image.Seek(0, io.SeekStart); err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	thumbnailType, err := thumbnail.Generate(&buf, image, a.cfg.ThumbnailSize)
+	if err != nil {
+		slog.Info("No thumbnail for attachment.", "filename", attachment.Filename, "reason", err)
+		return ""
+	}
+	if err := a.blobs.Put(ctx, key, &buf, int64(buf.Len())); err != nil {
+		slog.Error("Could not store thumbnail.", "key", key, "error", err)
+		return ""
+	}
+
+	return thumbnailType
+}
+
+// removeBlobs deletes the content and the thumbnail of the attachment unless
+// other attachments refer to them or an equal upload is in progress. Failures
+// leave unreferenced blobs behind, which are only logged.
+func (a *AttachmentUseCase) removeBlobs(ctx context.Context, attachment *domain.Attachment) {
+	hash := attachment.Hash
+
+	a.mutex.Lock()
+	_, isDeleting := a.deletions[hash]
+	if isDeleting || a.uploads[hash] > 0 || a.repository.HasHash(ctx, hash) {
+		a.mutex.Unlock()
+		return
+	}
+	deleted := make(chan struct{})
+	a.deletions[hash] = deleted
+	a.mutex.Unlock()
+
+	defer func() {
+		a.mutex.Lock()
+		delete(a.deletions, hash)
+		a.mutex.Unlock()
+		close(deleted)
+	}()
+
+	for _, key := range []string{attachment.BlobKey(), attachment.ThumbnailKey()} {
+		if err := a.blobs.Delete(ctx, key); err != nil {
+			slog.Error("Could not delete blob.", "key", key, "error", err)
+		}
+	}
+}
+
+// cleanFilename keeps the base name of the uploaded file without control
+// characters, so that it is safe in headers and listings.
+func cleanFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	filename = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == unicode.ReplacementChar {
+			return -1
+		}
+		return r
+	}, filename)
+	filename = strings.TrimSpace(filename)
+
+	for len(filename) > maxFilenameLength {
+		_, size := utf8.DecodeLastRuneInString(filename)
+		filename = filename[:len(filename)-size]
+	}
+	if filename == "." || filename == "/" || len(filename) == 0 {
+		return "attachment"
+	}
+	return filename
+}
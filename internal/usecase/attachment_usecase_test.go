@@ -0,0 +1,270 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type AttachmentUseCaseTestSuite struct {
+	mockRepository    *mocks.IAttachmentRepository
+	mockBlobs         *mocks.IBlobStore
+	mockPosts         *mocks.IPostReader
+	attachmentUseCase *usecase.AttachmentUseCase
+	ctx               context.Context
+	now               time.Time
+}
+
+func SetAttachmentSuite(t *testing.T) *AttachmentUseCaseTestSuite {
+	var suite = AttachmentUseCaseTestSuite{}
+	suite.mockRepository = new(mocks.IAttachmentRepository)
+	suite.mockBlobs = new(mocks.IBlobStore)
+	suite.mockPosts = new(mocks.IPostReader)
+	suite.now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	cfg := usecase.DefaultAttachmentConfig()
+	cfg.MaxBytes = 1024
+	cfg.TempDir = t.TempDir()
+	suite.attachmentUseCase = usecase.NewAttachmentUseCase(suite.mockRepository, suite.mockBlobs, suite.mockPosts, policy.Default(), cfg).
+		WithClock(func() time.Time { return suite.now })
+	suite.ctx = auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+
+	suite.mockPosts.On("GetPost", mock.Anything, int64(1)).Return(&domain.Post{ID: 1, AuthorID: 7}, nil)
+	suite.mockPosts.On("GetPost", mock.Anything, int64(2)).Return(&domain.Post{ID: 2, AuthorID: 8}, nil)
+	suite.mockPosts.On("GetPost", mock.Anything, int64(4)).Return(&domain.Post{ID: 4, AuthorID: 8, Draft: true}, nil)
+	suite.mockPosts.On("GetPost", mock.Anything, int64(5)).Return(&domain.Post{ID: 5, AuthorID: 7, Draft: true}, nil)
+	return &suite
+}
+
+func smallPNG(t *testing.T) []byte {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 4, 3))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func hashOf(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+func Test_CreateAttachment_Image_ShouldStoreContentAndThumbnail(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+	content := smallPNG(t)
+	hash := hashOf(content)
+
+	var stored []byte
+	suite.mockBlobs.On("Exists", mock.Anything, hash).Once().Return(false, nil)
+	suite.mockBlobs.On("Exists", mock.Anything, hash+".thumbnail").Once().Return(false, nil)
+	suite.mockBlobs.
+		On("Put", mock.Anything, hash, mock.Anything, int64(len(content))).
+		Once().
+		Run(func(args mock.Arguments) { stored, _ = io.ReadAll(args.Get(2).(io.Reader)) }).
+		Return(nil)
+	suite.mockBlobs.On("Put", mock.Anything, hash+".thumbnail", mock.Anything, mock.Anything).Once().Return(nil)
+	suite.mockRepository.On("CreateAttachment", mock.Anything, mock.Anything).Once().Return(int64(3), nil)
+
+	attachment, err := suite.attachmentUseCase.CreateAttachment(suite.ctx, 1, "../../tmp/Cat\n.png", bytes.NewReader(content))
+
+	assert.NoError(t, err)
+	assert.Equal(t, &domain.Attachment{
+		PostID:        1,
+		Filename:      "Cat.png",
+		ContentType:   "image/png",
+		Size:          int64(len(content)),
+		Hash:          hash,
+		ThumbnailType: "image/png",
+		CreatedAt:     suite.now,
+	}, attachment)
+	assert.Equal(t, content, stored)
+	suite.mockBlobs.AssertExpectations(t)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_CreateAttachment_SameContent_ShouldNotStoreAgain(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+	content := smallPNG(t)
+
+	suite.mockBlobs.On("Exists", mock.Anything, mock.Anything).Return(true, nil)
+	suite.mockRepository.On("CreateAttachment", mock.Anything, mock.Anything).Once().Return(int64(4), nil)
+
+	attachment, err := suite.attachmentUseCase.CreateAttachment(suite.ctx, 1, "copy.png", bytes.NewReader(content))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", attachment.ThumbnailType)
+	suite.mockBlobs.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_CreateAttachment_InvalidContent_ShouldNotStore(t *testing.T) {
+	for name, test := range map[string]struct {
+		content string
+		err     error
+	}{
+		"too large":   {content: strings.Repeat("a", 1025), err: domain.ErrorAttachmentTooLarge},
+		"empty":       {content: "", err: domain.ErrorInvalidInput},
+		"unsupported": {content: "PK\x03\x04 archive", err: domain.ErrorUnsupportedAttachment},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite := SetAttachmentSuite(t)
+
+			_, err := suite.attachmentUseCase.CreateAttachment(suite.ctx, 1, "file", strings.NewReader(test.content))
+
+			assert.ErrorIs(t, err, test.err)
+			suite.mockBlobs.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			suite.mockRepository.AssertNotCalled(t, "CreateAttachment", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func Test_CreateAttachment_PostOfOtherAuthor_ShouldReturnForbidden(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+
+	_, err := suite.attachmentUseCase.CreateAttachment(suite.ctx, 2, "notes.txt", strings.NewReader("notes"))
+
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	suite.mockBlobs.AssertNotCalled(t, "Put", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_DeleteAttachment_SharedContent_ShouldKeepBlob(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+
+	suite.mockRepository.On("GetAttachment", mock.Anything, int64(1), int64(3)).Return(&domain.Attachment{ID: 3, PostID: 1, Hash: "aaa"}, nil)
+	suite.mockRepository.On("DeleteAttachment", mock.Anything, int64(3)).Once().Return(nil)
+	suite.mockRepository.On("HasHash", mock.Anything, "aaa").Once().Return(true)
+
+	err := suite.attachmentUseCase.DeleteAttachment(suite.ctx, 1, 3)
+
+	assert.NoError(t, err)
+	suite.mockRepository.AssertExpectations(t)
+	suite.mockBlobs.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func Test_DeleteAttachment_LastReference_ShouldDeleteBlobAndThumbnail(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+
+	suite.mockRepository.On("GetAttachment", mock.Anything, int64(1), int64(3)).Return(&domain.Attachment{ID: 3, PostID: 1, Hash: "aaa"}, nil)
+	suite.mockRepository.On("DeleteAttachment", mock.Anything, int64(3)).Once().Return(nil)
+	suite.mockRepository.On("HasHash", mock.Anything, "aaa").Once().Return(false)
+	suite.mockBlobs.On("Delete", mock.Anything, "aaa").Once().Return(nil)
+	suite.mockBlobs.On("Delete", mock.Anything, "aaa.thumbnail").Once().Return(nil)
+
+	err := suite.attachmentUseCase.DeleteAttachment(suite.ctx, 1, 3)
+
+	assert.NoError(t, err)
+	suite.mockBlobs.AssertExpectations(t)
+}
+
+// uploadBlocked starts an upload of the content whose Put blocks until release
+// is closed. It returns when the Put has started and the result of the upload
+// is sent to the returned channel.
+func uploadBlocked(suite *AttachmentUseCaseTestSuite, content string, release chan struct{}) chan error {
+	hash := hashOf([]byte(content))
+	started := make(chan struct{})
+	suite.mockBlobs.On("Exists", mock.Anything, hash).Once().Return(false, nil)
+	suite.mockBlobs.
+		On("Put", mock.Anything, hash, mock.Anything, int64(len(content))).
+		Once().
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return(nil)
+
+	done := make(chan error)
+	go func() {
+		_, err := suite.attachmentUseCase.CreateAttachment(suite.ctx, 1, "slow.txt", strings.NewReader(content))
+		done <- err
+	}()
+	<-started
+
+	return done
+}
+
+func Test_CreateAttachment_OtherContent_ShouldNotWaitForSlowUpload(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+	suite.mockRepository.On("CreateAttachment", mock.Anything, mock.Anything).Return(int64(3), nil)
+	release := make(chan struct{})
+	done := uploadBlocked(suite, "slow notes", release)
+
+	suite.mockBlobs.On("Exists", mock.Anything, hashOf([]byte("fast notes"))).Once().Return(false, nil)
+	suite.mockBlobs.On("Put", mock.Anything, hashOf([]byte("fast notes")), mock.Anything, int64(10)).Once().Return(nil)
+	_, err := suite.attachmentUseCase.CreateAttachment(suite.ctx, 1, "fast.txt", strings.NewReader("fast notes"))
+	assert.NoError(t, err)
+
+	close(release)
+	assert.NoError(t, <-done)
+	suite.mockBlobs.AssertExpectations(t)
+}
+
+func Test_DeleteAttachment_EqualUploadInProgress_ShouldKeepBlob(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+	hash := hashOf([]byte("notes"))
+	suite.mockRepository.On("CreateAttachment", mock.Anything, mock.Anything).Return(int64(4), nil)
+	suite.mockRepository.On("GetAttachment", mock.Anything, int64(1), int64(3)).Return(&domain.Attachment{ID: 3, PostID: 1, Hash: hash}, nil)
+	suite.mockRepository.On("DeleteAttachment", mock.Anything, int64(3)).Once().Return(nil)
+	suite.mockRepository.On("HasHash", mock.Anything, hash).Return(false)
+	release := make(chan struct{})
+	done := uploadBlocked(suite, "notes", release)
+
+	err := suite.attachmentUseCase.DeleteAttachment(suite.ctx, 1, 3)
+
+	assert.NoError(t, err)
+	close(release)
+	assert.NoError(t, <-done)
+	suite.mockBlobs.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func Test_OpenThumbnail_WithoutThumbnail_ShouldReturnNotFound(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+
+	suite.mockRepository.On("GetAttachment", mock.Anything, int64(1), int64(3)).Return(&domain.Attachment{ID: 3, PostID: 1, Hash: "aaa"}, nil)
+
+	_, _, err := suite.attachmentUseCase.OpenThumbnail(suite.ctx, 1, 3)
+
+	assert.ErrorIs(t, err, domain.ErrorAttachmentNotFound)
+	suite.mockBlobs.AssertNotCalled(t, "Open", mock.Anything, mock.Anything)
+}
+
+func Test_GetAttachments_DraftOfOtherAuthor_ShouldReturnNotFound(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+
+	_, err := suite.attachmentUseCase.GetAttachments(suite.ctx, 4)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+
+	_, _, err = suite.attachmentUseCase.OpenAttachment(suite.ctx, 4, 3)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+
+	_, _, err = suite.attachmentUseCase.OpenThumbnail(suite.ctx, 4, 3)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+
+	suite.mockRepository.AssertNotCalled(t, "GetAttachments", mock.Anything, mock.Anything)
+	suite.mockRepository.AssertNotCalled(t, "GetAttachment", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_GetAttachments_OwnDraft_ShouldReturnAttachments(t *testing.T) {
+	suite := SetAttachmentSuite(t)
+
+	attachments := []*domain.Attachment{{ID: 3, PostID: 5}}
+	suite.mockRepository.On("GetAttachments", mock.Anything, int64(5)).Once().Return(attachments)
+
+	got, err := suite.attachmentUseCase.GetAttachments(suite.ctx, 5)
+
+	assert.NoError(t, err)
+	assert.Equal(t, attachments, got)
+}
@@ -0,0 +1,36 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+type IAuditRepository interface {
+	GetEntries(ctx context.Context, filter domain.AuditFilter) []*domain.AuditEntry
+}
+
+// AuditUseCase reads the audit log, which is written by audit.UseCase.
+type AuditUseCase struct {
+	repository IAuditRepository
+	policy     *policy.Policy
+}
+
+func NewAuditUseCase(repository IAuditRepository, policy *policy.Policy) *AuditUseCase {
+	return &AuditUseCase{repository: repository, policy: policy}
+}
+
+// GetAuditEntries returns the entries matching the filter ordered by ID.
+func (a *AuditUseCase) GetAuditEntries(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if err := a.policy.Authorize(a.policy.Subject(ctx), policy.ReadAudit, 0); err != nil {
+		return nil, err
+	}
+
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return nil, fmt.Errorf("%w: since must be before until", domain.ErrorInvalidInput)
+	}
+
+	return a.repository.GetEntries(ctx, filter), nil
+}
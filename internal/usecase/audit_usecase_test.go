@@ -0,0 +1,54 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetAuditEntries_Admin_ShouldReturnFilteredEntries(t *testing.T) {
+	mockRepository := new(mocks.IAuditRepository)
+	auditUseCase := usecase.NewAuditUseCase(mockRepository, policy.Default())
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleAdmin})
+	filter := domain.AuditFilter{PostID: 1}
+	entries := []*domain.AuditEntry{{ID: 1, Action: domain.AuditPostDeleted, PostID: 1}}
+
+	mockRepository.
+		On("GetEntries", ctx, filter).
+		Once().
+		Return(entries)
+
+	found, err := auditUseCase.GetAuditEntries(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, found)
+	mockRepository.AssertExpectations(t)
+}
+
+func Test_GetAuditEntries_Editor_ShouldReturnForbidden(t *testing.T) {
+	mockRepository := new(mocks.IAuditRepository)
+	auditUseCase := usecase.NewAuditUseCase(mockRepository, policy.Default())
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleEditor})
+
+	_, err := auditUseCase.GetAuditEntries(ctx, domain.AuditFilter{})
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+
+	_, err = auditUseCase.GetAuditEntries(context.Background(), domain.AuditFilter{})
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+	mockRepository.AssertNotCalled(t, "GetEntries")
+}
+
+func Test_GetAuditEntries_SinceNotBeforeUntil_ShouldReturnInvalidInput(t *testing.T) {
+	auditUseCase := usecase.NewAuditUseCase(new(mocks.IAuditRepository), policy.Default())
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleAdmin})
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	_, err := auditUseCase.GetAuditEntries(ctx, domain.AuditFilter{Since: at, Until: at})
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+}
@@ -0,0 +1,283 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+type IUserRepository interface {
+	CreateUser(ctx context.Context, user *domain.User) (int64, error)
+	GetUser(ctx context.Context, id int64) (*domain.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	RecordFailedLogin(ctx context.Context, id int64) (int, error)
+	RecordSuccessfulLogin(ctx context.Context, id int64) error
+	LockUser(ctx context.Context, id int64, until time.Time) error
+	SetUserRole(ctx context.Context, id int64, role domain.Role, authorID int64) (*domain.User, error)
+}
+
+type ISessionRepository interface {
+	CreateSession(ctx context.Context, session *domain.Session) (int64, error)
+	GetSessionByAccessToken(ctx context.Context, accessTokenHash string) (*domain.Session, error)
+	GetSessionByRefreshToken(ctx context.Context, refreshTokenHash string) (*domain.Session, error)
+	RotateSession(ctx context.Context, session *domain.Session, refreshTokenHash string) error
+	RevokeSession(ctx context.Context, id int64, at time.Time) error
+	DeleteExpiredSessions(ctx context.Context, now time.Time) int
+}
+
+type AuthConfig struct {
+	// AccessTokenTTL is how long an access token authenticates requests.
+	AccessTokenTTL time.Duration
+	// RefreshTokenTTL is how long a refresh token can be exchanged for new tokens.
+	// Every refresh issues a new refresh token and invalidates the old one.
+	RefreshTokenTTL time.Duration
+	// MaxFailedLogins is the number of failed logins in a row that lock the account for LockoutDuration.
+	MaxFailedLogins int
+	LockoutDuration time.Duration
+}
+
+func DefaultAuthConfig() AuthConfig {
+	return AuthConfig{
+		AccessTokenTTL:  15 * time.Minute,
+		RefreshTokenTTL: 30 * 24 * time.Hour,
+		MaxFailedLogins: 5,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+const minPasswordLength = 8
+
+// AuthUseCase registers users, issues, refreshes and revokes their tokens and assigns their roles.
+type AuthUseCase struct {
+	users    IUserRepository
+	sessions ISessionRepository
+	authors  IAuthorRepository
+	policy   *policy.Policy
+	cfg      AuthConfig
+	now      func() time.Time
+}
+
+func NewAuthUseCase(users IUserRepository, sessions ISessionRepository, authors IAuthorRepository, policy *policy.Policy, cfg AuthConfig) *AuthUseCase {
+	return &AuthUseCase{
+		users:    users,
+		sessions: sessions,
+		authors:  authors,
+		policy:   policy,
+		cfg:      cfg,
+		now:      func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// WithClock sets the clock that issues and expires sessions and lockouts, so
+// that tests can move past them.
+func (a *AuthUseCase) WithClock(now func() time.Time) *AuthUseCase {
+	a.now = now
+	return a
+}
+
+func (a *AuthUseCase) Register(ctx context.Context, email string, password string) (*domain.User, error) {
+	if len(password) < minPasswordLength {
+		return nil, fmt.Errorf("%w: password must have at least %d characters", domain.ErrorInvalidInput, minPasswordLength)
+	}
+	if len(password) > auth.MaxPasswordBytes {
+		return nil, fmt.Errorf("%w: password must not be longer than %d bytes", domain.ErrorInvalidInput, auth.MaxPasswordBytes)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := &domain.User{
+		Email:        domain.NormalizeEmail(email),
+		Role:         a.policy.DefaultRole(),
+		PasswordHash: hash,
+		CreatedAt:    a.now(),
+	}
+	if _, err := a.users.CreateUser(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Login starts a session. Unknown emails and wrong passwords are not told apart.
+// After MaxFailedLogins wrong passwords in a row the account is locked.
+func (a *AuthUseCase) Login(ctx context.Context, email string, password string) (*domain.Tokens, error) {
+	user, err := a.users.GetUserByEmail(ctx, email)
+	if errors.Is(err, domain.ErrorUserNotFound) {
+		auth.WastePasswordCheck(password)
+		return nil, fmt.Errorf("%w: wrong email or password", domain.ErrorUnauthorized)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	if user.IsLocked(now) {
+		return nil, domain.ErrorAccountLocked
+	}
+
+	if !auth.CheckPassword(user.PasswordHash, password) {
+		failures, err := a.users.RecordFailedLogin(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if failures < a.cfg.MaxFailedLogins {
+			return nil, fmt.Errorf("%w: wrong email or password", domain.ErrorUnauthorized)
+		}
+
+		if err := a.users.LockUser(ctx, user.ID, now.Add(a.cfg.LockoutDuration)); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrorAccountLocked
+	}
+
+	if err := a.users.RecordSuccessfulLogin(ctx, user.ID); err != nil {
+		return nil, err
+	}
+	a.sessions.DeleteExpiredSessions(ctx, now)
+
+	session := &domain.Session{UserID: user.ID, CreatedAt: now}
+	tokens, err := a.issueTokens(session, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := a.sessions.CreateSession(ctx, session); err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// Refresh exchanges a refresh token for new tokens. The old tokens of the session stop working.
+func (a *AuthUseCase) Refresh(ctx context.Context, refreshToken string) (*domain.Tokens, error) {
+	refreshTokenHash := auth.HashToken(refreshToken)
+	session, err := a.sessions.GetSessionByRefreshToken(ctx, refreshTokenHash)
+	if err != nil {
+		return nil, tokenError(err, "refresh")
+	}
+
+	now := a.now()
+	if err := checkSession(session, session.RefreshExpiresAt, now, "refresh"); err != nil {
+		return nil, err
+	}
+
+	tokens, err := a.issueTokens(session, now)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.sessions.RotateSession(ctx, session, refreshTokenHash); err != nil {
+		return nil, tokenError(err, "refresh")
+	}
+
+	return tokens, nil
+}
+
+// Logout revokes the session of the access token, together with its refresh token.
+func (a *AuthUseCase) Logout(ctx context.Context, accessToken string) error {
+	session, err := a.session(ctx, accessToken)
+	if err != nil {
+		return err
+	}
+
+	return a.sessions.RevokeSession(ctx, session.ID, a.now())
+}
+
+// Authenticate returns the user of a valid access token.
+func (a *AuthUseCase) Authenticate(ctx context.Context, accessToken string) (*domain.User, error) {
+	session, err := a.session(ctx, accessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := a.users.GetUser(ctx, session.UserID)
+	if errors.Is(err, domain.ErrorUserNotFound) {
+		return nil, fmt.Errorf("%w: invalid access token", domain.ErrorUnauthorized)
+	}
+
+	return user, err
+}
+
+// SetRole assigns the role to the user and links the user to the author whose
+// posts are their own. A zero author ID unlinks the user.
+func (a *AuthUseCase) SetRole(ctx context.Context, id int64, role domain.Role, authorID int64) (*domain.User, error) {
+	if err := a.policy.Authorize(a.policy.Subject(ctx), policy.ManageUsers, 0); err != nil {
+		return nil, err
+	}
+
+	if !role.IsValid() {
+		return nil, fmt.Errorf("%w: unknown role %q", domain.ErrorInvalidInput, role)
+	}
+	if authorID != 0 {
+		if _, err := a.authors.GetAuthor(ctx, authorID); errors.Is(err, domain.ErrorAuthorNotFound) {
+			return nil, fmt.Errorf("%w: author %d does not exist", domain.ErrorInvalidInput, authorID)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return a.users.SetUserRole(ctx, id, role, authorID)
+}
+
+func (a *AuthUseCase) session(ctx context.Context, accessToken string) (*domain.Session, error) {
+	session, err := a.sessions.GetSessionByAccessToken(ctx, auth.HashToken(accessToken))
+	if err != nil {
+		return nil, tokenError(err, "access")
+	}
+
+	if err := checkSession(session, session.AccessExpiresAt, a.now(), "access"); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// issueTokens sets new token hashes and expiry times to the session and returns the tokens.
+func (a *AuthUseCase) issueTokens(session *domain.Session, now time.Time) (*domain.Tokens, error) {
+	accessToken, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := auth.NewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.AccessTokenHash = auth.HashToken(accessToken)
+	session.AccessExpiresAt = now.Add(a.cfg.AccessTokenTTL)
+	session.RefreshTokenHash = auth.HashToken(refreshToken)
+	session.RefreshExpiresAt = now.Add(a.cfg.RefreshTokenTTL)
+
+	return &domain.Tokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    a.cfg.AccessTokenTTL,
+	}, nil
+}
+
+func checkSession(session *domain.Session, expiresAt time.Time, now time.Time, kind string) error {
+	if session.IsRevoked() {
+		return fmt.Errorf("%w: %s token was revoked", domain.ErrorUnauthorized, kind)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("%w: %s token expired", domain.ErrorUnauthorized, kind)
+	}
+
+	return nil
+}
+
+func tokenError(err error, kind string) error {
+	if errors.Is(err, domain.ErrorSessionNotFound) {
+		return fmt.Errorf("%w: invalid %s token", domain.ErrorUnauthorized, kind)
+	}
+
+	return err
+}
@@ -0,0 +1,235 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type AuthUseCaseTestSuite struct {
+	mockUsers    *mocks.IUserRepository
+	mockSessions *mocks.ISessionRepository
+	mockAuthors  *mocks.IAuthorRepository
+	authUseCase  *usecase.AuthUseCase
+	ctx          context.Context
+	now          time.Time
+	user         *domain.User
+}
+
+func SetAuthSuite(t *testing.T) *AuthUseCaseTestSuite {
+	var suite = AuthUseCaseTestSuite{}
+	suite.mockUsers = new(mocks.IUserRepository)
+	suite.mockSessions = new(mocks.ISessionRepository)
+	suite.mockAuthors = new(mocks.IAuthorRepository)
+	suite.now = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.authUseCase = usecase.NewAuthUseCase(suite.mockUsers, suite.mockSessions, suite.mockAuthors, policy.Default(), usecase.DefaultAuthConfig()).
+		WithClock(func() time.Time { return suite.now })
+	suite.ctx = context.Background()
+
+	hash, err := auth.HashPassword("correct horse")
+	assert.NoError(t, err)
+	suite.user = &domain.User{ID: 1, Email: "anton@example.com", PasswordHash: hash}
+	return &suite
+}
+
+func Test_Register_ShortPassword_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetAuthSuite(t)
+
+	_, err := suite.authUseCase.Register(suite.ctx, "anton@example.com", "short")
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockUsers.AssertNotCalled(t, "CreateUser", mock.Anything, mock.Anything)
+}
+
+func Test_Register_ShouldStoreNormalizedEmailAndPasswordHash(t *testing.T) {
+	suite := SetAuthSuite(t)
+
+	var created *domain.User
+	suite.mockUsers.
+		On("CreateUser", suite.ctx, mock.AnythingOfType("*domain.User")).
+		Once().
+		Run(func(args mock.Arguments) { created = args.Get(1).(*domain.User) }).
+		Return(int64(1), nil)
+
+	user, err := suite.authUseCase.Register(suite.ctx, " Anton@Example.com", "correct horse")
+
+	assert.NoError(t, err)
+	assert.Same(t, created, user)
+	assert.Equal(t, "anton@example.com", user.Email)
+	assert.Equal(t, domain.RoleReader, user.Role)
+	assert.Equal(t, suite.now, user.CreatedAt)
+	assert.NotContains(t, string(user.PasswordHash), "correct horse")
+	assert.True(t, auth.CheckPassword(user.PasswordHash, "correct horse"))
+	suite.mockUsers.AssertExpectations(t)
+}
+
+func Test_Login_ShouldCreateSessionOfTokens(t *testing.T) {
+	suite := SetAuthSuite(t)
+
+	var session *domain.Session
+	suite.mockUsers.On("GetUserByEmail", suite.ctx, "anton@example.com").Once().Return(suite.user, nil)
+	suite.mockUsers.On("RecordSuccessfulLogin", suite.ctx, int64(1)).Once().Return(nil)
+	suite.mockSessions.On("DeleteExpiredSessions", suite.ctx, suite.now).Once().Return(0)
+	suite.mockSessions.
+		On("CreateSession", suite.ctx, mock.AnythingOfType("*domain.Session")).
+		Once().
+		Run(func(args mock.Arguments) { session = args.Get(1).(*domain.Session) }).
+		Return(int64(1), nil)
+
+	tokens, err := suite.authUseCase.Login(suite.ctx, "anton@example.com", "correct horse")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 15*time.Minute, tokens.ExpiresIn)
+	assert.Equal(t, suite.now.Add(15*time.Minute), session.AccessExpiresAt)
+	assert.Equal(t, int64(1), session.UserID)
+	assert.Equal(t, auth.HashToken(tokens.AccessToken), session.AccessTokenHash)
+	assert.Equal(t, auth.HashToken(tokens.RefreshToken), session.RefreshTokenHash)
+	assert.Equal(t, suite.now.Add(30*24*time.Hour), session.RefreshExpiresAt)
+	suite.mockUsers.AssertExpectations(t)
+	suite.mockSessions.AssertExpectations(t)
+}
+
+func Test_Login_UnknownEmail_ShouldReturnUnauthorized(t *testing.T) {
+	suite := SetAuthSuite(t)
+
+	suite.mockUsers.On("GetUserByEmail", suite.ctx, "jonny@example.com").Once().Return(nil, domain.ErrorUserNotFound)
+
+	_, err := suite.authUseCase.Login(suite.ctx, "jonny@example.com", "correct horse")
+
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+	suite.mockUsers.AssertExpectations(t)
+}
+
+func Test_Login_WrongPassword_ShouldLockAccountAfterMaxFailures(t *testing.T) {
+	suite := SetAuthSuite(t)
+
+	suite.mockUsers.On("GetUserByEmail", suite.ctx, "anton@example.com").Twice().Return(suite.user, nil)
+	suite.mockUsers.On("RecordFailedLogin", suite.ctx, int64(1)).Once().Return(4, nil)
+	suite.mockUsers.On("RecordFailedLogin", suite.ctx, int64(1)).Once().Return(5, nil)
+	suite.mockUsers.On("LockUser", suite.ctx, int64(1), suite.now.Add(15*time.Minute)).Once().Return(nil)
+
+	_, err := suite.authUseCase.Login(suite.ctx, "anton@example.com", "wrong horse")
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+
+	_, err = suite.authUseCase.Login(suite.ctx, "anton@example.com", "wrong horse")
+	assert.ErrorIs(t, err, domain.ErrorAccountLocked)
+
+	suite.mockUsers.AssertExpectations(t)
+}
+
+func Test_Login_LockedAccount_ShouldRejectCorrectPassword(t *testing.T) {
+	suite := SetAuthSuite(t)
+	suite.user.LockedUntil = suite.now.Add(time.Minute)
+
+	suite.mockUsers.On("GetUserByEmail", suite.ctx, "anton@example.com").Once().Return(suite.user, nil)
+
+	_, err := suite.authUseCase.Login(suite.ctx, "anton@example.com", "correct horse")
+
+	assert.ErrorIs(t, err, domain.ErrorAccountLocked)
+	suite.mockUsers.AssertExpectations(t)
+	suite.mockSessions.AssertNotCalled(t, "CreateSession", mock.Anything, mock.Anything)
+}
+
+func Test_Refresh_ShouldRotateTokens(t *testing.T) {
+	suite := SetAuthSuite(t)
+	session := &domain.Session{
+		ID: 1, UserID: 1,
+		AccessTokenHash: auth.HashToken("access"), AccessExpiresAt: suite.now.Add(-time.Minute),
+		RefreshTokenHash: auth.HashToken("refresh"), RefreshExpiresAt: suite.now.Add(time.Hour),
+	}
+
+	suite.mockSessions.On("GetSessionByRefreshToken", suite.ctx, auth.HashToken("refresh")).Once().Return(session, nil)
+	suite.mockSessions.On("RotateSession", suite.ctx, session, auth.HashToken("refresh")).Once().Return(nil)
+
+	tokens, err := suite.authUseCase.Refresh(suite.ctx, "refresh")
+
+	assert.NoError(t, err)
+	assert.Equal(t, auth.HashToken(tokens.AccessToken), session.AccessTokenHash)
+	assert.Equal(t, auth.HashToken(tokens.RefreshToken), session.RefreshTokenHash)
+	assert.NotEqual(t, "refresh", tokens.RefreshToken)
+	suite.mockSessions.AssertExpectations(t)
+}
+
+func Test_Refresh_RevokedSession_ShouldReturnUnauthorized(t *testing.T) {
+	suite := SetAuthSuite(t)
+	session := &domain.Session{ID: 1, UserID: 1, RefreshExpiresAt: suite.now.Add(time.Hour), RevokedAt: suite.now.Add(-time.Minute)}
+
+	suite.mockSessions.On("GetSessionByRefreshToken", suite.ctx, auth.HashToken("refresh")).Once().Return(session, nil)
+
+	_, err := suite.authUseCase.Refresh(suite.ctx, "refresh")
+
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+	suite.mockSessions.AssertNotCalled(t, "RotateSession", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_Authenticate_ExpiredOrUnknownToken_ShouldReturnUnauthorized(t *testing.T) {
+	suite := SetAuthSuite(t)
+	session := &domain.Session{ID: 1, UserID: 1, AccessExpiresAt: suite.now}
+
+	suite.mockSessions.On("GetSessionByAccessToken", suite.ctx, auth.HashToken("expired")).Once().Return(session, nil)
+	suite.mockSessions.On("GetSessionByAccessToken", suite.ctx, auth.HashToken("unknown")).Once().Return(nil, domain.ErrorSessionNotFound)
+
+	_, err := suite.authUseCase.Authenticate(suite.ctx, "expired")
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+
+	_, err = suite.authUseCase.Authenticate(suite.ctx, "unknown")
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+}
+
+func Test_Logout_ShouldRevokeSession(t *testing.T) {
+	suite := SetAuthSuite(t)
+	session := &domain.Session{ID: 7, UserID: 1, AccessExpiresAt: suite.now.Add(time.Minute)}
+
+	suite.mockSessions.On("GetSessionByAccessToken", suite.ctx, auth.HashToken("access")).Once().Return(session, nil)
+	suite.mockSessions.On("RevokeSession", suite.ctx, int64(7), suite.now).Once().Return(nil)
+
+	assert.NoError(t, suite.authUseCase.Logout(suite.ctx, "access"))
+	suite.mockSessions.AssertExpectations(t)
+}
+
+func Test_Register_ShouldGetDefaultRole(t *testing.T) {
+	suite := SetAuthSuite(t)
+
+	suite.mockUsers.On("CreateUser", suite.ctx, mock.AnythingOfType("*domain.User")).Once().Return(int64(1), nil)
+
+	user, err := suite.authUseCase.Register(suite.ctx, "Admin@example.com", "correct horse")
+
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleReader, user.Role)
+}
+
+func Test_SetRole_ShouldRequireAdminAndKnownAuthor(t *testing.T) {
+	suite := SetAuthSuite(t)
+	admin := auth.WithUser(suite.ctx, &domain.User{ID: 1, Role: domain.RoleAdmin})
+	editor := auth.WithUser(suite.ctx, &domain.User{ID: 2, Role: domain.RoleEditor})
+
+	suite.mockAuthors.On("GetAuthor", admin, int64(7)).Once().Return(&domain.Author{ID: 7, Name: "Anton"}, nil)
+	suite.mockAuthors.On("GetAuthor", admin, int64(8)).Once().Return(nil, domain.ErrorAuthorNotFound)
+	suite.mockUsers.
+		On("SetUserRole", admin, int64(3), domain.RoleAuthor, int64(7)).
+		Once().
+		Return(&domain.User{ID: 3, Role: domain.RoleAuthor, AuthorID: 7}, nil)
+
+	user, err := suite.authUseCase.SetRole(admin, 3, domain.RoleAuthor, 7)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.RoleAuthor, user.Role)
+
+	_, err = suite.authUseCase.SetRole(admin, 3, domain.RoleAuthor, 8)
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+
+	_, err = suite.authUseCase.SetRole(admin, 3, "owner", 0)
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+
+	_, err = suite.authUseCase.SetRole(editor, 3, domain.RoleAdmin, 0)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+
+	suite.mockUsers.AssertExpectations(t)
+}
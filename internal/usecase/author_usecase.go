@@ -0,0 +1,182 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+// IPostService reads and writes posts through the blog use case, so that caches
+// and subscribers learn about posts changed by author updates.
+type IPostService interface {
+	GetPosts(ctx context.Context) []*domain.Post
+	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
+	GetDeletedPosts(ctx context.Context) ([]*domain.Post, error)
+}
+
+// AuthorUseCase authorizes changes of authors with the policy. An author is
+// owned by the users linked to it.
+type AuthorUseCase struct {
+	repository IAuthorRepository
+	posts      IPostService
+	policy     *policy.Policy
+}
+
+func NewAuthorUseCase(repository IAuthorRepository, posts IPostService, policy *policy.Policy) *AuthorUseCase {
+	return &AuthorUseCase{repository: repository, posts: posts, policy: policy}
+}
+
+func (a *AuthorUseCase) GetAuthor(ctx context.Context, id int64) (*domain.Author, error) {
+	return a.repository.GetAuthor(ctx, id)
+}
+
+func (a *AuthorUseCase) GetAuthors(ctx context.Context) []*domain.Author {
+	return a.repository.GetAuthors(ctx)
+}
+
+func (a *AuthorUseCase) CreateAuthor(ctx context.Context, author *domain.Author) (int64, error) {
+	if err := a.policy.Authorize(a.policy.Subject(ctx), policy.CreateAuthor, 0); err != nil {
+		return 0, err
+	}
+	if err := validateAuthor(author); err != nil {
+		return 0, err
+	}
+
+	return a.repository.CreateAuthor(ctx, author)
+}
+
+// UpdateAuthor updates the author and, when the name changed, the posts of the author.
+// Every post is authorized and validated before anything is written, and a failed
+// write restores the author and the posts renamed so far.
+func (a *AuthorUseCase) UpdateAuthor(ctx context.Context, author *domain.Author, id int64) error {
+	subject := a.policy.Subject(ctx)
+	if err := a.policy.Authorize(subject, policy.UpdateAuthor, id); err != nil {
+		return err
+	}
+	if err := validateAuthor(author); err != nil {
+		return err
+	}
+
+	current, err := a.repository.GetAuthor(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current.Name == author.Name {
+		return a.repository.UpdateAuthor(ctx, author, id)
+	}
+
+	posts := a.postsOf(ctx, id)
+	if len(posts) > 0 {
+		if err := a.policy.Authorize(subject, policy.UpdatePost, id); err != nil {
+			return err
+		}
+	}
+	for _, post := range posts {
+		renamedPost := *post
+		renamedPost.Author = author.Name
+		if err := renamedPost.Validate(); err != nil {
+			return fmt.Errorf("renaming author of post %d: %w", post.ID, err)
+		}
+	}
+
+	if err := a.repository.UpdateAuthor(ctx, author, id); err != nil {
+		return err
+	}
+	for i, post := range posts {
+		renamedPost := *post
+		if err := a.posts.UpdatePost(ctx, &renamedPost, post.ID); err != nil {
+			err = fmt.Errorf("renaming author of post %d: %w", post.ID, err)
+			return errors.Join(err, a.restoreAuthor(ctx, current, id, posts[:i]))
+		}
+	}
+
+	return nil
+}
+
+// restoreAuthor undoes a partial rename. The author is restored first, because
+// the posts take the name of their author when they are updated.
+func (a *AuthorUseCase) restoreAuthor(ctx context.Context, author *domain.Author, id int64, posts []*domain.Post) error {
+	if err := a.repository.UpdateAuthor(ctx, author, id); err != nil {
+		return fmt.Errorf("restoring author %d: %w", id, err)
+	}
+	for _, post := range posts {
+		restoredPost := *post
+		if err := a.posts.UpdatePost(ctx, &restoredPost, post.ID); err != nil {
+			return fmt.Errorf("restoring author of post %d: %w", post.ID, err)
+		}
+	}
+	return nil
+}
+
+// DeleteAuthor deletes an author without posts, also in the trash, so that
+// every restored post has an author.
+func (a *AuthorUseCase) DeleteAuthor(ctx context.Context, id int64) error {
+	if err := a.policy.Authorize(a.policy.Subject(ctx), policy.DeleteAuthor, id); err != nil {
+		return err
+	}
+	if _, err := a.repository.GetAuthor(ctx, id); err != nil {
+		return err
+	}
+
+	if posts := a.postsOf(ctx, id); len(posts) > 0 {
+		return fmt.Errorf("%w: author %d has %d posts", domain.ErrorConflict, id, len(posts))
+	}
+
+	deleted, err := a.posts.GetDeletedPosts(ctx)
+	if err != nil {
+		return err
+	}
+	trashed := 0
+	for _, post := range deleted {
+		if post.AuthorID == id {
+			trashed++
+		}
+	}
+	if trashed > 0 {
+		return fmt.Errorf("%w: author %d has %d posts in the trash", domain.ErrorConflict, id, trashed)
+	}
+
+	return a.repository.DeleteAuthor(ctx, id)
+}
+
+// GetAuthorPosts returns the posts of the author ordered by ID.
+func (a *AuthorUseCase) GetAuthorPosts(ctx context.Context, id int64) ([]*domain.Post, error) {
+	if _, err := a.repository.GetAuthor(ctx, id); err != nil {
+		return nil, err
+	}
+
+	return a.postsOf(ctx, id), nil
+}
+
+func (a *AuthorUseCase) postsOf(ctx context.Context, authorID int64) []*domain.Post {
+	posts := []*domain.Post{}
+	for _, post := range a.posts.GetPosts(ctx) {
+		if post.AuthorID == authorID {
+			posts = append(posts, post)
+		}
+	}
+	sort.Slice(posts, func(i, j int) bool { return posts[i].ID < posts[j].ID })
+
+	return posts
+}
+
+func validateAuthor(author *domain.Author) error {
+	author.Name = domain.NormalizeAuthorName(author.Name)
+	if len(author.Name) == 0 {
+		return fmt.Errorf("%w: author name must contain a visible character", domain.ErrorInvalidInput)
+	}
+
+	if len(author.AvatarURL) > 0 {
+		avatar, err := url.Parse(author.AvatarURL)
+		if err != nil || (avatar.Scheme != "http" && avatar.Scheme != "https") || len(avatar.Host) == 0 {
+			return fmt.Errorf("%w: avatar URL must be an absolute http or https URL", domain.ErrorInvalidInput)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,262 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type AuthorUseCaseTestSuite struct {
+	mockRepository *mocks.IAuthorRepository
+	mockPosts      *mocks.IPostService
+	authorUseCase  *usecase.AuthorUseCase
+	ctx            context.Context
+	postsInRepo    []*domain.Post
+}
+
+func SetAuthorSuite() *AuthorUseCaseTestSuite {
+	var suite = AuthorUseCaseTestSuite{}
+	suite.mockRepository = new(mocks.IAuthorRepository)
+	suite.mockPosts = new(mocks.IPostService)
+	suite.authorUseCase = usecase.NewAuthorUseCase(suite.mockRepository, suite.mockPosts, policy.Default())
+	suite.ctx = auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleEditor})
+	suite.postsInRepo = []*domain.Post{
+		{ID: 3, AuthorID: 1, Author: "Anton", Title: "On gin", Content: "asdf"},
+		{ID: 2, AuthorID: 2, Author: "Jonny", Title: "On golang", Content: "zxcv"},
+		{ID: 1, AuthorID: 1, Author: "Anton", Title: "On mockery", Content: "qwerty"},
+	}
+	return &suite
+}
+
+func Test_CreateAuthor_ShouldNormalizeName(t *testing.T) {
+	suite := SetAuthorSuite()
+
+	suite.mockRepository.
+		On("CreateAuthor", suite.ctx, &domain.Author{Name: "Anton Kondrushin"}).
+		Once().
+		Return(int64(1), nil)
+
+	id, err := suite.authorUseCase.CreateAuthor(suite.ctx, &domain.Author{Name: " Anton  Kondrushin "})
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_CreateAuthor_InvalidInput_ShouldNotCallRepo(t *testing.T) {
+	for name, author := range map[string]*domain.Author{
+		"blank name":      {Name: " \t"},
+		"relative avatar": {Name: "Anton", AvatarURL: "/avatar.png"},
+		"ftp avatar":      {Name: "Anton", AvatarURL: "ftp://example.com/avatar.png"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite := SetAuthorSuite()
+
+			_, err := suite.authorUseCase.CreateAuthor(suite.ctx, author)
+
+			assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+			suite.mockRepository.AssertNotCalled(t, "CreateAuthor", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func Test_UpdateAuthor_Renamed_ShouldUpdatePostsOfAuthor(t *testing.T) {
+	suite := SetAuthorSuite()
+	author := &domain.Author{Name: "Anton K."}
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(1)).
+		Once().
+		Return(&domain.Author{ID: 1, Name: "Anton"}, nil)
+	suite.mockRepository.
+		On("UpdateAuthor", suite.ctx, author, int64(1)).
+		Once().
+		Return(nil)
+	suite.mockPosts.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return(suite.postsInRepo)
+	suite.mockPosts.
+		On("UpdatePost", suite.ctx, mock.MatchedBy(func(p *domain.Post) bool { return p.AuthorID == 1 }), int64(1)).
+		Once().
+		Return(nil)
+	suite.mockPosts.
+		On("UpdatePost", suite.ctx, mock.MatchedBy(func(p *domain.Post) bool { return p.AuthorID == 1 }), int64(3)).
+		Once().
+		Return(nil)
+
+	err := suite.authorUseCase.UpdateAuthor(suite.ctx, author, 1)
+
+	assert.NoError(t, err)
+	suite.mockRepository.AssertExpectations(t)
+	suite.mockPosts.AssertExpectations(t)
+}
+
+func Test_UpdateAuthor_InvalidPost_ShouldNotWriteAnything(t *testing.T) {
+	suite := SetAuthorSuite()
+	author := &domain.Author{Name: "Anton K."}
+	posts := append(suite.postsInRepo, &domain.Post{ID: 4, AuthorID: 1, Author: "Anton", Title: " ", Content: "seeded"})
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(1)).
+		Once().
+		Return(&domain.Author{ID: 1, Name: "Anton"}, nil)
+	suite.mockPosts.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return(posts)
+
+	err := suite.authorUseCase.UpdateAuthor(suite.ctx, author, 1)
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockRepository.AssertNotCalled(t, "UpdateAuthor", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockPosts.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_UpdateAuthor_PostUpdateFails_ShouldRestoreAuthorAndPosts(t *testing.T) {
+	suite := SetAuthorSuite()
+	author := &domain.Author{Name: "Anton K."}
+	current := &domain.Author{ID: 1, Name: "Anton"}
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(1)).
+		Once().
+		Return(current, nil)
+	suite.mockRepository.
+		On("UpdateAuthor", suite.ctx, author, int64(1)).
+		Once().
+		Return(nil)
+	suite.mockRepository.
+		On("UpdateAuthor", suite.ctx, current, int64(1)).
+		Once().
+		Return(nil)
+	suite.mockPosts.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return(suite.postsInRepo)
+	suite.mockPosts.
+		On("UpdatePost", suite.ctx, mock.MatchedBy(func(p *domain.Post) bool { return p.AuthorID == 1 }), int64(1)).
+		Twice().
+		Return(nil)
+	suite.mockPosts.
+		On("UpdatePost", suite.ctx, mock.MatchedBy(func(p *domain.Post) bool { return p.AuthorID == 1 }), int64(3)).
+		Once().
+		Return(domain.ErrorConflict)
+
+	err := suite.authorUseCase.UpdateAuthor(suite.ctx, author, 1)
+
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+	suite.mockRepository.AssertExpectations(t)
+	suite.mockPosts.AssertExpectations(t)
+}
+
+func Test_UpdateAuthor_SameName_ShouldNotUpdatePosts(t *testing.T) {
+	suite := SetAuthorSuite()
+	author := &domain.Author{Name: "Anton", Bio: "Gopher"}
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(1)).
+		Once().
+		Return(&domain.Author{ID: 1, Name: "Anton"}, nil)
+	suite.mockRepository.
+		On("UpdateAuthor", suite.ctx, author, int64(1)).
+		Once().
+		Return(nil)
+
+	err := suite.authorUseCase.UpdateAuthor(suite.ctx, author, 1)
+
+	assert.NoError(t, err)
+	suite.mockPosts.AssertNotCalled(t, "GetPosts", mock.Anything)
+}
+
+func Test_DeleteAuthor_WithPosts_ShouldReturnConflict(t *testing.T) {
+	suite := SetAuthorSuite()
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(2)).
+		Once().
+		Return(&domain.Author{ID: 2, Name: "Jonny"}, nil)
+	suite.mockPosts.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return(suite.postsInRepo)
+
+	err := suite.authorUseCase.DeleteAuthor(suite.ctx, 2)
+
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+	suite.mockRepository.AssertNotCalled(t, "DeleteAuthor", mock.Anything, mock.Anything)
+}
+
+func Test_DeleteAuthor_WithPostsInTrash_ShouldReturnConflict(t *testing.T) {
+	suite := SetAuthorSuite()
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(3)).
+		Once().
+		Return(&domain.Author{ID: 3, Name: "Maria"}, nil)
+	suite.mockPosts.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return(suite.postsInRepo)
+	suite.mockPosts.
+		On("GetDeletedPosts", suite.ctx).
+		Once().
+		Return([]*domain.Post{{ID: 4, AuthorID: 3, Author: "Maria"}}, nil)
+
+	err := suite.authorUseCase.DeleteAuthor(suite.ctx, 3)
+
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+	suite.mockRepository.AssertNotCalled(t, "DeleteAuthor", mock.Anything, mock.Anything)
+}
+
+func Test_GetAuthorPosts_ShouldReturnPostsOfAuthorOrderedById(t *testing.T) {
+	suite := SetAuthorSuite()
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(1)).
+		Once().
+		Return(&domain.Author{ID: 1, Name: "Anton"}, nil)
+	suite.mockPosts.
+		On("GetPosts", suite.ctx).
+		Once().
+		Return(suite.postsInRepo)
+
+	posts, err := suite.authorUseCase.GetAuthorPosts(suite.ctx, 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.Post{suite.postsInRepo[2], suite.postsInRepo[0]}, posts)
+}
+
+func Test_GetAuthorPosts_UnknownAuthor_ShouldReturnNotFound(t *testing.T) {
+	suite := SetAuthorSuite()
+
+	suite.mockRepository.
+		On("GetAuthor", suite.ctx, int64(9)).
+		Once().
+		Return(nil, domain.ErrorAuthorNotFound)
+
+	_, err := suite.authorUseCase.GetAuthorPosts(suite.ctx, 9)
+
+	assert.ErrorIs(t, err, domain.ErrorAuthorNotFound)
+}
+
+func Test_UpdateAuthor_ByAuthorRole_ShouldAllowOnlyOwnAuthor(t *testing.T) {
+	suite := SetAuthorSuite()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 1})
+
+	err := suite.authorUseCase.UpdateAuthor(ctx, &domain.Author{Name: "Jonny"}, 2)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+
+	_, err = suite.authorUseCase.CreateAuthor(ctx, &domain.Author{Name: "Jonny"})
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+
+	suite.mockRepository.AssertNotCalled(t, "UpdateAuthor", mock.Anything, mock.Anything, mock.Anything)
+	suite.mockRepository.AssertNotCalled(t, "CreateAuthor", mock.Anything, mock.Anything)
+}
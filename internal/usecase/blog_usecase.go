@@ -2,42 +2,310 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
 )
 
 type IBlogRepository interface {
 	GetPost(ctx context.Context, id int64) (*domain.Post, error)
 	GetPosts(ctx context.Context) []*domain.Post
+	EachPost(ctx context.Context, yield func(*domain.Post) bool) error
 	CreatePost(ctx context.Context, post *domain.Post) (int64, error)
 	UpdatePost(ctx context.Context, post *domain.Post, id int64) error
 	DeletePost(ctx context.Context, id int64) error
+	GetDeletedPost(ctx context.Context, id int64) (*domain.Post, error)
+	GetDeletedPosts(ctx context.Context) []*domain.Post
+	RestorePost(ctx context.Context, id int64) error
 }
 
+type IEventPublisher interface {
+	Publish(ctx context.Context, event domain.PostEvent)
+}
+
+type IAuthorRepository interface {
+	GetAuthor(ctx context.Context, id int64) (*domain.Author, error)
+	GetAuthors(ctx context.Context) []*domain.Author
+	CreateAuthor(ctx context.Context, author *domain.Author) (int64, error)
+	GetOrCreateAuthorByName(ctx context.Context, name string) (*domain.Author, error)
+	UpdateAuthor(ctx context.Context, author *domain.Author, id int64) error
+	DeleteAuthor(ctx context.Context, id int64) error
+}
+
+// BlogUseCase authorizes every operation with the policy for the user of the context.
 type BlogUseCase struct {
 	repository IBlogRepository
+	authors    IAuthorRepository
+	publisher  IEventPublisher
+	policy     *policy.Policy
 }
 
-func NewBlogUseCase(repository IBlogRepository) *BlogUseCase {
-	return &BlogUseCase{repository: repository}
+func NewBlogUseCase(repository IBlogRepository, authors IAuthorRepository, publisher IEventPublisher, policy *policy.Policy) *BlogUseCase {
+	return &BlogUseCase{repository: repository, authors: authors, publisher: publisher, policy: policy}
 }
 
+// GetPost returns the post when the user may read it. Drafts the user may not
+// read are not found, so that they cannot be told apart from missing posts.
 func (b *BlogUseCase) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
-	return b.repository.GetPost(ctx, id)
+	post, err := b.repository.GetPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := b.policy.Subject(ctx)
+	if err := b.policy.Authorize(subject, policy.ReadPost, post.AuthorID); err != nil {
+		return nil, err
+	}
+	if post.Draft && !b.policy.Allows(subject, policy.ReadDraft, post.AuthorID) {
+		return nil, domain.ErrorPostNotFound
+	}
+
+	return post, nil
 }
 
+// GetPosts returns the posts the user may read.
 func (b *BlogUseCase) GetPosts(ctx context.Context) []*domain.Post {
-	return b.repository.GetPosts(ctx)
+	posts := b.repository.GetPosts(ctx)
+
+	subject := b.policy.Subject(ctx)
+	if readsAll(b.policy, subject) {
+		return posts
+	}
+
+	readable := make([]*domain.Post, 0, len(posts))
+	for _, post := range posts {
+		if canRead(b.policy, subject, post) {
+			readable = append(readable, post)
+		}
+	}
+
+	return readable
+}
+
+// EachPost calls yield with the posts the user may read, ordered by ID, until it
+// returns false.
+func (b *BlogUseCase) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	subject := b.policy.Subject(ctx)
+	switch {
+	case readsAll(b.policy, subject):
+		return b.repository.EachPost(ctx, yield)
+	case b.policy.Scope(subject, policy.ReadPost) == policy.ScopeNone:
+		return nil
+	}
+
+	return b.repository.EachPost(ctx, func(post *domain.Post) bool {
+		if !canRead(b.policy, subject, post) {
+			return true
+		}
+		return yield(post)
+	})
 }
 
 func (b *BlogUseCase) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
-	return b.repository.CreatePost(ctx, post)
+	if err := b.authorizeAuthorOf(b.policy.Subject(ctx), policy.CreatePost, post); err != nil {
+		return 0, err
+	}
+	if err := post.Validate(); err != nil {
+		return 0, err
+	}
+
+	if err := b.resolveAuthor(ctx, post); err != nil {
+		return 0, err
+	}
+	post.Summarize()
+
+	id, err := b.repository.CreatePost(ctx, post)
+	if err != nil {
+		return id, err
+	}
+
+	b.publish(ctx, domain.EventPostCreated, id, post)
+	return id, nil
 }
 
 func (b *BlogUseCase) UpdatePost(ctx context.Context, post *domain.Post, id int64) error {
-	return b.repository.UpdatePost(ctx, post, id)
+	subject := b.policy.Subject(ctx)
+	if err := b.authorizeStored(ctx, subject, policy.UpdatePost, id); err != nil {
+		return err
+	}
+	if err := b.authorizeAuthorOf(subject, policy.UpdatePost, post); err != nil {
+		return err
+	}
+	if err := post.Validate(); err != nil {
+		return err
+	}
+
+	if err := b.resolveAuthor(ctx, post); err != nil {
+		return err
+	}
+	post.Summarize()
+
+	if err := b.repository.UpdatePost(ctx, post, id); err != nil {
+		return err
+	}
+
+	b.publish(ctx, domain.EventPostUpdated, id, post)
+	return nil
 }
 
 func (b *BlogUseCase) DeletePost(ctx context.Context, id int64) error {
-	return b.repository.DeletePost(ctx, id)
+	if err := b.authorizeStored(ctx, b.policy.Subject(ctx), policy.DeletePost, id); err != nil {
+		return err
+	}
+
+	// The post is read first so that subscribers filtering by author learn about the deletion.
+	post, err := b.repository.GetPost(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := b.repository.DeletePost(ctx, id); err != nil {
+		return err
+	}
+
+	b.publish(ctx, domain.EventPostDeleted, id, post)
+	return nil
+}
+
+// GetDeletedPosts returns the posts of the trash the user may delete, and so restore.
+func (b *BlogUseCase) GetDeletedPosts(ctx context.Context) ([]*domain.Post, error) {
+	subject := b.policy.Subject(ctx)
+	switch b.policy.Scope(subject, policy.DeletePost) {
+	case policy.ScopeAny:
+		return b.repository.GetDeletedPosts(ctx), nil
+	case policy.ScopeOwn:
+		posts := []*domain.Post{}
+		for _, post := range b.repository.GetDeletedPosts(ctx) {
+			if b.policy.Allows(subject, policy.DeletePost, post.AuthorID) {
+				posts = append(posts, post)
+			}
+		}
+		return posts, nil
+	default:
+		return nil, b.policy.Authorize(subject, policy.DeletePost, 0)
+	}
+}
+
+// RestorePost takes the post out of the trash. Users who may delete a post may
+// restore it. The restored post gets the current name of its author and is
+// published as created again.
+func (b *BlogUseCase) RestorePost(ctx context.Context, id int64) (*domain.Post, error) {
+	post, err := b.repository.GetDeletedPost(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := b.policy.Authorize(b.policy.Subject(ctx), policy.DeletePost, post.AuthorID); err != nil {
+		return nil, err
+	}
+
+	restored := *post
+	restored.DeletedAt = time.Time{}
+	if restored.AuthorID != 0 {
+		author, err := b.authors.GetAuthor(ctx, restored.AuthorID)
+		if errors.Is(err, domain.ErrorAuthorNotFound) {
+			return nil, fmt.Errorf("%w: author %d of post %d does not exist", domain.ErrorConflict, restored.AuthorID, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		restored.Author = author.Name
+	}
+
+	if err := b.repository.RestorePost(ctx, id); err != nil {
+		return nil, err
+	}
+	if restored.Author != post.Author {
+		if err := b.repository.UpdatePost(ctx, &restored, id); err != nil {
+			return nil, err
+		}
+	}
+
+	b.publish(ctx, domain.EventPostCreated, id, &restored)
+	return &restored, nil
+}
+
+// canRead tells whether the subject may read the post. Drafts also take ReadDraft.
+func canRead(p *policy.Policy, subject policy.Subject, post *domain.Post) bool {
+	if !p.Allows(subject, policy.ReadPost, post.AuthorID) {
+		return false
+	}
+	return !post.Draft || p.Allows(subject, policy.ReadDraft, post.AuthorID)
+}
+
+// readsAll tells whether the subject may read every post, drafts included, so
+// that posts need not be checked one by one.
+func readsAll(p *policy.Policy, subject policy.Subject) bool {
+	return p.Scope(subject, policy.ReadPost) == policy.ScopeAny && p.Scope(subject, policy.ReadDraft) == policy.ScopeAny
+}
+
+// authorizeStored authorizes the action on the stored post. The post is only
+// read when the subject may act on their own posts only.
+func (b *BlogUseCase) authorizeStored(ctx context.Context, subject policy.Subject, action policy.Action, id int64) error {
+	switch b.policy.Scope(subject, action) {
+	case policy.ScopeAny:
+		return nil
+	case policy.ScopeOwn:
+		post, err := b.repository.GetPost(ctx, id)
+		if err != nil {
+			return err
+		}
+		return b.policy.Authorize(subject, action, post.AuthorID)
+	default:
+		return b.policy.Authorize(subject, action, 0)
+	}
+}
+
+// authorizeAuthorOf authorizes writing the post as its author before the author
+// is resolved, so that denied writes create no authors. Subjects who may write only
+// their own posts write as their author when the post has no author ID.
+func (b *BlogUseCase) authorizeAuthorOf(subject policy.Subject, action policy.Action, post *domain.Post) error {
+	switch b.policy.Scope(subject, action) {
+	case policy.ScopeAny:
+		return nil
+	case policy.ScopeOwn:
+		if post.AuthorID == 0 {
+			post.AuthorID = subject.AuthorID
+		}
+		return b.policy.Authorize(subject, action, post.AuthorID)
+	default:
+		return b.policy.Authorize(subject, action, 0)
+	}
+}
+
+// resolveAuthor links the post to its author, given either by ID or by name.
+// An author given by name is created when there is none with this name.
+func (b *BlogUseCase) resolveAuthor(ctx context.Context, post *domain.Post) error {
+	var author *domain.Author
+	var err error
+	switch {
+	case post.AuthorID != 0:
+		author, err = b.authors.GetAuthor(ctx, post.AuthorID)
+		if errors.Is(err, domain.ErrorAuthorNotFound) {
+			return fmt.Errorf("%w: author %d does not exist", domain.ErrorInvalidInput, post.AuthorID)
+		}
+	case len(domain.NormalizeAuthorName(post.Author)) > 0:
+		author, err = b.authors.GetOrCreateAuthorByName(ctx, post.Author)
+	default:
+		return fmt.Errorf("%w: post must have an author", domain.ErrorInvalidInput)
+	}
+	if err != nil {
+		return err
+	}
+
+	post.AuthorID = author.ID
+	post.Author = author.Name
+	return nil
+}
+
+func (b *BlogUseCase) publish(ctx context.Context, eventType domain.EventType, id int64, post *domain.Post) {
+	b.publisher.Publish(ctx, domain.PostEvent{
+		Type:       eventType,
+		PostID:     id,
+		Post:       *post,
+		OccurredAt: time.Now().UTC(),
+	})
 }
@@ -3,17 +3,22 @@ package usecase_test
 import (
 	"context"
 	"errors"
-
+	"strings"
 	"testing"
 
+	"github.com/kondrushin/blog/internal/auth"
 	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
 	"github.com/kondrushin/blog/internal/usecase"
 	"github.com/kondrushin/blog/internal/usecase/mocks"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 type UseCaseTestSuite struct {
 	mockRepository *mocks.IBlogRepository
+	mockAuthors    *mocks.IAuthorRepository
+	mockPublisher  *mocks.IEventPublisher
 	blogUseCase    *usecase.BlogUseCase
 	ctx            context.Context
 	postInRepo     *domain.Post
@@ -22,8 +27,10 @@ type UseCaseTestSuite struct {
 func SetSuite() *UseCaseTestSuite {
 	var suite = UseCaseTestSuite{}
 	suite.mockRepository = new(mocks.IBlogRepository)
-	suite.blogUseCase = usecase.NewBlogUseCase(suite.mockRepository)
-	suite.ctx = context.Background()
+	suite.mockAuthors = new(mocks.IAuthorRepository)
+	suite.mockPublisher = new(mocks.IEventPublisher)
+	suite.blogUseCase = usecase.NewBlogUseCase(suite.mockRepository, suite.mockAuthors, suite.mockPublisher, policy.Default())
+	suite.ctx = auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleEditor})
 	suite.postInRepo = &domain.Post{
 		Author:  "Anton",
 		Title:   "On mockery",
@@ -33,6 +40,14 @@ func SetSuite() *UseCaseTestSuite {
 	return &suite
 }
 
+// expectAuthorByName expects the author of postInRepo to be resolved by name.
+func (suite *UseCaseTestSuite) expectAuthorByName() {
+	suite.mockAuthors.
+		On("GetOrCreateAuthorByName", suite.ctx, "Anton").
+		Once().
+		Return(&domain.Author{ID: 7, Name: "Anton"}, nil)
+}
+
 func Test_GetPost_ShouldReturnPostFromRepositry(t *testing.T) {
 	suite := SetSuite()
 	id := int64(45)
@@ -99,22 +114,29 @@ func Test_GetPosts_ShouldReturnPostsFromRepositry(t *testing.T) {
 func Test_CreatePost_ShouldReturnIdFromRepositry(t *testing.T) {
 	suite := SetSuite()
 	postIdFromRepo := int64(45)
+	suite.expectAuthorByName()
 
 	suite.mockRepository.
 		On("CreatePost", suite.ctx, suite.postInRepo).
 		Once().
 		Return(postIdFromRepo, nil)
 
+	suite.mockPublisher.
+		On("Publish", suite.ctx, eventOf(domain.EventPostCreated, postIdFromRepo)).
+		Once()
+
 	id, err := suite.blogUseCase.CreatePost(suite.ctx, suite.postInRepo)
 
 	assert.Equal(t, postIdFromRepo, id)
 	assert.NoError(t, err)
 	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertExpectations(t)
 }
 
 func Test_CreatePost_Error_ShouldReturnErrorFromRepositry(t *testing.T) {
 	suite := SetSuite()
 	error := errors.New("problem")
+	suite.expectAuthorByName()
 
 	suite.mockRepository.
 		On("CreatePost", suite.ctx, suite.postInRepo).
@@ -125,21 +147,182 @@ func Test_CreatePost_Error_ShouldReturnErrorFromRepositry(t *testing.T) {
 
 	assert.ErrorIs(t, error, err)
 	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertNotCalled(t, "Publish")
+}
+
+func Test_CreatePost_ShouldLinkPostToAuthor(t *testing.T) {
+	suite := SetSuite()
+	suite.expectAuthorByName()
+
+	suite.mockRepository.
+		On("CreatePost", suite.ctx, &domain.Post{AuthorID: 7, Author: "Anton", Title: "On mockery", Content: "qwerty", Excerpt: "qwerty", WordCount: 1, ReadingMinutes: 1}).
+		Once().
+		Return(int64(45), nil)
+	suite.mockPublisher.On("Publish", suite.ctx, mock.Anything).Once()
+
+	_, err := suite.blogUseCase.CreatePost(suite.ctx, suite.postInRepo)
+
+	assert.NoError(t, err)
+	suite.mockAuthors.AssertExpectations(t)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_CreatePost_ShouldSummarizeContent(t *testing.T) {
+	for name, test := range map[string]struct {
+		content        string
+		excerpt        string
+		wordCount      int
+		readingMinutes int
+	}{
+		"first paragraph": {
+			content:        "  First\tparagraph\nstill first.\n \nSecond paragraph.",
+			excerpt:        "First paragraph still first.",
+			wordCount:      6,
+			readingMinutes: 1,
+		},
+		"long paragraph": {
+			content:        strings.Repeat("word ", 401),
+			excerpt:        strings.TrimSpace(strings.Repeat("word ", 40)) + "…",
+			wordCount:      401,
+			readingMinutes: 3,
+		},
+		"long word": {
+			content:        strings.Repeat("ä", 250),
+			excerpt:        strings.Repeat("ä", 200) + "…",
+			wordCount:      1,
+			readingMinutes: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite := SetSuite()
+			suite.expectAuthorByName()
+			suite.mockRepository.On("CreatePost", suite.ctx, mock.Anything).Once().Return(int64(45), nil)
+			suite.mockPublisher.On("Publish", suite.ctx, mock.Anything).Once()
+
+			post := &domain.Post{Author: "Anton", Title: "On mockery", Content: test.content}
+			_, err := suite.blogUseCase.CreatePost(suite.ctx, post)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.excerpt, post.Excerpt)
+			assert.Equal(t, test.wordCount, post.WordCount)
+			assert.Equal(t, test.readingMinutes, post.ReadingMinutes)
+		})
+	}
+}
+
+func Test_CreatePost_ByAuthorId_ShouldUseNameOfAuthor(t *testing.T) {
+	suite := SetSuite()
+	post := &domain.Post{AuthorID: 7, Author: "someone else", Title: "On mockery", Content: "qwerty"}
+
+	suite.mockAuthors.
+		On("GetAuthor", suite.ctx, int64(7)).
+		Once().
+		Return(&domain.Author{ID: 7, Name: "Anton"}, nil)
+	suite.mockRepository.
+		On("CreatePost", suite.ctx, post).
+		Once().
+		Return(int64(45), nil)
+	suite.mockPublisher.On("Publish", suite.ctx, mock.Anything).Once()
+
+	_, err := suite.blogUseCase.CreatePost(suite.ctx, post)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Anton", post.Author)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_CreatePost_UnknownAuthorId_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetSuite()
+
+	suite.mockAuthors.
+		On("GetAuthor", suite.ctx, int64(7)).
+		Once().
+		Return(nil, domain.ErrorAuthorNotFound)
+
+	_, err := suite.blogUseCase.CreatePost(suite.ctx, &domain.Post{AuthorID: 7, Title: "On mockery", Content: "qwerty"})
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockRepository.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+}
+
+func Test_CreatePost_NoAuthor_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetSuite()
+
+	_, err := suite.blogUseCase.CreatePost(suite.ctx, &domain.Post{Author: "  ", Title: "On mockery", Content: "qwerty"})
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockAuthors.AssertNotCalled(t, "GetOrCreateAuthorByName", mock.Anything, mock.Anything)
+}
+
+func Test_CreatePost_InvalidFields_ShouldReturnInvalidInput(t *testing.T) {
+	for name, post := range map[string]*domain.Post{
+		"negative author id":   {AuthorID: -1, Title: "On mockery", Content: "qwerty"},
+		"long author":          {Author: strings.Repeat("a", 101), Title: "On mockery", Content: "qwerty"},
+		"long title":           {Author: "Anton", Title: strings.Repeat("ä", 201), Content: "qwerty"},
+		"blank content":        {Author: "Anton", Title: "On mockery", Content: " \n\t"},
+		"long content":         {Author: "Anton", Title: "On mockery", Content: strings.Repeat("a", 100001)},
+		"tab in title":         {Author: "Anton", Title: "On\tmockery", Content: "qwerty"},
+		"delete in author":     {Author: "Anton\x7F", Title: "On mockery", Content: "qwerty"},
+		"form feed in content": {Author: "Anton", Title: "On mockery", Content: "qw\ferty"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			suite := SetSuite()
+
+			_, err := suite.blogUseCase.CreatePost(suite.ctx, post)
+
+			assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+			suite.mockRepository.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func Test_CreatePost_LongestFields_ShouldCreatePost(t *testing.T) {
+	suite := SetSuite()
+	suite.mockAuthors.
+		On("GetOrCreateAuthorByName", suite.ctx, strings.Repeat("ä", 100)).
+		Once().
+		Return(&domain.Author{ID: 7, Name: strings.Repeat("ä", 100)}, nil)
+	suite.mockRepository.On("CreatePost", suite.ctx, mock.Anything).Once().Return(int64(45), nil)
+	suite.mockPublisher.On("Publish", suite.ctx, mock.Anything).Once()
+
+	post := &domain.Post{Author: strings.Repeat("ä", 100), Title: strings.Repeat("ä", 200), Content: strings.Repeat("ä\r\n", 33333) + "ä"}
+	_, err := suite.blogUseCase.CreatePost(suite.ctx, post)
+
+	assert.NoError(t, err)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_UpdatePost_LongTitle_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetSuite()
+	suite.mockRepository.
+		On("GetPost", suite.ctx, int64(45)).
+		Return(&domain.Post{ID: 45, AuthorID: 7, Author: "Anton"}, nil)
+
+	err := suite.blogUseCase.UpdatePost(suite.ctx, &domain.Post{AuthorID: 7, Title: strings.Repeat("a", 201), Content: "qwerty"}, 45)
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockRepository.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything, mock.Anything)
 }
 
 func Test_UpdatePost_ShouldCallRepoMethodOnce(t *testing.T) {
 	suite := SetSuite()
 	id := int64(45)
+	suite.expectAuthorByName()
 
 	suite.mockRepository.
 		On("UpdatePost", suite.ctx, suite.postInRepo, id).
 		Once().
 		Return(nil)
 
+	suite.mockPublisher.
+		On("Publish", suite.ctx, eventOf(domain.EventPostUpdated, id)).
+		Once()
+
 	err := suite.blogUseCase.UpdatePost(suite.ctx, suite.postInRepo, id)
 
 	assert.NoError(t, err)
 	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertExpectations(t)
 }
 
 func Test_UpdatePost_Error_ShouldReturnErrorFromRepositry(t *testing.T) {
@@ -147,6 +330,7 @@ func Test_UpdatePost_Error_ShouldReturnErrorFromRepositry(t *testing.T) {
 	id := int64(45)
 
 	error := errors.New("problem")
+	suite.expectAuthorByName()
 	suite.mockRepository.
 		On("UpdatePost", suite.ctx, suite.postInRepo, id).
 		Once().
@@ -156,21 +340,48 @@ func Test_UpdatePost_Error_ShouldReturnErrorFromRepositry(t *testing.T) {
 
 	assert.ErrorIs(t, error, err)
 	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertNotCalled(t, "Publish")
 }
 
 func Test_DeleteePost_ShouldCallRepoMethodOnce(t *testing.T) {
 	suite := SetSuite()
 	id := int64(45)
 
+	suite.mockRepository.
+		On("GetPost", suite.ctx, id).
+		Once().
+		Return(suite.postInRepo, nil)
+
 	suite.mockRepository.
 		On("DeletePost", suite.ctx, id).
 		Once().
 		Return(nil)
 
+	suite.mockPublisher.
+		On("Publish", suite.ctx, eventOf(domain.EventPostDeleted, id)).
+		Once()
+
 	err := suite.blogUseCase.DeletePost(suite.ctx, id)
 
 	assert.NoError(t, err)
 	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertExpectations(t)
+}
+
+func Test_DeleteePost_NoPost_ShouldReturnNotFound(t *testing.T) {
+	suite := SetSuite()
+	id := int64(45)
+
+	suite.mockRepository.
+		On("GetPost", suite.ctx, id).
+		Once().
+		Return(nil, domain.ErrorPostNotFound)
+
+	err := suite.blogUseCase.DeletePost(suite.ctx, id)
+
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+	suite.mockRepository.AssertNotCalled(t, "DeletePost", mock.Anything, mock.Anything)
+	suite.mockPublisher.AssertNotCalled(t, "Publish")
 }
 
 func Test_DeleteePost_ShouldPassErrorFromRepo(t *testing.T) {
@@ -179,6 +390,11 @@ func Test_DeleteePost_ShouldPassErrorFromRepo(t *testing.T) {
 
 	error := errors.New("problem")
 
+	suite.mockRepository.
+		On("GetPost", suite.ctx, id).
+		Once().
+		Return(suite.postInRepo, nil)
+
 	suite.mockRepository.
 		On("DeletePost", suite.ctx, id).
 		Once().
@@ -188,4 +404,261 @@ func Test_DeleteePost_ShouldPassErrorFromRepo(t *testing.T) {
 
 	assert.ErrorIs(t, error, err)
 	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertNotCalled(t, "Publish")
+}
+
+func eventOf(eventType domain.EventType, postId int64) any {
+	return mock.MatchedBy(func(event domain.PostEvent) bool {
+		return event.Type == eventType && event.PostID == postId && event.Post.Author == "Anton"
+	})
+}
+
+func Test_CreatePost_Anonymous_ShouldReturnUnauthorized(t *testing.T) {
+	suite := SetSuite()
+
+	_, err := suite.blogUseCase.CreatePost(context.Background(), suite.postInRepo)
+
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+	suite.mockAuthors.AssertNotCalled(t, "GetOrCreateAuthorByName", mock.Anything, mock.Anything)
+	suite.mockRepository.AssertNotCalled(t, "CreatePost", mock.Anything, mock.Anything)
+}
+
+func Test_CreatePost_ByAuthorRole_ShouldWriteAsOwnAuthor(t *testing.T) {
+	suite := SetSuite()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+
+	suite.mockAuthors.
+		On("GetAuthor", ctx, int64(7)).
+		Once().
+		Return(&domain.Author{ID: 7, Name: "Anton"}, nil)
+	suite.mockRepository.
+		On("CreatePost", ctx, &domain.Post{AuthorID: 7, Author: "Anton", Title: "On mockery", Content: "qwerty", Excerpt: "qwerty", WordCount: 1, ReadingMinutes: 1}).
+		Once().
+		Return(int64(1), nil)
+	suite.mockPublisher.On("Publish", ctx, mock.Anything).Once()
+
+	_, err := suite.blogUseCase.CreatePost(ctx, &domain.Post{Title: "On mockery", Content: "qwerty"})
+
+	assert.NoError(t, err)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_CreatePost_ByAuthorRoleForOtherAuthor_ShouldReturnForbidden(t *testing.T) {
+	suite := SetSuite()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+
+	_, err := suite.blogUseCase.CreatePost(ctx, &domain.Post{AuthorID: 8, Title: "On mockery", Content: "qwerty"})
+
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	suite.mockAuthors.AssertNotCalled(t, "GetAuthor", mock.Anything, mock.Anything)
+}
+
+func Test_UpdatePost_ByAuthorRole_ShouldCheckOwnerOfStoredPost(t *testing.T) {
+	suite := SetSuite()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+
+	suite.mockRepository.
+		On("GetPost", ctx, int64(45)).
+		Once().
+		Return(&domain.Post{ID: 45, AuthorID: 8, Author: "Jonny"}, nil)
+
+	err := suite.blogUseCase.UpdatePost(ctx, &domain.Post{AuthorID: 7, Title: "On mockery", Content: "qwerty"}, 45)
+
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	suite.mockRepository.AssertNotCalled(t, "UpdatePost", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func Test_GetPosts_ShouldReturnOnlyReadablePosts(t *testing.T) {
+	suite := SetSuite()
+	cfg := policy.DefaultConfig()
+	cfg.Roles[domain.RoleReader] = map[policy.Action]policy.Scope{}
+	cfg.Roles[domain.RoleAuthor] = map[policy.Action]policy.Scope{policy.ReadPost: policy.ScopeOwn}
+	restricted, err := policy.New(cfg)
+	assert.NoError(t, err)
+	blogUseCase := usecase.NewBlogUseCase(suite.mockRepository, suite.mockAuthors, suite.mockPublisher, restricted)
+
+	posts := []*domain.Post{{ID: 1, AuthorID: 7}, {ID: 2, AuthorID: 8}}
+	suite.mockRepository.On("GetPosts", mock.Anything).Return(posts)
+
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+	assert.Equal(t, []*domain.Post{{ID: 1, AuthorID: 7}}, blogUseCase.GetPosts(ctx))
+	assert.Empty(t, blogUseCase.GetPosts(context.Background()))
+}
+
+func Test_EachPost_ShouldYieldOnlyReadablePosts(t *testing.T) {
+	suite := SetSuite()
+	cfg := policy.DefaultConfig()
+	cfg.Roles[domain.RoleReader] = map[policy.Action]policy.Scope{}
+	cfg.Roles[domain.RoleAuthor] = map[policy.Action]policy.Scope{policy.ReadPost: policy.ScopeOwn}
+	restricted, err := policy.New(cfg)
+	assert.NoError(t, err)
+	blogUseCase := usecase.NewBlogUseCase(suite.mockRepository, suite.mockAuthors, suite.mockPublisher, restricted)
+
+	suite.mockRepository.
+		On("EachPost", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			yield := args.Get(1).(func(*domain.Post) bool)
+			for _, post := range []*domain.Post{{ID: 1, AuthorID: 7}, {ID: 2, AuthorID: 8}, {ID: 3, AuthorID: 7}} {
+				if !yield(post) {
+					return
+				}
+			}
+		}).
+		Return(nil)
+
+	var ids []int64
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+	err = blogUseCase.EachPost(ctx, func(post *domain.Post) bool {
+		ids = append(ids, post.ID)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 3}, ids)
+
+	assert.NoError(t, blogUseCase.EachPost(context.Background(), func(post *domain.Post) bool {
+		t.Errorf("post %d is not readable anonymously", post.ID)
+		return true
+	}))
+	suite.mockRepository.AssertNumberOfCalls(t, "EachPost", 1)
+}
+
+var draftPosts = []*domain.Post{{ID: 1, AuthorID: 7}, {ID: 2, AuthorID: 7, Draft: true}, {ID: 3, AuthorID: 8, Draft: true}}
+
+func Test_GetPost_DraftOfOtherAuthor_ShouldReturnNotFound(t *testing.T) {
+	suite := SetSuite()
+	suite.mockRepository.On("GetPost", mock.Anything, int64(2)).Return(draftPosts[1], nil)
+	suite.mockRepository.On("GetPost", mock.Anything, int64(3)).Return(draftPosts[2], nil)
+
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+	post, err := suite.blogUseCase.GetPost(ctx, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, draftPosts[1], post)
+
+	_, err = suite.blogUseCase.GetPost(ctx, 3)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+
+	_, err = suite.blogUseCase.GetPost(context.Background(), 2)
+	assert.ErrorIs(t, err, domain.ErrorPostNotFound)
+
+	post, err = suite.blogUseCase.GetPost(suite.ctx, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, draftPosts[2], post)
+}
+
+func Test_GetPosts_ShouldReturnOnlyReadableDrafts(t *testing.T) {
+	suite := SetSuite()
+	suite.mockRepository.On("GetPosts", mock.Anything).Return(draftPosts)
+
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+	assert.Equal(t, draftPosts[:2], suite.blogUseCase.GetPosts(ctx))
+	assert.Equal(t, draftPosts[:1], suite.blogUseCase.GetPosts(context.Background()))
+	assert.Equal(t, draftPosts, suite.blogUseCase.GetPosts(suite.ctx))
+}
+
+func Test_EachPost_ShouldYieldOnlyReadableDrafts(t *testing.T) {
+	suite := SetSuite()
+	suite.mockRepository.
+		On("EachPost", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			yield := args.Get(1).(func(*domain.Post) bool)
+			for _, post := range draftPosts {
+				if !yield(post) {
+					return
+				}
+			}
+		}).
+		Return(nil)
+
+	var ids []int64
+	err := suite.blogUseCase.EachPost(context.Background(), func(post *domain.Post) bool {
+		ids = append(ids, post.ID)
+		return true
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, ids)
+}
+
+func Test_GetDeletedPosts_ByAuthorRole_ShouldReturnOnlyOwnPosts(t *testing.T) {
+	suite := SetSuite()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+
+	posts := []*domain.Post{{ID: 1, AuthorID: 7}, {ID: 2, AuthorID: 8}}
+	suite.mockRepository.On("GetDeletedPosts", mock.Anything).Return(posts)
+
+	deleted, err := suite.blogUseCase.GetDeletedPosts(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []*domain.Post{{ID: 1, AuthorID: 7}}, deleted)
+
+	_, err = suite.blogUseCase.GetDeletedPosts(auth.WithUser(context.Background(), &domain.User{ID: 3, Role: domain.RoleReader}))
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+}
+
+func Test_RestorePost_ShouldUseCurrentNameOfAuthorAndPublishEvent(t *testing.T) {
+	suite := SetSuite()
+	id := int64(45)
+
+	suite.mockRepository.
+		On("GetDeletedPost", suite.ctx, id).
+		Once().
+		Return(&domain.Post{ID: id, AuthorID: 7, Author: "Anton K", Title: "On mockery", Content: "qwerty"}, nil)
+	suite.mockAuthors.
+		On("GetAuthor", suite.ctx, int64(7)).
+		Once().
+		Return(&domain.Author{ID: 7, Name: "Anton"}, nil)
+	suite.mockRepository.
+		On("RestorePost", suite.ctx, id).
+		Once().
+		Return(nil)
+	restored := &domain.Post{ID: id, AuthorID: 7, Author: "Anton", Title: "On mockery", Content: "qwerty"}
+	suite.mockRepository.
+		On("UpdatePost", suite.ctx, restored, id).
+		Once().
+		Return(nil)
+	suite.mockPublisher.
+		On("Publish", suite.ctx, eventOf(domain.EventPostCreated, id)).
+		Once()
+
+	post, err := suite.blogUseCase.RestorePost(suite.ctx, id)
+
+	assert.NoError(t, err)
+	assert.Equal(t, restored, post)
+	suite.mockRepository.AssertExpectations(t)
+	suite.mockPublisher.AssertExpectations(t)
+}
+
+func Test_RestorePost_AuthorDeleted_ShouldReturnConflict(t *testing.T) {
+	suite := SetSuite()
+	id := int64(45)
+
+	suite.mockRepository.
+		On("GetDeletedPost", suite.ctx, id).
+		Once().
+		Return(&domain.Post{ID: id, AuthorID: 7, Author: "Anton"}, nil)
+	suite.mockAuthors.
+		On("GetAuthor", suite.ctx, int64(7)).
+		Once().
+		Return(nil, domain.ErrorAuthorNotFound)
+
+	_, err := suite.blogUseCase.RestorePost(suite.ctx, id)
+
+	assert.ErrorIs(t, err, domain.ErrorConflict)
+	suite.mockRepository.AssertNotCalled(t, "RestorePost", mock.Anything, mock.Anything)
+	suite.mockPublisher.AssertNotCalled(t, "Publish")
+}
+
+func Test_RestorePost_ByAuthorRoleOfOtherAuthor_ShouldReturnForbidden(t *testing.T) {
+	suite := SetSuite()
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+
+	suite.mockRepository.
+		On("GetDeletedPost", ctx, int64(45)).
+		Once().
+		Return(&domain.Post{ID: 45, AuthorID: 8, Author: "Jonny"}, nil)
+
+	_, err := suite.blogUseCase.RestorePost(ctx, 45)
+
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	suite.mockRepository.AssertNotCalled(t, "RestorePost", mock.Anything, mock.Anything)
 }
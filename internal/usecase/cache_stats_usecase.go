@@ -0,0 +1,30 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/kondrushin/blog/internal/cache"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+type ICacheStats interface {
+	Stats() cache.Stats
+}
+
+// CacheStatsUseCase reads the statistics of the post cache.
+type CacheStatsUseCase struct {
+	cache  ICacheStats
+	policy *policy.Policy
+}
+
+func NewCacheStatsUseCase(cache ICacheStats, policy *policy.Policy) *CacheStatsUseCase {
+	return &CacheStatsUseCase{cache: cache, policy: policy}
+}
+
+func (u *CacheStatsUseCase) GetCacheStats(ctx context.Context) (cache.Stats, error) {
+	if err := u.policy.Authorize(u.policy.Subject(ctx), policy.ReadStats, 0); err != nil {
+		return cache.Stats{}, err
+	}
+
+	return u.cache.Stats(), nil
+}
@@ -0,0 +1,39 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/cache"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_GetCacheStats_Admin_ShouldReturnStats(t *testing.T) {
+	mockCache := new(mocks.ICacheStats)
+	statsUseCase := usecase.NewCacheStatsUseCase(mockCache, policy.Default())
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleAdmin})
+
+	mockCache.On("Stats").Once().Return(cache.Stats{Hits: 3, Capacity: 10})
+
+	stats, err := statsUseCase.GetCacheStats(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, cache.Stats{Hits: 3, Capacity: 10}, stats)
+}
+
+func Test_GetCacheStats_NotAdmin_ShouldBeDenied(t *testing.T) {
+	mockCache := new(mocks.ICacheStats)
+	statsUseCase := usecase.NewCacheStatsUseCase(mockCache, policy.Default())
+	ctx := auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleEditor})
+
+	_, err := statsUseCase.GetCacheStats(ctx)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+
+	_, err = statsUseCase.GetCacheStats(context.Background())
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+	mockCache.AssertNotCalled(t, "Stats")
+}
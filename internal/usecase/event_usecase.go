@@ -0,0 +1,41 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+type IEventBus interface {
+	Subscribe(filter events.Filter, lastEventId int64) *events.Subscription
+}
+
+// EventUseCase subscribes users to the events of the posts they may read. All
+// transports of events subscribe through it.
+type EventUseCase struct {
+	bus    IEventBus
+	policy *policy.Policy
+}
+
+func NewEventUseCase(bus IEventBus, policy *policy.Policy) *EventUseCase {
+	return &EventUseCase{bus: bus, policy: policy}
+}
+
+// Subscribe subscribes the user of the context to the events matching the
+// filter, leaving out the events of posts the user may not read, drafts among
+// them. Users who may read no posts at all are refused. The subscription keeps
+// the role the user had when subscribing.
+func (e *EventUseCase) Subscribe(ctx context.Context, filter events.Filter, lastEventId int64) (*events.Subscription, error) {
+	subject := e.policy.Subject(ctx)
+	if e.policy.Scope(subject, policy.ReadPost) == policy.ScopeNone {
+		return nil, e.policy.Authorize(subject, policy.ReadPost, 0)
+	}
+
+	filter.Allows = func(event domain.PostEvent) bool {
+		return canRead(e.policy, subject, &event.Post)
+	}
+
+	return e.bus.Subscribe(filter, lastEventId), nil
+}
@@ -0,0 +1,92 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/events"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func publishDrafts(bus *events.Bus) {
+	for _, post := range draftPosts {
+		bus.Publish(context.Background(), domain.PostEvent{Type: domain.EventPostCreated, PostID: post.ID, Post: *post})
+	}
+}
+
+func eventPostIDs(subscription *events.Subscription) []int64 {
+	ids := []int64{}
+	for _, event := range subscription.Replay {
+		ids = append(ids, event.PostID)
+	}
+	for len(subscription.Events()) > 0 {
+		ids = append(ids, (<-subscription.Events()).PostID)
+	}
+	return ids
+}
+
+func Test_Subscribe_ShouldLeaveOutDraftsTheUserMayNotRead(t *testing.T) {
+	author := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleAuthor, AuthorID: 7})
+	editor := auth.WithUser(context.Background(), &domain.User{ID: 3, Role: domain.RoleEditor})
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		ids  []int64
+	}{
+		{"anonymous", context.Background(), []int64{1}},
+		{"author", author, []int64{1, 2}},
+		{"editor", editor, []int64{1, 2, 3}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			bus := events.NewBus(10)
+			eventUseCase := usecase.NewEventUseCase(bus, policy.Default())
+
+			live, err := eventUseCase.Subscribe(test.ctx, events.Filter{}, 0)
+			assert.NoError(t, err)
+			defer live.Close()
+
+			publishDrafts(bus)
+			assert.Equal(t, test.ids, eventPostIDs(live))
+
+			replayed, err := eventUseCase.Subscribe(test.ctx, events.Filter{}, 1)
+			assert.NoError(t, err)
+			defer replayed.Close()
+			assert.Equal(t, test.ids[1:], eventPostIDs(replayed))
+		})
+	}
+}
+
+func Test_Subscribe_ShouldKeepFilterOfSubscriber(t *testing.T) {
+	bus := events.NewBus(10)
+	eventUseCase := usecase.NewEventUseCase(bus, policy.Default())
+	editor := auth.WithUser(context.Background(), &domain.User{ID: 3, Role: domain.RoleEditor})
+
+	subscription, err := eventUseCase.Subscribe(editor, events.Filter{PostIDs: []int64{3}}, 0)
+	assert.NoError(t, err)
+	defer subscription.Close()
+
+	publishDrafts(bus)
+	assert.Equal(t, []int64{3}, eventPostIDs(subscription))
+}
+
+func Test_Subscribe_WithoutReadPost_ShouldBeDenied(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.Roles[domain.RoleReader] = map[policy.Action]policy.Scope{}
+	restricted, err := policy.New(cfg)
+	assert.NoError(t, err)
+	eventUseCase := usecase.NewEventUseCase(events.NewBus(10), restricted)
+
+	_, err = eventUseCase.Subscribe(context.Background(), events.Filter{}, 0)
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+
+	reader := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleReader})
+	_, err = eventUseCase.Subscribe(reader, events.Filter{}, 0)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+}
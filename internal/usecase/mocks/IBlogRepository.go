@@ -14,9 +14,9 @@ type IBlogRepository struct {
 	mock.Mock
 }
 
-// CreatePost provides a mock function with given fields: ctx, post
-func (_m *IBlogRepository) CreatePost(ctx context.Context, post *domain.Post) (int64, error) {
-	ret := _m.Called(ctx, post)
+// CreatePost provides a mock function with given fields: ctx, p
+func (_m *IBlogRepository) CreatePost(ctx context.Context, p *domain.Post) (int64, error) {
+	ret := _m.Called(ctx, p)
 
 	if len(ret) == 0 {
 		panic("no return value specified for CreatePost")
@@ -25,16 +25,16 @@ func (_m *IBlogRepository) CreatePost(ctx context.Context, post *domain.Post) (i
 	var r0 int64
 	var r1 error
 	if rf, ok := ret.Get(0).(func(context.Context, *domain.Post) (int64, error)); ok {
-		return rf(ctx, post)
+		return rf(ctx, p)
 	}
 	if rf, ok := ret.Get(0).(func(context.Context, *domain.Post) int64); ok {
-		r0 = rf(ctx, post)
+		r0 = rf(ctx, p)
 	} else {
 		r0 = ret.Get(0).(int64)
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, *domain.Post) error); ok {
-		r1 = rf(ctx, post)
+		r1 = rf(ctx, p)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -60,6 +60,74 @@ func (_m *IBlogRepository) DeletePost(ctx context.Context, id int64) error {
 	return r0
 }
 
+// EachPost provides a mock function with given fields: ctx, yield
+func (_m *IBlogRepository) EachPost(ctx context.Context, yield func(*domain.Post) bool) error {
+	ret := _m.Called(ctx, yield)
+
+	if len(ret) == 0 {
+		panic("no return value specified for EachPost")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, func(*domain.Post) bool) error); ok {
+		r0 = rf(ctx, yield)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetDeletedPost provides a mock function with given fields: ctx, id
+func (_m *IBlogRepository) GetDeletedPost(ctx context.Context, id int64) (*domain.Post, error) {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeletedPost")
+	}
+
+	var r0 *domain.Post
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*domain.Post, error)); ok {
+		return rf(ctx, id)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *domain.Post); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*domain.Post)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetDeletedPosts provides a mock function with given fields: ctx
+func (_m *IBlogRepository) GetDeletedPosts(ctx context.Context) []*domain.Post {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetDeletedPosts")
+	}
+
+	var r0 []*domain.Post
+	if rf, ok := ret.Get(0).(func(context.Context) []*domain.Post); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*domain.Post)
+		}
+	}
+
+	return r0
+}
+
 // GetPost provides a mock function with given fields: ctx, id
 func (_m *IBlogRepository) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
 	ret := _m.Called(ctx, id)
@@ -110,6 +178,24 @@ func (_m *IBlogRepository) GetPosts(ctx context.Context) []*domain.Post {
 	return r0
 }
 
+// RestorePost provides a mock function with given fields: ctx, id
+func (_m *IBlogRepository) RestorePost(ctx context.Context, id int64) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestorePost")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // UpdatePost provides a mock function with given fields: ctx, post, id
 func (_m *IBlogRepository) UpdatePost(ctx context.Context, post *domain.Post, id int64) error {
 	ret := _m.Called(ctx, post, id)
@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+)
+
+type IWebhookRepository interface {
+	CreateWebhook(ctx context.Context, webhook *domain.Webhook) (int64, error)
+	GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context) []*domain.Webhook
+	DeleteWebhook(ctx context.Context, id int64) error
+	GetDelivery(ctx context.Context, id int64) (*domain.Delivery, error)
+	GetDeliveries(ctx context.Context, filter domain.DeliveryFilter) []*domain.Delivery
+	UpdateDelivery(ctx context.Context, delivery *domain.Delivery) error
+}
+
+type IWebhookDispatcher interface {
+	Wake()
+	CheckTarget(target *url.URL) error
+}
+
+// WebhookUseCase authorizes every operation with policy.ManageWebhooks, since
+// webhooks send every post change to their URL.
+type WebhookUseCase struct {
+	repository IWebhookRepository
+	dispatcher IWebhookDispatcher
+	policy     *policy.Policy
+}
+
+func NewWebhookUseCase(repository IWebhookRepository, dispatcher IWebhookDispatcher, policy *policy.Policy) *WebhookUseCase {
+	return &WebhookUseCase{repository: repository, dispatcher: dispatcher, policy: policy}
+}
+
+// CreateWebhook validates the subscription and generates a secret when none is given.
+func (w *WebhookUseCase) CreateWebhook(ctx context.Context, webhook *domain.Webhook) (int64, error) {
+	if err := w.authorize(ctx); err != nil {
+		return 0, err
+	}
+
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || len(target.Host) == 0 {
+		return 0, fmt.Errorf("%w: webhook URL must be an absolute http or https URL", domain.ErrorInvalidInput)
+	}
+	if err := w.dispatcher.CheckTarget(target); err != nil {
+		return 0, fmt.Errorf("%w: %w", domain.ErrorInvalidInput, err)
+	}
+
+	for _, eventType := range webhook.Events {
+		switch eventType {
+		case domain.EventPostCreated, domain.EventPostUpdated, domain.EventPostDeleted:
+		default:
+			return 0, fmt.Errorf("%w: unknown event type %q", domain.ErrorInvalidInput, eventType)
+		}
+	}
+
+	if len(webhook.Secret) == 0 {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return 0, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+
+	webhook.CreatedAt = time.Now().UTC()
+	return w.repository.CreateWebhook(ctx, webhook)
+}
+
+func (w *WebhookUseCase) GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error) {
+	if err := w.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	return w.repository.GetWebhook(ctx, id)
+}
+
+func (w *WebhookUseCase) GetWebhooks(ctx context.Context) ([]*domain.Webhook, error) {
+	if err := w.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	return w.repository.GetWebhooks(ctx), nil
+}
+
+func (w *WebhookUseCase) DeleteWebhook(ctx context.Context, id int64) error {
+	if err := w.authorize(ctx); err != nil {
+		return err
+	}
+
+	return w.repository.DeleteWebhook(ctx, id)
+}
+
+func (w *WebhookUseCase) GetDeliveries(ctx context.Context, filter domain.DeliveryFilter) ([]*domain.Delivery, error) {
+	if err := w.authorize(ctx); err != nil {
+		return nil, err
+	}
+
+	if filter.WebhookID != 0 {
+		if _, err := w.repository.GetWebhook(ctx, filter.WebhookID); err != nil {
+			return nil, err
+		}
+	}
+
+	return w.repository.GetDeliveries(ctx, filter), nil
+}
+
+// RedeliverDelivery moves a dead-lettered delivery back to the queue with a fresh set of attempts.
+func (w *WebhookUseCase) RedeliverDelivery(ctx context.Context, id int64) error {
+	if err := w.authorize(ctx); err != nil {
+		return err
+	}
+
+	delivery, err := w.repository.GetDelivery(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if delivery.Status != domain.DeliveryDead {
+		return fmt.Errorf("%w: only dead deliveries can be redelivered", domain.ErrorInvalidInput)
+	}
+
+	delivery.Status = domain.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = time.Now().UTC()
+	delivery.CompletedAt = time.Time{}
+
+	if err := w.repository.UpdateDelivery(ctx, delivery); err != nil {
+		return err
+	}
+
+	w.dispatcher.Wake()
+	return nil
+}
+
+func (w *WebhookUseCase) authorize(ctx context.Context) error {
+	return w.policy.Authorize(w.policy.Subject(ctx), policy.ManageWebhooks, 0)
+}
@@ -0,0 +1,162 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/kondrushin/blog/internal/auth"
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/policy"
+	"github.com/kondrushin/blog/internal/usecase"
+	"github.com/kondrushin/blog/internal/usecase/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookUseCaseTestSuite struct {
+	mockRepository *mocks.IWebhookRepository
+	mockDispatcher *mocks.IWebhookDispatcher
+	webhookUseCase *usecase.WebhookUseCase
+	ctx            context.Context
+}
+
+func SetWebhookSuite() *WebhookUseCaseTestSuite {
+	var suite = WebhookUseCaseTestSuite{}
+	suite.mockRepository = new(mocks.IWebhookRepository)
+	suite.mockDispatcher = new(mocks.IWebhookDispatcher)
+	suite.mockDispatcher.On("CheckTarget", mock.Anything).Return(nil).Maybe()
+	suite.webhookUseCase = usecase.NewWebhookUseCase(suite.mockRepository, suite.mockDispatcher, policy.Default())
+	suite.ctx = auth.WithUser(context.Background(), &domain.User{ID: 1, Role: domain.RoleAdmin})
+	return &suite
+}
+
+func Test_CreateWebhook_NoSecret_ShouldGenerateSecret(t *testing.T) {
+	suite := SetWebhookSuite()
+	webhook := &domain.Webhook{URL: "https://example.com/hook", Events: []domain.EventType{domain.EventPostCreated}}
+
+	suite.mockRepository.
+		On("CreateWebhook", suite.ctx, webhook).
+		Once().
+		Return(int64(1), nil)
+
+	id, err := suite.webhookUseCase.CreateWebhook(suite.ctx, webhook)
+
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, id)
+	assert.Len(t, webhook.Secret, 64)
+	assert.False(t, webhook.CreatedAt.IsZero())
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_CreateWebhook_InvalidUrl_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetWebhookSuite()
+
+	for _, url := range []string{"", "example.com/hook", "ftp://example.com", "https://"} {
+		_, err := suite.webhookUseCase.CreateWebhook(suite.ctx, &domain.Webhook{URL: url})
+		assert.ErrorIs(t, err, domain.ErrorInvalidInput, url)
+	}
+
+	suite.mockRepository.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+}
+
+func Test_CreateWebhook_PrivateTarget_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetWebhookSuite()
+	refused := errors.New("private target")
+	suite.mockDispatcher.ExpectedCalls = nil
+	suite.mockDispatcher.
+		On("CheckTarget", mock.MatchedBy(func(target *url.URL) bool { return target.Host == "127.0.0.1" })).
+		Return(refused)
+
+	_, err := suite.webhookUseCase.CreateWebhook(suite.ctx, &domain.Webhook{URL: "http://127.0.0.1/hook"})
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	assert.ErrorIs(t, err, refused)
+	suite.mockRepository.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+}
+
+func Test_CreateWebhook_UnknownEvent_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetWebhookSuite()
+
+	_, err := suite.webhookUseCase.CreateWebhook(suite.ctx, &domain.Webhook{
+		URL:    "https://example.com/hook",
+		Events: []domain.EventType{"post.liked"},
+	})
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockRepository.AssertNotCalled(t, "CreateWebhook", mock.Anything, mock.Anything)
+}
+
+func Test_GetDeliveries_UnknownWebhook_ShouldReturnNotFound(t *testing.T) {
+	suite := SetWebhookSuite()
+
+	suite.mockRepository.
+		On("GetWebhook", suite.ctx, int64(7)).
+		Once().
+		Return(nil, domain.ErrorWebhookNotFound)
+
+	_, err := suite.webhookUseCase.GetDeliveries(suite.ctx, domain.DeliveryFilter{WebhookID: 7})
+
+	assert.ErrorIs(t, err, domain.ErrorWebhookNotFound)
+	suite.mockRepository.AssertExpectations(t)
+}
+
+func Test_RedeliverDelivery_DeadDelivery_ShouldRequeueAndWakeDispatcher(t *testing.T) {
+	suite := SetWebhookSuite()
+	delivery := &domain.Delivery{ID: 3, Status: domain.DeliveryDead, Attempts: 6}
+
+	suite.mockRepository.
+		On("GetDelivery", suite.ctx, int64(3)).
+		Once().
+		Return(delivery, nil)
+	suite.mockRepository.
+		On("UpdateDelivery", suite.ctx, mock.MatchedBy(func(d *domain.Delivery) bool {
+			return d.Status == domain.DeliveryPending && d.Attempts == 0
+		})).
+		Once().
+		Return(nil)
+	suite.mockDispatcher.On("Wake").Once()
+
+	err := suite.webhookUseCase.RedeliverDelivery(suite.ctx, 3)
+
+	assert.NoError(t, err)
+	suite.mockRepository.AssertExpectations(t)
+	suite.mockDispatcher.AssertExpectations(t)
+}
+
+func Test_RedeliverDelivery_SucceededDelivery_ShouldReturnInvalidInput(t *testing.T) {
+	suite := SetWebhookSuite()
+
+	suite.mockRepository.
+		On("GetDelivery", suite.ctx, int64(3)).
+		Once().
+		Return(&domain.Delivery{ID: 3, Status: domain.DeliverySucceeded}, nil)
+
+	err := suite.webhookUseCase.RedeliverDelivery(suite.ctx, 3)
+
+	assert.ErrorIs(t, err, domain.ErrorInvalidInput)
+	suite.mockDispatcher.AssertNotCalled(t, "Wake")
+}
+
+func Test_WebhookUseCase_WithoutManageWebhooks_ShouldBeDenied(t *testing.T) {
+	suite := SetWebhookSuite()
+	editor := auth.WithUser(context.Background(), &domain.User{ID: 2, Role: domain.RoleEditor})
+
+	_, err := suite.webhookUseCase.CreateWebhook(context.Background(), &domain.Webhook{URL: "https://example.com/hook"})
+	assert.ErrorIs(t, err, domain.ErrorUnauthorized)
+
+	_, err = suite.webhookUseCase.CreateWebhook(editor, &domain.Webhook{URL: "https://example.com/hook"})
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	_, err = suite.webhookUseCase.GetWebhook(editor, 1)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	_, err = suite.webhookUseCase.GetWebhooks(editor)
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	assert.ErrorIs(t, suite.webhookUseCase.DeleteWebhook(editor, 1), domain.ErrorForbidden)
+	_, err = suite.webhookUseCase.GetDeliveries(editor, domain.DeliveryFilter{})
+	assert.ErrorIs(t, err, domain.ErrorForbidden)
+	assert.ErrorIs(t, suite.webhookUseCase.RedeliverDelivery(editor, 1), domain.ErrorForbidden)
+
+	suite.mockRepository.AssertExpectations(t)
+	assert.Empty(t, suite.mockRepository.Calls)
+}
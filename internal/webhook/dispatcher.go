@@ -0,0 +1,375 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+)
+
+const (
+	SignatureHeader = "X-Blog-Signature"
+	TimestampHeader = "X-Blog-Timestamp"
+	EventHeader     = "X-Blog-Event"
+	DeliveryHeader  = "X-Blog-Delivery"
+)
+
+// ErrorPrivateTarget is wrapped by errors of webhook URLs that point to the
+// loopback, private or link-local network of the blog.
+var ErrorPrivateTarget = errors.New("Webhook target is not a public address")
+
+type IDeliveryRepository interface {
+	GetWebhook(ctx context.Context, id int64) (*domain.Webhook, error)
+	GetWebhooks(ctx context.Context) []*domain.Webhook
+	CreateDelivery(ctx context.Context, delivery *domain.Delivery) (int64, error)
+	UpdateDelivery(ctx context.Context, delivery *domain.Delivery) error
+	GetDueDeliveries(ctx context.Context, now time.Time) []*domain.Delivery
+	PruneDeliveries(ctx context.Context, completedBefore time.Time, keep int) int
+}
+
+type Config struct {
+	// MaxAttempts is the number of attempts after which a delivery is dead-lettered.
+	MaxAttempts int
+	// BaseDelay is the wait before the first retry. It doubles with every attempt up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// Timeout limits a single attempt.
+	Timeout time.Duration
+	// PollInterval is how often the worker looks for retries that became due.
+	PollInterval time.Duration
+	// Concurrency is the number of deliveries attempted at the same time, at least one.
+	Concurrency int
+	// Retention is how long completed deliveries stay in the delivery log, and
+	// MaxDeliveriesPerWebhook how many of them are kept per webhook. Zero keeps
+	// them without limit.
+	Retention               time.Duration
+	MaxDeliveriesPerWebhook int
+	// PruneInterval is how often the delivery log is pruned, zero disables pruning.
+	PruneInterval time.Duration
+	// AllowPrivateTargets lets webhooks reach loopback, private and link-local
+	// addresses, e.g. receivers on the same host during development.
+	AllowPrivateTargets bool
+}
+
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:  6,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Hour,
+		Timeout:      10 * time.Second,
+		PollInterval: time.Second,
+		Concurrency:  8,
+
+		Retention:               7 * 24 * time.Hour,
+		MaxDeliveriesPerWebhook: 1000,
+		PruneInterval:           time.Minute,
+	}
+}
+
+// Dispatcher turns post events into deliveries for the subscribed webhooks
+// and POSTs them with retries.
+type Dispatcher struct {
+	repository IDeliveryRepository
+	client     *http.Client
+	cfg        Config
+	wake       chan struct{}
+}
+
+// NewDispatcher returns a dispatcher whose client refuses to connect to private
+// targets, unless they are allowed. The address is checked after the host name
+// is resolved, for every connection and redirect, so that a host name cannot be
+// pointed at a private address after the webhook was created.
+func NewDispatcher(repository IDeliveryRepository, cfg Config) *Dispatcher {
+	dialer := &net.Dialer{Timeout: cfg.Timeout}
+	if !cfg.AllowPrivateTargets {
+		dialer.Control = refusePrivateAddress
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &Dispatcher{
+		repository: repository,
+		client:     &http.Client{Timeout: cfg.Timeout, Transport: transport},
+		cfg:        cfg,
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// CheckTarget refuses webhook URLs whose host is localhost or a private address,
+// unless private targets are allowed. Other host names are checked when they are
+// dialed.
+func (d *Dispatcher) CheckTarget(target *url.URL) error {
+	if d.cfg.AllowPrivateTargets {
+		return nil
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(target.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrorPrivateTarget, host)
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && !isPublic(addr) {
+		return fmt.Errorf("%w: %s", ErrorPrivateTarget, addr)
+	}
+
+	return nil
+}
+
+// refusePrivateAddress is the control of the dialer, called with the resolved
+// address of every connection.
+func refusePrivateAddress(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return err
+	}
+	if !isPublic(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrorPrivateTarget, addrPort.Addr())
+	}
+
+	return nil
+}
+
+// sharedAddressSpace is the carrier-grade NAT range of RFC 6598, which is not
+// routed on the internet either.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func isPublic(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// HandleEvent records a pending delivery for every webhook subscribed to the event.
+// It does not block on the network and can be registered as an events.Handler.
+func (d *Dispatcher) HandleEvent(ctx context.Context, event domain.PostEvent) {
+	payload, err := json.Marshal(toPayload(event))
+	if err != nil {
+		slog.Error("Could not encode webhook payload.", "error", err, "event", event.ID)
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, w := range d.repository.GetWebhooks(ctx) {
+		if !w.Accepts(event.Type) {
+			continue
+		}
+
+		_, err := d.repository.CreateDelivery(ctx, &domain.Delivery{
+			WebhookID:     w.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Payload:       payload,
+			Status:        domain.DeliveryPending,
+			CreatedAt:     now,
+			NextAttemptAt: now,
+		})
+		if err != nil {
+			slog.Error("Could not create webhook delivery.", "error", err, "webhook", w.ID, "event", event.ID)
+		}
+	}
+
+	d.Wake()
+}
+
+// Wake makes the worker look for due deliveries immediately.
+func (d *Dispatcher) Wake() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run delivers due deliveries and prunes the delivery log until the context is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+
+	var prune <-chan time.Time
+	if d.cfg.PruneInterval > 0 {
+		pruneTicker := time.NewTicker(d.cfg.PruneInterval)
+		defer pruneTicker.Stop()
+		prune = pruneTicker.C
+	}
+
+	for {
+		d.deliverDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-d.wake:
+		case <-prune:
+			d.prune(ctx)
+		}
+	}
+}
+
+func (d *Dispatcher) prune(ctx context.Context) {
+	var completedBefore time.Time
+	if d.cfg.Retention > 0 {
+		completedBefore = time.Now().UTC().Add(-d.cfg.Retention)
+	}
+	keep := math.MaxInt
+	if d.cfg.MaxDeliveriesPerWebhook > 0 {
+		keep = d.cfg.MaxDeliveriesPerWebhook
+	}
+
+	if removed := d.repository.PruneDeliveries(ctx, completedBefore, keep); removed > 0 {
+		slog.Info("Pruned webhook deliveries.", "removed", removed)
+	}
+}
+
+// deliverDue attempts the due deliveries with at most Concurrency of them in flight.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	slots := make(chan struct{}, max(d.cfg.Concurrency, 1))
+
+	var wg sync.WaitGroup
+	for _, delivery := range d.repository.GetDueDeliveries(ctx, time.Now()) {
+		slots <- struct{}{}
+		wg.Add(1)
+		go func(delivery *domain.Delivery) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			d.attempt(ctx, delivery)
+		}(delivery)
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) attempt(ctx context.Context, delivery *domain.Delivery) {
+	delivery.Attempts++
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+
+	w, err := d.repository.GetWebhook(ctx, delivery.WebhookID)
+	if err != nil {
+		delivery.LastError = err.Error()
+		d.complete(ctx, delivery, domain.DeliveryDead)
+		return
+	}
+
+	status, err := d.post(ctx, w, delivery)
+	delivery.ResponseStatus = status
+	switch {
+	case err == nil:
+		d.complete(ctx, delivery, domain.DeliverySucceeded)
+		return
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.LastError = err.Error()
+		d.complete(ctx, delivery, domain.DeliveryDead)
+		return
+	}
+
+	delivery.LastError = err.Error()
+	delivery.NextAttemptAt = time.Now().UTC().Add(d.backoff(delivery.Attempts))
+	d.update(ctx, delivery)
+}
+
+func (d *Dispatcher) post(ctx context.Context, w *domain.Webhook, delivery *domain.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(delivery.EventType))
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(SignatureHeader, "sha256="+Sign(w.Secret, timestamp, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("Webhook responded with status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BaseDelay
+	for i := 1; i < attempts && delay < d.cfg.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, d.cfg.MaxDelay)
+}
+
+func (d *Dispatcher) complete(ctx context.Context, delivery *domain.Delivery, status domain.DeliveryStatus) {
+	delivery.Status = status
+	delivery.CompletedAt = time.Now().UTC()
+	d.update(ctx, delivery)
+}
+
+func (d *Dispatcher) update(ctx context.Context, delivery *domain.Delivery) {
+	if err := d.repository.UpdateDelivery(ctx, delivery); err != nil {
+		slog.Error("Could not update webhook delivery.", "error", err, "delivery", delivery.ID)
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 of "<timestamp>.<body>" keyed with the webhook secret.
+// Receivers should recompute it from the X-Blog-Timestamp header and the raw body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+type payload struct {
+	ID         int64       `json:"id"`
+	Type       string      `json:"type"`
+	PostID     int64       `json:"post_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Post       payloadPost `json:"post"`
+}
+
+type payloadPost struct {
+	ID      int64  `json:"id"`
+	Author  string `json:"author"`
+	Title   string `json:"title"`
+	Content string `json:"content"`
+}
+
+func toPayload(event domain.PostEvent) payload {
+	return payload{
+		ID:         event.ID,
+		Type:       string(event.Type),
+		PostID:     event.PostID,
+		OccurredAt: event.OccurredAt,
+		Post: payloadPost{
+			ID:      event.Post.ID,
+			Author:  event.Post.Author,
+			Title:   event.Post.Title,
+			Content: event.Post.Content,
+		},
+	}
+}
@@ -0,0 +1,276 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kondrushin/blog/internal/domain"
+	"github.com/kondrushin/blog/internal/repository"
+	"github.com/kondrushin/blog/internal/webhook"
+	"github.com/stretchr/testify/assert"
+)
+
+var testConfig = webhook.Config{
+	MaxAttempts:  3,
+	BaseDelay:    time.Millisecond,
+	MaxDelay:     5 * time.Millisecond,
+	Timeout:      time.Second,
+	PollInterval: time.Millisecond,
+	Concurrency:  4,
+	// The receivers of the tests listen on the loopback interface.
+	AllowPrivateTargets: true,
+}
+
+type DispatcherTestSuite struct {
+	ctx        context.Context
+	repository *repository.WebhookRepository
+	dispatcher *webhook.Dispatcher
+}
+
+func SetSuite(t *testing.T) *DispatcherTestSuite {
+	return setSuiteWithConfig(t, testConfig)
+}
+
+func setSuiteWithConfig(t *testing.T, cfg webhook.Config) *DispatcherTestSuite {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	var suite = DispatcherTestSuite{ctx: ctx}
+	suite.repository = repository.NewWebhookRepository()
+	suite.dispatcher = webhook.NewDispatcher(suite.repository, cfg)
+	go suite.dispatcher.Run(ctx)
+
+	return &suite
+}
+
+func (s *DispatcherTestSuite) addWebhook(url string, events ...domain.EventType) int64 {
+	id, _ := s.repository.CreateWebhook(s.ctx, &domain.Webhook{URL: url, Secret: "secret", Events: events})
+	return id
+}
+
+func (s *DispatcherTestSuite) waitForCompletedDeliveries(t *testing.T, count int) []*domain.Delivery {
+	var deliveries []*domain.Delivery
+	assert.Eventually(t, func() bool {
+		deliveries = s.repository.GetDeliveries(s.ctx, domain.DeliveryFilter{})
+		completed := 0
+		for _, d := range deliveries {
+			if d.Status != domain.DeliveryPending {
+				completed++
+			}
+		}
+		return completed == count
+	}, 2*time.Second, 5*time.Millisecond)
+
+	return deliveries
+}
+
+var createdEvent = domain.PostEvent{
+	ID:     11,
+	Type:   domain.EventPostCreated,
+	PostID: 1,
+	Post:   domain.Post{ID: 1, Author: "Anton", Title: "Big post", Content: "something"},
+}
+
+func Test_HandleEvent_ShouldPostSignedPayload(t *testing.T) {
+	suite := SetSuite(t)
+
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- r
+		bodies <- body
+	}))
+	defer receiver.Close()
+
+	suite.addWebhook(receiver.URL)
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	req := <-requests
+	body := <-bodies
+
+	timestamp, err := strconv.ParseInt(req.Header.Get(webhook.TimestampHeader), 10, 64)
+	assert.NoError(t, err)
+	assert.Equal(t, "sha256="+webhook.Sign("secret", timestamp, body), req.Header.Get(webhook.SignatureHeader))
+	assert.Equal(t, "post.created", req.Header.Get(webhook.EventHeader))
+
+	var payload map[string]any
+	assert.NoError(t, json.Unmarshal(body, &payload))
+	assert.EqualValues(t, 11, payload["id"])
+	assert.Equal(t, "Anton", payload["post"].(map[string]any)["author"])
+
+	deliveries := suite.waitForCompletedDeliveries(t, 1)
+	assert.Equal(t, domain.DeliverySucceeded, deliveries[0].Status)
+	assert.Equal(t, 1, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusOK, deliveries[0].ResponseStatus)
+}
+
+func Test_HandleEvent_ReceiverFailsOnce_ShouldRetry(t *testing.T) {
+	suite := SetSuite(t)
+
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer receiver.Close()
+
+	suite.addWebhook(receiver.URL)
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	deliveries := suite.waitForCompletedDeliveries(t, 1)
+	assert.Equal(t, domain.DeliverySucceeded, deliveries[0].Status)
+	assert.Equal(t, 2, deliveries[0].Attempts)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func Test_HandleEvent_ReceiverAlwaysFails_ShouldDeadLetter(t *testing.T) {
+	suite := SetSuite(t)
+
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	suite.addWebhook(receiver.URL)
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	deliveries := suite.waitForCompletedDeliveries(t, 1)
+	assert.Equal(t, domain.DeliveryDead, deliveries[0].Status)
+	assert.Equal(t, testConfig.MaxAttempts, deliveries[0].Attempts)
+	assert.Equal(t, http.StatusInternalServerError, deliveries[0].ResponseStatus)
+	assert.Equal(t, "Webhook responded with status 500", deliveries[0].LastError)
+	assert.EqualValues(t, testConfig.MaxAttempts, atomic.LoadInt32(&calls))
+
+	dead := suite.repository.GetDeliveries(suite.ctx, domain.DeliveryFilter{Status: domain.DeliveryDead})
+	assert.Len(t, dead, 1)
+}
+
+func Test_HandleEvent_ShouldOnlyDeliverSubscribedEvents(t *testing.T) {
+	suite := SetSuite(t)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	createdHook := suite.addWebhook(receiver.URL, domain.EventPostCreated)
+	suite.addWebhook(receiver.URL, domain.EventPostDeleted)
+
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	deliveries := suite.waitForCompletedDeliveries(t, 1)
+	assert.Len(t, deliveries, 1)
+	assert.Equal(t, createdHook, deliveries[0].WebhookID)
+}
+
+func Test_HandleEvent_PrivateTarget_ShouldNotConnect(t *testing.T) {
+	cfg := testConfig
+	cfg.AllowPrivateTargets = false
+	suite := setSuiteWithConfig(t, cfg)
+
+	var calls int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+	}))
+	defer receiver.Close()
+
+	// A host name resolving to the loopback address is refused when it is dialed.
+	suite.addWebhook(strings.Replace(receiver.URL, "127.0.0.1", "localhost", 1))
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	deliveries := suite.waitForCompletedDeliveries(t, 1)
+	assert.Equal(t, domain.DeliveryDead, deliveries[0].Status)
+	assert.Contains(t, deliveries[0].LastError, webhook.ErrorPrivateTarget.Error())
+	assert.Zero(t, atomic.LoadInt32(&calls))
+}
+
+func Test_CheckTarget_ShouldRefusePrivateHosts(t *testing.T) {
+	dispatcher := webhook.NewDispatcher(repository.NewWebhookRepository(), webhook.DefaultConfig())
+
+	for _, target := range []string{
+		"http://localhost:8080/hook",
+		"http://api.localhost/hook",
+		"http://127.0.0.1/hook",
+		"http://10.1.2.3/hook",
+		"http://172.16.0.1/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	} {
+		parsed, _ := url.Parse(target)
+		assert.ErrorIs(t, dispatcher.CheckTarget(parsed), webhook.ErrorPrivateTarget, target)
+	}
+
+	for _, target := range []string{"https://example.com/hook", "http://93.184.216.34/hook", "http://[2606:2800:220:1::]/hook"} {
+		parsed, _ := url.Parse(target)
+		assert.NoError(t, dispatcher.CheckTarget(parsed), target)
+	}
+}
+
+func Test_Run_ShouldPruneCompletedDeliveries(t *testing.T) {
+	cfg := testConfig
+	cfg.MaxDeliveriesPerWebhook = 1
+	cfg.PruneInterval = time.Millisecond
+	suite := setSuiteWithConfig(t, cfg)
+
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer receiver.Close()
+
+	suite.addWebhook(receiver.URL)
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	assert.Eventually(t, func() bool {
+		deliveries := suite.repository.GetDeliveries(suite.ctx, domain.DeliveryFilter{})
+		return len(deliveries) == 1 && deliveries[0].Status == domain.DeliverySucceeded
+	}, 2*time.Second, 5*time.Millisecond)
+}
+
+func Test_Run_ShouldBoundConcurrentDeliveries(t *testing.T) {
+	cfg := testConfig
+	cfg.Concurrency = 2
+	suite := setSuiteWithConfig(t, cfg)
+
+	var inFlight, maxInFlight int32
+	release := make(chan struct{})
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		<-release
+	}))
+	defer receiver.Close()
+
+	for i := 0; i < 5; i++ {
+		suite.addWebhook(receiver.URL)
+	}
+	suite.dispatcher.HandleEvent(suite.ctx, createdEvent)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&inFlight) == 2 }, 2*time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	suite.waitForCompletedDeliveries(t, 5)
+	assert.EqualValues(t, 2, atomic.LoadInt32(&maxInFlight))
+}